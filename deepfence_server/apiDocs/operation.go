@@ -6,7 +6,9 @@ import (
 	"github.com/deepfence/ThreatMapper/deepfence_server/diagnosis"
 	"github.com/deepfence/ThreatMapper/deepfence_server/ingesters"
 	. "github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/msgtemplate"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/scope/render/detailed"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/threatintel"
 	. "github.com/deepfence/ThreatMapper/deepfence_server/reporters/graph"
 	. "github.com/deepfence/ThreatMapper/deepfence_server/reporters/lookup"
 	. "github.com/deepfence/ThreatMapper/deepfence_server/reporters/search"
@@ -606,6 +608,31 @@ func (d *OpenApiDocs) AddScansOperations() {
 		"Notify Scans Results", "Notify scan results in connected integration channels",
 		http.StatusNoContent, []string{tagScanResults}, bearerToken, new(ScanResultsActionRequest), nil)
 
+	// Scan Result Exceptions
+	d.AddOperation("listScanResultExceptions", http.MethodGet, "/deepfence/scan/results/exceptions",
+		"List Scan Result Exceptions", "List risk exceptions of scan results, including expired ones",
+		http.StatusOK, []string{tagScanResults}, bearerToken, nil, new([]ScanResultException))
+	d.AddOperation("addScanResultException", http.MethodPost, "/deepfence/scan/results/exceptions",
+		"Add Scan Result Exception", "Accept the risk of a finding in a scope until an expiry date, its results are masked in existing and future scans",
+		http.StatusOK, []string{tagScanResults}, bearerToken, new(ScanResultExceptionReq), new(ScanResultException))
+	d.AddOperation("exportScanResultExceptions", http.MethodGet, "/deepfence/scan/results/exceptions/export",
+		"Export Scan Result Exceptions", "Download all risk exceptions as json",
+		http.StatusOK, []string{tagScanResults}, bearerToken, nil, new([]ScanResultException))
+	d.AddOperation("updateScanResultException", http.MethodPut, "/deepfence/scan/results/exceptions/{exception_id}",
+		"Update Scan Result Exception", "Update the reason, owner or expiry of a risk exception",
+		http.StatusOK, []string{tagScanResults}, bearerToken, new(ScanResultExceptionUpdateReq), new(ScanResultException))
+	d.AddOperation("deleteScanResultException", http.MethodDelete, "/deepfence/scan/results/exceptions/{exception_id}",
+		"Delete Scan Result Exception", "Revoke a risk exception, its results are unmasked",
+		http.StatusNoContent, []string{tagScanResults}, bearerToken, new(ScanResultExceptionIDPathReq), nil)
+
+	// Finding Lifecycle
+	d.AddOperation("listFindingLifecycles", http.MethodPost, "/deepfence/scan/results/lifecycle",
+		"List Finding Lifecycles", "List when the findings of a node were first seen, last seen, resolved and reopened",
+		http.StatusOK, []string{tagScanResults}, bearerToken, new(FindingLifecycleReq), new([]FindingLifecycle))
+	d.AddOperation("getFindingMTTR", http.MethodPost, "/deepfence/scan/results/lifecycle/mttr",
+		"Get Findings MTTR", "Get the mean time to resolve findings per period, grouped by severity, node type or label",
+		http.StatusOK, []string{tagScanResults}, bearerToken, new(FindingMTTRReq), new([]FindingMTTR))
+
 	// Bulk Delete Scans
 	d.AddOperation("bulkDeleteScans", http.MethodPost, "/deepfence/scans/bulk/delete",
 		"Bulk Delete Scans", "Bulk delete scans along with their results for a particular scan type",
@@ -665,6 +692,69 @@ func (d *OpenApiDocs) AddRegistryOperations() {
 	d.AddOperation("syncRegistry", http.MethodPost, "/deepfence/registryaccount/{registry_id}/sync",
 		"Sync Registry", "synchronize registry images",
 		http.StatusOK, []string{tagRegistry}, bearerToken, new(RegistryIDPathReq), new(MessageResponse))
+	d.AddOperation("updateRegistrySyncOptions", http.MethodPut, "/deepfence/registryaccount/{registry_id}/sync-options",
+		"Update Registry Sync Options", "Update repository and tag filters and the latest tags limit of registry sync",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(RegistrySyncOptionsReq), new(MessageResponse))
+	d.AddOperation("getRegistryCredentialStatus", http.MethodGet, "/deepfence/registryaccount/{registry_id}/credentials",
+		"Get Registry Credential Status", "Get validity, last error and expiry of the registry credentials from the last check",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(RegistryIDPathReq), new(RegistryCredentialStatus))
+	d.AddOperation("checkRegistryCredentials", http.MethodPost, "/deepfence/registryaccount/{registry_id}/credentials/check",
+		"Check Registry Credentials", "Validate the registry credentials now and look up their expiry",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(RegistryIDPathReq), new(RegistryCredentialStatus))
+	d.AddOperation("getImageTagHistory", http.MethodPost, "/deepfence/registryaccount/{registry_id}/tag-history",
+		"Get Image Tag History", "Get the digests an image tag pointed to over time, latest first",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(ImageTagHistoryReq), new(ImageTagHistoryResp))
+	d.AddOperation("getImageTagChange", http.MethodGet, "/deepfence/registryaccount/tag-history/{history_id}/changes",
+		"Get Image Tag Change", "Compare the vulnerabilities of the digest a tag moved to with the digest it pointed to before",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(ImageTagChangeReq), new(ImageTagChangeResp))
+	d.AddOperation("getImagesByDigest", http.MethodPost, "/deepfence/registryaccount/images/digest",
+		"Get Images By Digest", "Get the tags currently pointing to an image digest and the containers running it",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(ImageDigestReq), new(ImageDigestResp))
+	d.AddOperation("compareNativeVulnerabilities", http.MethodPost, "/deepfence/registryaccount/images/native-vulnerabilities",
+		"Compare Native Vulnerabilities", "Compare the vulnerability reports imported from Harbor and Quay for an image digest with the latest ThreatMapper scan of the digest",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(NativeVulnerabilityCompareReq), new(NativeVulnerabilityCompareResp))
+	d.AddOperation("listRegistryScanPolicies", http.MethodGet, "/deepfence/registryaccount/{registry_id}/scan-policies",
+		"List Registry Scan Policies", "List the scan policies evaluated after every sync of the registry",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(RegistryIDPathReq), new([]RegistryScanPolicy))
+	d.AddOperation("createRegistryScanPolicy", http.MethodPost, "/deepfence/registryaccount/{registry_id}/scan-policies",
+		"Create Registry Scan Policy", "Scan the images never scanned, or scanned longer ago than rescan_after_days, after every sync of the registry",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(RegistryScanPolicyReq), new(RegistryScanPolicy))
+	d.AddOperation("updateRegistryScanPolicy", http.MethodPut, "/deepfence/registryaccount/{registry_id}/scan-policies/{policy_id}",
+		"Update Registry Scan Policy", "Update scan types, filters and limits of a registry scan policy",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(RegistryScanPolicyUpdateReq), new(RegistryScanPolicy))
+	d.AddOperation("deleteRegistryScanPolicy", http.MethodDelete, "/deepfence/registryaccount/{registry_id}/scan-policies/{policy_id}",
+		"Delete Registry Scan Policy", "Delete a registry scan policy",
+		http.StatusNoContent, []string{tagRegistry}, bearerToken, new(RegistryScanPolicyIDPathReq), nil)
+	d.AddOperation("listRegistryArtifacts", http.MethodGet, "/deepfence/registryaccount/{registry_id}/artifacts",
+		"List Registry Artifacts", "List the Helm charts and other OCI artifacts discovered in the registry, when discover_artifacts is enabled in the sync options",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(RegistryIDPathReq), new([]RegistryArtifact))
+	d.AddOperation("scanRegistryArtifacts", http.MethodPost, "/deepfence/registryaccount/artifacts/scan",
+		"Scan Helm Charts", "Render Helm charts with their default values, check the rendered manifests for Kubernetes misconfigurations and scan the referenced images for vulnerabilities",
+		http.StatusAccepted, []string{tagRegistry}, bearerToken, new(RegistryArtifactScanReq), new(RegistryArtifactScanResp))
+	d.AddOperation("getRegistryWebhook", http.MethodGet, "/deepfence/registryaccount/{registry_id}/webhook",
+		"Get Registry Webhook", "Get push webhook status of the registry",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(RegistryIDPathReq), new(RegistryWebhookResp))
+	d.AddOperation("createRegistryWebhook", http.MethodPost, "/deepfence/registryaccount/{registry_id}/webhook",
+		"Create Registry Webhook", "Enable push webhook of the registry and generate a new webhook token, optionally scanning pushed images",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(RegistryWebhookReq), new(RegistryWebhookResp))
+	d.AddOperation("deleteRegistryWebhook", http.MethodDelete, "/deepfence/registryaccount/{registry_id}/webhook",
+		"Delete Registry Webhook", "Disable push webhook of the registry",
+		http.StatusNoContent, []string{tagRegistry}, bearerToken, new(RegistryIDPathReq), nil)
+	d.AddOperation("registryWebhook", http.MethodPost, "/deepfence/registry-webhook/{registry_id}",
+		"Registry Push Webhook", "Receive push events from Harbor, JFrog and Docker Distribution (GitLab) registries, authenticated with the webhook token in the Authorization, X-Gitlab-Token or X-JFrog-Event-Auth header",
+		http.StatusAccepted, []string{tagRegistry}, nil, new(RegistryIDPathReq), new(RegistryWebhookEventResp))
+	d.AddOperation("registryWebhookWithToken", http.MethodPost, "/deepfence/registry-webhook/{registry_id}/{token}",
+		"Registry Push Webhook With Token", "Receive push events from Docker Hub and Quay registries, which can not send custom headers, authenticated with the webhook token in the url path",
+		http.StatusAccepted, []string{tagRegistry}, nil, new(RegistryWebhookTokenPathReq), new(RegistryWebhookEventResp))
+	d.AddOperation("listSourceRepositories", http.MethodGet, "/deepfence/source-repository",
+		"List Source Repositories", "List the git repositories scanned for secrets",
+		http.StatusOK, []string{tagRegistry}, bearerToken, nil, new([]SourceRepository))
+	d.AddOperation("addSourceRepository", http.MethodPost, "/deepfence/source-repository",
+		"Add Source Repository", "Add a GitHub, GitLab, Gitea or plain git repository, scanned for secrets in its full commit history with node_type source_repository",
+		http.StatusOK, []string{tagRegistry}, bearerToken, new(SourceRepositoryAddReq), new(SourceRepository))
+	d.AddOperation("deleteSourceRepository", http.MethodDelete, "/deepfence/source-repository/{repository_id}",
+		"Delete Source Repository", "Delete a source repository",
+		http.StatusNoContent, []string{tagRegistry}, bearerToken, new(SourceRepositoryIDPathReq), nil)
 	d.AddOperation("getSummaryAll", http.MethodGet, "/deepfence/registryaccount/summary",
 		"Get All Registries Summary By Type", "get summary of all registries scans, images and tags by registry type",
 		http.StatusOK, []string{tagRegistry}, bearerToken, nil, new(RegistrySummaryAllResp))
@@ -698,6 +788,15 @@ func (d *OpenApiDocs) AddIntegrationOperations() {
 	d.AddOperation("deleteIntegration", http.MethodDelete, "/deepfence/integration/{integration_id}",
 		"Delete Integration", "Delete integration",
 		http.StatusNoContent, []string{tagIntegration}, bearerToken, new(IntegrationIDPathReq), nil)
+	d.AddOperation("updateIntegration", http.MethodPut, "/deepfence/integration/{integration_id}",
+		"Update Integration", "Update integration config, notification type and filters",
+		http.StatusOK, []string{tagIntegration}, bearerToken, new(IntegrationUpdateReq), new(MessageResponse))
+	d.AddOperation("testIntegration", http.MethodPost, "/deepfence/integration/{integration_id}/test",
+		"Test Integration", "Send a sample notification using the integration",
+		http.StatusOK, []string{tagIntegration}, bearerToken, new(IntegrationIDPathReq), new(IntegrationTestResp))
+	d.AddOperation("previewIntegrationTemplate", http.MethodPost, "/deepfence/integration/preview",
+		"Preview Integration Template", "Render a message template against a sample scan",
+		http.StatusOK, []string{tagIntegration}, bearerToken, new(IntegrationPreviewReq), new([]msgtemplate.Group))
 }
 
 func (d *OpenApiDocs) AddReportsOperations() {
@@ -725,6 +824,18 @@ func (d *OpenApiDocs) AddSettingsOperations() {
 	d.AddOperation("deleteEmailConfiguration", http.MethodDelete, "/deepfence/settings/email/{config_id}",
 		"Delete Email Configurations", "Delete Email Smtp / ses Configurations in system",
 		http.StatusNoContent, []string{tagSettings}, bearerToken, new(ConfigIDPathReq), nil)
+	d.AddOperation("listSLAPolicies", http.MethodGet, "/deepfence/settings/sla-policies",
+		"List SLA Policies", "List the days to fix findings per severity",
+		http.StatusOK, []string{tagSettings}, bearerToken, nil, new([]SLAPolicy))
+	d.AddOperation("addSLAPolicy", http.MethodPost, "/deepfence/settings/sla-policies",
+		"Add SLA Policy", "Add the days to fix findings of a severity, optionally scoped by node label or cloud account",
+		http.StatusOK, []string{tagSettings}, bearerToken, new(SLAPolicyReq), new(SLAPolicy))
+	d.AddOperation("updateSLAPolicy", http.MethodPut, "/deepfence/settings/sla-policies/{policy_id}",
+		"Update SLA Policy", "Update the severity, days or scope of an SLA policy",
+		http.StatusOK, []string{tagSettings}, bearerToken, new(SLAPolicyUpdateReq), new(SLAPolicy))
+	d.AddOperation("deleteSLAPolicy", http.MethodDelete, "/deepfence/settings/sla-policies/{policy_id}",
+		"Delete SLA Policy", "Delete an SLA policy",
+		http.StatusNoContent, []string{tagSettings}, bearerToken, new(SLAPolicyIDPathReq), nil)
 	d.AddOperation("getSettings", http.MethodGet, "/deepfence/settings/global-settings",
 		"Get settings", "Get all settings",
 		http.StatusOK, []string{tagSettings}, bearerToken, nil, new([]SettingsResponse))
@@ -750,6 +861,9 @@ func (d *OpenApiDocs) AddSettingsOperations() {
 	d.AddOperation("uploadVulnerabilityDatabase", http.MethodPut, "/deepfence/database/vulnerability",
 		"Upload Vulnerability Database", "Upload Vulnerability Database for use in vulnerability scans",
 		http.StatusOK, []string{tagSettings}, bearerToken, new(vulnerability_db.DBUploadRequest), new(MessageResponse))
+	d.AddOperation("uploadThreatIntel", http.MethodPut, "/deepfence/database/threat-intel",
+		"Upload Threat Intel", "Upload the EPSS scores csv and the CISA known exploited vulnerabilities catalog used to prioritize vulnerabilities",
+		http.StatusOK, []string{tagSettings}, bearerToken, new(threatintel.UploadRequest), new(MessageResponse))
 }
 
 func (d *OpenApiDocs) AddDiffAddOperations() {
@@ -768,4 +882,19 @@ func (d *OpenApiDocs) AddDiffAddOperations() {
 	d.AddOperation("addDiffAdd", http.MethodPost, "/deepfence/diff-add/cloud-compliance",
 		"Get Cloud Compliance Diff", "Get Cloud Compliance Diff between two scans",
 		http.StatusOK, []string{tagDiffAdd}, bearerToken, new(ScanCompareReq), new(ScanCompareResCloudCompliance))
+	d.AddOperation("compareVulnerabilityScans", http.MethodPost, "/deepfence/diff/vulnerability",
+		"Compare Vulnerability Scans", "Get added, removed and unchanged vulnerabilities between two scans or the latest scans of two nodes",
+		http.StatusOK, []string{tagDiffAdd}, bearerToken, new(ScanComparisonReq), new(ScanComparisonResVulnerability))
+	d.AddOperation("compareSecretScans", http.MethodPost, "/deepfence/diff/secret",
+		"Compare Secret Scans", "Get added, removed and unchanged secrets between two scans or the latest scans of two nodes",
+		http.StatusOK, []string{tagDiffAdd}, bearerToken, new(ScanComparisonReq), new(ScanComparisonResSecret))
+	d.AddOperation("compareComplianceScans", http.MethodPost, "/deepfence/diff/compliance",
+		"Compare Compliance Scans", "Get added, removed and unchanged compliances between two scans or the latest scans of two nodes",
+		http.StatusOK, []string{tagDiffAdd}, bearerToken, new(ScanComparisonReq), new(ScanComparisonResCompliance))
+	d.AddOperation("compareMalwareScans", http.MethodPost, "/deepfence/diff/malware",
+		"Compare Malware Scans", "Get added, removed and unchanged malwares between two scans or the latest scans of two nodes",
+		http.StatusOK, []string{tagDiffAdd}, bearerToken, new(ScanComparisonReq), new(ScanComparisonResMalware))
+	d.AddOperation("compareCloudComplianceScans", http.MethodPost, "/deepfence/diff/cloud-compliance",
+		"Compare Cloud Compliance Scans", "Get added, removed and unchanged cloud compliances between two scans or the latest scans of two nodes",
+		http.StatusOK, []string{tagDiffAdd}, bearerToken, new(ScanComparisonReq), new(ScanComparisonResCloudCompliance))
 }
@@ -6,6 +6,8 @@ const (
 	ErrRegistryNotExists  = "registry with this name does not exists"
 	ErrRegistryAuthFailed = "Authentication failed for given credentials"
 	ErrRegistryIdMissing  = "registry id is missing"
+
+	ErrRegistryWebhookAuthFailed = "invalid registry webhook token"
 )
 
 // Integration errors
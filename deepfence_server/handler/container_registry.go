@@ -17,9 +17,11 @@ import (
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/constants"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/gcr"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registrysync"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/encryption"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	"github.com/go-chi/chi/v5"
 	httpext "github.com/go-playground/pkg/v5/net/http"
@@ -55,15 +57,27 @@ func (h *Handler) ListRegistry(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 		registryId := model.GetRegistryID(reg.GetRegistryType(), reg.GetNamespace())
+		syncStatus, err := model.GetRegistrySyncStatus(ctx, registryId)
+		if err != nil {
+			log.Warn().Msgf("no sync status for registry %s: %v", registryId, err)
+		}
+		credentialStatus, err := model.GetRegistryCredentialStatus(ctx, registryId)
+		if err != nil {
+			log.Warn().Msgf("no credential status for registry %s: %v", registryId, err)
+		}
 		registryResponse := model.RegistryListResp{
 			ID:           r.ID,
 			NodeID:       registryId,
 			Name:         r.Name,
 			RegistryType: r.RegistryType,
-			IsSyncing:    req.IsRegistrySyncing(ctx, registryId),
+			IsSyncing:    syncStatus.IsSyncing,
 			NonSecret:    r.NonSecret,
+			SyncOptions:  r.SyncOptions,
+			SyncStatus:   syncStatus,
 			CreatedAt:    r.CreatedAt.Unix(),
 			UpdatedAt:    r.UpdatedAt.Unix(),
+
+			CredentialStatus: credentialStatus,
 		}
 		registriesResponse = append(registriesResponse, registryResponse)
 	}
@@ -519,6 +533,115 @@ func (h *Handler) RefreshRegistry(w http.ResponseWriter, r *http.Request) {
 		model.MessageResponse{Message: "started sync registry"})
 }
 
+func (h *Handler) GetRegistryCredentialStatus(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "registry_id")
+
+	status, err := model.GetRegistryCredentialStatus(r.Context(), id)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+	httpext.JSON(w, http.StatusOK, status)
+}
+
+// CheckRegistryCredentials validates the credentials of the registry now
+// instead of waiting for the periodic check
+func (h *Handler) CheckRegistryCredentials(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "registry_id")
+
+	ctx := r.Context()
+	pgIds, err := model.GetRegistryPgIds(ctx, id)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	var status model.RegistryCredentialStatus
+	for _, pgId := range pgIds {
+		row, err := pgClient.GetContainerRegistry(ctx, int32(pgId))
+		if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(&NotFoundError{err}, w)
+			return
+		}
+		reg, err := registry.GetRegistryWithRegistryRow(postgresqlDb.GetContainerRegistriesRow(row))
+		if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+		status, err = registrysync.CheckRegistryCredentials(ctx, pgClient, reg, row.Name)
+		if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+	}
+
+	httpext.JSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) UpdateRegistrySyncOptions(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.RegistrySyncOptionsReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	req.RegistryId = chi.URLParam(r, "registry_id")
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	if _, err := req.SyncOptions.Filter(); err != nil {
+		h.respondError(&ValidatorError{err: errors.New("sync_options:" + err.Error()), skipOverwriteErrorMessage: true}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgIds, err := model.GetRegistryPgIds(ctx, req.RegistryId)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	options, err := json.Marshal(req.SyncOptions)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	for _, pgId := range pgIds {
+		err = pgClient.UpdateContainerRegistrySyncOptions(ctx, postgresqlDb.UpdateContainerRegistrySyncOptionsParams{
+			ID:          int32(pgId),
+			SyncOptions: options,
+		})
+		if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+	}
+
+	h.AuditUserActivity(r, EVENT_REGISTRY, ACTION_UPDATE, req, true)
+
+	httpext.JSON(w, http.StatusOK, model.MessageResponse{Message: "registry sync options updated"})
+}
+
 func (h *Handler) getImages(w http.ResponseWriter, r *http.Request) ([]model.ContainerImage, error) {
 	images := []model.ContainerImage{}
 	var req model.RegistryImagesReq
@@ -678,9 +801,11 @@ func (h *Handler) Summary(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) SyncRegistry(rCtx context.Context, pgID int32) error {
 	log.Info().Msgf("sync registry with id=%d", pgID)
-	payload, err := json.Marshal(utils.RegistrySyncParams{
-		PgID: pgID,
-	})
+	return h.publishRegistrySync(rCtx, utils.RegistrySyncParams{PgID: pgID})
+}
+
+func (h *Handler) publishRegistrySync(rCtx context.Context, params utils.RegistrySyncParams) error {
+	payload, err := json.Marshal(params)
 	if err != nil {
 		log.Error().Msgf("cannot marshal payload:", err)
 		return err
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	httpext "github.com/go-playground/pkg/v5/net/http"
+)
+
+// ListFindingLifecycles returns when the findings of a node were first and
+// last seen, open findings first
+func (h *Handler) ListFindingLifecycles(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.FindingLifecycleReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	if req.Window.Size <= 0 {
+		req.Window.Size = 100
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	rows, err := pgClient.GetFindingLifecycles(ctx, postgresqlDb.GetFindingLifecyclesParams{
+		ScanType: req.ScanType,
+		NodeID:   req.NodeId,
+		Limit:    int32(req.Window.Size),
+		Offset:   int32(req.Window.Offset),
+	})
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	findings := []model.FindingLifecycle{}
+	for _, row := range rows {
+		findings = append(findings, model.NewFindingLifecycle(row))
+	}
+	httpext.JSON(w, http.StatusOK, findings)
+}
+
+// GetFindingMTTR returns the mean time to resolve the findings resolved in
+// each period, grouped by severity, node type or a label like team
+func (h *Handler) GetFindingMTTR(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.FindingMTTRReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	to := time.Now()
+	if req.To != 0 {
+		to = time.Unix(req.To, 0)
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	rows, err := pgClient.GetFindingMTTR(ctx, postgresqlDb.GetFindingMTTRParams{
+		GroupBy:      req.GroupBy,
+		Label:        req.Label,
+		ResolvedFrom: sql.NullTime{Time: time.Unix(req.From, 0), Valid: true},
+		ResolvedTo:   sql.NullTime{Time: to, Valid: true},
+		ScanType:     req.ScanType,
+	})
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	httpext.JSON(w, http.StatusOK, model.AggregateFindingMTTR(rows, req.Period))
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/reporters"
+	reporters_scan "github.com/deepfence/ThreatMapper/deepfence_server/reporters/scan"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/go-chi/chi/v5"
+	httpext "github.com/go-playground/pkg/v5/net/http"
+)
+
+// GetImagesByDigest returns the tags currently pointing to a digest and the
+// containers running it
+func (h *Handler) GetImagesByDigest(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.ImageDigestReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	if _, hash, _ := strings.Cut(model.IDToDigest(req.Digest), ":"); len(hash) < 12 {
+		h.respondError(&ValidatorError{err: errors.New("digest:invalid digest"), skipOverwriteErrorMessage: true}, w)
+		return
+	}
+	digest := model.IDToDigest(req.Digest)
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	imageIds, parents, err := model.GetDigestImageIds(ctx, digest)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(err, w)
+		return
+	}
+
+	resp := model.ImageDigestResp{
+		Digest:     digest,
+		ImageIds:   imageIds,
+		Tags:       []model.ImageTagRef{},
+		Containers: []model.ImageDigestContainer{},
+	}
+	for _, d := range append([]string{digest}, parents...) {
+		rows, err := pgClient.GetCurrentImageTagsByDigest(ctx, d)
+		if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+		for _, row := range rows {
+			resp.Tags = append(resp.Tags, model.ImageTagRef{
+				RegistryId: row.RegistryID,
+				ImageName:  row.ImageName,
+				Tag:        row.Tag,
+				Digest:     row.Digest,
+				Since:      row.FirstSeenAt.UnixMilli(),
+			})
+		}
+	}
+
+	resp.Containers, err = model.GetImageIdContainers(ctx, imageIds)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(err, w)
+		return
+	}
+	resp.VulnerabilityScanIds, err = model.GetLatestImageVulnerabilityScans(ctx, imageIds)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(err, w)
+		return
+	}
+
+	httpext.JSON(w, http.StatusOK, resp)
+}
+
+// GetImageTagHistory returns the digests a tag pointed to, latest first
+func (h *Handler) GetImageTagHistory(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.ImageTagHistoryReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	req.RegistryId = chi.URLParam(r, "registry_id")
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	rows, err := pgClient.GetImageTagHistory(ctx, postgresqlDb.GetImageTagHistoryParams{
+		RegistryID: req.RegistryId,
+		ImageName:  req.ImageName,
+		Tag:        req.Tag,
+	})
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	imageIds := []string{}
+	for _, row := range rows {
+		imageId, _ := model.DigestToID(row.Digest)
+		imageIds = append(imageIds, imageId)
+	}
+	scanIds, err := model.GetLatestImageVulnerabilityScans(ctx, imageIds)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(err, w)
+		return
+	}
+
+	resp := model.ImageTagHistoryResp{History: []model.ImageTagHistoryEntry{}}
+	for i, row := range rows {
+		entry := model.NewImageTagHistoryEntry(row)
+		entry.VulnerabilityScanId = scanIds[imageIds[i]]
+		resp.History = append(resp.History, entry)
+	}
+	httpext.JSON(w, http.StatusOK, resp)
+}
+
+// GetImageTagChange compares the vulnerabilities of the digest a tag moved to
+// with the digest it pointed to before
+func (h *Handler) GetImageTagChange(w http.ResponseWriter, r *http.Request) {
+	historyId, err := strconv.ParseInt(chi.URLParam(r, "history_id"), 10, 64)
+	if err != nil || historyId < 1 {
+		h.respondError(&ValidatorError{err: errors.New("history_id:invalid id"), skipOverwriteErrorMessage: true}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	row, err := pgClient.GetImageTagHistoryByID(ctx, historyId)
+	if errors.Is(err, sql.ErrNoRows) {
+		h.respondError(&NotFoundError{err}, w)
+		return
+	} else if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	currentId, _ := model.DigestToID(row.Digest)
+	imageIds := []string{currentId}
+	previousId := ""
+	if row.PreviousDigest != "" {
+		previousId, _ = model.DigestToID(row.PreviousDigest)
+		imageIds = append(imageIds, previousId)
+	}
+	scanIds, err := model.GetLatestImageVulnerabilityScans(ctx, imageIds)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(err, w)
+		return
+	}
+
+	resp := model.ImageTagChangeResp{
+		Change:             model.NewImageTagHistoryEntry(row),
+		PreviousScanId:     scanIds[previousId],
+		CurrentScanId:      scanIds[currentId],
+		AddedVulnerability: []model.Vulnerability{},
+		FixedVulnerability: []model.Vulnerability{},
+	}
+	resp.Change.VulnerabilityScanId = resp.CurrentScanId
+
+	// without a scan of both digests there is nothing to compare
+	if resp.PreviousScanId != "" && resp.CurrentScanId != "" {
+		resp.AddedVulnerability, err = reporters_scan.GetScanResultDiff[model.Vulnerability](ctx,
+			utils.NEO4J_VULNERABILITY_SCAN, resp.CurrentScanId, resp.PreviousScanId,
+			reporters.FieldsFilters{}, model.FetchWindow{})
+		if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(err, w)
+			return
+		}
+		resp.FixedVulnerability, err = reporters_scan.GetScanResultDiff[model.Vulnerability](ctx,
+			utils.NEO4J_VULNERABILITY_SCAN, resp.PreviousScanId, resp.CurrentScanId,
+			reporters.FieldsFilters{}, model.FetchWindow{})
+		if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(err, w)
+			return
+		}
+	}
+
+	httpext.JSON(w, http.StatusOK, resp)
+}
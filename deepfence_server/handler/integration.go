@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -9,9 +12,12 @@ import (
 
 	api_messages "github.com/deepfence/ThreatMapper/deepfence_server/constants/api-messages"
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/constants"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/msgtemplate"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
 	"github.com/go-chi/chi/v5"
 	httpext "github.com/go-playground/pkg/v5/net/http"
 )
@@ -51,6 +57,16 @@ func (h *Handler) AddIntegration(w http.ResponseWriter, r *http.Request) {
 		h.respondError(&ValidatorError{err: err}, w)
 		return
 	}
+	err = req.MessageTemplate.Validate()
+	if err != nil {
+		h.respondError(messageTemplateError(err), w)
+		return
+	}
+	err = validateIntegrationDelivery(req.IntegrationType, req.NotificationType, req.DigestInterval, req.RateLimit)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
 
 	// add integration to database
 	// before that check if integration already exists
@@ -141,6 +157,22 @@ func (h *Handler) GetIntegrations(w http.ResponseWriter, r *http.Request) {
 			Config:           config,
 			Filters:          filters,
 			LastErrorMsg:     integrationStatus,
+			DigestInterval:   integration.DigestInterval,
+			RateLimit:        integration.RateLimit,
+			NewFindingsOnly:  integration.NewFindingsOnly,
+			NotifyResolved:   integration.NotifyResolved,
+		}
+		if msgTemplate, err := msgtemplate.Parse(integration.MessageTemplate); err == nil && msgTemplate != nil {
+			newIntegration.MessageTemplate = *msgTemplate
+		}
+		if integration.LastSentTime.Valid {
+			newIntegration.LastSentTime = &integration.LastSentTime.Time
+		}
+		if integration.LastSuccessTime.Valid {
+			newIntegration.LastSuccessTime = &integration.LastSuccessTime.Time
+		}
+		if integration.LastDigestTime.Valid {
+			newIntegration.LastDigestTime = &integration.LastDigestTime.Time
 		}
 
 		newIntegration.RedactSensitiveFieldsInConfig()
@@ -176,3 +208,250 @@ func (h *Handler) DeleteIntegration(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 
 }
+
+func (h *Handler) UpdateIntegration(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.IntegrationUpdateReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+
+	req.IntegrationID = chi.URLParam(r, "integration_id")
+	id, err := strconv.ParseInt(req.IntegrationID, 10, 32)
+	if err != nil {
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	existing, err := pgClient.GetIntegrationFromID(ctx, int32(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		h.respondError(&NotFoundError{err}, w)
+		return
+	} else if err != nil {
+		log.Error().Msgf(err.Error())
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	// integration type is fixed, redacted secrets are not changed
+	var existingConfig map[string]interface{}
+	err = json.Unmarshal(existing.Config, &existingConfig)
+	if err != nil {
+		log.Error().Msgf(err.Error())
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	req.IntegrationType = existing.IntegrationType
+	if req.NotificationType == "" {
+		req.NotificationType = existing.Resource
+	}
+	req.RestoreSensitiveFields(existingConfig)
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	obj, err := integration.GetIntegration(ctx, req.IntegrationType, b)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	err = obj.ValidateConfig(h.Validator)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	err = req.MessageTemplate.Validate()
+	if err != nil {
+		h.respondError(messageTemplateError(err), w)
+		return
+	}
+	err = validateIntegrationDelivery(req.IntegrationType, req.NotificationType, req.DigestInterval, req.RateLimit)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+
+	integrationExists, err := req.IntegrationExists(ctx, pgClient, int32(id))
+	if err != nil {
+		log.Error().Msgf(err.Error())
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	if integrationExists {
+		httpext.JSON(w, http.StatusBadRequest, model.ErrorResponse{Message: api_messages.ErrIntegrationExists})
+		return
+	}
+
+	err = req.UpdateIntegration(ctx, pgClient, int32(id))
+	if err != nil {
+		log.Error().Msgf(err.Error())
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	h.AuditUserActivity(r, EVENT_INTEGRATION, ACTION_UPDATE, req, true)
+
+	httpext.JSON(w, http.StatusOK, model.MessageResponse{Message: api_messages.SuccessIntegrationUpdated})
+}
+
+func (h *Handler) TestIntegration(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "integration_id"), 10, 32)
+	if err != nil {
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	integrationRow, err := pgClient.GetIntegrationFromID(ctx, int32(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		h.respondError(&NotFoundError{err}, w)
+		return
+	} else if err != nil {
+		log.Error().Msgf(err.Error())
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	b, err := json.Marshal(integrationRow)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	obj, err := integration.GetIntegration(ctx, integrationRow.IntegrationType, b)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+
+	sample, extras, err := model.SampleIntegrationMessage(integrationRow.Resource,
+		integration.IsMessagingFormat(integrationRow.IntegrationType))
+	if err != nil {
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	// render with the template of the integration, same as the notification
+	// cron job does
+	messages, err := msgtemplate.Messages(integrationRow.MessageTemplate, integrationRow.Resource, sample, extras)
+	if err != nil {
+		h.respondError(messageTemplateError(err), w)
+		return
+	}
+
+	h.AuditUserActivity(r, EVENT_INTEGRATION, ACTION_NOTIFY,
+		map[string]interface{}{"integration_id": id}, true)
+
+	for _, m := range messages {
+		message, err := json.Marshal(m.Findings)
+		if err != nil {
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+		err = obj.SendNotification(ctx, string(message), m.Extras)
+		if err != nil {
+			log.Error().Msgf("test notification failed for integration id %d: %v", id, err)
+			httpext.JSON(w, http.StatusOK, model.IntegrationTestResp{Success: false, ErrorMsg: err.Error()})
+			return
+		}
+	}
+
+	httpext.JSON(w, http.StatusOK, model.IntegrationTestResp{Success: true})
+}
+
+// PreviewIntegrationTemplate renders the message template against a sample
+// scan, one group is one notification
+func (h *Handler) PreviewIntegrationTemplate(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.IntegrationPreviewReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	err = req.MessageTemplate.Validate()
+	if err != nil {
+		h.respondError(messageTemplateError(err), w)
+		return
+	}
+
+	findings, extras, err := model.SampleIntegrationScan(req.NotificationType)
+	if err != nil {
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	groups, err := req.MessageTemplate.Render(req.NotificationType, findings, extras)
+	if err != nil {
+		h.respondError(messageTemplateError(err), w)
+		return
+	}
+
+	httpext.JSON(w, http.StatusOK, groups)
+}
+
+func messageTemplateError(err error) error {
+	return &ValidatorError{
+		err:                       fmt.Errorf("message_template:%v", err),
+		skipOverwriteErrorMessage: true,
+	}
+}
+
+// validateIntegrationDelivery checks digest interval and rate limit, both need
+// an integration which can send a summary message
+func validateIntegrationDelivery(integrationType, notificationType, digestInterval string, rateLimit int32) error {
+	switch digestInterval {
+	case constants.DigestImmediate, constants.DigestHourly, constants.DigestDaily:
+	default:
+		return &ValidatorError{
+			err:                       errors.New("digest_interval:must be one of hourly, daily"),
+			skipOverwriteErrorMessage: true,
+		}
+	}
+	if rateLimit < 0 {
+		return &ValidatorError{
+			err:                       errors.New("rate_limit:must not be negative"),
+			skipOverwriteErrorMessage: true,
+		}
+	}
+	if digestInterval == constants.DigestImmediate && rateLimit == 0 {
+		return nil
+	}
+	if !integration.SupportsDigest(integrationType) {
+		return &ValidatorError{
+			err:                       fmt.Errorf("digest_interval:digest is not supported for %s", integrationType),
+			skipOverwriteErrorMessage: true,
+		}
+	}
+	// events are always sent as they happen
+	if notificationType == notification.EventResource {
+		return &ValidatorError{
+			err:                       errors.New("digest_interval:digest is not supported for events"),
+			skipOverwriteErrorMessage: true,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registrysync"
+	"github.com/deepfence/ThreatMapper/deepfence_server/reporters"
+	reporters_scan "github.com/deepfence/ThreatMapper/deepfence_server/reporters/scan"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	httpext "github.com/go-playground/pkg/v5/net/http"
+)
+
+// CompareNativeVulnerabilities compares the vulnerability reports imported
+// from the registries for a digest with the latest ThreatMapper scan of it
+func (h *Handler) CompareNativeVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.NativeVulnerabilityCompareReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	if _, hash, _ := strings.Cut(model.IDToDigest(req.Digest), ":"); len(hash) < 12 {
+		h.respondError(&ValidatorError{err: errors.New("digest:invalid digest"), skipOverwriteErrorMessage: true}, w)
+		return
+	}
+	digest := model.IDToDigest(req.Digest)
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	rows, err := pgClient.GetNativeVulnerabilityReportsByDigest(ctx, digest)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	if len(rows) == 0 {
+		h.respondError(&NotFoundError{errors.New("no registry vulnerability report for digest")}, w)
+		return
+	}
+
+	imageId, _ := model.DigestToID(digest)
+	scanIds, err := model.GetLatestImageVulnerabilityScans(ctx, []string{imageId})
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(err, w)
+		return
+	}
+
+	resp := model.NativeVulnerabilityCompareResp{
+		Digest:      digest,
+		ScanId:      scanIds[imageId],
+		Comparisons: []model.NativeVulnerabilityComparison{},
+	}
+	vulnerabilities := []model.Vulnerability{}
+	if resp.ScanId != "" {
+		vulnerabilities, _, err = reporters_scan.GetScanResults[model.Vulnerability](ctx,
+			utils.NEO4J_VULNERABILITY_SCAN, resp.ScanId, reporters.FieldsFilters{}, model.FetchWindow{})
+		if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(err, w)
+			return
+		}
+	}
+
+	for _, row := range rows {
+		report, err := model.NewNativeVulnerabilityReport(row)
+		if err != nil {
+			log.Error().Msgf("native vulnerability report %d: %v", row.ID, err)
+			continue
+		}
+		resp.Comparisons = append(resp.Comparisons, registrysync.CompareNativeVulnerabilities(report, vulnerabilities))
+	}
+
+	httpext.JSON(w, http.StatusOK, resp)
+}
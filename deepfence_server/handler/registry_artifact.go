@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	ctl "github.com/deepfence/ThreatMapper/deepfence_utils/controls"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/go-chi/chi/v5"
+	httpext "github.com/go-playground/pkg/v5/net/http"
+)
+
+func (h *Handler) ListRegistryArtifacts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "registry_id")
+	if _, err := model.GetRegistryPgIds(r.Context(), id); err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+
+	artifacts, err := model.GetRegistryArtifacts(r.Context(), id)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	httpext.JSON(w, http.StatusOK, artifacts)
+}
+
+// registryArtifactScanActionBuilder builds the compliance scan of a chart,
+// the console workers pull it with the credentials of its registry
+func registryArtifactScanActionBuilder(ctx context.Context) func(string, model.NodeIdentifier, int32) (ctl.Action, error) {
+	return func(scanId string, req model.NodeIdentifier, _ int32) (ctl.Action, error) {
+		artifact, pgIds, err := model.GetRegistryArtifact(ctx, req.NodeId)
+		if err != nil {
+			return ctl.Action{}, err
+		}
+		if len(pgIds) == 0 {
+			return ctl.Action{}, model.ErrRegistryArtifactNotFound
+		}
+		binArgs := map[string]string{
+			"scan_id":       scanId,
+			"node_type":     req.NodeType,
+			"node_id":       req.NodeId,
+			"registry_id":   strconv.FormatInt(pgIds[0], 10),
+			"chart_name":    artifact.Name,
+			"chart_version": artifact.Tag,
+		}
+		b, err := json.Marshal(ctl.StartComplianceScanRequest{
+			NodeId:   req.NodeId,
+			NodeType: ctl.RegistryArtifact,
+			BinArgs:  binArgs,
+		})
+		if err != nil {
+			return ctl.Action{}, err
+		}
+		return ctl.Action{ID: ctl.StartComplianceScan, RequestPayload: string(b)}, nil
+	}
+}
+
+// ScanRegistryArtifacts starts the scans of helm charts, their templates are
+// checked for misconfigurations and the images they reference are scanned
+// for vulnerabilities
+func (h *Handler) ScanRegistryArtifacts(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.RegistryArtifactScanReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+
+	ctx := r.Context()
+	nodeIds := []model.NodeIdentifier{}
+	for _, nodeId := range req.NodeIds {
+		artifact, _, err := model.GetRegistryArtifact(ctx, nodeId)
+		if errors.Is(err, model.ErrRegistryArtifactNotFound) {
+			h.respondError(&NotFoundError{err}, w)
+			return
+		} else if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+		if artifact.ArtifactType != model.ArtifactTypeHelmChart {
+			h.respondError(&ValidatorError{
+				err:                       errors.New("node_ids:only helm charts can be scanned"),
+				skipOverwriteErrorMessage: true,
+			}, w)
+			return
+		}
+		nodeIds = append(nodeIds, model.NodeIdentifier{
+			NodeId:   nodeId,
+			NodeType: ctl.ResourceTypeToString(ctl.RegistryArtifact),
+		})
+	}
+
+	scanTrigger := model.ScanTriggerCommon{NodeIds: nodeIds, Filters: model.ScanFilter{}}
+	scanIds, _, err := StartMultiScan(ctx, false, utils.NEO4J_COMPLIANCE_SCAN, scanTrigger,
+		registryArtifactScanActionBuilder(ctx))
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	h.AuditUserActivity(r, EVENT_COMPLIANCE_SCAN, ACTION_START, req, true)
+
+	httpext.JSON(w, http.StatusAccepted, model.RegistryArtifactScanResp{ScanIds: scanIds})
+}
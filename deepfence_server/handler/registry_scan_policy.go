@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/go-chi/chi/v5"
+	httpext "github.com/go-playground/pkg/v5/net/http"
+)
+
+var errRegistryScanPolicyNotFound = errors.New("registry scan policy not found")
+
+func policyIdParam(r *http.Request) (int32, error) {
+	policyId, err := strconv.ParseInt(chi.URLParam(r, "policy_id"), 10, 32)
+	if err != nil || policyId < 1 {
+		return 0, &ValidatorError{err: errors.New("policy_id:invalid id"), skipOverwriteErrorMessage: true}
+	}
+	return int32(policyId), nil
+}
+
+// getRegistryScanPolicy returns the policy if it belongs to the registry
+func getRegistryScanPolicy(ctx context.Context, pgClient *postgresqlDb.Queries, pgIds []int64,
+	policyId int32) (postgresqlDb.RegistryScanPolicy, error) {
+
+	policy, err := pgClient.GetRegistryScanPolicy(ctx, policyId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return policy, &NotFoundError{errRegistryScanPolicyNotFound}
+	} else if err != nil {
+		return policy, &InternalServerError{err}
+	}
+	for _, pgId := range pgIds {
+		if int32(pgId) == policy.ContainerRegistryID {
+			return policy, nil
+		}
+	}
+	return policy, &NotFoundError{errRegistryScanPolicyNotFound}
+}
+
+func (h *Handler) decodeRegistryScanPolicy(w http.ResponseWriter, r *http.Request) (model.RegistryScanPolicyReq, bool) {
+	var req model.RegistryScanPolicyReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return req, false
+	}
+	req.RegistryId = chi.URLParam(r, "registry_id")
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return req, false
+	}
+	if _, err := req.Filters.Filter(); err != nil {
+		h.respondError(&ValidatorError{err: errors.New("filters:" + err.Error()), skipOverwriteErrorMessage: true}, w)
+		return req, false
+	}
+	return req, true
+}
+
+func (h *Handler) ListRegistryScanPolicies(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "registry_id")
+
+	pgIds, err := model.GetRegistryPgIds(r.Context(), id)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	policies := []model.RegistryScanPolicy{}
+	for _, pgId := range pgIds {
+		rows, err := pgClient.GetRegistryScanPolicies(ctx, int32(pgId))
+		if err != nil {
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+		for _, row := range rows {
+			policy, err := model.NewRegistryScanPolicy(id, row)
+			if err != nil {
+				log.Error().Msgf("registry scan policy %d: %v", row.ID, err)
+			}
+			policies = append(policies, policy)
+		}
+	}
+
+	httpext.JSON(w, http.StatusOK, policies)
+}
+
+// CreateRegistryScanPolicy adds a policy evaluated after every sync of the
+// registry
+func (h *Handler) CreateRegistryScanPolicy(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	req, ok := h.decodeRegistryScanPolicy(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	pgIds, err := model.GetRegistryPgIds(ctx, req.RegistryId)
+	if err != nil || len(pgIds) == 0 {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	scanTypes, err := json.Marshal(req.ScanTypes)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	filters, err := json.Marshal(req.Filters)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	// policies are attached to the first registry of the account, the
+	// images of all of them are hosted by the same registry account node
+	row, err := pgClient.CreateRegistryScanPolicy(ctx, postgresqlDb.CreateRegistryScanPolicyParams{
+		ContainerRegistryID: int32(pgIds[0]),
+		Name:                req.Name,
+		ScanTypes:           scanTypes,
+		Filters:             filters,
+		RescanAfterDays:     req.RescanAfterDays,
+		MaxConcurrentScans:  req.MaxConcurrentScans,
+		IsEnabled:           req.IsEnabled,
+	})
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	h.AuditUserActivity(r, EVENT_REGISTRY, ACTION_CREATE, req, true)
+
+	policy, err := model.NewRegistryScanPolicy(req.RegistryId, row)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	httpext.JSON(w, http.StatusOK, policy)
+}
+
+func (h *Handler) UpdateRegistryScanPolicy(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	policyId, err := policyIdParam(r)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+	req, ok := h.decodeRegistryScanPolicy(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	pgIds, err := model.GetRegistryPgIds(ctx, req.RegistryId)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	if _, err := getRegistryScanPolicy(ctx, pgClient, pgIds, policyId); err != nil {
+		h.respondError(err, w)
+		return
+	}
+	scanTypes, err := json.Marshal(req.ScanTypes)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	filters, err := json.Marshal(req.Filters)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	row, err := pgClient.UpdateRegistryScanPolicy(ctx, postgresqlDb.UpdateRegistryScanPolicyParams{
+		Name:               req.Name,
+		ScanTypes:          scanTypes,
+		Filters:            filters,
+		RescanAfterDays:    req.RescanAfterDays,
+		MaxConcurrentScans: req.MaxConcurrentScans,
+		IsEnabled:          req.IsEnabled,
+		ID:                 policyId,
+	})
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	h.AuditUserActivity(r, EVENT_REGISTRY, ACTION_UPDATE,
+		model.RegistryScanPolicyUpdateReq{PolicyId: policyId, RegistryScanPolicyReq: req}, true)
+
+	policy, err := model.NewRegistryScanPolicy(req.RegistryId, row)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	httpext.JSON(w, http.StatusOK, policy)
+}
+
+func (h *Handler) DeleteRegistryScanPolicy(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "registry_id")
+	policyId, err := policyIdParam(r)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgIds, err := model.GetRegistryPgIds(ctx, id)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	if _, err := getRegistryScanPolicy(ctx, pgClient, pgIds, policyId); err != nil {
+		h.respondError(err, w)
+		return
+	}
+	if err := pgClient.DeleteRegistryScanPolicy(ctx, policyId); err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	h.AuditUserActivity(r, EVENT_REGISTRY, ACTION_DELETE,
+		model.RegistryScanPolicyIDPathReq{RegistryId: id, PolicyId: policyId}, true)
+
+	w.WriteHeader(http.StatusNoContent)
+}
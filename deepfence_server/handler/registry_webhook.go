@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	api_messages "github.com/deepfence/ThreatMapper/deepfence_server/constants/api-messages"
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registrywebhook"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/go-chi/chi/v5"
+	httpext "github.com/go-playground/pkg/v5/net/http"
+)
+
+const registryWebhookPath = "/deepfence/registry-webhook/"
+
+func registryWebhookURL(r *http.Request, pgClient *postgresqlDb.Queries, registryID string) string {
+	consoleURL, err := model.GetManagementConsoleURL(r.Context(), pgClient)
+	if err != nil {
+		log.Warn().Msgf("console url not set: %v", err)
+	}
+	return consoleURL + registryWebhookPath + registryID
+}
+
+func (h *Handler) GetRegistryWebhook(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "registry_id")
+
+	pgIds, err := model.GetRegistryPgIds(r.Context(), id)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	resp := model.RegistryWebhookResp{
+		URL:       registryWebhookURL(r, pgClient, id),
+		ScanTypes: []string{},
+	}
+	for _, pgId := range pgIds {
+		webhook, err := pgClient.GetRegistryWebhook(ctx, int32(pgId))
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+		resp.Enabled = true
+		if err := json.Unmarshal(webhook.ScanTypes, &resp.ScanTypes); err != nil {
+			log.Error().Msgf("%v", err)
+		}
+		break
+	}
+
+	httpext.JSON(w, http.StatusOK, resp)
+}
+
+// CreateRegistryWebhook enables the webhook of the registry account, a new
+// token is generated every time, invalidating the previous one
+func (h *Handler) CreateRegistryWebhook(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.RegistryWebhookReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	req.RegistryId = chi.URLParam(r, "registry_id")
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	if req.ScanTypes == nil {
+		req.ScanTypes = []string{}
+	}
+
+	ctx := r.Context()
+	pgIds, err := model.GetRegistryPgIds(ctx, req.RegistryId)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	scanTypes, err := json.Marshal(req.ScanTypes)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	token := registrywebhook.NewToken()
+	for _, pgId := range pgIds {
+		_, err = pgClient.UpsertRegistryWebhook(ctx, postgresqlDb.UpsertRegistryWebhookParams{
+			ContainerRegistryID: int32(pgId),
+			TokenHash:           registrywebhook.HashToken(token),
+			ScanTypes:           scanTypes,
+		})
+		if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+	}
+
+	h.AuditUserActivity(r, EVENT_REGISTRY, ACTION_ENABLE, req, true)
+
+	url := registryWebhookURL(r, pgClient, req.RegistryId)
+	httpext.JSON(w, http.StatusOK, model.RegistryWebhookResp{
+		Enabled:   true,
+		URL:       url,
+		Token:     token,
+		TokenURL:  url + "/" + token,
+		ScanTypes: req.ScanTypes,
+	})
+}
+
+func (h *Handler) DeleteRegistryWebhook(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "registry_id")
+
+	pgIds, err := model.GetRegistryPgIds(r.Context(), id)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&NotFoundError{err}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	for _, pgId := range pgIds {
+		if err := pgClient.DeleteRegistryWebhook(ctx, int32(pgId)); err != nil {
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+	}
+
+	h.AuditUserActivity(r, EVENT_REGISTRY, ACTION_DISABLE,
+		map[string]interface{}{"registry_id": id}, true)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegistryWebhookHandler receives push events from registries, it is not
+// behind user authentication and is authenticated with the webhook token
+func (h *Handler) RegistryWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	id := chi.URLParam(r, "registry_id")
+	unauthorized := errors.New(api_messages.ErrRegistryWebhookAuthFailed)
+
+	token := registrywebhook.TokenFromRequest(r, chi.URLParam(r, "token"))
+	if token == "" {
+		h.respondWithErrorCode(unauthorized, w, http.StatusUnauthorized)
+		return
+	}
+	ctx, webhooks, err := registryWebhooksFromToken(token)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	if len(webhooks) == 0 {
+		log.Warn().Msgf("registry webhook for registry %s with invalid token", id)
+		h.respondWithErrorCode(unauthorized, w, http.StatusUnauthorized)
+		return
+	}
+
+	pgIds, err := model.GetRegistryPgIds(ctx, id)
+	if err != nil {
+		log.Warn().Msgf("registry webhook for unknown registry %s: %v", id, err)
+		h.respondWithErrorCode(unauthorized, w, http.StatusUnauthorized)
+		return
+	}
+
+	var webhook *postgresqlDb.RegistryWebhook
+	for _, pgId := range pgIds {
+		for i := range webhooks {
+			if webhooks[i].ContainerRegistryID == int32(pgId) &&
+				registrywebhook.VerifyToken(token, webhooks[i].TokenHash) {
+				webhook = &webhooks[i]
+				break
+			}
+		}
+	}
+	if webhook == nil {
+		log.Warn().Msgf("registry webhook for registry %s with invalid token", id)
+		h.respondWithErrorCode(unauthorized, w, http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxPostRequestSize))
+	if err != nil {
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	source, images, err := registrywebhook.Parse(body)
+	if err != nil {
+		log.Error().Msgf("registry webhook for registry %s: %v", id, err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+
+	resp := model.RegistryWebhookEventResp{Source: source, Images: images}
+	if len(images) == 0 {
+		// test deliveries and events other than pushes
+		httpext.JSON(w, http.StatusOK, resp)
+		return
+	}
+
+	var scanTypes []string
+	if err := json.Unmarshal(webhook.ScanTypes, &scanTypes); err != nil {
+		log.Error().Msgf("%v", err)
+	}
+	log.Info().Msgf("registry webhook from %s for registry %s pushed %v", source, id, images)
+
+	err = h.publishRegistrySync(ctx, utils.RegistrySyncParams{
+		PgID:      webhook.ContainerRegistryID,
+		Images:    images,
+		ScanTypes: scanTypes,
+	})
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	httpext.JSON(w, http.StatusAccepted, resp)
+}
+
+// registryWebhooksFromToken looks up the webhooks of the token in every
+// namespace, the namespace of the matching webhooks is returned as context
+func registryWebhooksFromToken(token string) (context.Context, []postgresqlDb.RegistryWebhook, error) {
+	hash := registrywebhook.HashToken(token)
+	for _, namespace := range directory.GetAllNamespaces() {
+		ctx := directory.NewContextWithNameSpace(namespace)
+		pgClient, err := directory.PostgresClient(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		webhooks, err := pgClient.GetRegistryWebhooksFromTokenHash(ctx, hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(webhooks) > 0 {
+			return ctx, webhooks, nil
+		}
+	}
+	return nil, nil, nil
+}
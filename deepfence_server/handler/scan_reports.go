@@ -63,38 +63,44 @@ func bulkScanId() string {
 	return fmt.Sprintf("%s", random_id.String())
 }
 
-func GetImageFromId(ctx context.Context, node_id string) (string, string, error) {
+// GetImageFromId returns the name and tag of the image, and the platform of
+// the platform images of manifest lists
+func GetImageFromId(ctx context.Context, node_id string) (string, string, string, string, error) {
 	var name string
 	var tag string
+	var platform string
+	var digest string
 
 	driver, err := directory.Neo4jClient(ctx)
 	if err != nil {
-		return name, tag, err
+		return name, tag, platform, digest, err
 	}
 
 	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	if err != nil {
-		return name, tag, err
+		return name, tag, platform, digest, err
 	}
 	defer session.Close()
 
 	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
 	if err != nil {
-		return name, tag, err
+		return name, tag, platform, digest, err
 	}
 	defer tx.Close()
 
 	res, err := tx.Run(`
 		MATCH (n:ContainerImage{node_id:$node_id})
-		RETURN  n.docker_image_name, n.docker_image_tag`,
+		RETURN  n.docker_image_name, n.docker_image_tag,
+			CASE WHEN n.parent_image_id IS NULL THEN '' ELSE n.platform END AS platform,
+			COALESCE(n.docker_image_digest, '') AS digest`,
 		map[string]interface{}{"node_id": node_id})
 	if err != nil {
-		return name, tag, err
+		return name, tag, platform, digest, err
 	}
 
 	rec, err := res.Single()
 	if err != nil {
-		return name, tag, err
+		return name, tag, platform, digest, err
 	}
 
 	if vi, ok := rec.Get("n.docker_image_name"); ok && vi != nil {
@@ -103,8 +109,14 @@ func GetImageFromId(ctx context.Context, node_id string) (string, string, error)
 	if vt, ok := rec.Get("n.docker_image_tag"); ok && vt != nil {
 		tag = vt.(string)
 	}
+	if vp, ok := rec.Get("platform"); ok && vp != nil {
+		platform = vp.(string)
+	}
+	if vd, ok := rec.Get("digest"); ok && vd != nil {
+		digest = vd.(string)
+	}
 
-	return name, tag, nil
+	return name, tag, platform, digest, nil
 }
 
 func GetContainerKubeClusterNameFromId(ctx context.Context, node_id string) (string, string, error) {
@@ -170,9 +182,19 @@ func StartScanActionBuilder(ctx context.Context, scanType ctl.ActionID, addition
 		nodeTypeInternal := ctl.StringToResourceType(req.NodeType)
 
 		if nodeTypeInternal == ctl.Image {
-			name, tag, err := GetImageFromId(ctx, req.NodeId)
+			name, tag, platform, digest, err := GetImageFromId(ctx, req.NodeId)
 			if err != nil {
 				log.Error().Msgf("image not found %s", err.Error())
+			} else if platform != "" {
+				// platform images of manifest lists are pulled by digest, the
+				// digest already selects the platform
+				binArgs["image_name"] = name + "@" + model.IDToDigest(req.NodeId)
+				log.Info().Msgf("node_id=%s image_name=%s platform=%s", req.NodeId, binArgs["image_name"], platform)
+			} else if digest != "" && registryId != -1 {
+				// registry images are pulled by the digest saved on the scan,
+				// the tag may have moved since the registry was synced
+				binArgs["image_name"] = name + "@" + digest
+				log.Info().Msgf("node_id=%s image_name=%s tag=%s", req.NodeId, binArgs["image_name"], tag)
 			} else {
 				binArgs["image_name"] = name + ":" + tag
 				log.Info().Msgf("node_id=%s image_name=%s", req.NodeId, binArgs["image_name"])
@@ -182,6 +204,14 @@ func StartScanActionBuilder(ctx context.Context, scanType ctl.ActionID, addition
 			}
 		}
 
+		if nodeTypeInternal == ctl.SourceRepository {
+			pgId, err := model.GetSourceRepositoryPgId(ctx, req.NodeId)
+			if err != nil {
+				return ctl.Action{}, err
+			}
+			binArgs["source_repository_id"] = strconv.FormatInt(pgId, 10)
+		}
+
 		if nodeTypeInternal == ctl.Container {
 			clusterID, clusterName, err := GetContainerKubeClusterNameFromId(ctx, req.NodeId)
 			if err != nil {
@@ -347,6 +377,70 @@ func (h *Handler) DiffAddCloudComplianceScan(w http.ResponseWriter, r *http.Requ
 	httpext.JSON(w, http.StatusOK, model.ScanCompareRes[model.CloudCompliance]{New: new})
 }
 
+// comparisonScanID resolves one side of a comparison to a scan id
+func comparisonScanID(ctx context.Context, scanType utils.Neo4jScanType, field, scanID, nodeID string) (string, error) {
+	if (scanID == "") == (nodeID == "") {
+		return "", &ValidatorError{
+			err:                       fmt.Errorf("%s_scan_id:either %s_scan_id or %s_node_id is required", field, field, field),
+			skipOverwriteErrorMessage: true,
+		}
+	}
+	if scanID != "" {
+		return scanID, nil
+	}
+	return reporters_scan.GetLatestScanID(ctx, scanType, nodeID)
+}
+
+func compareScans[T any](h *Handler, w http.ResponseWriter, r *http.Request, scanType utils.Neo4jScanType) {
+	defer r.Body.Close()
+	var req model.ScanComparisonReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+
+	ctx := r.Context()
+	baseScanID, err := comparisonScanID(ctx, scanType, "base", req.BaseScanID, req.BaseNodeID)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+	toScanID, err := comparisonScanID(ctx, scanType, "to", req.ToScanID, req.ToNodeID)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+
+	res, err := reporters_scan.GetScanResultComparison[T](ctx, scanType, baseScanID, toScanID, req.FieldsFilter, req.Window)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+	httpext.JSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) CompareVulnerabilityScans(w http.ResponseWriter, r *http.Request) {
+	compareScans[model.Vulnerability](h, w, r, utils.NEO4J_VULNERABILITY_SCAN)
+}
+
+func (h *Handler) CompareSecretScans(w http.ResponseWriter, r *http.Request) {
+	compareScans[model.Secret](h, w, r, utils.NEO4J_SECRET_SCAN)
+}
+
+func (h *Handler) CompareComplianceScans(w http.ResponseWriter, r *http.Request) {
+	compareScans[model.Compliance](h, w, r, utils.NEO4J_COMPLIANCE_SCAN)
+}
+
+func (h *Handler) CompareMalwareScans(w http.ResponseWriter, r *http.Request) {
+	compareScans[model.Malware](h, w, r, utils.NEO4J_MALWARE_SCAN)
+}
+
+func (h *Handler) CompareCloudComplianceScans(w http.ResponseWriter, r *http.Request) {
+	compareScans[model.CloudCompliance](h, w, r, utils.NEO4J_CLOUD_COMPLIANCE_SCAN)
+}
+
 func (h *Handler) StartSecretScanHandler(w http.ResponseWriter, r *http.Request) {
 	var reqs model.SecretScanTriggerReq
 	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &reqs)
@@ -1888,7 +1982,7 @@ func FindImageRegistryIds(ctx context.Context, image_id string) ([]int32, error)
 
 	nres, err := tx.Run(`
 		MATCH (n:ContainerImage{node_id:$node_id})
-		MATCH (m:RegistryAccount) -[:HOSTS]-> (n)
+		MATCH (m:RegistryAccount) -[:HOSTS]-> (:ContainerImage) -[:HAS_PLATFORM*0..1]-> (n)
 		RETURN m.container_registry_ids
 		LIMIT 1`,
 		map[string]interface{}{"node_id": image_id})
@@ -2004,6 +2098,13 @@ func StartMultiScan(ctx context.Context,
 		reqs = append(reqs, pod_container_nodes...)
 	}
 
+	if req.ImagePlatform != "" {
+		reqs, err = reporters_scan.GetImagePlatformIDs(ctx, reqs, req.ImagePlatform)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
 	defer tx.Close()
 	scanIds := []string{}
 	for _, req := range reqs {
@@ -2048,6 +2149,14 @@ func StartMultiScan(ctx context.Context,
 			log.Error().Err(err)
 			return nil, "", err
 		}
+
+		if req.NodeType == ctl.ResourceTypeToString(controls.Image) {
+			err = setScanImageDigest(tx, scan_type, scanId, req.NodeId)
+			if err != nil {
+				log.Error().Err(err)
+				return nil, "", err
+			}
+		}
 		scanIds = append(scanIds, scanId)
 	}
 
@@ -2067,6 +2176,17 @@ func StartMultiScan(ctx context.Context,
 	return scanIds, bulkId, tx.Commit()
 }
 
+// setScanImageDigest saves on the scan the digest and the tag of the image
+// when it was scanned, tags can move to other digests later
+func setScanImageDigest(tx neo4j.Transaction, scanType utils.Neo4jScanType, scanId, nodeId string) error {
+	_, err := tx.Run(`
+		MATCH (s:`+string(scanType)+`{node_id:$scan_id}) -[:SCANNED]-> (n:ContainerImage{node_id:$node_id})
+		SET s.image_digest = COALESCE(n.docker_image_digest, ""),
+		s.image_tag = n.docker_image_name+":"+n.docker_image_tag`,
+		map[string]interface{}{"scan_id": scanId, "node_id": nodeId})
+	return err
+}
+
 func StartMultiCloudComplianceScan(ctx context.Context, reqs []model.NodeIdentifier, benchmarkTypes []string) ([]string, string, error) {
 	driver, err := directory.Neo4jClient(ctx)
 
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/go-chi/chi/v5"
+	httpext "github.com/go-playground/pkg/v5/net/http"
+)
+
+var errScanResultExceptionNotFound = errors.New("exception not found")
+
+func exceptionIdFromPath(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "exception_id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, &ValidatorError{err: errors.New("exception_id:invalid id"), skipOverwriteErrorMessage: true}
+	}
+	return id, nil
+}
+
+func (h *Handler) listScanResultExceptions(r *http.Request) ([]model.ScanResultException, error) {
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		return nil, &InternalServerError{err}
+	}
+	rows, err := pgClient.GetScanResultExceptions(ctx)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return nil, &InternalServerError{err}
+	}
+	exceptions := []model.ScanResultException{}
+	for _, row := range rows {
+		exceptions = append(exceptions, model.NewScanResultException(row))
+	}
+	return exceptions, nil
+}
+
+func (h *Handler) ListScanResultExceptions(w http.ResponseWriter, r *http.Request) {
+	exceptions, err := h.listScanResultExceptions(r)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+	httpext.JSON(w, http.StatusOK, exceptions)
+}
+
+// ExportScanResultExceptions downloads all exceptions, including expired
+// ones, as a json file for review outside the console
+func (h *Handler) ExportScanResultExceptions(w http.ResponseWriter, r *http.Request) {
+	exceptions, err := h.listScanResultExceptions(r)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+	data, err := json.Marshal(exceptions)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	w.Header().Set("Content-Disposition",
+		"attachment; filename="+strconv.Quote("exceptions-"+time.Now().UTC().Format("2006-01-02")+".json"))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+	h.AuditUserActivity(r, EVENT_REPORTS, ACTION_DOWNLOAD, map[string]int{"count": len(exceptions)}, true)
+}
+
+// AddScanResultException records the acceptance of a finding, its results
+// are masked in existing scans and in the scans ingested until it expires
+func (h *Handler) AddScanResultException(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.ScanResultExceptionReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	expiresAt, err := model.ExceptionExpiry(req.ExpiresAt, time.Now())
+	if err != nil {
+		h.respondError(&ValidatorError{err: err, skipOverwriteErrorMessage: true}, w)
+		return
+	}
+	if req.ScopeType == model.ExceptionScopeGlobal {
+		req.ScopeValue = ""
+	}
+
+	ctx := r.Context()
+	user, statusCode, pgClient, err := h.GetUserFromJWT(ctx)
+	if err != nil {
+		h.respondWithErrorCode(err, w, statusCode)
+		return
+	}
+
+	row, err := pgClient.CreateScanResultException(ctx, postgresqlDb.CreateScanResultExceptionParams{
+		ScanType:   req.ScanType,
+		ResultID:   req.ResultId,
+		ScopeType:  req.ScopeType,
+		ScopeValue: req.ScopeValue,
+		Reason:     req.Reason,
+		Owner:      req.Owner,
+		CreatedBy:  user.Email,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	if err := model.ApplyNewScanResultException(ctx, row); err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	h.AuditUserActivity(r, req.ScanType, ACTION_CREATE, model.NewScanResultException(row), true)
+
+	httpext.JSON(w, http.StatusOK, model.NewScanResultException(row))
+}
+
+// UpdateScanResultException changes the reason, owner or expiry of an
+// exception, an expired exception updated with a new expiry masks again
+func (h *Handler) UpdateScanResultException(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	id, err := exceptionIdFromPath(r)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+	var req model.ScanResultExceptionUpdateReq
+	err = httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	req.ExceptionId = id
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	expiresAt, err := model.ExceptionExpiry(req.ExpiresAt, time.Now())
+	if err != nil {
+		h.respondError(&ValidatorError{err: err, skipOverwriteErrorMessage: true}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	row, err := pgClient.UpdateScanResultException(ctx, postgresqlDb.UpdateScanResultExceptionParams{
+		Reason:    req.Reason,
+		Owner:     req.Owner,
+		ExpiresAt: expiresAt,
+		ID:        id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		h.respondError(&NotFoundError{errScanResultExceptionNotFound}, w)
+		return
+	} else if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	if err := model.ApplyNewScanResultException(ctx, row); err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	h.AuditUserActivity(r, row.ScanType, ACTION_UPDATE, model.NewScanResultException(row), true)
+
+	httpext.JSON(w, http.StatusOK, model.NewScanResultException(row))
+}
+
+// DeleteScanResultException revokes an exception, the results it masked
+// are shown again unless another exception covers them
+func (h *Handler) DeleteScanResultException(w http.ResponseWriter, r *http.Request) {
+	id, err := exceptionIdFromPath(r)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	row, err := pgClient.GetScanResultException(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		h.respondError(&NotFoundError{errScanResultExceptionNotFound}, w)
+		return
+	} else if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	if !row.Expired {
+		_, err = model.RemoveScanResultException(ctx, utils.Neo4jScanType(row.ScanType), id)
+		if err != nil {
+			log.Error().Msgf("%v", err)
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+	}
+	if err := pgClient.DeleteScanResultException(ctx, id); err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	h.AuditUserActivity(r, row.ScanType, ACTION_DELETE, model.NewScanResultException(row), true)
+
+	w.WriteHeader(http.StatusNoContent)
+}
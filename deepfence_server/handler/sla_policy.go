@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/go-chi/chi/v5"
+	httpext "github.com/go-playground/pkg/v5/net/http"
+)
+
+var errSLAPolicyNotFound = errors.New("sla policy not found")
+
+func policyIdFromPath(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "policy_id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, &ValidatorError{err: errors.New("policy_id:invalid id"), skipOverwriteErrorMessage: true}
+	}
+	return id, nil
+}
+
+// evaluateSLA re-evaluates the deadlines of open findings after the policies
+// changed instead of waiting for the next scheduled evaluation
+func (h *Handler) evaluateSLA(ctx context.Context) {
+	namespace, err := directory.ExtractNamespace(ctx)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		return
+	}
+	msg := message.NewMessage(watermill.NewUUID(), []byte{})
+	msg.Metadata = map[string]string{directory.NamespaceKey: string(namespace)}
+	msg.SetContext(directory.NewContextWithNameSpace(namespace))
+	middleware.SetCorrelationID(watermill.NewShortUUID(), msg)
+	if err := h.TasksPublisher.Publish(utils.EvaluateSLATask, msg); err != nil {
+		log.Error().Msgf("failed to publish task: %+v", err)
+	}
+}
+
+func (h *Handler) ListSLAPolicies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	rows, err := pgClient.GetSLAPolicies(ctx)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	policies := []model.SLAPolicy{}
+	for _, row := range rows {
+		policies = append(policies, model.NewSLAPolicy(row))
+	}
+	httpext.JSON(w, http.StatusOK, policies)
+}
+
+func (h *Handler) AddSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.SLAPolicyReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	if req.ScopeType != model.SLAScopeLabel {
+		req.ScopeKey = ""
+	}
+	if req.ScopeType == model.SLAScopeGlobal {
+		req.ScopeValue = ""
+	}
+
+	ctx := r.Context()
+	user, statusCode, pgClient, err := h.GetUserFromJWT(ctx)
+	if err != nil {
+		h.respondWithErrorCode(err, w, statusCode)
+		return
+	}
+
+	row, err := pgClient.CreateSLAPolicy(ctx, postgresqlDb.CreateSLAPolicyParams{
+		Name:        req.Name,
+		ScanType:    req.ScanType,
+		Severity:    strings.ToLower(req.Severity),
+		Days:        req.Days,
+		DueSoonDays: req.DueSoonDays,
+		ScopeType:   req.ScopeType,
+		ScopeKey:    req.ScopeKey,
+		ScopeValue:  req.ScopeValue,
+		CreatedBy:   user.Email,
+	})
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	h.evaluateSLA(ctx)
+
+	h.AuditUserActivity(r, EVENT_SETTINGS, ACTION_CREATE, model.NewSLAPolicy(row), true)
+
+	httpext.JSON(w, http.StatusOK, model.NewSLAPolicy(row))
+}
+
+func (h *Handler) UpdateSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	id, err := policyIdFromPath(r)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+	var req model.SLAPolicyUpdateReq
+	err = httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	req.PolicyId = id
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	if req.ScopeType != model.SLAScopeLabel {
+		req.ScopeKey = ""
+	}
+	if req.ScopeType == model.SLAScopeGlobal {
+		req.ScopeValue = ""
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	row, err := pgClient.UpdateSLAPolicy(ctx, postgresqlDb.UpdateSLAPolicyParams{
+		Name:        req.Name,
+		Severity:    strings.ToLower(req.Severity),
+		Days:        req.Days,
+		DueSoonDays: req.DueSoonDays,
+		ScopeType:   req.ScopeType,
+		ScopeKey:    req.ScopeKey,
+		ScopeValue:  req.ScopeValue,
+		ID:          id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		h.respondError(&NotFoundError{errSLAPolicyNotFound}, w)
+		return
+	} else if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	h.evaluateSLA(ctx)
+
+	h.AuditUserActivity(r, EVENT_SETTINGS, ACTION_UPDATE, model.NewSLAPolicy(row), true)
+
+	httpext.JSON(w, http.StatusOK, model.NewSLAPolicy(row))
+}
+
+// DeleteSLAPolicy removes a policy, its findings fall back to the other
+// matching policies on the next evaluation
+func (h *Handler) DeleteSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := policyIdFromPath(r)
+	if err != nil {
+		h.respondError(err, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	row, err := pgClient.GetSLAPolicy(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		h.respondError(&NotFoundError{errSLAPolicyNotFound}, w)
+		return
+	} else if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	if err := pgClient.DeleteSLAPolicy(ctx, id); err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	h.evaluateSLA(ctx)
+
+	h.AuditUserActivity(r, EVENT_SETTINGS, ACTION_DELETE, model.NewSLAPolicy(row), true)
+
+	w.WriteHeader(http.StatusNoContent)
+}
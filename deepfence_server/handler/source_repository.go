@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/encryption"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/go-chi/chi/v5"
+	httpext "github.com/go-playground/pkg/v5/net/http"
+)
+
+var errSourceRepositoryNotFound = errors.New("source repository not found")
+
+func (h *Handler) AddSourceRepository(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var req model.SourceRepositoryAddReq
+	err := httpext.DecodeJSON(r, httpext.NoQueryParams, MaxPostRequestSize, &req)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+	err = h.Validator.Struct(req)
+	if err != nil {
+		h.respondError(&ValidatorError{err: err}, w)
+		return
+	}
+	if err := req.ValidateURL(); err != nil {
+		h.respondError(&ValidatorError{err: err, skipOverwriteErrorMessage: true}, w)
+		return
+	}
+	if err := req.ValidateKnownHosts(); err != nil {
+		h.respondError(&ValidatorError{err: err, skipOverwriteErrorMessage: true}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	// the url is unique per branch, the same repository cloned over ssh and
+	// https is the same graph node
+	nodeId := model.GetSourceRepositoryID(req.URL, req.Branch)
+	existing, err := pgClient.GetSourceRepositories(ctx)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	for _, e := range existing {
+		if model.GetSourceRepositoryID(e.Url, e.Branch) == nodeId {
+			h.respondError(&ValidatorError{err: errors.New("url:source repository already exists"), skipOverwriteErrorMessage: true}, w)
+			return
+		}
+	}
+
+	// encrypt secret
+	aesValue, err := model.GetAESValueForEncryption(ctx, pgClient)
+	if err != nil {
+		log.Error().Msgf(err.Error())
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	aes := encryption.AES{}
+	err = json.Unmarshal(aesValue, &aes)
+	if err != nil {
+		log.Error().Msgf(err.Error())
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	secret := model.SourceRepositorySecret{}
+	switch req.AuthType {
+	case model.SourceRepositoryAuthToken:
+		secret.Token, err = aes.Encrypt(req.Token)
+	case model.SourceRepositoryAuthSSHKey:
+		secret.SSHKey, err = aes.Encrypt(req.SSHKey)
+	}
+	if err != nil {
+		log.Error().Msgf(err.Error())
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	bSecret, err := json.Marshal(secret)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	row, err := pgClient.CreateSourceRepository(ctx, postgresqlDb.CreateSourceRepositoryParams{
+		Name:            req.Name,
+		Provider:        req.Provider,
+		Url:             req.URL,
+		Branch:          req.Branch,
+		AuthType:        req.AuthType,
+		Username:        req.Username,
+		KnownHosts:      req.KnownHosts,
+		EncryptedSecret: bSecret,
+	})
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	repository := model.NewSourceRepository(row)
+	if err := model.UpsertSourceRepositoryNode(ctx, repository); err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	// don't log secrets in audit logs
+	req.Token = ""
+	req.SSHKey = ""
+	h.AuditUserActivity(r, EVENT_REGISTRY, ACTION_CREATE, req, true)
+
+	httpext.JSON(w, http.StatusOK, repository)
+}
+
+func (h *Handler) ListSourceRepositories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	rows, err := pgClient.GetSourceRepositories(ctx)
+	if err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	repositories := []model.SourceRepository{}
+	for _, row := range rows {
+		repositories = append(repositories, model.NewSourceRepository(row))
+	}
+	httpext.JSON(w, http.StatusOK, repositories)
+}
+
+func (h *Handler) DeleteSourceRepository(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "repository_id"), 10, 32)
+	if err != nil || id < 1 {
+		h.respondError(&ValidatorError{err: errors.New("repository_id:invalid id"), skipOverwriteErrorMessage: true}, w)
+		return
+	}
+
+	ctx := r.Context()
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	row, err := pgClient.GetSourceRepository(ctx, int32(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		h.respondError(&NotFoundError{errSourceRepositoryNotFound}, w)
+		return
+	} else if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	if err := pgClient.DeleteSourceRepository(ctx, row.ID); err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	if err := model.DeleteSourceRepositoryNode(ctx, model.GetSourceRepositoryID(row.Url, row.Branch)); err != nil {
+		log.Error().Msgf("%v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	h.AuditUserActivity(r, EVENT_REGISTRY, ACTION_DELETE,
+		model.SourceRepositoryIDPathReq{RepositoryId: row.ID}, true)
+
+	w.WriteHeader(http.StatusNoContent)
+}
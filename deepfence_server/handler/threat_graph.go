@@ -70,27 +70,32 @@ func (h *Handler) GetIndividualThreatGraph(w http.ResponseWriter, r *http.Reques
 		individualThreatGraph, err = reporters_graph.GetIndividualThreatGraph[model.Vulnerability](
 			r.Context(),
 			req.GraphType,
-			req.NodeIds)
+			req.NodeIds,
+			req.CisaKevOnly)
 	case "secret":
 		individualThreatGraph, err = reporters_graph.GetIndividualThreatGraph[model.Secret](
 			r.Context(),
 			req.GraphType,
-			req.NodeIds)
+			req.NodeIds,
+			false)
 	case "malware":
 		individualThreatGraph, err = reporters_graph.GetIndividualThreatGraph[model.Malware](
 			r.Context(),
 			req.GraphType,
-			req.NodeIds)
+			req.NodeIds,
+			false)
 	case "compliance":
 		individualThreatGraph, err = reporters_graph.GetIndividualThreatGraph[model.Compliance](
 			r.Context(),
 			req.GraphType,
-			req.NodeIds)
+			req.NodeIds,
+			false)
 	case "cloud_compliance":
 		individualThreatGraph, err = reporters_graph.GetIndividualThreatGraph[model.CloudCompliance](
 			r.Context(),
 			req.GraphType,
-			req.NodeIds)
+			req.NodeIds,
+			false)
 	}
 
 	if err != nil {
@@ -4,12 +4,19 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"time"
 
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/threatintel"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/vulnerability_db"
 	httpext "github.com/go-playground/pkg/v5/net/http"
 )
@@ -59,3 +66,76 @@ func (h *Handler) UploadVulnerabilityDB(w http.ResponseWriter, r *http.Request)
 
 	httpext.JSON(w, http.StatusOK, model.MessageResponse{Message: path + " " + checksum})
 }
+
+// UploadThreatIntel replaces the EPSS scores and the CISA KEV catalog used to
+// enrich vulnerabilities, the enrichment of the tenant starts right away and
+// the other tenants are enriched on their next run
+func (h *Handler) UploadThreatIntel(w http.ResponseWriter, r *http.Request) {
+
+	defer r.Body.Close()
+
+	if err := r.ParseMultipartForm(1024 * 1024); err != nil {
+		h.respondError(&BadDecoding{err}, w)
+		return
+	}
+
+	counts := map[string]int{}
+	feeds := map[string][]byte{}
+	for _, feed := range []string{threatintel.FeedEPSS, threatintel.FeedKEV} {
+		file, _, err := r.FormFile(feed)
+		if errors.Is(err, http.ErrMissingFile) {
+			continue
+		} else if err != nil {
+			h.respondError(&BadDecoding{err}, w)
+			return
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			h.respondError(&BadDecoding{err}, w)
+			return
+		}
+		count, err := threatintel.Validate(feed, data)
+		if err != nil {
+			h.respondError(&ValidatorError{err: errors.New(feed + ":" + err.Error()), skipOverwriteErrorMessage: true}, w)
+			return
+		}
+		counts[feed] = count
+		feeds[feed] = data
+	}
+	if len(feeds) == 0 {
+		h.respondError(&ValidatorError{err: errors.New("epss:epss or kev file is required"), skipOverwriteErrorMessage: true}, w)
+		return
+	}
+
+	ctx := r.Context()
+	for feed, data := range feeds {
+		if err := threatintel.Store(ctx, feed, data); err != nil {
+			log.Error().Msg(err.Error())
+			h.respondError(&InternalServerError{err}, w)
+			return
+		}
+	}
+
+	namespace, err := directory.ExtractNamespace(ctx)
+	if err != nil {
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+	msg := message.NewMessage(watermill.NewUUID(), []byte{})
+	msg.Metadata = map[string]string{directory.NamespaceKey: string(namespace)}
+	msg.SetContext(directory.NewContextWithNameSpace(namespace))
+	middleware.SetCorrelationID(watermill.NewShortUUID(), msg)
+	if err := h.TasksPublisher.Publish(utils.ThreatIntelTask, msg); err != nil {
+		log.Error().Msgf("failed to publish task: %+v", err)
+		h.respondError(&InternalServerError{err}, w)
+		return
+	}
+
+	h.AuditUserActivity(r, EVENT_SETTINGS, ACTION_UPDATE, counts, true)
+
+	httpext.JSON(w, http.StatusOK, model.MessageResponse{
+		Message: fmt.Sprintf("loaded %d EPSS scores and %d known exploited vulnerabilities",
+			counts[threatintel.FeedEPSS], counts[threatintel.FeedKEV]),
+	})
+}
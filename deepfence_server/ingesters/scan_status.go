@@ -10,6 +10,7 @@ import (
 	"github.com/deepfence/ThreatMapper/deepfence_utils/controls"
 	ctl "github.com/deepfence/ThreatMapper/deepfence_utils/controls"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	ingestersUtil "github.com/deepfence/ThreatMapper/deepfence_utils/utils/ingesters"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
@@ -213,6 +214,17 @@ func AddNewScan(tx WriteDBTransaction,
 			}); err != nil {
 			return err
 		}
+	case controls.SourceRepository, controls.RegistryArtifact:
+		// repositories and charts are pulled and scanned by the console workers
+		if _, err = tx.Run(fmt.Sprintf(`
+		MATCH (n:%s{node_id: $scan_id})
+		MATCH (l:Node{node_id: "deepfence-console-cron"})
+		MERGE (n)-[:SCHEDULED]->(l)`, scan_type),
+			map[string]interface{}{
+				"scan_id": scan_id,
+			}); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -374,6 +386,29 @@ func UpdateScanStatus(ctx context.Context, scan_type string, scan_id string, sta
 	}
 	defer tx.Close()
 
+	// scanned node of the scan, if it is failing now
+	var failed []*neo4j.Record
+	if status == utils.SCAN_STATUS_FAILED {
+		res, err := tx.Run(fmt.Sprintf(`
+			MATCH (n:%s{node_id: $scan_id}) -[:SCANNED]- (m)
+			WHERE n.status <> $status
+			RETURN m.node_id AS node_id, labels(m)[0] AS node_type, m.node_name AS node_name,
+				n.node_id AS scan_id, $scan_type AS scan_type,
+				$scan_type + ' on ' + coalesce(m.node_name, m.node_id) + ' failed: ' + $message AS message`, scan_type),
+			map[string]interface{}{
+				"scan_id":   scan_id,
+				"scan_type": scan_type,
+				"message":   message,
+				"status":    status})
+		if err != nil {
+			return err
+		}
+		failed, err = res.Collect()
+		if err != nil {
+			return err
+		}
+	}
+
 	if _, err = tx.Run(fmt.Sprintf(`
 		MERGE (n:%s{node_id: $scan_id})
 		SET n.status = $status, n.status_message = $message, n.updated_at = TIMESTAMP()`, scan_type),
@@ -384,7 +419,12 @@ func UpdateScanStatus(ctx context.Context, scan_type string, scan_id string, sta
 		return err
 	}
 
-	return tx.Commit()
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	notification.PublishRecords(ctx, notification.ScanFailed, failed)
+	return nil
 }
 
 func AddBulkScan(tx WriteDBTransaction, scan_type utils.Neo4jScanType, bulk_scan_id string, scan_ids []string) error {
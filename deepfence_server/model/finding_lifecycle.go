@@ -0,0 +1,312 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/helmchart"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// findingKeyField identifies a finding on the scanned node across scans, d
+// is the detected node and e its rule. Vulnerabilities, secrets and malware
+// are result nodes keyed on package or file and rule, compliance results are
+// created per node and cloud compliance results per scan so they are keyed
+// on their benchmark, control and resource
+var findingKeyField = map[utils.Neo4jScanType]string{
+	utils.NEO4J_VULNERABILITY_SCAN:    "d.node_id",
+	utils.NEO4J_SECRET_SCAN:           "d.node_id",
+	utils.NEO4J_MALWARE_SCAN:          "d.node_id",
+	utils.NEO4J_COMPLIANCE_SCAN:       "coalesce(d.compliance_check_type, '') + '--' + coalesce(d.test_number, e.node_id, '') + '--' + coalesce(d.resource, '')",
+	utils.NEO4J_CLOUD_COMPLIANCE_SCAN: "coalesce(d.compliance_check_type, '') + '--' + coalesce(d.control_id, '') + '--' + coalesce(d.resource, '')",
+}
+
+// findingSeverityField is the severity of a result, d is the detected node
+// and e its rule
+var findingSeverityField = map[utils.Neo4jScanType]string{
+	utils.NEO4J_VULNERABILITY_SCAN:    "coalesce(d.cve_severity, e.cve_severity, '')",
+	utils.NEO4J_SECRET_SCAN:           "coalesce(d.level, e.level, '')",
+	utils.NEO4J_MALWARE_SCAN:          "coalesce(d.file_severity, e.file_severity, '')",
+	utils.NEO4J_COMPLIANCE_SCAN:       "coalesce(d.test_severity, e.test_severity, '')",
+	utils.NEO4J_CLOUD_COMPLIANCE_SCAN: "coalesce(d.severity, '')",
+}
+
+// findingPassCondition drops the passing compliance results, they are
+// recorded for passing checks too and only the failing ones are findings. The
+// passing statuses depend on the checks like in GetPassStatus, warn passes
+// linux checks of hosts but fails helm chart and kubernetes checks
+const findingPassCondition = ` AND NOT d.status IN CASE
+	WHEN d.type = 'helm' THEN $helm_pass_statuses
+	WHEN s:Node THEN $linux_pass_statuses
+	ELSE $pass_statuses END`
+
+// findingPassStatuses returns the query parameters of findingPassCondition
+func findingPassStatuses(params map[string]interface{}) map[string]interface{} {
+	params["helm_pass_statuses"] = []string{helmchart.StatusPass}
+	params["linux_pass_statuses"] = CloudNodeAccountInfo{CloudProvider: PostureProviderLinux}.GetPassStatus()
+	params["pass_statuses"] = CloudNodeAccountInfo{CloudProvider: PostureProviderKubernetes}.GetPassStatus()
+	return params
+}
+
+type FindingLifecycleReq struct {
+	ScanType string      `json:"scan_type" validate:"required,oneof=SecretScan VulnerabilityScan MalwareScan ComplianceScan CloudComplianceScan" required:"true" enum:"SecretScan,VulnerabilityScan,MalwareScan,ComplianceScan,CloudComplianceScan"`
+	NodeId   string      `json:"node_id" validate:"required" required:"true"`
+	Window   FetchWindow `json:"window" required:"true"`
+}
+
+type FindingLifecycle struct {
+	ScanType string `json:"scan_type" required:"true"`
+	NodeId   string `json:"node_id" required:"true"`
+	NodeType string `json:"node_type" required:"true"`
+	// key of the finding on the node, the vulnerability, secret or malware
+	// node id, or the compliance test or control and the resource
+	ResultId   string                 `json:"result_id" required:"true"`
+	Severity   string                 `json:"severity" required:"true"`
+	Labels     map[string]interface{} `json:"labels" required:"true"`
+	FirstSeen  int64                  `json:"first_seen" required:"true" format:"int64"`
+	LastSeen   int64                  `json:"last_seen" required:"true" format:"int64"`
+	ResolvedAt int64                  `json:"resolved_at" required:"true" format:"int64"`
+	Reopened   int32                  `json:"reopened" required:"true"`
+	LastScanId string                 `json:"last_scan_id" required:"true"`
+	// within_sla, due_soon or breached, empty when no SLA policy applies
+	SLAStatus string `json:"sla_status" required:"true"`
+	SLADueAt  int64  `json:"sla_due_at" required:"true" format:"int64"`
+}
+
+func NewFindingLifecycle(row postgresqlDb.FindingLifecycle) FindingLifecycle {
+	f := FindingLifecycle{
+		ScanType:   row.ScanType,
+		NodeId:     row.NodeID,
+		NodeType:   row.NodeType,
+		ResultId:   row.ResultID,
+		Severity:   row.Severity,
+		Labels:     map[string]interface{}{},
+		FirstSeen:  row.FirstSeen.Unix(),
+		LastSeen:   row.LastSeen.Unix(),
+		Reopened:   row.Reopened,
+		LastScanId: row.LastScanID,
+		SLAStatus:  row.SlaStatus,
+	}
+	_ = json.Unmarshal(row.Labels, &f.Labels)
+	if row.ResolvedAt.Valid {
+		f.ResolvedAt = row.ResolvedAt.Time.Unix()
+	}
+	if row.SlaDueAt.Valid {
+		f.SLADueAt = row.SlaDueAt.Time.Unix()
+	}
+	return f
+}
+
+type FindingMTTRReq struct {
+	// all scan types when empty
+	ScanType string `json:"scan_type" validate:"omitempty,oneof=SecretScan VulnerabilityScan MalwareScan ComplianceScan CloudComplianceScan" enum:"SecretScan,VulnerabilityScan,MalwareScan,ComplianceScan,CloudComplianceScan"`
+	GroupBy  string `json:"group_by" validate:"required,oneof=severity node_type label" required:"true" enum:"severity,node_type,label"`
+	// docker or kubernetes label key, e.g. team, to group by
+	Label  string `json:"label" validate:"required_if=GroupBy label,max=256"`
+	Period string `json:"period" validate:"required,oneof=day week month quarter year" required:"true" enum:"day,week,month,quarter,year"`
+	// resolved between from and to, unix seconds, to defaults to now
+	From int64 `json:"from" validate:"required,min=1" required:"true" format:"int64"`
+	To   int64 `json:"to" format:"int64"`
+}
+
+type FindingMTTR struct {
+	PeriodStart   int64   `json:"period_start" required:"true" format:"int64"`
+	Group         string  `json:"group" required:"true"`
+	ResolvedCount int64   `json:"resolved_count" required:"true" format:"int64"`
+	MTTRSeconds   float64 `json:"mttr_seconds" required:"true"`
+}
+
+// findingPeriodStart truncates t to the start of its period in UTC, weeks
+// start on monday
+func findingPeriodStart(t time.Time, period string) time.Time {
+	t = t.UTC()
+	year, month, day := t.Date()
+	switch period {
+	case "week":
+		day -= (int(t.Weekday()) + 6) % 7
+	case "month":
+		day = 1
+	case "quarter":
+		month, day = month-(month-1)%3, 1
+	case "year":
+		month, day = time.January, 1
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// AggregateFindingMTTR rolls the daily totals of resolved findings up to
+// the periods, the mean of a period is weighted by the findings resolved
+// each day
+func AggregateFindingMTTR(rows []postgresqlDb.GetFindingMTTRRow, period string) []FindingMTTR {
+	type key struct {
+		start int64
+		group string
+	}
+	totals := map[key]*FindingMTTR{}
+	seconds := map[key]float64{}
+	keys := []key{}
+	for _, row := range rows {
+		k := key{start: findingPeriodStart(row.ResolvedDay, period).Unix(), group: row.GroupValue}
+		m, ok := totals[k]
+		if !ok {
+			m = &FindingMTTR{PeriodStart: k.start, Group: k.group}
+			totals[k] = m
+			keys = append(keys, k)
+		}
+		m.ResolvedCount += row.ResolvedCount
+		seconds[k] += row.ResolvedSeconds
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].start != keys[j].start {
+			return keys[i].start < keys[j].start
+		}
+		return keys[i].group < keys[j].group
+	})
+	res := []FindingMTTR{}
+	for _, k := range keys {
+		m := totals[k]
+		if m.ResolvedCount > 0 {
+			m.MTTRSeconds = seconds[k] / float64(m.ResolvedCount)
+		}
+		res = append(res, *m)
+	}
+	return res
+}
+
+// findingLabels merges the docker and kubernetes labels of the scanned node,
+// they are stored as json strings
+func findingLabels(values ...interface{}) json.RawMessage {
+	labels := map[string]interface{}{}
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok || s == "" {
+			continue
+		}
+		l := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(s), &l); err != nil {
+			continue
+		}
+		for k, v := range l {
+			labels[k] = v
+		}
+	}
+	b, _ := json.Marshal(labels)
+	return b
+}
+
+// lifecycleFindings returns the keys and severities of the [key, severity]
+// pairs collected for a scan, a finding is counted once
+func lifecycleFindings(findings []interface{}) ([]string, []string) {
+	resultIds := []string{}
+	severities := []string{}
+	seen := map[string]bool{}
+	for _, f := range findings {
+		finding, ok := f.([]interface{})
+		if !ok || len(finding) != 2 {
+			continue
+		}
+		resultId, ok := finding[0].(string)
+		if !ok || resultId == "" || seen[resultId] {
+			continue
+		}
+		seen[resultId] = true
+		severity, _ := finding[1].(string)
+		resultIds = append(resultIds, resultId)
+		severities = append(severities, severity)
+	}
+	return resultIds, severities
+}
+
+// UpdateFindingLifecycles records the findings of completed scans on the
+// scanned nodes, findings of a node missing from its scan are resolved and
+// resolved findings detected again are reopened
+func UpdateFindingLifecycles(ctx context.Context, scanType utils.Neo4jScanType, scanIds []string) error {
+	keyField, ok := findingKeyField[scanType]
+	if !ok || len(scanIds) == 0 {
+		return nil
+	}
+	passCondition := ""
+	if scanType == utils.NEO4J_COMPLIANCE_SCAN || scanType == utils.NEO4J_CLOUD_COMPLIANCE_SCAN {
+		passCondition = findingPassCondition
+	}
+
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(60 * time.Second))
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	res, err := tx.Run(`
+		UNWIND $scan_ids AS scan_id
+		MATCH (m:`+string(scanType)+`{node_id: scan_id}) -[:SCANNED]-> (s)
+		OPTIONAL MATCH (m) -[:DETECTED]-> (d)
+		OPTIONAL MATCH (d) -[:IS]-> (e)
+		WITH m, s, collect(CASE WHEN d IS NOT NULL`+passCondition+`
+			THEN [`+keyField+`, `+findingSeverityField[scanType]+`] END) AS findings
+		RETURN m.node_id, m.updated_at, s.node_id, labels(s)[0], s.docker_label, s.kubernetes_labels, findings,
+			CASE WHEN s:CloudNode THEN s.node_id ELSE coalesce(s.account_id, '') END`,
+		findingPassStatuses(map[string]interface{}{"scan_ids": scanIds}))
+	if err != nil {
+		return err
+	}
+	recs, err := res.Collect()
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range recs {
+		scanId := rec.Values[0].(string)
+		nodeId := rec.Values[2].(string)
+		seenAt := time.Now()
+		if updatedAt, ok := rec.Values[1].(int64); ok {
+			seenAt = time.UnixMilli(updatedAt)
+		}
+		nodeType, _ := rec.Values[3].(string)
+		cloudAccount, _ := rec.Values[7].(string)
+
+		resultIds, severities := lifecycleFindings(rec.Values[6].([]interface{}))
+
+		err = pgClient.UpsertFindingLifecycles(ctx, postgresqlDb.UpsertFindingLifecyclesParams{
+			ScanType:     string(scanType),
+			NodeID:       nodeId,
+			NodeType:     nodeType,
+			Labels:       findingLabels(rec.Values[4], rec.Values[5]),
+			ResultIds:    resultIds,
+			Severities:   severities,
+			SeenAt:       seenAt,
+			ScanID:       scanId,
+			CloudAccount: cloudAccount,
+		})
+		if err != nil {
+			return err
+		}
+		err = pgClient.ResolveFindingLifecycles(ctx, postgresqlDb.ResolveFindingLifecyclesParams{
+			ResolvedAt: sql.NullTime{Time: seenAt, Valid: true},
+			ScanType:   string(scanType),
+			NodeID:     nodeId,
+			ResultIds:  resultIds,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"gotest.tools/assert"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestFindingPeriodStart(t *testing.T) {
+	// a wednesday
+	resolved := time.Date(2023, time.August, 16, 13, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		period string
+		want   string
+	}{
+		{"day", "2023-08-16"},
+		{"week", "2023-08-14"},
+		{"month", "2023-08-01"},
+		{"quarter", "2023-07-01"},
+		{"year", "2023-01-01"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, findingPeriodStart(resolved, tt.period), day(tt.want), tt.period)
+	}
+
+	assert.Equal(t, findingPeriodStart(day("2023-08-13"), "week"), day("2023-08-07"), "sundays end the week")
+	assert.Equal(t, findingPeriodStart(day("2023-12-31"), "quarter"), day("2023-10-01"))
+}
+
+func TestAggregateFindingMTTR(t *testing.T) {
+	hour := float64(time.Hour / time.Second)
+	rows := []postgresqlDb.GetFindingMTTRRow{
+		{ResolvedDay: day("2023-08-14"), GroupValue: "high", ResolvedCount: 1, ResolvedSeconds: 10 * hour},
+		{ResolvedDay: day("2023-08-14"), GroupValue: "low", ResolvedCount: 2, ResolvedSeconds: 100 * hour},
+		{ResolvedDay: day("2023-08-16"), GroupValue: "high", ResolvedCount: 3, ResolvedSeconds: 30 * hour},
+		{ResolvedDay: day("2023-08-21"), GroupValue: "high", ResolvedCount: 1, ResolvedSeconds: 2 * hour},
+	}
+
+	daily := AggregateFindingMTTR(rows, "day")
+	assert.Equal(t, len(daily), 4)
+	assert.Equal(t, daily[0].MTTRSeconds, 10*hour)
+	assert.Equal(t, daily[1].MTTRSeconds, 50*hour)
+
+	weekly := AggregateFindingMTTR(rows, "week")
+	assert.DeepEqual(t, weekly, []FindingMTTR{
+		// the mean is weighted by the findings resolved each day
+		{PeriodStart: day("2023-08-14").Unix(), Group: "high", ResolvedCount: 4, MTTRSeconds: 10 * hour},
+		{PeriodStart: day("2023-08-14").Unix(), Group: "low", ResolvedCount: 2, MTTRSeconds: 50 * hour},
+		{PeriodStart: day("2023-08-21").Unix(), Group: "high", ResolvedCount: 1, MTTRSeconds: 2 * hour},
+	})
+
+	monthly := AggregateFindingMTTR(rows, "month")
+	assert.Equal(t, len(monthly), 2)
+	assert.Equal(t, monthly[0].Group, "high")
+	assert.Equal(t, monthly[0].ResolvedCount, int64(5))
+	assert.Equal(t, monthly[0].MTTRSeconds, 42*hour/5)
+
+	assert.Equal(t, len(AggregateFindingMTTR(nil, "week")), 0)
+}
+
+func TestLifecycleFindings(t *testing.T) {
+	findings := []interface{}{
+		[]interface{}{"s3.1--arn:aws:s3:::a", "high"},
+		[]interface{}{"s3.1--arn:aws:s3:::b", "high"},
+		[]interface{}{"s3.1--arn:aws:s3:::a", "high"},
+		[]interface{}{"", "low"},
+		[]interface{}{"iam.2--arn:aws:iam::1:root", nil},
+		nil,
+	}
+
+	ids, severities := lifecycleFindings(findings)
+	assert.DeepEqual(t, ids, []string{"s3.1--arn:aws:s3:::a", "s3.1--arn:aws:s3:::b", "iam.2--arn:aws:iam::1:root"})
+	assert.DeepEqual(t, severities, []string{"high", "high", ""})
+}
+
+func TestFindingPassStatuses(t *testing.T) {
+	contains := func(statuses interface{}, status string) bool {
+		for _, s := range statuses.([]string) {
+			if s == status {
+				return true
+			}
+		}
+		return false
+	}
+	params := findingPassStatuses(map[string]interface{}{"scan_ids": []string{"s1"}})
+	assert.DeepEqual(t, params["scan_ids"], []string{"s1"})
+	// warn fails helm chart and kubernetes checks but passes linux checks
+	assert.Assert(t, !contains(params["helm_pass_statuses"], "warn"))
+	assert.Assert(t, !contains(params["pass_statuses"], "warn"))
+	assert.Assert(t, contains(params["linux_pass_statuses"], "warn"))
+	assert.Assert(t, contains(params["helm_pass_statuses"], "pass"))
+	assert.Assert(t, contains(params["pass_statuses"], "ok"))
+}
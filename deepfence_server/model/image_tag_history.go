@@ -0,0 +1,229 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+type ImageDigestReq struct {
+	Digest string `json:"digest" validate:"required,min=12,max=255" required:"true"`
+}
+
+type ImageTagRef struct {
+	RegistryId string `json:"registry_id" required:"true"`
+	ImageName  string `json:"image_name" required:"true"`
+	Tag        string `json:"tag" required:"true"`
+	// digest the tag points to, the manifest list digest for platform images
+	Digest string `json:"digest" required:"true"`
+	Since  int64  `json:"since" required:"true" format:"int64"`
+}
+
+type ImageDigestContainer struct {
+	NodeId   string `json:"node_id" required:"true"`
+	NodeName string `json:"node_name" required:"true"`
+	HostName string `json:"host_name" required:"true"`
+	ImageId  string `json:"image_id" required:"true"`
+}
+
+type ImageDigestResp struct {
+	Digest string `json:"digest" required:"true"`
+	// node ids of the image and of its platform images
+	ImageIds   []string               `json:"image_ids" required:"true"`
+	Tags       []ImageTagRef          `json:"tags" required:"true"`
+	Containers []ImageDigestContainer `json:"containers" required:"true"`
+	// latest completed vulnerability scan of every image id
+	VulnerabilityScanIds map[string]string `json:"vulnerability_scan_ids" required:"true"`
+}
+
+type ImageTagHistoryReq struct {
+	RegistryId string `path:"registry_id" validate:"required" required:"true"`
+	ImageName  string `json:"image_name" validate:"required" required:"true"`
+	Tag        string `json:"tag" validate:"required" required:"true"`
+}
+
+type ImageTagHistoryEntry struct {
+	ID             int64  `json:"id" required:"true"`
+	RegistryId     string `json:"registry_id" required:"true"`
+	ImageName      string `json:"image_name" required:"true"`
+	Tag            string `json:"tag" required:"true"`
+	Digest         string `json:"digest" required:"true"`
+	PreviousDigest string `json:"previous_digest" required:"true"`
+	FirstSeenAt    int64  `json:"first_seen_at" required:"true" format:"int64"`
+	LastSeenAt     int64  `json:"last_seen_at" required:"true" format:"int64"`
+	// zero while the tag still points to the digest
+	ReplacedAt          int64  `json:"replaced_at" required:"true" format:"int64"`
+	VulnerabilityScanId string `json:"vulnerability_scan_id" required:"true"`
+}
+
+type ImageTagHistoryResp struct {
+	History []ImageTagHistoryEntry `json:"history" required:"true"`
+}
+
+type ImageTagChangeReq struct {
+	HistoryId int64 `path:"history_id" validate:"required,min=1" required:"true"`
+}
+
+type ImageTagChangeResp struct {
+	Change             ImageTagHistoryEntry `json:"change" required:"true"`
+	PreviousScanId     string               `json:"previous_scan_id" required:"true"`
+	CurrentScanId      string               `json:"current_scan_id" required:"true"`
+	AddedVulnerability []Vulnerability      `json:"added_vulnerabilities" required:"true"`
+	FixedVulnerability []Vulnerability      `json:"fixed_vulnerabilities" required:"true"`
+}
+
+func NewImageTagHistoryEntry(row postgresqlDb.ImageTagHistory) ImageTagHistoryEntry {
+	entry := ImageTagHistoryEntry{
+		ID:             row.ID,
+		RegistryId:     row.RegistryID,
+		ImageName:      row.ImageName,
+		Tag:            row.Tag,
+		Digest:         row.Digest,
+		PreviousDigest: row.PreviousDigest,
+		FirstSeenAt:    row.FirstSeenAt.UnixMilli(),
+		LastSeenAt:     row.LastSeenAt.UnixMilli(),
+	}
+	if row.ReplacedAt.Valid {
+		entry.ReplacedAt = row.ReplacedAt.Time.UnixMilli()
+	}
+	return entry
+}
+
+// GetDigestImageIds returns the node ids of the image with the digest and of
+// its platform images, and the digests of the manifest lists including it
+func GetDigestImageIds(ctx context.Context, digest string) ([]string, []string, error) {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Close()
+
+	imageId, _ := DigestToID(digest)
+	r, err := tx.Run(`
+		OPTIONAL MATCH (n:ContainerImage{node_id:$node_id}) -[:HAS_PLATFORM]-> (c:ContainerImage)
+		WITH collect(c.node_id) as children
+		OPTIONAL MATCH (p:ContainerImage) -[:HAS_PLATFORM]-> (:ContainerImage{node_id:$node_id})
+		RETURN children, collect(p.docker_image_digest)`,
+		map[string]interface{}{"node_id": imageId})
+	if err != nil {
+		return nil, nil, err
+	}
+	rec, err := r.Single()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := []string{imageId}
+	for _, v := range rec.Values[0].([]interface{}) {
+		ids = append(ids, v.(string))
+	}
+	parents := []string{}
+	for _, v := range rec.Values[1].([]interface{}) {
+		if s, ok := v.(string); ok && s != "" {
+			parents = append(parents, s)
+		}
+	}
+	return ids, parents, nil
+}
+
+// GetImageIdContainers returns the containers running one of the image ids,
+// containers are matched on the image id reported by the agent
+func GetImageIdContainers(ctx context.Context, imageIds []string) ([]ImageDigestContainer, error) {
+	res := []ImageDigestContainer{}
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return res, err
+	}
+	defer tx.Close()
+
+	r, err := tx.Run(`
+		MATCH (n:Container)
+		WHERE n.active = true
+		AND (n.docker_image_id IN $ids OR n.docker_image_id IN $digests)
+		RETURN n.node_id, COALESCE(n.node_name, n.node_id), COALESCE(n.host_name, ""), n.docker_image_id`,
+		map[string]interface{}{"ids": imageIds, "digests": digests(imageIds)})
+	if err != nil {
+		return res, err
+	}
+	recs, err := r.Collect()
+	if err != nil {
+		return res, err
+	}
+	for _, rec := range recs {
+		res = append(res, ImageDigestContainer{
+			NodeId:   rec.Values[0].(string),
+			NodeName: rec.Values[1].(string),
+			HostName: rec.Values[2].(string),
+			ImageId:  rec.Values[3].(string),
+		})
+	}
+	return res, nil
+}
+
+func digests(imageIds []string) []string {
+	res := make([]string, 0, len(imageIds))
+	for _, id := range imageIds {
+		res = append(res, IDToDigest(id))
+	}
+	return res
+}
+
+// GetLatestImageVulnerabilityScans returns the latest completed vulnerability
+// scan of every image id, image ids are digests so the scans are of the
+// exact content
+func GetLatestImageVulnerabilityScans(ctx context.Context, imageIds []string) (map[string]string, error) {
+	res := map[string]string{}
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return res, err
+	}
+	defer tx.Close()
+
+	r, err := tx.Run(`
+		MATCH (s:`+string(utils.NEO4J_VULNERABILITY_SCAN)+`) -[:SCANNED]-> (n:ContainerImage)
+		WHERE n.node_id IN $ids
+		AND s.status = $complete
+		WITH n, s
+		ORDER BY s.updated_at DESC
+		RETURN n.node_id, collect(s.node_id)[0]`,
+		map[string]interface{}{"ids": imageIds, "complete": utils.SCAN_STATUS_SUCCESS})
+	if err != nil {
+		return res, err
+	}
+	recs, err := r.Collect()
+	if err != nil {
+		return res, err
+	}
+	for _, rec := range recs {
+		res[rec.Values[0].(string)] = rec.Values[1].(string)
+	}
+	return res, nil
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"reflect"
+	"time"
 
 	"github.com/deepfence/ThreatMapper/deepfence_server/reporters"
 
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/constants"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/msgtemplate"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
 	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
 )
 
@@ -22,15 +26,37 @@ type IntegrationAddReq struct {
 	IntegrationType  string                 `json:"integration_type"`
 	NotificationType string                 `json:"notification_type"`
 	Filters          IntegrationFilters     `json:"filters"`
+	MessageTemplate  msgtemplate.Template   `json:"message_template"`
+	// DigestInterval aggregates findings into one summary message per
+	// interval instead of a message per scan
+	DigestInterval string `json:"digest_interval" enum:"hourly,daily"`
+	// RateLimit is the max messages sent per hour, overflow is rolled into
+	// the next digest, 0 is unlimited
+	RateLimit int32 `json:"rate_limit"`
+	// NewFindingsOnly notifies only the findings which were not reported by
+	// the previous scan of the node, NotifyResolved also notifies the findings
+	// which are no longer reported
+	NewFindingsOnly bool `json:"new_findings_only"`
+	NotifyResolved  bool `json:"notify_resolved"`
 }
 
 type IntegrationFilters struct {
 	FieldsFilters reporters.FieldsFilters `json:"fields_filters"`
 	NodeIds       []NodeIdentifier        `json:"node_ids" required:"true"`
+	// EventTypes of the Event notification type to send, all if empty
+	EventTypes []string `json:"event_types" enum:"scan_failed,agent_inactive,agent_upgrade_failed,registry_sync_failed,registry_credentials_invalid,registry_credentials_expiring,vulnerability_db_update_failed,exception_expired,sla_breached"`
 }
 
 func (i *IntegrationAddReq) IntegrationExists(ctx context.Context, pgClient *postgresqlDb.Queries) (bool, error) {
-	integrations, err := pgClient.GetIntegrationsFromType(ctx, i.IntegrationType)
+	return integrationExists(ctx, pgClient, i.IntegrationType, i.NotificationType, i.Config, 0)
+}
+
+// integrationExists checks for another integration of the same type and
+// resource with the same config, excludeID is skipped when non zero
+func integrationExists(ctx context.Context, pgClient *postgresqlDb.Queries,
+	integrationType, resource string, config map[string]interface{}, excludeID int32) (bool, error) {
+
+	integrations, err := pgClient.GetIntegrationsFromType(ctx, integrationType)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, nil
@@ -38,18 +64,18 @@ func (i *IntegrationAddReq) IntegrationExists(ctx context.Context, pgClient *pos
 		return false, err
 	}
 
-	var config map[string]interface{}
-	var found = false
-
 	for _, integration := range integrations {
+		if integration.ID == excludeID || integration.Resource != resource {
+			continue
+		}
 		// json.rawmessage to map[string]interface{}
-		err = json.Unmarshal(integration.Config, &config)
+		var existing map[string]interface{}
+		err = json.Unmarshal(integration.Config, &existing)
 		if err != nil {
 			return false, err
 		}
 		// compare the config
-		found = reflect.DeepEqual(config, i.Config)
-		if found && i.NotificationType == integration.Resource {
+		if reflect.DeepEqual(existing, config) {
 			return true, nil
 		}
 	}
@@ -67,18 +93,111 @@ func (i *IntegrationAddReq) CreateIntegration(ctx context.Context, pgClient *pos
 		return err
 	}
 
+	bTemplate, err := json.Marshal(i.MessageTemplate)
+	if err != nil {
+		return err
+	}
+
 	arg := postgresqlDb.CreateIntegrationParams{
 		Resource:        i.NotificationType,
 		IntegrationType: i.IntegrationType,
 		Config:          bConfig,
 		Filters:         bFilter,
 		CreatedByUserID: userID,
+		MessageTemplate: bTemplate,
+		DigestInterval:  i.DigestInterval,
+		RateLimit:       i.RateLimit,
+		NewFindingsOnly: i.NewFindingsOnly,
+		NotifyResolved:  i.NotifyResolved,
 	}
 	_, err = pgClient.CreateIntegration(ctx, arg)
 
 	return err
 }
 
+// IntegrationUpdateReq is the request body for updating an existing integration,
+// integration type can not be changed
+type IntegrationUpdateReq struct {
+	IntegrationID    string                 `path:"integration_id" validate:"required" required:"true"`
+	Config           map[string]interface{} `json:"config"`
+	IntegrationType  string                 `json:"integration_type"`
+	NotificationType string                 `json:"notification_type"`
+	Filters          IntegrationFilters     `json:"filters"`
+	MessageTemplate  msgtemplate.Template   `json:"message_template"`
+	// DigestInterval aggregates findings into one summary message per
+	// interval instead of a message per scan
+	DigestInterval string `json:"digest_interval" enum:"hourly,daily"`
+	// RateLimit is the max messages sent per hour, overflow is rolled into
+	// the next digest, 0 is unlimited
+	RateLimit int32 `json:"rate_limit"`
+	// NewFindingsOnly notifies only the findings which were not reported by
+	// the previous scan of the node, NotifyResolved also notifies the findings
+	// which are no longer reported
+	NewFindingsOnly bool `json:"new_findings_only"`
+	NotifyResolved  bool `json:"notify_resolved"`
+}
+
+// RestoreSensitiveFields keeps the stored value of sensitive config fields
+// which are missing or sent back redacted as returned by list integrations
+func (i *IntegrationUpdateReq) RestoreSensitiveFields(existing map[string]interface{}) {
+	if i.Config == nil {
+		i.Config = map[string]interface{}{}
+	}
+	for key := range constants.SensitiveFields {
+		old, ok := existing[key].(string)
+		if !ok {
+			continue
+		}
+		value, found := i.Config[key]
+		if !found {
+			i.Config[key] = old
+			continue
+		}
+		if v, ok := value.(string); ok && v == redactLastHalfString(old) {
+			i.Config[key] = old
+		}
+	}
+}
+
+// IntegrationExists checks for another integration with the updated config
+func (i *IntegrationUpdateReq) IntegrationExists(ctx context.Context, pgClient *postgresqlDb.Queries, id int32) (bool, error) {
+	return integrationExists(ctx, pgClient, i.IntegrationType, i.NotificationType, i.Config, id)
+}
+
+func (i *IntegrationUpdateReq) UpdateIntegration(ctx context.Context, pgClient *postgresqlDb.Queries, id int32) error {
+	bConfig, err := json.Marshal(i.Config)
+	if err != nil {
+		return err
+	}
+
+	bFilter, err := json.Marshal(i.Filters)
+	if err != nil {
+		return err
+	}
+
+	bTemplate, err := json.Marshal(i.MessageTemplate)
+	if err != nil {
+		return err
+	}
+
+	return pgClient.UpdateIntegration(ctx, postgresqlDb.UpdateIntegrationParams{
+		ID:              id,
+		Resource:        i.NotificationType,
+		Filters:         bFilter,
+		Config:          bConfig,
+		MessageTemplate: bTemplate,
+		DigestInterval:  i.DigestInterval,
+		RateLimit:       i.RateLimit,
+		NewFindingsOnly: i.NewFindingsOnly,
+		NotifyResolved:  i.NotifyResolved,
+	})
+}
+
+type IntegrationTestResp struct {
+	Success  bool   `json:"success" required:"true"`
+	ErrorMsg string `json:"error_msg"`
+}
+
 type IntegrationListReq struct {
 	IntegrationTypes []string `json:"integration_types"`
 }
@@ -90,6 +209,20 @@ type IntegrationListResp struct {
 	Config           map[string]interface{}  `json:"config"`
 	Filters          reporters.FieldsFilters `json:"filters"`
 	LastErrorMsg     string                  `json:"last_error_msg"`
+	LastSentTime     *time.Time              `json:"last_sent_time"`
+	LastSuccessTime  *time.Time              `json:"last_success_time"`
+	MessageTemplate  msgtemplate.Template    `json:"message_template"`
+	DigestInterval   string                  `json:"digest_interval"`
+	RateLimit        int32                   `json:"rate_limit"`
+	LastDigestTime   *time.Time              `json:"last_digest_time"`
+	NewFindingsOnly  bool                    `json:"new_findings_only"`
+	NotifyResolved   bool                    `json:"notify_resolved"`
+}
+
+// IntegrationPreviewReq renders a message template against sample findings
+type IntegrationPreviewReq struct {
+	NotificationType string               `json:"notification_type" validate:"required" required:"true"`
+	MessageTemplate  msgtemplate.Template `json:"message_template"`
 }
 
 func (i *IntegrationListReq) GetIntegrations(ctx context.Context, pgClient *postgresqlDb.Queries) ([]postgresqlDb.Integration, error) {
@@ -131,3 +264,122 @@ func DeleteIntegration(ctx context.Context, pgClient *postgresqlDb.Queries, inte
 	err := pgClient.DeleteIntegration(ctx, integrationID)
 	return err
 }
+
+var sampleSeverityField = map[string]string{
+	"Vulnerability":   "cve_severity",
+	"Secret":          "level",
+	"Malware":         "file_severity",
+	"Compliance":      "test_severity",
+	"CloudCompliance": "test_severity",
+}
+
+// SampleIntegrationMessage returns a synthetic finding of the given resource
+// type along with the extras, used to test an integration
+func SampleIntegrationMessage(resource string, messagingFormat bool) ([]map[string]interface{}, map[string]interface{}, error) {
+	var updatedAt interface{} = time.Now().UnixMilli()
+	if messagingFormat {
+		updatedAt = time.Now()
+	}
+
+	var sample map[string]interface{}
+	switch resource {
+	case "Vulnerability":
+		sample = map[string]interface{}{
+			"cve_id":                "CVE-0000-0000",
+			"cve_type":              "base",
+			"cve_caused_by_package": "deepfence-sample:1.0.0",
+			"cve_fixed_in":          "1.0.1",
+			"cve_cvss_score":        7.5,
+			"cve_description":       "Sample vulnerability sent to test the integration",
+			"cve_link":              "https://deepfence.io",
+		}
+	case "Secret":
+		sample = map[string]interface{}{
+			"rule_id":         0,
+			"name":            "Sample secret",
+			"score":           7.5,
+			"full_filename":   "/deepfence/sample.txt",
+			"matched_content": "********",
+		}
+	case "Malware":
+		sample = map[string]interface{}{
+			"rule_id":           "sample",
+			"rule_name":         "Sample malware",
+			"class":             "sample",
+			"complete_filename": "/deepfence/sample.bin",
+			"summary":           "Sample malware sent to test the integration",
+		}
+	case notification.EventResource:
+		// events carry the event details, not the scanned node, same as
+		// delivered by the notification cron job
+		sample = map[string]interface{}{
+			"event_type": notification.ScanFailed,
+			"scan_type":  "VulnerabilityScan",
+			"scan_id":    "deepfence-sample-scan",
+			"node_id":    "deepfence-sample-node",
+			"node_type":  "host",
+			"message":    "Sample event sent to test the integration",
+			"created_at": updatedAt,
+		}
+		return []map[string]interface{}{sample},
+			map[string]interface{}{"scan_type": notification.EventResource}, nil
+	case "Compliance", "CloudCompliance":
+		sample = map[string]interface{}{
+			"test_number":           "0.0.0",
+			"test_category":         "sample",
+			"compliance_check_type": "cis",
+			"status":                "alarm",
+			"description":           "Sample compliance check sent to test the integration",
+		}
+	default:
+		return nil, nil, errors.New("invalid notification type")
+	}
+
+	if field, ok := sampleSeverityField[resource]; ok {
+		sample[field] = "high"
+	}
+	sample["updated_at"] = updatedAt
+	sample["node_id"] = "deepfence-sample-node"
+	sample["node_name"] = "deepfence-sample-node"
+	sample["node_type"] = "host"
+	sample["scan_id"] = "deepfence-sample-scan"
+	sample["host_name"] = "deepfence-sample-node"
+
+	extras := map[string]interface{}{
+		"node_id":   "deepfence-sample-node",
+		"node_name": "deepfence-sample-node",
+		"node_type": "host",
+		"scan_id":   "deepfence-sample-scan",
+		"host_name": "deepfence-sample-node",
+		"scan_type": resource,
+	}
+
+	return []map[string]interface{}{sample}, extras, nil
+}
+
+// SampleIntegrationScan returns synthetic findings spread across nodes and
+// severities, used to preview the message template of an integration
+func SampleIntegrationScan(resource string) ([]map[string]interface{}, map[string]interface{}, error) {
+	sample, extras, err := SampleIntegrationMessage(resource, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	findings := []map[string]interface{}{}
+	for _, node := range []string{"deepfence-sample-node", "deepfence-sample-node-2"} {
+		for _, severity := range []string{"critical", "high", "medium"} {
+			f := make(map[string]interface{}, len(sample[0]))
+			for k, v := range sample[0] {
+				f[k] = v
+			}
+			f["node_id"] = node
+			f["node_name"] = node
+			f["host_name"] = node
+			if field, ok := sampleSeverityField[resource]; ok {
+				f[field] = severity
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings, extras, nil
+}
@@ -19,6 +19,9 @@ type KubernetesCluster struct {
 	Name         string `json:"node_name" required:"true"`
 	AgentRunning bool   `json:"agent_running" required:"true"`
 	Hosts        []Host `json:"hosts" required:"true"`
+	// open findings past or near their SLA deadline
+	SLABreachedCount int64 `json:"sla_breached_count" required:"true"`
+	SLADueSoonCount  int64 `json:"sla_due_soon_count" required:"true"`
 }
 
 func (KubernetesCluster) NodeType() string {
@@ -102,6 +105,8 @@ type Host struct {
 	CompliancesCount          int64            `json:"compliances_count" required:"true"`
 	ComplianceScanStatus      string           `json:"compliance_scan_status" required:"true"`
 	ComplianceLatestScanId    string           `json:"compliance_latest_scan_id" required:"true"`
+	SLABreachedCount          int64            `json:"sla_breached_count" required:"true"`
+	SLADueSoonCount           int64            `json:"sla_due_soon_count" required:"true"`
 	InboundConnections        []Connection     `json:"inbound_connections" required:"true"`
 	OutboundConnections       []Connection     `json:"outbound_connections" required:"true"`
 }
@@ -227,6 +232,8 @@ type Container struct {
 	MalwaresCount              int64                  `json:"malwares_count" required:"true"`
 	MalwareScanStatus          string                 `json:"malware_scan_status" required:"true"`
 	MalwareLatestScanId        string                 `json:"malware_latest_scan_id" required:"true"`
+	SLABreachedCount           int64                  `json:"sla_breached_count" required:"true"`
+	SLADueSoonCount            int64                  `json:"sla_due_soon_count" required:"true"`
 }
 
 func (Container) NodeType() string {
@@ -294,6 +301,34 @@ type IngestedContainerImage struct {
 	DockerImageID          string   `json:"docker_image_id" required:"true"`
 	ShortImageID           string   `json:"short_image_id"`
 	Metadata               Metadata `json:"metadata" nested_json:"true"`
+	// os/architecture of single platform images, empty for manifest lists
+	Platform string `json:"platform"`
+	// images of every platform of manifest lists and OCI indexes
+	Platforms []ImagePlatform `json:"platforms,omitempty"`
+}
+
+// ImagePlatform is the image built for a platform of a multi-arch image
+type ImagePlatform struct {
+	Digest       string `json:"digest"`
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant"`
+}
+
+// String returns the platform formatted like os/architecture/variant
+func (p ImagePlatform) String() string {
+	return FormatImagePlatform(p.OS, p.Architecture, p.Variant)
+}
+
+func FormatImagePlatform(os, architecture, variant string) string {
+	if os == "" && architecture == "" {
+		return ""
+	}
+	platform := os + "/" + architecture
+	if variant != "" {
+		platform += "/" + variant
+	}
+	return platform
 }
 
 func (IngestedContainerImage) NodeType() string {
@@ -332,7 +367,31 @@ type ContainerImage struct {
 	MalwaresCount             int64       `json:"malwares_count" required:"true"`
 	MalwareScanStatus         string      `json:"malware_scan_status" required:"true"`
 	MalwareLatestScanId       string      `json:"malware_latest_scan_id" required:"true"`
+	SLABreachedCount          int64       `json:"sla_breached_count" required:"true"`
+	SLADueSoonCount           int64       `json:"sla_due_soon_count" required:"true"`
 	Containers                []Container `json:"containers" required:"true"`
+	Platform                  string      `json:"platform"`
+	IsManifestList            bool        `json:"is_manifest_list"`
+	ParentImageID             string      `json:"parent_image_id"`
+	// scan results of every platform of manifest lists
+	Platforms []ContainerImagePlatform `json:"platforms"`
+}
+
+type ContainerImagePlatform struct {
+	ID                        string `json:"node_id" required:"true"`
+	Platform                  string `json:"platform" required:"true"`
+	OS                        string `json:"os" required:"true"`
+	Architecture              string `json:"architecture" required:"true"`
+	Variant                   string `json:"variant" required:"true"`
+	VulnerabilitiesCount      int64  `json:"vulnerabilities_count" required:"true"`
+	VulnerabilityScanStatus   string `json:"vulnerability_scan_status" required:"true"`
+	VulnerabilityLatestScanId string `json:"vulnerability_latest_scan_id" required:"true"`
+	SecretsCount              int64  `json:"secrets_count" required:"true"`
+	SecretScanStatus          string `json:"secret_scan_status" required:"true"`
+	SecretLatestScanId        string `json:"secret_latest_scan_id" required:"true"`
+	MalwaresCount             int64  `json:"malwares_count" required:"true"`
+	MalwareScanStatus         string `json:"malware_scan_status" required:"true"`
+	MalwareLatestScanId       string `json:"malware_latest_scan_id" required:"true"`
 }
 
 func (ContainerImage) NodeType() string {
@@ -395,6 +454,8 @@ type CloudNode struct {
 	CloudCompliancesCount       int64  `json:"cloud_compliances_count" required:"true"`
 	CloudComplianceScanStatus   string `json:"cloud_compliance_scan_status" required:"true"`
 	CloudComplianceLatestScanId string `json:"cloud_compliance_latest_scan_id" required:"true"`
+	SLABreachedCount            int64  `json:"sla_breached_count" required:"true"`
+	SLADueSoonCount             int64  `json:"sla_due_soon_count" required:"true"`
 }
 
 func (CloudNode) NodeType() string {
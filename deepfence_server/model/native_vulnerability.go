@@ -0,0 +1,100 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+)
+
+const VulnerabilitySourceThreatMapper = "threatmapper"
+
+// NativeVulnerabilityReport is the vulnerability report computed by the
+// registry itself for an image digest, like Trivy in Harbor or Clair in Quay
+type NativeVulnerabilityReport struct {
+	RegistryId      string          `json:"registry_id" required:"true"`
+	ImageName       string          `json:"image_name" required:"true"`
+	Digest          string          `json:"digest" required:"true"`
+	Source          string          `json:"source" required:"true"`
+	Scanner         string          `json:"scanner" required:"true"`
+	GeneratedAt     int64           `json:"generated_at" required:"true" format:"int64"`
+	FetchedAt       int64           `json:"fetched_at" required:"true" format:"int64"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities" required:"true"`
+}
+
+func NewNativeVulnerabilityReport(row postgresqlDb.NativeVulnerabilityReport) (NativeVulnerabilityReport, error) {
+	report := NativeVulnerabilityReport{
+		RegistryId:      row.RegistryID,
+		ImageName:       row.ImageName,
+		Digest:          row.Digest,
+		Source:          row.Source,
+		Scanner:         row.Scanner,
+		FetchedAt:       row.FetchedAt.UnixMilli(),
+		Vulnerabilities: []Vulnerability{},
+	}
+	if row.GeneratedAt.Valid {
+		report.GeneratedAt = row.GeneratedAt.Time.UnixMilli()
+	}
+	err := json.Unmarshal(row.Vulnerabilities, &report.Vulnerabilities)
+	return report, err
+}
+
+// NormalizeNativeSeverity maps the severities of the registry scanners to
+// the severities of ThreatMapper
+func NormalizeNativeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "defcon1":
+		return "critical"
+	case "high":
+		return "high"
+	case "medium", "moderate":
+		return "medium"
+	case "low", "negligible":
+		return "low"
+	}
+	return "unknown"
+}
+
+// ParseCVSSAttackVector returns the attack vector of a CVSS vector string
+func ParseCVSSAttackVector(vector string) string {
+	for _, metric := range strings.Split(vector, "/") {
+		switch metric {
+		case "AV:N":
+			return "network"
+		case "AV:A", "AV:L", "AV:P":
+			return "local"
+		}
+	}
+	return ""
+}
+
+type NativeVulnerabilityCompareReq struct {
+	Digest string `json:"digest" validate:"required,min=12,max=255" required:"true"`
+}
+
+// NativeVulnerabilityMatch is a vulnerability reported both by the registry
+// and by ThreatMapper
+type NativeVulnerabilityMatch struct {
+	CveId                string `json:"cve_id" required:"true"`
+	Package              string `json:"package" required:"true"`
+	RegistrySeverity     string `json:"registry_severity" required:"true"`
+	ThreatMapperSeverity string `json:"threatmapper_severity" required:"true"`
+}
+
+type NativeVulnerabilityComparison struct {
+	RegistryId       string                     `json:"registry_id" required:"true"`
+	Source           string                     `json:"source" required:"true"`
+	Scanner          string                     `json:"scanner" required:"true"`
+	FetchedAt        int64                      `json:"fetched_at" required:"true" format:"int64"`
+	Common           []NativeVulnerabilityMatch `json:"common" required:"true"`
+	RegistryOnly     []Vulnerability            `json:"registry_only" required:"true"`
+	ThreatMapperOnly []Vulnerability            `json:"threatmapper_only" required:"true"`
+}
+
+type NativeVulnerabilityCompareResp struct {
+	Digest string `json:"digest" required:"true"`
+	// latest completed vulnerability scan of the digest, empty if the
+	// digest was never scanned by ThreatMapper
+	ScanId      string                          `json:"scan_id" required:"true"`
+	Comparisons []NativeVulnerabilityComparison `json:"comparisons" required:"true"`
+}
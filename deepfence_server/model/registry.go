@@ -50,6 +50,31 @@ type RegistryIDPathReq struct {
 	RegistryId string `path:"registry_id" validate:"required" required:"true"`
 }
 
+type RegistryWebhookReq struct {
+	RegistryId string   `path:"registry_id" validate:"required" required:"true"`
+	ScanTypes  []string `json:"scan_types" validate:"dive,oneof=SecretScan VulnerabilityScan MalwareScan" enum:"SecretScan,VulnerabilityScan,MalwareScan"`
+}
+
+// token is only returned when the webhook is created, token url has the token
+// in the path for registries which can not send it in a header
+type RegistryWebhookResp struct {
+	Enabled   bool     `json:"enabled"`
+	URL       string   `json:"url"`
+	Token     string   `json:"token,omitempty"`
+	TokenURL  string   `json:"token_url,omitempty"`
+	ScanTypes []string `json:"scan_types"`
+}
+
+type RegistryWebhookTokenPathReq struct {
+	RegistryId string `path:"registry_id" validate:"required" required:"true"`
+	Token      string `path:"token" validate:"required" required:"true"`
+}
+
+type RegistryWebhookEventResp struct {
+	Source string                      `json:"source"`
+	Images []utils.RegistryPushedImage `json:"images"`
+}
+
 type RegistryImagesReq struct {
 	RegistryId  string                  `json:"registry_id" validate:"required" required:"true"`
 	ImageFilter reporters.FieldsFilters `json:"image_filter" required:"true"`
@@ -103,14 +128,18 @@ type RegistryImage struct {
 }
 
 type RegistryListResp struct {
-	ID           int32           `json:"id"`
-	NodeID       string          `json:"node_id"`
-	Name         string          `json:"name"`
-	RegistryType string          `json:"registry_type"`
-	IsSyncing    bool            `json:"is_syncing"`
-	NonSecret    json.RawMessage `json:"non_secret"`
-	CreatedAt    int64           `json:"created_at"`
-	UpdatedAt    int64           `json:"updated_at"`
+	ID           int32              `json:"id"`
+	NodeID       string             `json:"node_id"`
+	Name         string             `json:"name"`
+	RegistryType string             `json:"registry_type"`
+	IsSyncing    bool               `json:"is_syncing"`
+	NonSecret    json.RawMessage    `json:"non_secret"`
+	SyncOptions  json.RawMessage    `json:"sync_options"`
+	SyncStatus   RegistrySyncStatus `json:"sync_status"`
+	CreatedAt    int64              `json:"created_at"`
+	UpdatedAt    int64              `json:"updated_at"`
+
+	CredentialStatus RegistryCredentialStatus `json:"credential_status"`
 }
 
 type RegistrySummaryAllResp map[string]Summary
@@ -633,7 +662,7 @@ func RegistrySummary(ctx context.Context, registryId mo.Option[string], registry
 	)
 	if regId, ok := registryId.Get(); ok {
 		if result, err = tx.Run(queryPerRegistry, map[string]interface{}{"id": regId}); err != nil {
-			log.Error().Err(err).Msgf("failed to query summary for registry id %d", regId)
+			log.Error().Err(err).Msgf("failed to query summary for registry id %s", regId)
 			return count, err
 		}
 	} else if regType, ok := registryType.Get(); ok {
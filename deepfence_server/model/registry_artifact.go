@@ -0,0 +1,229 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/helmchart"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const (
+	ArtifactTypeHelmChart = "helm_chart"
+	// any other OCI artifact, like signatures, SBOMs or WASM modules
+	ArtifactTypeOther = "artifact"
+
+	MediaTypeHelmChartConfig = "application/vnd.cncf.helm.config.v1+json"
+)
+
+var ErrRegistryArtifactNotFound = errors.New("registry artifact not found")
+
+// IngestedRegistryArtifact is a non image artifact stored in a registry
+type IngestedRegistryArtifact struct {
+	Name         string `json:"name"`
+	Tag          string `json:"tag"`
+	Digest       string `json:"digest"`
+	ArtifactType string `json:"artifact_type"`
+	// config media type of the artifact manifest
+	MediaType string `json:"media_type"`
+	Size      int64  `json:"size"`
+	PushedAt  int64  `json:"pushed_at"`
+}
+
+// GetRegistryArtifactID returns the graph node id of an artifact, artifacts
+// are identified per registry as they are scanned with its credentials
+func GetRegistryArtifactID(registryId, name, tag string) string {
+	return fmt.Sprintf("%s;%s:%s", registryId, EscapeSlashToUnderscore(name), tag)
+}
+
+func ArtifactTypeFromMediaType(mediaType string) string {
+	if mediaType == MediaTypeHelmChartConfig {
+		return ArtifactTypeHelmChart
+	}
+	return ArtifactTypeOther
+}
+
+type RegistryArtifact struct {
+	NodeId       string `json:"node_id" required:"true"`
+	Name         string `json:"name" required:"true"`
+	Tag          string `json:"tag" required:"true"`
+	Digest       string `json:"digest" required:"true"`
+	ArtifactType string `json:"artifact_type" required:"true"`
+	MediaType    string `json:"media_type" required:"true"`
+	Size         int64  `json:"size" required:"true" format:"int64"`
+	PushedAt     int64  `json:"pushed_at" required:"true" format:"int64"`
+	// images referenced by the templates of helm charts, set by scans
+	ReferencedImages       []string `json:"referenced_images" required:"true"`
+	ComplianceScanStatus   string   `json:"compliance_scan_status" required:"true"`
+	ComplianceLatestScanId string   `json:"compliance_latest_scan_id" required:"true"`
+}
+
+type RegistryArtifactScanReq struct {
+	NodeIds []string `json:"node_ids" validate:"required,min=1,max=100,dive,required" required:"true"`
+}
+
+type RegistryArtifactScanResp struct {
+	ScanIds []string `json:"scan_ids" required:"true"`
+}
+
+func GetRegistryArtifacts(ctx context.Context, registryId string) ([]RegistryArtifact, error) {
+	res := []RegistryArtifact{}
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return res, err
+	}
+	defer tx.Close()
+
+	r, err := tx.Run(`
+		MATCH (m:RegistryAccount{node_id: $registry_id}) -[:HOSTS]-> (n:RegistryArtifact)
+		RETURN n.node_id, n.docker_image_name, n.docker_image_tag, n.digest, n.artifact_type, n.media_type,
+		n.size, n.pushed_at, COALESCE(n.referenced_images, []),
+		COALESCE(n.compliance_scan_status, ''), COALESCE(n.compliance_latest_scan_id, '')
+		ORDER BY n.docker_image_name, n.docker_image_tag`,
+		map[string]interface{}{"registry_id": registryId})
+	if err != nil {
+		return res, err
+	}
+	records, err := r.Collect()
+	if err != nil {
+		return res, err
+	}
+
+	for _, rec := range records {
+		artifact := RegistryArtifact{ReferencedImages: []string{}}
+		artifact.NodeId, _ = rec.Values[0].(string)
+		artifact.Name, _ = rec.Values[1].(string)
+		artifact.Tag, _ = rec.Values[2].(string)
+		artifact.Digest, _ = rec.Values[3].(string)
+		artifact.ArtifactType, _ = rec.Values[4].(string)
+		artifact.MediaType, _ = rec.Values[5].(string)
+		artifact.Size, _ = rec.Values[6].(int64)
+		artifact.PushedAt, _ = rec.Values[7].(int64)
+		for _, image := range rec.Values[8].([]interface{}) {
+			artifact.ReferencedImages = append(artifact.ReferencedImages, image.(string))
+		}
+		artifact.ComplianceScanStatus, _ = rec.Values[9].(string)
+		artifact.ComplianceLatestScanId, _ = rec.Values[10].(string)
+		res = append(res, artifact)
+	}
+	return res, nil
+}
+
+// GetRegistryArtifact returns the artifact and the postgres ids of the
+// registry hosting it
+func GetRegistryArtifact(ctx context.Context, nodeId string) (RegistryArtifact, []int64, error) {
+	artifact := RegistryArtifact{NodeId: nodeId, ReferencedImages: []string{}}
+	pgIds := []int64{}
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return artifact, pgIds, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return artifact, pgIds, err
+	}
+	defer tx.Close()
+
+	r, err := tx.Run(`
+		MATCH (m:RegistryAccount) -[:HOSTS]-> (n:RegistryArtifact{node_id: $node_id})
+		RETURN n.docker_image_name, n.docker_image_tag, n.digest, n.artifact_type, m.container_registry_ids`,
+		map[string]interface{}{"node_id": nodeId})
+	if err != nil {
+		return artifact, pgIds, err
+	}
+	records, err := r.Collect()
+	if err != nil {
+		return artifact, pgIds, err
+	}
+	if len(records) == 0 {
+		return artifact, pgIds, ErrRegistryArtifactNotFound
+	}
+
+	rec := records[0]
+	artifact.Name, _ = rec.Values[0].(string)
+	artifact.Tag, _ = rec.Values[1].(string)
+	artifact.Digest, _ = rec.Values[2].(string)
+	artifact.ArtifactType, _ = rec.Values[3].(string)
+	ids, _ := rec.Values[4].([]interface{})
+	for _, id := range ids {
+		pgIds = append(pgIds, id.(int64))
+	}
+	return artifact, pgIds, nil
+}
+
+// SetRegistryArtifactImages saves the images referenced by a chart and links
+// it to the synced images they match, the ids of the matched images are
+// returned
+func SetRegistryArtifactImages(ctx context.Context, nodeId string, images []string) ([]string, error) {
+	matched := []string{}
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return matched, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return matched, err
+	}
+	defer tx.Close()
+
+	refs := []map[string]interface{}{}
+	for _, image := range images {
+		ref := helmchart.ParseImageReference(image)
+		refs = append(refs, map[string]interface{}{
+			"names":    ref.Names(),
+			"tag":      ref.Tag,
+			"image_id": strings.TrimPrefix(ref.Digest, "sha256:"),
+		})
+	}
+
+	if _, err = tx.Run(`
+		MATCH (a:RegistryArtifact{node_id: $node_id})
+		SET a.referenced_images = $images
+		WITH a
+		OPTIONAL MATCH (a) -[r:REFERENCES]-> (:ContainerImage)
+		DELETE r`,
+		map[string]interface{}{"node_id": nodeId, "images": images}); err != nil {
+		return matched, err
+	}
+
+	r, err := tx.Run(`
+		UNWIND $refs as ref
+		MATCH (img:ContainerImage)
+		WHERE img.docker_image_name IN ref.names
+		AND (img.docker_image_tag = ref.tag OR (ref.image_id <> '' AND img.docker_image_id = ref.image_id))
+		MATCH (a:RegistryArtifact{node_id: $node_id})
+		MERGE (a) -[:REFERENCES]-> (img)
+		RETURN DISTINCT img.node_id`,
+		map[string]interface{}{"node_id": nodeId, "refs": refs})
+	if err != nil {
+		return matched, err
+	}
+	records, err := r.Collect()
+	if err != nil {
+		return matched, err
+	}
+	for _, rec := range records {
+		matched = append(matched, rec.Values[0].(string))
+	}
+	return matched, tx.Commit()
+}
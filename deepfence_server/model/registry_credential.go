@@ -0,0 +1,110 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// CredentialExpiryWarning is how long before the expiry of the credentials
+// of a registry a warning is sent
+const CredentialExpiryWarning = 7 * 24 * time.Hour
+
+type RegistryCredentialStatus struct {
+	// true until the credentials are checked
+	Valid     bool   `json:"valid"`
+	LastError string `json:"last_error"`
+	CheckedAt int64  `json:"checked_at"`
+	// zero when the credentials don't advertise an expiry
+	ExpiresAt int64 `json:"expires_at"`
+	// renewable credentials, like ECR authorization tokens, are renewed on
+	// every sync and never warned about
+	Renewable bool `json:"renewable"`
+}
+
+// ExpiresSoon reports if non renewable credentials expire within the warning
+// period of the time of the check
+func (s RegistryCredentialStatus) ExpiresSoon() bool {
+	if s.ExpiresAt == 0 || s.Renewable {
+		return false
+	}
+	return s.ExpiresAt-s.CheckedAt < CredentialExpiryWarning.Milliseconds()
+}
+
+func GetRegistryCredentialStatus(ctx context.Context, registryId string) (RegistryCredentialStatus, error) {
+	status := RegistryCredentialStatus{Valid: true}
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return status, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return status, err
+	}
+	defer tx.Close()
+
+	r, err := tx.Run(`
+	MATCH (n:RegistryAccount{node_id: $id})
+	RETURN COALESCE(n.credentials_valid, true), COALESCE(n.credentials_error, ""),
+		COALESCE(n.credentials_checked_at, 0), COALESCE(n.credentials_expires_at, 0),
+		COALESCE(n.credentials_renewable, false)`,
+		map[string]interface{}{"id": registryId})
+	if err != nil {
+		return status, err
+	}
+
+	record, err := r.Single()
+	if err != nil {
+		return status, err
+	}
+
+	status.Valid, _ = record.Values[0].(bool)
+	status.LastError, _ = record.Values[1].(string)
+	status.CheckedAt, _ = record.Values[2].(int64)
+	status.ExpiresAt, _ = record.Values[3].(int64)
+	status.Renewable, _ = record.Values[4].(bool)
+	return status, nil
+}
+
+func SetRegistryCredentialStatus(ctx context.Context, registryId string, status RegistryCredentialStatus) error {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	_, err = tx.Run(`
+	MATCH (n:RegistryAccount{node_id: $id})
+	SET n.credentials_valid = $valid,
+		n.credentials_error = $error,
+		n.credentials_checked_at = $checked_at,
+		n.credentials_expires_at = $expires_at,
+		n.credentials_renewable = $renewable`,
+		map[string]interface{}{
+			"id":         registryId,
+			"valid":      status.Valid,
+			"error":      status.LastError,
+			"checked_at": status.CheckedAt,
+			"expires_at": status.ExpiresAt,
+			"renewable":  status.Renewable,
+		})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
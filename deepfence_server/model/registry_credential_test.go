@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestRegistryCredentialExpiresSoon(t *testing.T) {
+	checkedAt := time.Now()
+
+	tests := []struct {
+		name   string
+		status RegistryCredentialStatus
+		want   bool
+	}{
+		{"no expiry", RegistryCredentialStatus{}, false},
+		{"expires later", RegistryCredentialStatus{ExpiresAt: checkedAt.Add(30 * 24 * time.Hour).UnixMilli()}, false},
+		{"expires within warning", RegistryCredentialStatus{ExpiresAt: checkedAt.Add(24 * time.Hour).UnixMilli()}, true},
+		{"expired", RegistryCredentialStatus{ExpiresAt: checkedAt.Add(-time.Hour).UnixMilli()}, true},
+		{"renewable", RegistryCredentialStatus{ExpiresAt: checkedAt.Add(time.Hour).UnixMilli(), Renewable: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.status.CheckedAt = checkedAt.UnixMilli()
+			assert.Equal(t, tt.status.ExpiresSoon(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"encoding/json"
+
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+)
+
+type RegistryScanPolicyFilters struct {
+	IncludeRepositories string `json:"include_repositories" validate:"omitempty,max=1024"`
+	ExcludeRepositories string `json:"exclude_repositories" validate:"omitempty,max=1024"`
+	IncludeTags         string `json:"include_tags" validate:"omitempty,max=1024"`
+	ExcludeTags         string `json:"exclude_tags" validate:"omitempty,max=1024"`
+}
+
+// Filter compiles the filters like the sync options of the registry
+func (f RegistryScanPolicyFilters) Filter() (*RegistrySyncFilter, error) {
+	return RegistrySyncOptions{
+		IncludeRepositories: f.IncludeRepositories,
+		ExcludeRepositories: f.ExcludeRepositories,
+		IncludeTags:         f.IncludeTags,
+		ExcludeTags:         f.ExcludeTags,
+	}.Filter()
+}
+
+type RegistryScanPolicyReq struct {
+	RegistryId string                    `path:"registry_id" validate:"required" required:"true"`
+	Name       string                    `json:"name" validate:"required,min=2,max=64" required:"true"`
+	ScanTypes  []string                  `json:"scan_types" validate:"required,min=1,dive,oneof=SecretScan VulnerabilityScan MalwareScan" required:"true" enum:"SecretScan,VulnerabilityScan,MalwareScan"`
+	Filters    RegistryScanPolicyFilters `json:"filters"`
+	// images scanned longer ago are scanned again, 0 scans only the images
+	// never scanned
+	RescanAfterDays    int32 `json:"rescan_after_days" validate:"min=0,max=365"`
+	MaxConcurrentScans int32 `json:"max_concurrent_scans" validate:"required,min=1,max=1000" required:"true"`
+	IsEnabled          bool  `json:"is_enabled"`
+}
+
+type RegistryScanPolicyUpdateReq struct {
+	PolicyId int32 `path:"policy_id" validate:"required" required:"true"`
+	RegistryScanPolicyReq
+}
+
+type RegistryScanPolicyIDPathReq struct {
+	RegistryId string `path:"registry_id" validate:"required" required:"true"`
+	PolicyId   int32  `path:"policy_id" validate:"required" required:"true"`
+}
+
+type RegistryScanPolicy struct {
+	ID                 int32                     `json:"id"`
+	RegistryId         string                    `json:"registry_id"`
+	Name               string                    `json:"name"`
+	ScanTypes          []string                  `json:"scan_types"`
+	Filters            RegistryScanPolicyFilters `json:"filters"`
+	RescanAfterDays    int32                     `json:"rescan_after_days"`
+	MaxConcurrentScans int32                     `json:"max_concurrent_scans"`
+	IsEnabled          bool                      `json:"is_enabled"`
+	LastEvaluatedAt    int64                     `json:"last_evaluated_at"`
+	LastStatus         string                    `json:"last_status"`
+	CreatedAt          int64                     `json:"created_at"`
+	UpdatedAt          int64                     `json:"updated_at"`
+}
+
+func NewRegistryScanPolicy(registryId string, row postgresqlDb.RegistryScanPolicy) (RegistryScanPolicy, error) {
+	policy := RegistryScanPolicy{
+		ID:                 row.ID,
+		RegistryId:         registryId,
+		Name:               row.Name,
+		ScanTypes:          []string{},
+		RescanAfterDays:    row.RescanAfterDays,
+		MaxConcurrentScans: row.MaxConcurrentScans,
+		IsEnabled:          row.IsEnabled,
+		LastStatus:         row.LastStatus,
+		CreatedAt:          row.CreatedAt.Unix(),
+		UpdatedAt:          row.UpdatedAt.Unix(),
+	}
+	if row.LastEvaluatedAt.Valid {
+		policy.LastEvaluatedAt = row.LastEvaluatedAt.Time.Unix()
+	}
+	if err := json.Unmarshal(row.ScanTypes, &policy.ScanTypes); err != nil {
+		return policy, err
+	}
+	if len(row.Filters) > 0 {
+		if err := json.Unmarshal(row.Filters, &policy.Filters); err != nil {
+			return policy, err
+		}
+	}
+	return policy, nil
+}
@@ -0,0 +1,322 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+type RegistrySyncOptions struct {
+	IncludeRepositories string `json:"include_repositories" validate:"omitempty,max=1024"`
+	ExcludeRepositories string `json:"exclude_repositories" validate:"omitempty,max=1024"`
+	IncludeTags         string `json:"include_tags" validate:"omitempty,max=1024"`
+	ExcludeTags         string `json:"exclude_tags" validate:"omitempty,max=1024"`
+	// keep only the latest tags of every repository, by image creation or
+	// push time, 0 keeps all tags
+	LatestTags int `json:"latest_tags" validate:"min=0,max=10000"`
+	// import the vulnerability reports computed by registries supporting
+	// it, like Harbor and Quay
+	ImportNativeVulnerabilities bool `json:"import_native_vulnerabilities"`
+	// list the helm charts and other OCI artifacts of registries supporting
+	// it, like Harbor and OCI registries
+	DiscoverArtifacts bool `json:"discover_artifacts"`
+}
+
+type RegistrySyncOptionsReq struct {
+	RegistryId  string              `path:"registry_id" validate:"required" required:"true"`
+	SyncOptions RegistrySyncOptions `json:"sync_options" required:"true"`
+}
+
+type RegistrySyncStatus struct {
+	IsSyncing        bool  `json:"is_syncing"`
+	SyncProgress     int   `json:"sync_progress"`
+	LastSyncedAt     int64 `json:"last_synced_at"`
+	LastSyncDuration int64 `json:"last_sync_duration"`
+	LastSyncImages   int64 `json:"last_sync_images"`
+	LastSyncSkipped  int64 `json:"last_sync_skipped_repositories"`
+}
+
+func ParseRegistrySyncOptions(raw json.RawMessage) (RegistrySyncOptions, error) {
+	var options RegistrySyncOptions
+	if len(raw) == 0 {
+		return options, nil
+	}
+	err := json.Unmarshal(raw, &options)
+	return options, err
+}
+
+// GetRegistrySyncStatus returns the sync progress and the stats of the last
+// sync saved on the registry account
+func GetRegistrySyncStatus(ctx context.Context, registryId string) (RegistrySyncStatus, error) {
+	var status RegistrySyncStatus
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return status, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return status, err
+	}
+	defer tx.Close()
+
+	r, err := tx.Run(`
+	MATCH (n:RegistryAccount{node_id: $id})
+	RETURN COALESCE(n.syncing, false), COALESCE(n.sync_progress, 0), COALESCE(n.last_synced_at, 0),
+		COALESCE(n.last_sync_duration, 0), COALESCE(n.last_sync_images, 0),
+		COALESCE(n.last_sync_skipped_repositories, 0)`,
+		map[string]interface{}{"id": registryId})
+	if err != nil {
+		return status, err
+	}
+
+	record, err := r.Single()
+	if err != nil {
+		return status, err
+	}
+
+	status.IsSyncing, _ = record.Values[0].(bool)
+	progress, _ := record.Values[1].(int64)
+	status.SyncProgress = int(progress)
+	status.LastSyncedAt, _ = record.Values[2].(int64)
+	status.LastSyncDuration, _ = record.Values[3].(int64)
+	status.LastSyncImages, _ = record.Values[4].(int64)
+	status.LastSyncSkipped, _ = record.Values[5].(int64)
+	return status, nil
+}
+
+// RegistrySyncFilter selects the repositories and tags synced from a registry
+type RegistrySyncFilter struct {
+	includeRepositories *regexp.Regexp
+	excludeRepositories *regexp.Regexp
+	includeTags         *regexp.Regexp
+	excludeTags         *regexp.Regexp
+	latestTags          int
+	hash                string
+}
+
+func compileOption(name, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return re, nil
+}
+
+func (o RegistrySyncOptions) Filter() (*RegistrySyncFilter, error) {
+	var (
+		f   = &RegistrySyncFilter{latestTags: o.LatestTags}
+		err error
+	)
+	if f.includeRepositories, err = compileOption("include_repositories", o.IncludeRepositories); err != nil {
+		return nil, err
+	}
+	if f.excludeRepositories, err = compileOption("exclude_repositories", o.ExcludeRepositories); err != nil {
+		return nil, err
+	}
+	if f.includeTags, err = compileOption("include_tags", o.IncludeTags); err != nil {
+		return nil, err
+	}
+	if f.excludeTags, err = compileOption("exclude_tags", o.ExcludeTags); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(b)
+	f.hash = hex.EncodeToString(sum[:8])
+	return f, nil
+}
+
+func match(include, exclude *regexp.Regexp, s string) bool {
+	if include != nil && !include.MatchString(s) {
+		return false
+	}
+	return exclude == nil || !exclude.MatchString(s)
+}
+
+func (f *RegistrySyncFilter) MatchRepository(repository string) bool {
+	return match(f.includeRepositories, f.excludeRepositories, repository)
+}
+
+func (f *RegistrySyncFilter) MatchTag(tag string) bool {
+	return match(f.includeTags, f.excludeTags, tag)
+}
+
+// Tags returns the tags matching the filters, the registry v2 api lists tags
+// without a push time and sorted by name, so the latest tags are only kept by
+// Apply once the image configs are fetched
+func (f *RegistrySyncFilter) Tags(tags []string) []string {
+	res := []string{}
+	for _, tag := range tags {
+		if f.MatchTag(tag) {
+			res = append(res, tag)
+		}
+	}
+	return res
+}
+
+// Apply drops the images not matching the filters and keeps the latest tags
+// of every repository, ordered by the last_updated metadata of the images
+func (f *RegistrySyncFilter) Apply(images []IngestedContainerImage) []IngestedContainerImage {
+	repos := map[string][]IngestedContainerImage{}
+	names := []string{}
+	for _, image := range images {
+		if !f.MatchRepository(image.Name) || !f.MatchTag(image.Tag) {
+			continue
+		}
+		if _, ok := repos[image.Name]; !ok {
+			names = append(names, image.Name)
+		}
+		repos[image.Name] = append(repos[image.Name], image)
+	}
+
+	res := []IngestedContainerImage{}
+	for _, name := range names {
+		tags := repos[name]
+		if f.latestTags > 0 && len(tags) > f.latestTags {
+			sort.SliceStable(tags, func(i, j int) bool {
+				return imageUpdatedAt(tags[i]) > imageUpdatedAt(tags[j])
+			})
+			tags = tags[:f.latestTags]
+		}
+		res = append(res, tags...)
+	}
+	return res
+}
+
+// ApplyArtifacts keeps the latest tags of the artifacts of every repository,
+// ordered by push time, artifacts are already matched when listed
+func (f *RegistrySyncFilter) ApplyArtifacts(artifacts []IngestedRegistryArtifact) []IngestedRegistryArtifact {
+	if f.latestTags == 0 {
+		return artifacts
+	}
+	repos := map[string][]IngestedRegistryArtifact{}
+	names := []string{}
+	for _, a := range artifacts {
+		if _, ok := repos[a.Name]; !ok {
+			names = append(names, a.Name)
+		}
+		repos[a.Name] = append(repos[a.Name], a)
+	}
+
+	res := []IngestedRegistryArtifact{}
+	for _, name := range names {
+		tags := repos[name]
+		if len(tags) > f.latestTags {
+			sort.SliceStable(tags, func(i, j int) bool {
+				return tags[i].PushedAt > tags[j].PushedAt
+			})
+			tags = tags[:f.latestTags]
+		}
+		res = append(res, tags...)
+	}
+	return res
+}
+
+func imageUpdatedAt(image IngestedContainerImage) int64 {
+	switch v := image.Metadata["last_updated"].(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case time.Time:
+		return v.Unix()
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err == nil {
+			return t.Unix()
+		}
+	}
+	return 0
+}
+
+// RegistrySyncState tracks the repositories changed since the previous sync,
+// registries save a version of every repository, an etag or a digest of its
+// tags, and skip fetching the manifests of repositories with the same version
+type RegistrySyncState struct {
+	filter   *RegistrySyncFilter
+	previous map[string]string
+	current  map[string]string
+	skipped  []string
+	progress func(done, total int)
+}
+
+// NewRegistrySyncState returns the sync state from the repository versions
+// of the previous sync, versions saved with other filters are ignored
+func NewRegistrySyncState(filter *RegistrySyncFilter, previous map[string]string,
+	progress func(done, total int)) *RegistrySyncState {
+
+	if previous == nil {
+		previous = map[string]string{}
+	}
+	if progress == nil {
+		progress = func(int, int) {}
+	}
+	return &RegistrySyncState{
+		filter:   filter,
+		previous: previous,
+		current:  map[string]string{},
+		progress: progress,
+	}
+}
+
+func (s *RegistrySyncState) key(version string) string {
+	return s.filter.hash + ":" + version
+}
+
+// Changed records the version of the repository and reports if it changed
+// since the previous sync, repositories without a version always change
+func (s *RegistrySyncState) Changed(repository, version string) bool {
+	if version == "" {
+		return true
+	}
+	s.current[repository] = s.key(version)
+	if s.previous[repository] == s.key(version) {
+		s.skipped = append(s.skipped, repository)
+		return false
+	}
+	return true
+}
+
+// Forget drops the version of a repository which failed to sync, so it is
+// fetched again on the next sync
+func (s *RegistrySyncState) Forget(repository string) {
+	delete(s.current, repository)
+}
+
+func (s *RegistrySyncState) Progress(done, total int) {
+	s.progress(done, total)
+}
+
+// Versions returns the repository versions to save once the images are synced
+func (s *RegistrySyncState) Versions() map[string]string {
+	return s.current
+}
+
+func (s *RegistrySyncState) Skipped() int {
+	return len(s.skipped)
+}
+
+// Unchanged returns the repositories skipped since they didn't change, their
+// images are kept up to date without upserting them
+func (s *RegistrySyncState) Unchanged() []string {
+	return s.skipped
+}
@@ -0,0 +1,153 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func image(name, tag string, lastUpdated int64) IngestedContainerImage {
+	return IngestedContainerImage{
+		Name:     name,
+		Tag:      tag,
+		Metadata: Metadata{"last_updated": lastUpdated},
+	}
+}
+
+func TestRegistrySyncFilter(t *testing.T) {
+	images := []IngestedContainerImage{
+		image("app", "v1", 1),
+		image("app", "v3", 3),
+		image("app", "v2", 2),
+		image("app", "dev", 4),
+		image("tools/debug", "v1", 1),
+		image("web", "v1", 1),
+	}
+
+	tests := []struct {
+		name    string
+		options RegistrySyncOptions
+		want    []string
+	}{
+		{"no filters", RegistrySyncOptions{},
+			[]string{"app:v1", "app:v3", "app:v2", "app:dev", "tools/debug:v1", "web:v1"}},
+		{"include repositories", RegistrySyncOptions{IncludeRepositories: "^app$"},
+			[]string{"app:v1", "app:v3", "app:v2", "app:dev"}},
+		{"exclude repositories", RegistrySyncOptions{ExcludeRepositories: "^tools/"},
+			[]string{"app:v1", "app:v3", "app:v2", "app:dev", "web:v1"}},
+		{"include and exclude tags", RegistrySyncOptions{IncludeTags: "^v", ExcludeTags: "^v1$"},
+			[]string{"app:v3", "app:v2"}},
+		{"latest tags", RegistrySyncOptions{LatestTags: 2, ExcludeTags: "^dev$"},
+			[]string{"app:v3", "app:v2", "tools/debug:v1", "web:v1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.options.Filter()
+			assert.NilError(t, err)
+			got := []string{}
+			for _, i := range f.Apply(images) {
+				got = append(got, i.Name+":"+i.Tag)
+			}
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+
+	_, err := RegistrySyncOptions{IncludeTags: "("}.Filter()
+	assert.ErrorContains(t, err, "include_tags")
+}
+
+func TestRegistrySyncFilterTags(t *testing.T) {
+	tags := []string{"v1", "v2", "dev", "v3"}
+
+	tests := []struct {
+		name    string
+		options RegistrySyncOptions
+		want    []string
+	}{
+		{"all", RegistrySyncOptions{}, []string{"v1", "v2", "dev", "v3"}},
+		{"matching", RegistrySyncOptions{ExcludeTags: "dev"}, []string{"v1", "v2", "v3"}},
+		// listed tags are sorted by name, latest tags are kept by Apply
+		{"latest not by name", RegistrySyncOptions{LatestTags: 2}, []string{"v1", "v2", "dev", "v3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.options.Filter()
+			assert.NilError(t, err)
+			assert.DeepEqual(t, f.Tags(tags), tt.want)
+		})
+	}
+}
+
+func TestRegistrySyncFilterLatestByTime(t *testing.T) {
+	f, err := RegistrySyncOptions{LatestTags: 2}.Filter()
+	assert.NilError(t, err)
+
+	// tags are listed by name, v10 before v9 and zz-debug last
+	assert.Equal(t, len(f.Tags([]string{"latest", "v10", "v9", "zz-debug"})), 4)
+	images := []IngestedContainerImage{
+		image("app", "latest", 1),
+		image("app", "v10", 10),
+		image("app", "v9", 9),
+		image("app", "zz-debug", 2),
+	}
+	got := []string{}
+	for _, i := range f.Apply(images) {
+		got = append(got, i.Tag)
+	}
+	assert.DeepEqual(t, got, []string{"v10", "v9"})
+
+	artifacts := f.ApplyArtifacts([]IngestedRegistryArtifact{
+		{Name: "chart", Tag: "1.10.0", PushedAt: 10},
+		{Name: "chart", Tag: "1.9.0", PushedAt: 9},
+		{Name: "chart", Tag: "latest", PushedAt: 1},
+	})
+	assert.Equal(t, len(artifacts), 2)
+	assert.Equal(t, artifacts[0].Tag, "1.10.0")
+	assert.Equal(t, artifacts[1].Tag, "1.9.0")
+}
+
+func TestRegistrySyncState(t *testing.T) {
+	filter, err := RegistrySyncOptions{}.Filter()
+	assert.NilError(t, err)
+	other, err := RegistrySyncOptions{LatestTags: 1}.Filter()
+	assert.NilError(t, err)
+
+	first := NewRegistrySyncState(filter, nil, nil)
+	assert.Assert(t, first.Changed("app", "a"))
+	assert.Assert(t, first.Changed("web", "w"))
+	previous := first.Versions()
+
+	tests := []struct {
+		name      string
+		filter    *RegistrySyncFilter
+		repo      string
+		version   string
+		changed   bool
+		unchanged []string
+	}{
+		{"same version", filter, "app", "a", false, []string{"app"}},
+		{"new version", filter, "app", "b", true, []string{}},
+		{"new repository", filter, "api", "a", true, []string{}},
+		{"no version", filter, "app", "", true, []string{}},
+		{"other filters", other, "app", "a", true, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewRegistrySyncState(tt.filter, previous, nil)
+			assert.Equal(t, s.Changed(tt.repo, tt.version), tt.changed)
+			assert.Equal(t, s.Skipped(), len(tt.unchanged))
+			if len(tt.unchanged) > 0 {
+				assert.DeepEqual(t, s.Unchanged(), tt.unchanged)
+			}
+		})
+	}
+
+	s := NewRegistrySyncState(filter, previous, nil)
+	assert.Assert(t, s.Changed("app", "b"))
+	s.Forget("app")
+	_, ok := s.Versions()["app"]
+	assert.Assert(t, !ok, "failed repositories are synced again")
+}
@@ -0,0 +1,282 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const (
+	ExceptionScopeNode                = "node"
+	ExceptionScopeImageName           = "image_name"
+	ExceptionScopeKubernetesNamespace = "kubernetes_namespace"
+	ExceptionScopeGlobal              = "global"
+)
+
+// exceptionResultIDField is the field holding the id an exception is
+// created for, d is the detected node and e its rule
+var exceptionResultIDField = map[utils.Neo4jScanType]string{
+	utils.NEO4J_VULNERABILITY_SCAN:    "e.node_id",
+	utils.NEO4J_SECRET_SCAN:           "e.rule_id",
+	utils.NEO4J_MALWARE_SCAN:          "e.rule_id",
+	utils.NEO4J_COMPLIANCE_SCAN:       "e.node_id",
+	utils.NEO4J_CLOUD_COMPLIANCE_SCAN: "d.control_id",
+}
+
+type ScanResultExceptionReq struct {
+	ScanType string `json:"scan_type" validate:"required,oneof=SecretScan VulnerabilityScan MalwareScan ComplianceScan CloudComplianceScan" required:"true" enum:"SecretScan,VulnerabilityScan,MalwareScan,ComplianceScan,CloudComplianceScan"`
+	// cve id, secret or malware rule id, compliance test number or cloud
+	// compliance control id
+	ResultId  string `json:"result_id" validate:"required,max=1024" required:"true"`
+	ScopeType string `json:"scope_type" validate:"required,oneof=node image_name kubernetes_namespace global" required:"true" enum:"node,image_name,kubernetes_namespace,global"`
+	// node id, image name pattern with * wildcards or kubernetes namespace,
+	// empty for the global scope
+	ScopeValue string `json:"scope_value" validate:"required_unless=ScopeType global,max=1024"`
+	Reason     string `json:"reason" validate:"required,max=4096" required:"true"`
+	Owner      string `json:"owner" validate:"required,max=255" required:"true"`
+	// unix time after which the results are unmasked, 0 never expires
+	ExpiresAt int64 `json:"expires_at" validate:"gte=0" format:"int64"`
+}
+
+type ScanResultExceptionUpdateReq struct {
+	ExceptionId int64  `path:"exception_id" validate:"required" required:"true"`
+	Reason      string `json:"reason" validate:"required,max=4096" required:"true"`
+	Owner       string `json:"owner" validate:"required,max=255" required:"true"`
+	ExpiresAt   int64  `json:"expires_at" validate:"gte=0" format:"int64"`
+}
+
+type ScanResultExceptionIDPathReq struct {
+	ExceptionId int64 `path:"exception_id" validate:"required" required:"true"`
+}
+
+type ScanResultException struct {
+	ID         int64  `json:"id" required:"true"`
+	ScanType   string `json:"scan_type" required:"true"`
+	ResultId   string `json:"result_id" required:"true"`
+	ScopeType  string `json:"scope_type" required:"true"`
+	ScopeValue string `json:"scope_value" required:"true"`
+	Reason     string `json:"reason" required:"true"`
+	Owner      string `json:"owner" required:"true"`
+	CreatedBy  string `json:"created_by" required:"true"`
+	ExpiresAt  int64  `json:"expires_at" required:"true" format:"int64"`
+	Expired    bool   `json:"expired" required:"true"`
+	CreatedAt  int64  `json:"created_at" required:"true" format:"int64"`
+	UpdatedAt  int64  `json:"updated_at" required:"true" format:"int64"`
+}
+
+func NewScanResultException(row postgresqlDb.ScanResultException) ScanResultException {
+	e := ScanResultException{
+		ID:         row.ID,
+		ScanType:   row.ScanType,
+		ResultId:   row.ResultID,
+		ScopeType:  row.ScopeType,
+		ScopeValue: row.ScopeValue,
+		Reason:     row.Reason,
+		Owner:      row.Owner,
+		CreatedBy:  row.CreatedBy,
+		Expired:    row.Expired,
+		CreatedAt:  row.CreatedAt.Unix(),
+		UpdatedAt:  row.UpdatedAt.Unix(),
+	}
+	if row.ExpiresAt.Valid {
+		e.ExpiresAt = row.ExpiresAt.Time.Unix()
+	}
+	return e
+}
+
+// ExceptionExpiry converts the expires_at of requests, it must be in the
+// future
+func ExceptionExpiry(expiresAt int64, now time.Time) (sql.NullTime, error) {
+	if expiresAt == 0 {
+		return sql.NullTime{}, nil
+	}
+	t := time.Unix(expiresAt, 0)
+	if !t.After(now) {
+		return sql.NullTime{}, errors.New("expires_at:must be in the future")
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+// ImageNamePatternRegex converts an image name pattern, * matches any
+// characters including /
+func ImageNamePatternRegex(pattern string) string {
+	parts := strings.Split(pattern, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return "^" + strings.Join(parts, ".*") + "$"
+}
+
+func exceptionsToMaps(exceptions []postgresqlDb.ScanResultException) []map[string]interface{} {
+	res := []map[string]interface{}{}
+	for _, e := range exceptions {
+		res = append(res, map[string]interface{}{
+			"id":          e.ID,
+			"result_id":   e.ResultID,
+			"scope_type":  e.ScopeType,
+			"scope_value": e.ScopeValue,
+			"scope_regex": ImageNamePatternRegex(e.ScopeValue),
+		})
+	}
+	return res
+}
+
+// ApplyScanResultExceptions masks the results of the scans matching the
+// exceptions, all scans when scanIds is nil. Masks are set on the DETECTED
+// relationship so an exception only hides results of the nodes in its scope
+func ApplyScanResultExceptions(tx neo4j.Transaction, scanType utils.Neo4jScanType,
+	exceptions []postgresqlDb.ScanResultException, scanIds []string) error {
+
+	resultIDField, ok := exceptionResultIDField[scanType]
+	if !ok || len(exceptions) == 0 {
+		return nil
+	}
+	_, err := tx.Run(`
+		UNWIND $exceptions AS x
+		MATCH (m:`+string(scanType)+`) -[:SCANNED]-> (s)
+		WHERE ($scan_ids IS NULL OR m.node_id IN $scan_ids)
+		AND (x.scope_type = 'global'
+			OR (x.scope_type = 'node' AND s.node_id = x.scope_value)
+			OR (x.scope_type = 'image_name' AND s.docker_image_name =~ x.scope_regex)
+			OR (x.scope_type = 'kubernetes_namespace' AND (s.kubernetes_namespace = x.scope_value
+				OR EXISTS { MATCH (p:Pod{kubernetes_namespace: x.scope_value}) WHERE p.node_id = s.pod_id })))
+		MATCH (m) -[r:DETECTED]-> (d)
+		OPTIONAL MATCH (d) -[:IS]-> (e)
+		WITH x, r, `+resultIDField+` AS result_id
+		WHERE result_id = x.result_id
+		SET r.masked = true, r.exception_id = x.id`,
+		map[string]interface{}{"exceptions": exceptionsToMaps(exceptions), "scan_ids": scanIds})
+	return err
+}
+
+// ApplyActiveScanResultExceptions masks the results of newly ingested scans
+// with the exceptions which did not expire
+func ApplyActiveScanResultExceptions(ctx context.Context, tx neo4j.Transaction,
+	scanType utils.Neo4jScanType, scanIds []string) error {
+
+	if _, ok := exceptionResultIDField[scanType]; !ok || len(scanIds) == 0 {
+		return nil
+	}
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		return err
+	}
+	exceptions, err := pgClient.GetActiveScanResultExceptions(ctx, string(scanType))
+	if err != nil {
+		return err
+	}
+	return ApplyScanResultExceptions(tx, scanType, exceptions, scanIds)
+}
+
+// ScanResultExceptionNodes are the nodes having results unmasked when an
+// exception is removed
+type ScanResultExceptionNodes struct {
+	NodeIds   []string
+	ResultIds []string
+	Count     int64
+}
+
+// RemoveScanResultException unmasks the results masked by the exception,
+// other active exceptions are applied again to the results they also cover
+func RemoveScanResultException(ctx context.Context, scanType utils.Neo4jScanType,
+	id int64) (ScanResultExceptionNodes, error) {
+
+	res := ScanResultExceptionNodes{NodeIds: []string{}, ResultIds: []string{}}
+	resultIDField, ok := exceptionResultIDField[scanType]
+	if !ok {
+		return res, nil
+	}
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return res, err
+	}
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(120 * time.Second))
+	if err != nil {
+		return res, err
+	}
+	defer tx.Close()
+
+	r, err := tx.Run(`
+		MATCH (s) <-[:SCANNED]- (m:`+string(scanType)+`) -[r:DETECTED{exception_id: $id}]-> (d)
+		OPTIONAL MATCH (d) -[:IS]-> (e)
+		SET r.masked = false
+		REMOVE r.exception_id
+		RETURN collect(distinct m.node_id), collect(distinct s.node_id), collect(distinct `+resultIDField+`), count(r)`,
+		map[string]interface{}{"id": id})
+	if err != nil {
+		return res, err
+	}
+	rec, err := r.Single()
+	if err != nil {
+		return res, err
+	}
+	scanIds := []string{}
+	for _, v := range rec.Values[0].([]interface{}) {
+		scanIds = append(scanIds, v.(string))
+	}
+	for _, v := range rec.Values[1].([]interface{}) {
+		res.NodeIds = append(res.NodeIds, v.(string))
+	}
+	for _, v := range rec.Values[2].([]interface{}) {
+		if s, ok := v.(string); ok {
+			res.ResultIds = append(res.ResultIds, s)
+		}
+	}
+	res.Count = rec.Values[3].(int64)
+
+	remaining := []postgresqlDb.ScanResultException{}
+	active, err := pgClient.GetActiveScanResultExceptions(ctx, string(scanType))
+	if err != nil {
+		return res, err
+	}
+	for _, e := range active {
+		if e.ID != id {
+			remaining = append(remaining, e)
+		}
+	}
+	if err := ApplyScanResultExceptions(tx, scanType, remaining, scanIds); err != nil {
+		return res, err
+	}
+	return res, tx.Commit()
+}
+
+// ApplyNewScanResultException masks the existing results of all scans
+// matching a new or updated exception
+func ApplyNewScanResultException(ctx context.Context, exception postgresqlDb.ScanResultException) error {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(120 * time.Second))
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	err = ApplyScanResultExceptions(tx, utils.Neo4jScanType(exception.ScanType),
+		[]postgresqlDb.ScanResultException{exception}, nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"gotest.tools/assert"
+)
+
+func TestExceptionExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	expiry, err := ExceptionExpiry(0, now)
+	assert.NilError(t, err)
+	assert.Assert(t, !expiry.Valid, "0 never expires")
+
+	expiry, err = ExceptionExpiry(now.Unix()+3600, now)
+	assert.NilError(t, err)
+	assert.Assert(t, expiry.Valid)
+	assert.Equal(t, expiry.Time.Unix(), now.Unix()+3600)
+
+	_, err = ExceptionExpiry(now.Unix(), now)
+	assert.ErrorContains(t, err, "expires_at:")
+	_, err = ExceptionExpiry(now.Unix()-1, now)
+	assert.ErrorContains(t, err, "expires_at:")
+}
+
+func TestNewScanResultException(t *testing.T) {
+	expires := time.Unix(1700003600, 0)
+	row := postgresqlDb.ScanResultException{
+		ID:        1,
+		ScanType:  "SecretScan",
+		ResultID:  "12",
+		ScopeType: ExceptionScopeGlobal,
+		ExpiresAt: sql.NullTime{Time: expires, Valid: true},
+		Expired:   true,
+	}
+	e := NewScanResultException(row)
+	assert.Equal(t, e.ExpiresAt, expires.Unix())
+	assert.Assert(t, e.Expired)
+
+	row.ExpiresAt = sql.NullTime{}
+	assert.Equal(t, NewScanResultException(row).ExpiresAt, int64(0))
+}
+
+func TestImageNamePatternRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"nginx", "nginx", true},
+		{"nginx", "nginx-debug", false},
+		{"nginx", "library/nginx", false},
+		{"*nginx", "library/nginx", true},
+		{"registry.example.com/team/*", "registry.example.com/team/app/api", true},
+		{"registry.example.com/team/*", "registry.example.com/other/app", false},
+		{"registry.example.com/*", "registryXexample.com/app", false},
+		{"app-*-api", "app-billing-api", true},
+		{"app-*-api", "app-billing-api-v2", false},
+		{"*", "anything/at:all", true},
+		{"app+(v1)", "app+(v1)", true},
+		{"app+(v1)", "appp(v1)", false},
+	}
+
+	for _, tt := range tests {
+		re := regexp.MustCompile(ImageNamePatternRegex(tt.pattern))
+		assert.Equal(t, re.MatchString(tt.name), tt.match, "%s ~ %s", tt.name, tt.pattern)
+	}
+}
+
+func TestExceptionsToMaps(t *testing.T) {
+	maps := exceptionsToMaps([]postgresqlDb.ScanResultException{
+		{ID: 1, ResultID: "CVE-1", ScopeType: ExceptionScopeImageName, ScopeValue: "app/*"},
+		{ID: 2, ResultID: "CVE-2", ScopeType: ExceptionScopeNode, ScopeValue: "host-1"},
+	})
+	assert.Equal(t, len(maps), 2)
+	assert.Equal(t, maps[0]["scope_regex"], `^app/.*$`)
+	assert.Equal(t, maps[1]["scope_value"], "host-1")
+	assert.Equal(t, maps[1]["result_id"], "CVE-2")
+	assert.Equal(t, maps[1]["id"], int64(2))
+}
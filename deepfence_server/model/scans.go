@@ -48,6 +48,34 @@ type ScanCompareResMalware = ScanCompareRes[Malware]
 type ScanCompareResCompliance = ScanCompareRes[Compliance]
 type ScanCompareResCloudCompliance = ScanCompareRes[CloudCompliance]
 
+// ScanComparisonReq compares two scans of the same type, each side is either
+// a scan id or a node id whose latest completed scan is used
+type ScanComparisonReq struct {
+	BaseScanID   string                  `json:"base_scan_id"`
+	ToScanID     string                  `json:"to_scan_id"`
+	BaseNodeID   string                  `json:"base_node_id"`
+	ToNodeID     string                  `json:"to_node_id"`
+	FieldsFilter reporters.FieldsFilters `json:"fields_filter" required:"true"`
+	Window       FetchWindow             `json:"window"  required:"true"`
+}
+
+type ScanComparisonRes[T any] struct {
+	BaseScanID              string           `json:"base_scan_id" required:"true"`
+	ToScanID                string           `json:"to_scan_id" required:"true"`
+	Added                   []T              `json:"added" required:"true"`
+	Removed                 []T              `json:"removed" required:"true"`
+	Unchanged               []T              `json:"unchanged" required:"true"`
+	AddedSeverityCounts     map[string]int32 `json:"added_severity_counts" required:"true"`
+	RemovedSeverityCounts   map[string]int32 `json:"removed_severity_counts" required:"true"`
+	UnchangedSeverityCounts map[string]int32 `json:"unchanged_severity_counts" required:"true"`
+}
+
+type ScanComparisonResVulnerability = ScanComparisonRes[Vulnerability]
+type ScanComparisonResSecret = ScanComparisonRes[Secret]
+type ScanComparisonResMalware = ScanComparisonRes[Malware]
+type ScanComparisonResCompliance = ScanComparisonRes[Compliance]
+type ScanComparisonResCloudCompliance = ScanComparisonRes[CloudCompliance]
+
 type ScanFilter struct {
 	ImageScanFilter             reporters.ContainsFilter `json:"image_scan_filter" required:"true"`
 	ContainerScanFilter         reporters.ContainsFilter `json:"container_scan_filter" required:"true"`
@@ -59,11 +87,14 @@ type ScanFilter struct {
 type ScanTriggerCommon struct {
 	NodeIds []NodeIdentifier `json:"node_ids" required:"true"`
 	Filters ScanFilter       `json:"filters" required:"true"`
+	// platform of the multi-arch images to scan, like linux/arm64, or all,
+	// the manifest list is scanned for the default platform when empty
+	ImagePlatform string `json:"image_platform,omitempty" validate:"omitempty,max=64"`
 }
 
 type NodeIdentifier struct {
 	NodeId   string `json:"node_id" required:"true"`
-	NodeType string `json:"node_type" required:"true" enum:"image,host,container,cloud_account,cluster,registry,pod"`
+	NodeType string `json:"node_type" required:"true" enum:"image,host,container,cloud_account,cluster,registry,pod,source_repository"`
 }
 
 type ComplianceBenchmarkTypes struct {
@@ -82,6 +113,8 @@ type ScanInfo struct {
 	NodeType       string           `json:"node_type" required:"true"`
 	SeverityCounts map[string]int32 `json:"severity_counts" required:"true"`
 	NodeName       string           `json:"node_name" required:"true"`
+	// digest of the image when it was scanned, empty for other nodes
+	ImageDigest string `json:"image_digest"`
 }
 
 type ComplianceScanInfo struct {
@@ -285,6 +318,11 @@ type Secret struct {
 	Part                  string   `json:"part" required:"true"`
 	SignatureToMatch      string   `json:"signature_to_match" required:"true"`
 	Resources             []string `json:"resources" required:"false"`
+	// commit introducing the secret, only set for source repositories
+	Commit            string `json:"commit" required:"false"`
+	CommitAuthor      string `json:"commit_author" required:"false"`
+	CommitAuthorEmail string `json:"commit_author_email" required:"false"`
+	CommitTime        int64  `json:"commit_time" required:"false" format:"int64"`
 }
 
 func (Secret) NodeType() string {
@@ -349,7 +387,19 @@ type Vulnerability struct {
 	URLs                       []interface{} `json:"urls" required:"true"`
 	ExploitPOC                 string        `json:"exploit_poc" required:"true"`
 	ParsedAttackVector         string        `json:"parsed_attack_vector" required:"true"`
-	Resources                  []string      `json:"resources" required:"false"`
+	ExploitabilityScore        int           `json:"exploitability_score" required:"true"`
+	// probability of exploitation in the next 30 days from the EPSS scores
+	EPSSScore      float64 `json:"epss_score" required:"true"`
+	EPSSPercentile float64 `json:"epss_percentile" required:"true"`
+	// in the CISA known exploited vulnerabilities catalog
+	CisaKev           bool     `json:"cisa_kev" required:"true"`
+	CisaKevDateAdded  string   `json:"cisa_kev_date_added" required:"false"`
+	CisaKevDueDate    string   `json:"cisa_kev_due_date" required:"false"`
+	CisaKevRansomware bool     `json:"cisa_kev_ransomware" required:"false"`
+	Resources         []string `json:"resources" required:"false"`
+	// registry type for vulnerabilities imported from the registry, empty
+	// for the ones found by ThreatMapper
+	Source string `json:"source" required:"false"`
 }
 
 func (Vulnerability) NodeType() string {
@@ -384,6 +434,12 @@ type VulnerabilityRule struct {
 	Masked             bool          `json:"masked" required:"true"`
 	UpdatedAt          int64         `json:"updated_at" required:"true"`
 	ParsedAttackVector string        `json:"parsed_attack_vector" required:"true"`
+	EPSSScore          float64       `json:"epss_score" required:"true"`
+	EPSSPercentile     float64       `json:"epss_percentile" required:"true"`
+	CisaKev            bool          `json:"cisa_kev" required:"true"`
+	CisaKevDateAdded   string        `json:"cisa_kev_date_added" required:"false"`
+	CisaKevDueDate     string        `json:"cisa_kev_due_date" required:"false"`
+	CisaKevRansomware  bool          `json:"cisa_kev_ransomware" required:"false"`
 	Resources          []string      `json:"resources" required:"false"`
 }
 
@@ -0,0 +1,81 @@
+package model
+
+import (
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+)
+
+const (
+	SLAScopeGlobal       = "global"
+	SLAScopeLabel        = "label"
+	SLAScopeCloudAccount = "cloud_account"
+
+	SLAStatusWithin   = "within_sla"
+	SLAStatusDueSoon  = "due_soon"
+	SLAStatusBreached = "breached"
+)
+
+// SLAPolicyReq sets the days to fix the findings of a severity, the most
+// specific policy applies: label and cloud account scopes before global, then
+// the shortest deadline
+type SLAPolicyReq struct {
+	Name     string `json:"name" validate:"required,max=255" required:"true"`
+	ScanType string `json:"scan_type" validate:"required,oneof=SecretScan VulnerabilityScan MalwareScan ComplianceScan CloudComplianceScan" required:"true" enum:"SecretScan,VulnerabilityScan,MalwareScan,ComplianceScan,CloudComplianceScan"`
+	// cve_severity, level, file_severity, test_severity of compliance or
+	// severity of cloud compliance results, matched case insensitively
+	Severity string `json:"severity" validate:"required,max=32" required:"true"`
+	Days     int32  `json:"days" validate:"required,min=1,max=3650" required:"true"`
+	// findings are due soon this many days before their deadline
+	DueSoonDays int32  `json:"due_soon_days" validate:"gte=0,ltfield=Days"`
+	ScopeType   string `json:"scope_type" validate:"required,oneof=global label cloud_account" required:"true" enum:"global,label,cloud_account"`
+	// docker or kubernetes label key for the label scope
+	ScopeKey string `json:"scope_key" validate:"required_if=ScopeType label,max=256"`
+	// label value or cloud account id, empty for the global scope
+	ScopeValue string `json:"scope_value" validate:"required_unless=ScopeType global,max=1024"`
+}
+
+type SLAPolicyUpdateReq struct {
+	PolicyId    int64  `path:"policy_id" validate:"required" required:"true"`
+	Name        string `json:"name" validate:"required,max=255" required:"true"`
+	Severity    string `json:"severity" validate:"required,max=32" required:"true"`
+	Days        int32  `json:"days" validate:"required,min=1,max=3650" required:"true"`
+	DueSoonDays int32  `json:"due_soon_days" validate:"gte=0,ltfield=Days"`
+	ScopeType   string `json:"scope_type" validate:"required,oneof=global label cloud_account" required:"true" enum:"global,label,cloud_account"`
+	ScopeKey    string `json:"scope_key" validate:"required_if=ScopeType label,max=256"`
+	ScopeValue  string `json:"scope_value" validate:"required_unless=ScopeType global,max=1024"`
+}
+
+type SLAPolicyIDPathReq struct {
+	PolicyId int64 `path:"policy_id" validate:"required" required:"true"`
+}
+
+type SLAPolicy struct {
+	ID          int64  `json:"id" required:"true"`
+	Name        string `json:"name" required:"true"`
+	ScanType    string `json:"scan_type" required:"true"`
+	Severity    string `json:"severity" required:"true"`
+	Days        int32  `json:"days" required:"true"`
+	DueSoonDays int32  `json:"due_soon_days" required:"true"`
+	ScopeType   string `json:"scope_type" required:"true"`
+	ScopeKey    string `json:"scope_key" required:"true"`
+	ScopeValue  string `json:"scope_value" required:"true"`
+	CreatedBy   string `json:"created_by" required:"true"`
+	CreatedAt   int64  `json:"created_at" required:"true" format:"int64"`
+	UpdatedAt   int64  `json:"updated_at" required:"true" format:"int64"`
+}
+
+func NewSLAPolicy(row postgresqlDb.SlaPolicy) SLAPolicy {
+	return SLAPolicy{
+		ID:          row.ID,
+		Name:        row.Name,
+		ScanType:    row.ScanType,
+		Severity:    row.Severity,
+		Days:        row.Days,
+		DueSoonDays: row.DueSoonDays,
+		ScopeType:   row.ScopeType,
+		ScopeKey:    row.ScopeKey,
+		ScopeValue:  row.ScopeValue,
+		CreatedBy:   row.CreatedBy,
+		CreatedAt:   row.CreatedAt.Unix(),
+		UpdatedAt:   row.UpdatedAt.Unix(),
+	}
+}
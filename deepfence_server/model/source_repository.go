@@ -0,0 +1,251 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	SourceRepositoryAuthNone   = "none"
+	SourceRepositoryAuthToken  = "token"
+	SourceRepositoryAuthSSHKey = "ssh_key"
+)
+
+type SourceRepositoryAddReq struct {
+	Name     string `json:"name" validate:"required,min=2,max=64" required:"true"`
+	Provider string `json:"provider" validate:"required,oneof=github gitlab gitea git" required:"true" enum:"github,gitlab,gitea,git"`
+	URL      string `json:"url" validate:"required,min=4,max=2048" required:"true"`
+	// branch to scan, empty scans the history of all branches and tags
+	Branch   string `json:"branch" validate:"omitempty,max=255"`
+	AuthType string `json:"auth_type" validate:"required,oneof=none token ssh_key" required:"true" enum:"none,token,ssh_key"`
+	Username string `json:"username" validate:"omitempty,max=255"`
+	Token    string `json:"token" validate:"required_if=AuthType token"`
+	SSHKey   string `json:"ssh_key" validate:"required_if=AuthType ssh_key"`
+	// host keys of the ssh server in known_hosts format, as printed by
+	// ssh-keyscan, the clone fails if the server presents another key
+	KnownHosts string `json:"known_hosts" validate:"required_if=AuthType ssh_key,max=16384"`
+}
+
+type SourceRepositoryIDPathReq struct {
+	RepositoryId int32 `path:"repository_id" validate:"required" required:"true"`
+}
+
+// SourceRepositorySecret is saved encrypted in the encrypted_secret column
+type SourceRepositorySecret struct {
+	Token  string `json:"token,omitempty"`
+	SSHKey string `json:"ssh_key,omitempty"`
+}
+
+type SourceRepository struct {
+	ID         int32  `json:"id" required:"true"`
+	NodeId     string `json:"node_id" required:"true"`
+	Name       string `json:"name" required:"true"`
+	Provider   string `json:"provider" required:"true"`
+	URL        string `json:"url" required:"true"`
+	Branch     string `json:"branch" required:"true"`
+	AuthType   string `json:"auth_type" required:"true"`
+	Username   string `json:"username" required:"true"`
+	KnownHosts string `json:"known_hosts" required:"true"`
+	CreatedAt  int64  `json:"created_at" required:"true" format:"int64"`
+	UpdatedAt  int64  `json:"updated_at" required:"true" format:"int64"`
+}
+
+func NewSourceRepository(row postgresqlDb.SourceRepository) SourceRepository {
+	return SourceRepository{
+		ID:         row.ID,
+		NodeId:     GetSourceRepositoryID(row.Url, row.Branch),
+		Name:       row.Name,
+		Provider:   row.Provider,
+		URL:        row.Url,
+		Branch:     row.Branch,
+		AuthType:   row.AuthType,
+		Username:   row.Username,
+		KnownHosts: row.KnownHosts,
+		CreatedAt:  row.CreatedAt.Unix(),
+		UpdatedAt:  row.UpdatedAt.Unix(),
+	}
+}
+
+// ValidateURL checks the url can be cloned with the auth type, ssh keys
+// need ssh urls and tokens need http urls
+func (r SourceRepositoryAddReq) ValidateURL() error {
+	if isSSHURL(r.URL) {
+		if r.AuthType == SourceRepositoryAuthToken {
+			return errors.New("url:token auth needs an https url")
+		}
+		return nil
+	}
+	u, err := url.Parse(r.URL)
+	if err != nil || u.Host == "" {
+		return errors.New("url:invalid repository url")
+	}
+	switch u.Scheme {
+	case "http", "https":
+	default:
+		return errors.New("url:unsupported url scheme")
+	}
+	if u.User != nil {
+		return errors.New("url:credentials are not allowed in the url")
+	}
+	if r.AuthType == SourceRepositoryAuthSSHKey {
+		return errors.New("url:ssh key auth needs an ssh url")
+	}
+	// the token is sent in a header, never in clear text
+	if r.AuthType == SourceRepositoryAuthToken && u.Scheme != "https" {
+		return errors.New("url:token auth needs an https url")
+	}
+	return nil
+}
+
+// ValidateKnownHosts checks every line of the known hosts is a host key
+func (r SourceRepositoryAddReq) ValidateKnownHosts() error {
+	for _, line := range strings.Split(r.KnownHosts, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, _, _, _, _, err := ssh.ParseKnownHosts([]byte(line)); err != nil {
+			return errors.New("known_hosts:invalid known_hosts entry")
+		}
+	}
+	return nil
+}
+
+// isSSHURL matches ssh://host/path and the scp like user@host:path
+func isSSHURL(s string) bool {
+	if strings.HasPrefix(s, "ssh://") {
+		return true
+	}
+	if strings.Contains(s, "://") {
+		return false
+	}
+	at := strings.Index(s, "@")
+	colon := strings.Index(s, ":")
+	return at > 0 && colon > at
+}
+
+// GetSourceRepositoryID returns the graph node id of the repository, the
+// same repository cloned over ssh or https gets the same id
+func GetSourceRepositoryID(repositoryURL, branch string) string {
+	id := repositoryURL
+	if i := strings.Index(id, "://"); i >= 0 {
+		id = id[i+3:]
+	}
+	if i := strings.Index(id, "@"); i >= 0 && i < strings.IndexAny(id+"/", ":/") {
+		id = id[i+1:]
+	}
+	id = strings.Replace(id, ":", "/", 1)
+	id = strings.TrimSuffix(strings.TrimSuffix(id, "/"), ".git")
+	if branch != "" {
+		id += "@" + branch
+	}
+	return "source_repository_" + EscapeSlashToUnderscore(id)
+}
+
+func UpsertSourceRepositoryNode(ctx context.Context, repository SourceRepository) error {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	_, err = tx.Run(`
+		MERGE (n:SourceRepository{node_id: $node_id})
+		SET n.node_name = $name,
+		n.node_type = "source_repository",
+		n.provider = $provider,
+		n.url = $url,
+		n.branch = $branch,
+		n.pg_id = $pg_id,
+		n.updated_at = TIMESTAMP()`,
+		map[string]interface{}{
+			"node_id":  repository.NodeId,
+			"name":     repository.Name,
+			"provider": repository.Provider,
+			"url":      repository.URL,
+			"branch":   repository.Branch,
+			"pg_id":    repository.ID,
+		})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func DeleteSourceRepositoryNode(ctx context.Context, nodeId string) error {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	_, err = tx.Run(`
+		MATCH (n:SourceRepository{node_id: $node_id})
+		DETACH DELETE n`,
+		map[string]interface{}{"node_id": nodeId})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetSourceRepositoryPgId returns the postgres id of a repository node
+func GetSourceRepositoryPgId(ctx context.Context, nodeId string) (int64, error) {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+
+	r, err := tx.Run(`
+		MATCH (n:SourceRepository{node_id: $node_id})
+		RETURN n.pg_id`,
+		map[string]interface{}{"node_id": nodeId})
+	if err != nil {
+		return 0, err
+	}
+
+	record, err := r.Single()
+	if err != nil {
+		return 0, err
+	}
+	pgId, ok := record.Values[0].(int64)
+	if !ok {
+		return 0, errors.New("source repository without pg id")
+	}
+	return pgId, nil
+}
@@ -9,6 +9,14 @@ func DigestToID(digest string) (string, string) {
 	return imageID, imageID[:12]
 }
 
+// IDToDigest returns the digest an image id was built from by DigestToID
+func IDToDigest(imageID string) string {
+	if strings.Contains(imageID, ":") {
+		return imageID
+	}
+	return "sha256:" + imageID
+}
+
 func GetRegistryID(registryType, ns string) string {
 	return registryType + "_" + EscapeSlashToUnderscore(ns)
 }
@@ -10,6 +10,7 @@ const (
 	GITLAB         = "gitlab"
 	HARBOR         = "harbor"
 	JFROG          = "jfrog_container_registry"
+	OCI            = "oci_registry"
 	QUAY           = "quay"
 )
 
@@ -18,7 +19,7 @@ const (
 )
 
 var RegistryTypes = []string{
-	ACR, DOCKER_HUB, DOCKER_PRIVATE, ECR, GCR, GITLAB, HARBOR, JFROG, QUAY,
+	ACR, DOCKER_HUB, DOCKER_PRIVATE, ECR, GCR, GITLAB, HARBOR, JFROG, OCI, QUAY,
 }
 
 // Integration related consts
@@ -35,6 +36,18 @@ const (
 	Email           = "email"
 	Jira            = "jira"
 	SumoLogic       = "sumologic"
+	Opsgenie        = "opsgenie"
+	ServiceNow      = "servicenow"
+	WebexTeams      = "webex_teams"
+	Syslog          = "syslog"
+	Kafka           = "kafka"
+)
+
+// Integration digest intervals, empty interval sends a notification per scan
+const (
+	DigestImmediate = ""
+	DigestHourly    = "hourly"
+	DigestDaily     = "daily"
 )
 
 const (
@@ -42,6 +55,7 @@ const (
 	WebhookURL     = "webhook_url"
 	IntegrationKey = "integration_key"
 	APIKey         = "api_key"
+	SigningSecret  = "signing_secret"
 
 	DeepfenceCommunityEmailId = "community@deepfence.io"
 )
@@ -51,6 +65,7 @@ var SensitiveFields = map[string]struct{}{
 	WebhookURL:     {},
 	IntegrationKey: {},
 	APIKey:         {},
+	SigningSecret:  {},
 }
 
 const (
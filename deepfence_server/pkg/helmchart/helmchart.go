@@ -0,0 +1,320 @@
+package helmchart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+
+	// compliance check type of the findings, the checks follow the NSA and
+	// CISA kubernetes hardening guidance
+	ComplianceCheckType = "nsa-cisa"
+)
+
+type Resource struct {
+	Kind      string
+	Namespace string
+	Name      string
+	object    map[string]interface{}
+}
+
+func (r Resource) String() string {
+	if r.Namespace != "" {
+		return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+	}
+	return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+}
+
+// ParseManifests parses the multi document yaml rendered from the chart
+// templates, empty documents are skipped
+func ParseManifests(manifests []byte) ([]Resource, error) {
+	resources := []Resource{}
+	decoder := yaml.NewDecoder(bytes.NewReader(manifests))
+	for {
+		var object map[string]interface{}
+		err := decoder.Decode(&object)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return resources, err
+		}
+		if len(object) == 0 {
+			continue
+		}
+		kind, _ := object["kind"].(string)
+		metadata, _ := object["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		namespace, _ := metadata["namespace"].(string)
+		resources = append(resources, Resource{Kind: kind, Namespace: namespace, Name: name, object: object})
+	}
+	return resources, nil
+}
+
+func lookup(object interface{}, path ...string) interface{} {
+	for _, key := range path {
+		m, ok := object.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		object = m[key]
+	}
+	return object
+}
+
+// podSpec returns the pod spec of the workloads
+func (r Resource) podSpec() map[string]interface{} {
+	var spec interface{}
+	switch r.Kind {
+	case "Pod":
+		spec = lookup(r.object, "spec")
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		spec = lookup(r.object, "spec", "template", "spec")
+	case "CronJob":
+		spec = lookup(r.object, "spec", "jobTemplate", "spec", "template", "spec")
+	}
+	m, _ := spec.(map[string]interface{})
+	return m
+}
+
+func containers(podSpec map[string]interface{}) []map[string]interface{} {
+	res := []map[string]interface{}{}
+	for _, key := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		list, _ := podSpec[key].([]interface{})
+		for _, c := range list {
+			if m, ok := c.(map[string]interface{}); ok {
+				res = append(res, m)
+			}
+		}
+	}
+	return res
+}
+
+// collectImages walks any object, custom resources embed pod specs too
+func collectImages(object interface{}, images map[string]bool) {
+	switch v := object.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			switch key {
+			case "initContainers", "containers", "ephemeralContainers":
+				list, _ := value.([]interface{})
+				for _, c := range list {
+					if image, ok := lookup(c, "image").(string); ok && image != "" {
+						images[image] = true
+					}
+				}
+			}
+			collectImages(value, images)
+		}
+	case []interface{}:
+		for _, value := range v {
+			collectImages(value, images)
+		}
+	}
+}
+
+// ExtractImages returns the images of the containers of the resources
+func ExtractImages(resources []Resource) []string {
+	found := map[string]bool{}
+	for _, r := range resources {
+		collectImages(r.object, found)
+	}
+	images := []string{}
+	for image := range found {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images
+}
+
+type ImageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImageReference splits an image like ghcr.io/org/app:1.0, images
+// without registry are on docker hub
+func ParseImageReference(image string) ImageReference {
+	ref := ImageReference{}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+	if first, rest, found := strings.Cut(name, "/"); found &&
+		(strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry, name = first, rest
+	} else {
+		ref.Registry = "docker.io"
+	}
+	if ref.Registry == "docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref
+}
+
+// Names returns the names the image can be synced with from a registry,
+// with or without the registry host
+func (r ImageReference) Names() []string {
+	names := []string{r.Repository, r.Registry + "/" + r.Repository}
+	if strings.HasPrefix(r.Repository, "library/") {
+		names = append(names, strings.TrimPrefix(r.Repository, "library/"))
+	}
+	return names
+}
+
+type Finding struct {
+	RuleID      string
+	Title       string
+	Description string
+	Remediation string
+	Severity    string
+	Resource    string
+	Status      string
+}
+
+type rule struct {
+	id          string
+	title       string
+	description string
+	remediation string
+	severity    string
+}
+
+var (
+	rulePrivileged = rule{"helm-privileged", "Privileged container",
+		"Privileged containers have all the capabilities of the host and can escape the container.",
+		"Remove securityContext.privileged or set it to false.", "high"}
+	rulePrivilegeEscalation = rule{"helm-privilege-escalation", "Privilege escalation allowed",
+		"Processes of the container can gain more privileges than their parent, through setuid binaries.",
+		"Set securityContext.allowPrivilegeEscalation to false.", "medium"}
+	ruleRunAsNonRoot = rule{"helm-run-as-root", "Container may run as root",
+		"Containers running as root make a container escape more damaging.",
+		"Set securityContext.runAsNonRoot to true or runAsUser to a non zero user.", "medium"}
+	ruleReadOnlyRootFS = rule{"helm-writable-root-filesystem", "Writable root filesystem",
+		"A writable root filesystem lets attackers persist binaries in the container.",
+		"Set securityContext.readOnlyRootFilesystem to true and mount volumes for writable paths.", "low"}
+	ruleCapabilities = rule{"helm-dangerous-capabilities", "Dangerous capabilities added",
+		"Capabilities like SYS_ADMIN or NET_ADMIN give the container control over the host.",
+		"Remove the capabilities from securityContext.capabilities.add and drop ALL.", "high"}
+	ruleResourceLimits = rule{"helm-resource-limits", "Missing resource limits",
+		"Containers without cpu and memory limits can exhaust the resources of the node.",
+		"Set resources.limits.cpu and resources.limits.memory.", "low"}
+	ruleImageTag = rule{"helm-mutable-image-tag", "Mutable image tag",
+		"Images without tag or with the latest tag change without the chart changing.",
+		"Pin the image to a version tag or a digest.", "low"}
+	ruleHostNamespaces = rule{"helm-host-namespaces", "Host namespaces shared",
+		"Pods sharing the network, pid or ipc namespace of the host can see and reach its processes and interfaces.",
+		"Remove hostNetwork, hostPID and hostIPC or set them to false.", "high"}
+	ruleHostPath = rule{"helm-host-path", "Host path volume",
+		"Host path volumes expose the filesystem of the node to the pod.",
+		"Replace hostPath volumes with persistent volumes or emptyDir.", "medium"}
+)
+
+var dangerousCapabilities = map[string]bool{
+	"ALL": true, "SYS_ADMIN": true, "NET_ADMIN": true, "NET_RAW": true, "SYS_PTRACE": true, "SYS_MODULE": true,
+}
+
+func (r rule) finding(resource string, failed bool) Finding {
+	status := StatusPass
+	if failed {
+		status = StatusWarn
+	}
+	return Finding{
+		RuleID:      r.id,
+		Title:       r.title,
+		Description: r.description,
+		Remediation: r.remediation,
+		Severity:    r.severity,
+		Resource:    resource,
+		Status:      status,
+	}
+}
+
+func isTrue(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+func checkContainer(resource string, podSecurity, c map[string]interface{}) []Finding {
+	name, _ := c["name"].(string)
+	resource = resource + "/" + name
+	security, _ := c["securityContext"].(map[string]interface{})
+
+	runAsNonRoot := isTrue(security["runAsNonRoot"]) || isTrue(podSecurity["runAsNonRoot"])
+	for _, user := range []interface{}{security["runAsUser"], podSecurity["runAsUser"]} {
+		if uid, ok := user.(int); ok && uid > 0 {
+			runAsNonRoot = true
+		}
+	}
+
+	dangerous := false
+	added, _ := lookup(security, "capabilities", "add").([]interface{})
+	for _, capability := range added {
+		if s, ok := capability.(string); ok && dangerousCapabilities[strings.TrimPrefix(strings.ToUpper(s), "CAP_")] {
+			dangerous = true
+		}
+	}
+
+	limits, _ := lookup(c, "resources", "limits").(map[string]interface{})
+	image, _ := c["image"].(string)
+	ref := ParseImageReference(image)
+
+	escalation, set := security["allowPrivilegeEscalation"].(bool)
+	return []Finding{
+		rulePrivileged.finding(resource, isTrue(security["privileged"])),
+		rulePrivilegeEscalation.finding(resource, !set || escalation),
+		ruleRunAsNonRoot.finding(resource, !runAsNonRoot),
+		ruleReadOnlyRootFS.finding(resource, !isTrue(security["readOnlyRootFilesystem"])),
+		ruleCapabilities.finding(resource, dangerous),
+		ruleResourceLimits.finding(resource, limits["cpu"] == nil || limits["memory"] == nil),
+		ruleImageTag.finding(resource, ref.Digest == "" && ref.Tag == "latest"),
+	}
+}
+
+// CheckResources runs the misconfiguration checks on the workloads, every
+// check is reported passed or failed for every pod and container
+func CheckResources(resources []Resource) []Finding {
+	findings := []Finding{}
+	for _, r := range resources {
+		spec := r.podSpec()
+		if spec == nil {
+			continue
+		}
+		resource := r.String()
+
+		hostPath := false
+		volumes, _ := spec["volumes"].([]interface{})
+		for _, v := range volumes {
+			if lookup(v, "hostPath") != nil {
+				hostPath = true
+			}
+		}
+		findings = append(findings,
+			ruleHostNamespaces.finding(resource, isTrue(spec["hostNetwork"]) || isTrue(spec["hostPID"]) || isTrue(spec["hostIPC"])),
+			ruleHostPath.finding(resource, hostPath))
+
+		podSecurity, _ := spec["securityContext"].(map[string]interface{})
+		for _, c := range containers(spec) {
+			findings = append(findings, checkContainer(resource, podSecurity, c)...)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,128 @@
+package helmchart
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const manifests = `
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: prod
+spec:
+  template:
+    spec:
+      hostNetwork: true
+      securityContext:
+        runAsUser: 1000
+      initContainers:
+        - name: init
+          image: busybox
+      containers:
+        - name: app
+          image: ghcr.io/org/app:1.2.0
+          securityContext:
+            allowPrivilegeEscalation: false
+            readOnlyRootFilesystem: true
+            capabilities:
+              add: ["NET_ADMIN"]
+          resources:
+            limits:
+              cpu: 100m
+              memory: 128Mi
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          volumes:
+            - name: host
+              hostPath:
+                path: /var
+          containers:
+            - name: backup
+              image: registry.local:5000/tools/backup@sha256:0123
+              securityContext:
+                privileged: true
+`
+
+func findingsOf(findings []Finding, resource string) map[string]string {
+	res := map[string]string{}
+	for _, f := range findings {
+		if f.Resource == resource {
+			res[f.RuleID] = f.Status
+		}
+	}
+	return res
+}
+
+func TestParseImageReference(t *testing.T) {
+	assert.DeepEqual(t, ParseImageReference("nginx"),
+		ImageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"})
+	assert.DeepEqual(t, ParseImageReference("bitnami/redis:7.0"),
+		ImageReference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7.0"})
+	assert.DeepEqual(t, ParseImageReference("registry.local:5000/tools/backup@sha256:0123"),
+		ImageReference{Registry: "registry.local:5000", Repository: "tools/backup", Digest: "sha256:0123"})
+	assert.DeepEqual(t, ParseImageReference("localhost/app:1@sha256:ab"),
+		ImageReference{Registry: "localhost", Repository: "app", Tag: "1", Digest: "sha256:ab"})
+	assert.DeepEqual(t, ParseImageReference("nginx").Names(),
+		[]string{"library/nginx", "docker.io/library/nginx", "nginx"})
+}
+
+func TestCheckResources(t *testing.T) {
+	resources, err := ParseManifests([]byte(manifests))
+	assert.NilError(t, err)
+	assert.Equal(t, len(resources), 3)
+	assert.Equal(t, resources[1].String(), "Deployment/prod/app")
+
+	assert.DeepEqual(t, ExtractImages(resources),
+		[]string{"busybox", "ghcr.io/org/app:1.2.0", "registry.local:5000/tools/backup@sha256:0123"})
+
+	findings := CheckResources(resources)
+	// 2 checks for every pod and 7 for every container
+	assert.Equal(t, len(findings), 2*2+7*3)
+
+	assert.DeepEqual(t, findingsOf(findings, "Deployment/prod/app"), map[string]string{
+		"helm-host-namespaces": StatusWarn,
+		"helm-host-path":       StatusPass,
+	})
+	assert.DeepEqual(t, findingsOf(findings, "Deployment/prod/app/app"), map[string]string{
+		"helm-privileged":               StatusPass,
+		"helm-privilege-escalation":     StatusPass,
+		"helm-run-as-root":              StatusPass,
+		"helm-writable-root-filesystem": StatusPass,
+		"helm-dangerous-capabilities":   StatusWarn,
+		"helm-resource-limits":          StatusPass,
+		"helm-mutable-image-tag":        StatusPass,
+	})
+	assert.DeepEqual(t, findingsOf(findings, "Deployment/prod/app/init"), map[string]string{
+		"helm-privileged":               StatusPass,
+		"helm-privilege-escalation":     StatusWarn,
+		"helm-run-as-root":              StatusPass,
+		"helm-writable-root-filesystem": StatusWarn,
+		"helm-dangerous-capabilities":   StatusPass,
+		"helm-resource-limits":          StatusWarn,
+		"helm-mutable-image-tag":        StatusWarn,
+	})
+	backup := findingsOf(findings, "CronJob/backup/backup")
+	assert.Equal(t, backup["helm-privileged"], StatusWarn)
+	assert.Equal(t, backup["helm-run-as-root"], StatusWarn)
+	assert.Equal(t, backup["helm-mutable-image-tag"], StatusPass)
+	assert.Equal(t, findingsOf(findings, "CronJob/backup")["helm-host-path"], StatusWarn)
+}
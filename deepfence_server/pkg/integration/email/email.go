@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/msgtemplate"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/sendemail"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
@@ -33,8 +34,11 @@ func New(ctx context.Context, b []byte) (*Email, error) {
 	return &h, nil
 }
 
-func (e Email) FormatMessage(message []map[string]interface{}) string {
-	entiremsg := "*" + e.Resource + "*\n\n"
+func (e Email) FormatMessage(message []map[string]interface{}, title, summary string) string {
+	entiremsg := "*" + title + "*\n\n"
+	if summary != "" {
+		entiremsg += summary + "\n\n"
+	}
 
 	//Prepare the sorted keys so that the output has the records in same order
 	var keys []string
@@ -87,12 +91,13 @@ func (e Email) SendNotification(ctx context.Context, message string, extras map[
 		return err
 	}
 
-	m := e.FormatMessage(msg)
+	m := e.FormatMessage(msg, msgtemplate.Title(extras, e.Resource), msgtemplate.Summary(extras))
 	emailSender, err := sendemail.NewEmailSender(ctx)
 	if err != nil {
 		return err
 	}
-	return emailSender.Send([]string{e.Config.EmailId}, "Deepfence Subscription", m, "", nil)
+	subject := msgtemplate.Title(extras, "Deepfence Subscription")
+	return emailSender.Send([]string{e.Config.EmailId}, subject, m, "", nil)
 }
 
 func (e Email) IsEmailConfigured(ctx context.Context) bool {
@@ -0,0 +1,130 @@
+// Package finding has helpers shared by integrations to identify the finding
+// in a notification message, messages are scan results with node data injected
+package finding
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// StatusKey is set on findings which are notified as resolved, findings
+// without it are reported by the scan
+const (
+	StatusKey      = "finding_status"
+	StatusResolved = "resolved"
+)
+
+// keys identifying the finding, in order of preference
+var idFields = []string{"cve_id", "rule_id", "test_number", "control_id"}
+
+// keys identifying the finding on its node, the result node_id is replaced by
+// the scanned node and the rule id is shared by all the findings of the rule,
+// first key is only present in results of the resource
+var keyFields = [][]string{
+	{"cve_id", "cve_caused_by_package"},
+	{"control_id", "resource"},
+	{"test_number", "resource"},
+	{"full_filename", "rule_id", "commit"},
+	{"complete_filename", "rule_id"},
+}
+
+// keys holding the severity of the finding, in order of preference
+var severityFields = []string{"cve_severity", "level", "file_severity", "test_severity", "severity"}
+
+// ID returns the CVE / rule id of the finding
+func ID(m map[string]interface{}) string {
+	for _, f := range idFields {
+		if v, ok := m[f]; ok && v != nil && fmt.Sprint(v) != "" {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// NodeID returns the node on which the finding was detected
+func NodeID(m map[string]interface{}) string {
+	if v, ok := m["node_id"]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// Severity returns lower case severity of the finding
+func Severity(m map[string]interface{}) string {
+	for _, f := range severityFields {
+		if v, ok := m[f]; ok && v != nil && fmt.Sprint(v) != "" {
+			return strings.ToLower(fmt.Sprint(v))
+		}
+	}
+	return ""
+}
+
+// Fields returns the keys integrations read to identify a finding, its
+// severity and status, projections of the finding should keep them
+func Fields() []string {
+	fields := []string{"node_id", StatusKey}
+	seen := map[string]bool{"node_id": true, StatusKey: true}
+	add := func(keys []string) {
+		for _, k := range keys {
+			if !seen[k] {
+				seen[k] = true
+				fields = append(fields, k)
+			}
+		}
+	}
+	add(idFields)
+	for _, keys := range keyFields {
+		add(keys)
+	}
+	add(severityFields)
+	return fields
+}
+
+// Key returns the identity of the finding on its node, vulnerable package,
+// file or cloud resource, falls back to the finding id
+func Key(m map[string]interface{}) string {
+	for _, keys := range keyFields {
+		if v, ok := m[keys[0]]; !ok || v == nil {
+			continue
+		}
+		values := []string{}
+		for _, k := range keys {
+			if v, ok := m[k]; ok && v != nil {
+				values = append(values, fmt.Sprint(v))
+			} else {
+				values = append(values, "")
+			}
+		}
+		return strings.Join(values, "/")
+	}
+	return ID(m)
+}
+
+// DedupKey is derived from node id and finding key, so that the same finding
+// reported by repeated scans of a node maps to the same alert
+func DedupKey(m map[string]interface{}) string {
+	sum := sha256.Sum256([]byte(NodeID(m) + "/" + Key(m)))
+	return "deepfence-" + hex.EncodeToString(sum[:])
+}
+
+// Title returns a short one line description of the finding
+func Title(resource string, m map[string]interface{}) string {
+	title := resource
+	if id := ID(m); id != "" {
+		title = fmt.Sprintf("%s %s", title, id)
+	}
+	if node := NodeName(m); node != "" {
+		title = fmt.Sprintf("%s on %s", title, node)
+	}
+	return title
+}
+
+// NodeName returns the name of the node, falls back to node id
+func NodeName(m map[string]interface{}) string {
+	if v, ok := m["node_name"]; ok && v != nil && fmt.Sprint(v) != "" {
+		return fmt.Sprint(v)
+	}
+	return NodeID(m)
+}
@@ -3,13 +3,33 @@ package httpendpoint
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/google/uuid"
 )
 
-// todo: add support for batch size
-const BatchSize = 100
+const (
+	BatchSize = 100
+
+	MaxRetries    = 3
+	BaseBackoff   = time.Second
+	MaxRetryAfter = time.Minute
+
+	SignatureHeader  = "X-Deepfence-Signature"
+	TimestampHeader  = "X-Deepfence-Timestamp"
+	DeliveryIDHeader = "X-Deepfence-Delivery-Id"
+)
 
 func New(ctx context.Context, b []byte) (*HTTPEndpoint, error) {
 	h := HTTPEndpoint{}
@@ -21,41 +41,149 @@ func New(ctx context.Context, b []byte) (*HTTPEndpoint, error) {
 }
 
 func (h HTTPEndpoint) SendNotification(ctx context.Context, message string, extras map[string]interface{}) error {
-	var req *http.Request
-	var err error
+	var msg []json.RawMessage
+	d := json.NewDecoder(strings.NewReader(message))
+	d.UseNumber()
+	if err := d.Decode(&msg); err != nil {
+		return err
+	}
+
+	batchSize := h.Config.BatchSize
+	if batchSize <= 0 {
+		batchSize = BatchSize
+	}
 
-	payloadBytes := []byte(message)
+	for startIdx := 0; startIdx < len(msg); startIdx += batchSize {
+		endIdx := startIdx + batchSize
+		if endIdx > len(msg) {
+			endIdx = len(msg)
+		}
 
-	// send message to this http url using http
-	// Set up the HTTP request.
-	req, err = http.NewRequest("POST", h.Config.URL, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return err
+		payloadBytes, err := json.Marshal(msg[startIdx:endIdx])
+		if err != nil {
+			return err
+		}
+
+		if err := h.send(ctx, payloadBytes); err != nil {
+			return err
+		}
 	}
 
-	if h.Config.AuthHeader != "" {
-		req.Header.Set("Authorization", h.Config.AuthHeader)
+	return nil
+}
+
+// send posts one batch, retrying on network errors, 5xx and 429 responses
+// with the same delivery id so that receivers can drop duplicates
+func (h HTTPEndpoint) send(ctx context.Context, payloadBytes []byte) error {
+	deliveryID := uuid.New().String()
+	client := utils.GetHttpClient()
+
+	var lastErr error
+	for attempt := 0; attempt <= MaxRetries; attempt++ {
+		if attempt > 0 {
+			log.Warn().Msgf("%v, retrying delivery %s", lastErr, deliveryID)
+		}
+
+		req, err := h.newRequest(ctx, payloadBytes, deliveryID)
+		if err != nil {
+			return err
+		}
+
+		delay := backoff(attempt)
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+		} else {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			resp.Body.Close()
+
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				return nil
+			}
+
+			lastErr = fmt.Errorf("http endpoint returned status %d: %s",
+				resp.StatusCode, strings.TrimSpace(string(body)))
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return lastErr
+			}
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+		}
+
+		if attempt < MaxRetries && !wait(ctx, delay) {
+			return ctx.Err()
+		}
 	}
 
+	return lastErr
+}
+
+func (h HTTPEndpoint) newRequest(ctx context.Context, payloadBytes []byte, deliveryID string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.Config.URL, bytes.NewBuffer(payloadBytes))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	// Make the HTTP request.
-	client := utils.GetHttpClient()
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+	if h.Config.AuthHeader != "" {
+		req.Header.Set("Authorization", h.Config.AuthHeader)
 	}
-	defer resp.Body.Close()
 
-	// Check the response status code.
-	if resp.StatusCode != http.StatusOK {
-		return err
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(DeliveryIDHeader, deliveryID)
+	if h.Config.SigningSecret != "" {
+		req.Header.Set(SignatureHeader, Sign(h.Config.SigningSecret, timestamp, payloadBytes))
 	}
 
-	return nil
+	return req, nil
 }
 
-// func (s Slack) FormatMessage
+// Sign returns the value of X-Deepfence-Signature header, hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" so that a captured request can not be
+// replayed with a different timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func backoff(attempt int) time.Duration {
+	return BaseBackoff << attempt
+}
+
+// parseRetryAfter supports both delay-seconds and http-date formats
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		delay = time.Until(t)
+	} else {
+		return 0, false
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > MaxRetryAfter {
+		delay = MaxRetryAfter
+	}
+	return delay, true
+}
+
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
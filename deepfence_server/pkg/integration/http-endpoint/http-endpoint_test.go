@@ -0,0 +1,57 @@
+package httpendpoint
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSendNotificationSignsAndRetries(t *testing.T) {
+	var requests, batches int
+	deliveryIDs := map[string]struct{}{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get(TimestampHeader)
+		assert.Equal(t, r.Header.Get(SignatureHeader), Sign("secret", timestamp, body), "signature should match")
+		deliveryIDs[r.Header.Get(DeliveryIDHeader)] = struct{}{}
+
+		// first attempt of every batch is throttled
+		if requests%2 == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		var batch []map[string]interface{}
+		assert.NilError(t, json.Unmarshal(body, &batch))
+		assert.Assert(t, len(batch) <= 2, "batch size should be honoured")
+		batches++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	h := HTTPEndpoint{Config: Config{URL: server.URL, SigningSecret: "secret", BatchSize: 2}}
+	err := h.SendNotification(context.Background(), `[{"a":1},{"a":2},{"a":3}]`, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, batches, 2, "should send two batches")
+	assert.Equal(t, requests, 4, "should retry throttled requests")
+	assert.Equal(t, len(deliveryIDs), 2, "retries should reuse the delivery id")
+}
+
+func TestSendNotificationReturnsErrorOnFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	h := HTTPEndpoint{Config: Config{URL: server.URL}}
+	err := h.SendNotification(context.Background(), `[{"a":1}]`, nil)
+	assert.ErrorContains(t, err, "401")
+}
@@ -16,6 +16,10 @@ type HTTPEndpoint struct {
 type Config struct {
 	URL        string `json:"url" validate:"required,url" required:"true"`
 	AuthHeader string `json:"auth_header"`
+	// SigningSecret is used to sign the payload, signature is sent in X-Deepfence-Signature header
+	SigningSecret string `json:"signing_secret"`
+	// BatchSize is the number of findings sent in one request, defaults to 100
+	BatchSize int `json:"batch_size" validate:"omitempty,min=1,max=1000"`
 }
 
 func (h HTTPEndpoint) ValidateConfig(validate *validator.Validate) error {
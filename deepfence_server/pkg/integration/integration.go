@@ -15,12 +15,17 @@ import (
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/constants"
 	httpendpoint "github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/http-endpoint"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/jira"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/kafka"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/opsgenie"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/pagerduty"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/s3"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/servicenow"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/slack"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/splunk"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/sumologic"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/syslog"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/teams"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/webex"
 )
 
 // GetIntegration returns an integration object based on the integration type
@@ -50,6 +55,16 @@ func GetIntegration(ctx context.Context, integrationType string, b []byte) (Inte
 		return jira.New(ctx, b)
 	case constants.SumoLogic:
 		return sumologic.New(ctx, b)
+	case constants.Opsgenie:
+		return opsgenie.New(ctx, b)
+	case constants.ServiceNow:
+		return servicenow.New(ctx, b)
+	case constants.WebexTeams:
+		return webex.New(ctx, b)
+	case constants.Syslog:
+		return syslog.New(ctx, b)
+	case constants.Kafka:
+		return kafka.New(ctx, b)
 	default:
 		return nil, errors.New("invalid integration type")
 	}
@@ -59,15 +74,48 @@ func IsMessagingFormat(integrationType string) bool {
 	retVal := false
 	switch integrationType {
 	case constants.Slack, constants.Teams, constants.PagerDuty,
-		constants.Email, constants.Jira:
+		constants.Email, constants.Jira, constants.Opsgenie,
+		constants.ServiceNow, constants.WebexTeams:
 		retVal = true
 	}
 	return retVal
 }
 
+// SupportsDigest returns true if findings can be aggregated into a single
+// summary message, required for digest mode and rate limiting
+func SupportsDigest(integrationType string) bool {
+	switch integrationType {
+	case constants.Slack, constants.Teams, constants.Email, constants.WebexTeams:
+		return true
+	}
+	return false
+}
+
 // Integration is the interface for all integrations
 type Integration interface {
 	// extras are additional fields that are not part of the message
 	SendNotification(ctx context.Context, message string, extras map[string]interface{}) error
 	ValidateConfig(*validator.Validate) error
 }
+
+// ResolvedNotifier is implemented by integrations which track findings and
+// close them once a later scan of the node no longer reports them
+type ResolvedNotifier interface {
+	// message has the findings of the previous scan missing in the latest scan
+	NotifyResolved(ctx context.Context, message string, extras map[string]interface{}) error
+}
+
+// MessageCounter is implemented by integrations which split the findings of
+// a notification into several messages
+type MessageCounter interface {
+	MessageCount(findings int) int
+}
+
+// MessageCount returns the number of messages the integration sends for the
+// findings of one notification, counted by the rate limit
+func MessageCount(i Integration, findings int) int {
+	if c, ok := i.(MessageCounter); ok {
+		return c.MessageCount(findings)
+	}
+	return 1
+}
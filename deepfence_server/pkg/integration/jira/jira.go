@@ -2,18 +2,27 @@ package jira
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 
 	jira "github.com/andygrunwald/go-jira"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
 )
 
+const (
+	deepfenceLabel  = "deepfence"
+	nodeLabelPrefix = "deepfence-node-"
+)
+
 func New(ctx context.Context, b []byte) (*Jira, error) {
 	h := Jira{}
 	err := json.Unmarshal(b, &h)
@@ -23,7 +32,7 @@ func New(ctx context.Context, b []byte) (*Jira, error) {
 	return &h, nil
 }
 
-func (j Jira) SendNotification(ctx context.Context, message string, extras map[string]interface{}) error {
+func (j Jira) newClient() (*jira.Client, error) {
 	auth := jira.BasicAuthTransport{
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{RootCAs: x509.NewCertPool(), InsecureSkipVerify: true},
@@ -37,16 +46,121 @@ func (j Jira) SendNotification(ctx context.Context, message string, extras map[s
 		auth.Password = strings.TrimSpace(j.Config.Password)
 	}
 
-	client, err := jira.NewClient(auth.Client(), strings.TrimSpace(j.Config.JiraSiteUrl))
+	return jira.NewClient(auth.Client(), strings.TrimSpace(j.Config.JiraSiteUrl))
+}
+
+// SendNotification files one issue per finding labeled with the finding
+// fingerprint, if an open issue already exists for the fingerprint the
+// finding is still present and a comment is added instead
+func (j Jira) SendNotification(ctx context.Context, message string, extras map[string]interface{}) error {
+	msg, err := decodeMessage(message)
+	if err != nil {
+		return err
+	}
+
+	client, err := j.newClient()
+	if err != nil {
+		log.Error().Msgf(err.Error())
+		return err
+	}
+
+	openIssues := map[string]map[string]jira.Issue{}
+	for _, m := range msg {
+		nodeID := finding.NodeID(m)
+		if _, ok := openIssues[nodeID]; !ok {
+			openIssues[nodeID], err = j.openIssues(client, nodeID)
+			if err != nil {
+				return err
+			}
+		}
+
+		fingerprint := finding.DedupKey(m)
+		if issue, ok := openIssues[nodeID][fingerprint]; ok {
+			comment := jira.Comment{
+				Body: fmt.Sprintf("Finding is still present in scan %v", extras["scan_id"]),
+			}
+			_, resp, err := client.Issue.AddComment(issue.ID, &comment)
+			if err != nil {
+				return responseError("comment", resp, err)
+			}
+			log.Info().Msgf("jira issue %s commented, finding still present", issue.Key)
+			continue
+		}
+
+		issue, err := j.createIssue(client, m, extras)
+		if err != nil {
+			return err
+		}
+		openIssues[nodeID][fingerprint] = *issue
+	}
+
+	return nil
+}
+
+// NotifyResolved transitions the open issues of findings which are no longer
+// reported by the latest scan to done
+func (j Jira) NotifyResolved(ctx context.Context, message string, extras map[string]interface{}) error {
+	msg, err := decodeMessage(message)
+	if err != nil {
+		return err
+	}
+	if len(msg) == 0 {
+		return nil
+	}
+
+	client, err := j.newClient()
 	if err != nil {
 		log.Error().Msgf(err.Error())
 		return err
 	}
 
-	extraStr := []string{}
-	for k, v := range extras {
-		if v != "" {
-			extraStr = append(extraStr, fmt.Sprintf("%s: %v", k, v))
+	openIssues := map[string]map[string]jira.Issue{}
+	for _, m := range msg {
+		nodeID := finding.NodeID(m)
+		if _, ok := openIssues[nodeID]; !ok {
+			openIssues[nodeID], err = j.openIssues(client, nodeID)
+			if err != nil {
+				return err
+			}
+		}
+
+		issue, ok := openIssues[nodeID][finding.DedupKey(m)]
+		if !ok {
+			continue
+		}
+
+		comment := jira.Comment{
+			Body: fmt.Sprintf("Finding is no longer reported by scan %v", extras["scan_id"]),
+		}
+		_, resp, err := client.Issue.AddComment(issue.ID, &comment)
+		if err != nil {
+			return responseError("comment", resp, err)
+		}
+
+		if err := transitionToDone(client, issue); err != nil {
+			return err
+		}
+		log.Info().Msgf("jira issue %s resolved", issue.Key)
+	}
+
+	return nil
+}
+
+func (j Jira) createIssue(client *jira.Client, m map[string]interface{}, extras map[string]interface{}) (*jira.Issue, error) {
+	resource := j.Resource
+	if resource == "" {
+		resource = fmt.Sprint(extras["scan_type"])
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	details := []string{}
+	for _, k := range keys {
+		if v := m[k]; v != nil && v != "" {
+			details = append(details, fmt.Sprintf("%s: %v", k, v))
 		}
 	}
 
@@ -55,53 +169,96 @@ func (j Jira) SendNotification(ctx context.Context, message string, extras map[s
 			Assignee: &jira.User{
 				Name: j.Config.JiraAssignee,
 			},
-			Description: fmt.Sprintf("Scan Details:\n\n%s", strings.Join(extraStr, "\n")),
+			Description: fmt.Sprintf("Finding Details:\n\n%s", strings.Join(details, "\n")),
 			Type: jira.IssueType{
 				Name: j.Config.IssueType,
 			},
 			Project: jira.Project{
 				Key: j.Config.JiraProjectKey,
 			},
-			Summary: fmt.Sprintf("Deepfence %v Scan Issues for %s - %s",
-				extras["scan_type"], extras["node_type"], extras["node_name"]),
+			Summary: "Deepfence " + finding.Title(resource, m),
+			Labels:  []string{deepfenceLabel, nodeLabel(finding.NodeID(m)), finding.DedupKey(m)},
 		},
 	}
 
 	issue, resp, err := client.Issue.Create(&i)
 	if err != nil {
-		log.Error().Msgf(err.Error())
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Error().Msgf(err.Error())
-		}
-		log.Error().Msgf("jira error reponse: %s", string(body))
-		return err
+		return nil, responseError("create issue", resp, err)
 	}
 	log.Info().Msgf("jira issue created id %s link %s", issue.ID, issue.Self)
 
-	attachment, resp, err := client.Issue.PostAttachment(issue.ID, strings.NewReader(message), "scan-results.json")
+	issue.Fields = i.Fields
+	return issue, nil
+}
+
+// openIssues returns not done issues filed for the node keyed by fingerprint
+func (j Jira) openIssues(client *jira.Client, nodeID string) (map[string]jira.Issue, error) {
+	issues := map[string]jira.Issue{}
+	jql := fmt.Sprintf(`project = "%s" AND labels = "%s" AND statusCategory != Done`,
+		j.Config.JiraProjectKey, nodeLabel(nodeID))
+
+	err := client.Issue.SearchPages(jql, &jira.SearchOptions{MaxResults: 100, Fields: []string{"labels"}},
+		func(issue jira.Issue) error {
+			if issue.Fields == nil {
+				return nil
+			}
+			for _, label := range issue.Fields.Labels {
+				if strings.HasPrefix(label, deepfenceLabel+"-") && !strings.HasPrefix(label, nodeLabelPrefix) {
+					issues[label] = issue
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		log.Error().Msgf("jira search error: %v", err)
+		return nil, err
+	}
+	return issues, nil
+}
+
+func transitionToDone(client *jira.Client, issue jira.Issue) error {
+	transitions, resp, err := client.Issue.GetTransitions(issue.ID)
 	if err != nil {
-		log.Error().Msgf(err.Error())
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Error().Msgf(err.Error())
-		}
-		log.Error().Msgf("jira attachment error reponse: %s", string(body))
-		return err
+		return responseError("get transitions", resp, err)
 	}
-	defer resp.Body.Close()
 
-	log.Info().Msgf(
-		"jira issue id %s attchment added %+v",
-		issue.ID,
-		func(as *[]jira.Attachment) string {
-			a := []string{}
-			for _, i := range *as {
-				a = append(a, i.Self)
+	for _, t := range transitions {
+		if t.To.StatusCategory.Key == jira.StatusCategoryComplete {
+			resp, err := client.Issue.DoTransition(issue.ID, t.ID)
+			if err != nil {
+				return responseError("transition", resp, err)
 			}
-			return strings.Join(a, ",")
-		}(attachment),
-	)
+			return nil
+		}
+	}
 
+	log.Warn().Msgf("jira issue %s has no transition to done", issue.Key)
 	return nil
 }
+
+func nodeLabel(nodeID string) string {
+	sum := sha256.Sum256([]byte(nodeID))
+	return nodeLabelPrefix + hex.EncodeToString(sum[:16])
+}
+
+func decodeMessage(message string) ([]map[string]interface{}, error) {
+	var msg []map[string]interface{}
+	d := json.NewDecoder(strings.NewReader(message))
+	d.UseNumber()
+	if err := d.Decode(&msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func responseError(action string, resp *jira.Response, err error) error {
+	log.Error().Msgf(err.Error())
+	if resp != nil && resp.Body != nil {
+		body, rerr := io.ReadAll(resp.Body)
+		if rerr != nil {
+			log.Error().Msgf(rerr.Error())
+		}
+		log.Error().Msgf("jira %s error reponse: %s", action, string(body))
+	}
+	return err
+}
@@ -0,0 +1,169 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type fakeIssue struct {
+	labels   []string
+	comments int
+	done     bool
+}
+
+// fakeJira serves the issue search, create, comment and transition apis
+type fakeJira struct {
+	sync.Mutex
+	issues []*fakeIssue
+}
+
+func (f *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/rest/api/2/")
+	switch {
+	case path == "search":
+		jql := r.URL.Query().Get("jql")
+		issues := []map[string]interface{}{}
+		for i, issue := range f.issues {
+			if issue.done || !strings.Contains(jql, fmt.Sprintf(`labels = "%s"`, issue.labels[1])) {
+				continue
+			}
+			issues = append(issues, map[string]interface{}{
+				"id":     fmt.Sprint(i),
+				"key":    fmt.Sprintf("DF-%d", i),
+				"fields": map[string]interface{}{"labels": issue.labels},
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"startAt": 0, "maxResults": 100, "total": len(issues), "issues": issues,
+		})
+	case path == "issue" && r.Method == http.MethodPost:
+		var req struct {
+			Fields struct {
+				Labels []string `json:"labels"`
+			} `json:"fields"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.issues = append(f.issues, &fakeIssue{labels: req.Fields.Labels})
+		id := len(f.issues) - 1
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": fmt.Sprint(id), "key": fmt.Sprintf("DF-%d", id)})
+	default:
+		var id int
+		var action string
+		if _, err := fmt.Sscanf(strings.Replace(path, "/", " ", -1), "issue %d %s", &id, &action); err != nil || id >= len(f.issues) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch {
+		case action == "comment":
+			f.issues[id].comments++
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		case action == "transitions" && r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`{"transitions":[{"id":"11","to":{"statusCategory":{"key":"indeterminate"}}},{"id":"31","to":{"statusCategory":{"key":"done"}}}]}`))
+		case action == "transitions":
+			var req struct {
+				Transition struct {
+					ID string `json:"id"`
+				} `json:"transition"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			f.issues[id].done = req.Transition.ID == "31"
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func newTestJira(t *testing.T) (*fakeJira, Jira) {
+	f := &fakeJira{}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, Jira{
+		Config: Config{
+			JiraSiteUrl:    server.URL,
+			Username:       "user",
+			JiraProjectKey: "DF",
+			IssueType:      "Task",
+			IsAuthToken:    true,
+			APIToken:       "token",
+		},
+		Resource: "Secret",
+	}
+}
+
+const secretsMessage = `[
+	{"node_id":"host-1","rule_id":12,"full_filename":"/etc/a.pem","level":"high"},
+	{"node_id":"host-1","rule_id":12,"full_filename":"/etc/b.pem","level":"high"},
+	{"node_id":"host-1","rule_id":12,"full_filename":"/etc/a.pem","level":"high"},
+	{"node_id":"host-2","rule_id":12,"full_filename":"/etc/a.pem","level":"high"}]`
+
+func TestSendNotificationCreate(t *testing.T) {
+	f, j := newTestJira(t)
+
+	assert.NilError(t, j.SendNotification(context.Background(), secretsMessage, map[string]interface{}{"scan_id": "scan-1"}))
+
+	assert.Equal(t, len(f.issues), 3, "one issue per file and node")
+	assert.Equal(t, f.issues[0].comments, 1, "repeated finding in the same scan is commented")
+	assert.Equal(t, f.issues[1].comments, 0)
+	assert.Assert(t, f.issues[0].labels[2] != f.issues[1].labels[2], "files of the same rule are different findings")
+	assert.Assert(t, f.issues[0].labels[1] != f.issues[2].labels[1], "nodes are labeled separately")
+}
+
+func TestSendNotificationDedup(t *testing.T) {
+	f, j := newTestJira(t)
+
+	assert.NilError(t, j.SendNotification(context.Background(), secretsMessage, map[string]interface{}{"scan_id": "scan-1"}))
+	assert.NilError(t, j.SendNotification(context.Background(), secretsMessage, map[string]interface{}{"scan_id": "scan-2"}))
+
+	assert.Equal(t, len(f.issues), 3, "findings still present are not filed again")
+	assert.Equal(t, f.issues[1].comments, 1)
+	assert.Equal(t, f.issues[2].comments, 1)
+}
+
+func TestSendNotificationCloudComplianceDedup(t *testing.T) {
+	f, j := newTestJira(t)
+	j.Resource = "CloudCompliance"
+
+	for _, scanID := range []string{"scan-1", "scan-2"} {
+		message := fmt.Sprintf(`[{"node_id":"account-1","control_id":"s3.1","resource":"arn:aws:s3:::bucket","scan_id":"%s"}]`, scanID)
+		assert.NilError(t, j.SendNotification(context.Background(), message, map[string]interface{}{"scan_id": scanID}))
+	}
+
+	assert.Equal(t, len(f.issues), 1, "control on the same resource is the same finding in every scan")
+	assert.Equal(t, f.issues[0].comments, 1)
+}
+
+func TestNotifyResolved(t *testing.T) {
+	f, j := newTestJira(t)
+
+	assert.NilError(t, j.SendNotification(context.Background(), secretsMessage, map[string]interface{}{"scan_id": "scan-1"}))
+
+	resolved := `[{"node_id":"host-1","rule_id":12,"full_filename":"/etc/b.pem","finding_status":"resolved"},
+		{"node_id":"host-1","rule_id":12,"full_filename":"/etc/c.pem","finding_status":"resolved"}]`
+	assert.NilError(t, j.NotifyResolved(context.Background(), resolved, map[string]interface{}{"scan_id": "scan-2"}))
+
+	assert.Assert(t, !f.issues[0].done, "other files of the rule are still open")
+	assert.Assert(t, f.issues[1].done)
+	assert.Assert(t, !f.issues[2].done, "same file on another node is still open")
+	assert.Equal(t, f.issues[1].comments, 1)
+
+	// a resolved issue is filed again when the finding comes back
+	assert.NilError(t, j.SendNotification(context.Background(), secretsMessage, map[string]interface{}{"scan_id": "scan-3"}))
+	assert.Equal(t, len(f.issues), 4)
+}
@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+const produceTimeout = time.Minute
+
+func New(ctx context.Context, b []byte) (*Kafka, error) {
+	k := Kafka{}
+	err := json.Unmarshal(b, &k)
+	if err != nil {
+		return &k, err
+	}
+	return &k, nil
+}
+
+// SendNotification produces one record per finding, records are keyed by
+// the finding dedup key so that updates of a finding land in one partition
+func (k Kafka) SendNotification(ctx context.Context, message string, extras map[string]interface{}) error {
+	var msg []map[string]interface{}
+	d := json.NewDecoder(strings.NewReader(message))
+	d.UseNumber()
+	if err := d.Decode(&msg); err != nil {
+		return err
+	}
+	if len(msg) == 0 {
+		return nil
+	}
+
+	resource := k.Resource
+	if resource == "" {
+		resource = fmt.Sprint(extras["scan_type"])
+	}
+
+	records, err := k.records(resource, msg)
+	if err != nil {
+		return err
+	}
+
+	opts, err := k.clientOpts()
+	if err != nil {
+		return err
+	}
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, produceTimeout)
+	defer cancel()
+
+	return client.ProduceSync(ctx, records...).FirstErr()
+}
+
+// records are produced to the configured topic, the resource and severity of
+// the finding are set as headers so that consumers can route without parsing
+func (k Kafka) records(resource string, msg []map[string]interface{}) ([]*kgo.Record, error) {
+	records := make([]*kgo.Record, 0, len(msg))
+	for _, m := range msg {
+		m["event_type"] = resource
+		value, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, &kgo.Record{
+			Topic: k.Config.Topic,
+			Key:   []byte(finding.DedupKey(m)),
+			Value: value,
+			Headers: []kgo.RecordHeader{
+				{Key: "event_type", Value: []byte(resource)},
+				{Key: "severity", Value: []byte(finding.Severity(m))},
+			},
+		})
+	}
+	return records, nil
+}
+
+func (k Kafka) clientOpts() ([]kgo.Opt, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(k.Config.Brokers...),
+		kgo.WithLogger(utils.KgoLogger),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		kgo.RecordRetries(5),
+	}
+
+	if k.Config.TLS {
+		opts = append(opts, kgo.DialTLSConfig(&tls.Config{InsecureSkipVerify: k.Config.InsecureSkipVerify}))
+	}
+
+	var mechanism sasl.Mechanism
+	switch k.Config.SASLMechanism {
+	case "":
+	case "PLAIN":
+		mechanism = plain.Auth{User: k.Config.Username, Pass: k.Config.Password}.AsMechanism()
+	case "SCRAM-SHA-256":
+		mechanism = scram.Auth{User: k.Config.Username, Pass: k.Config.Password}.AsSha256Mechanism()
+	case "SCRAM-SHA-512":
+		mechanism = scram.Auth{User: k.Config.Username, Pass: k.Config.Password}.AsSha512Mechanism()
+	default:
+		return nil, fmt.Errorf("unsupported sasl mechanism %s", k.Config.SASLMechanism)
+	}
+	if mechanism != nil {
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+
+	return opts, nil
+}
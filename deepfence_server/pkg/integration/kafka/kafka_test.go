@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"gotest.tools/assert"
+)
+
+func header(r *kgo.Record, key string) string {
+	for _, h := range r.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestRecords(t *testing.T) {
+	k := Kafka{Config: Config{Brokers: []string{"localhost:9092"}, Topic: "findings"}}
+	msg := []map[string]interface{}{
+		{"node_id": "host-1", "cve_id": "CVE-1", "cve_caused_by_package": "openssl:1.1", "cve_severity": "Critical"},
+		{"node_id": "host-1", "cve_id": "CVE-1", "cve_caused_by_package": "openssl:1.1", "cve_severity": "Critical"},
+		{"node_id": "host-1", "cve_id": "CVE-1", "cve_caused_by_package": "libssl:1.1", "cve_severity": "low"},
+		{"node_id": "host-2", "cve_id": "CVE-1", "cve_caused_by_package": "openssl:1.1"},
+	}
+
+	records, err := k.records("Vulnerability", msg)
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 4)
+
+	for _, r := range records {
+		assert.Equal(t, r.Topic, "findings")
+		assert.Equal(t, header(r, "event_type"), "Vulnerability")
+
+		var value map[string]interface{}
+		assert.NilError(t, json.Unmarshal(r.Value, &value))
+		assert.Equal(t, value["event_type"], "Vulnerability")
+	}
+
+	assert.Equal(t, header(records[0], "severity"), "critical")
+	assert.Equal(t, header(records[2], "severity"), "low")
+	assert.Equal(t, header(records[3], "severity"), "")
+
+	assert.Equal(t, string(records[0].Key), string(records[1].Key), "same finding on same node should have the same key")
+	assert.Assert(t, string(records[0].Key) != string(records[2].Key), "other package is another finding")
+	assert.Assert(t, string(records[0].Key) != string(records[3].Key), "same finding on another node is another key")
+}
+
+func TestRecordsKeyIgnoresScan(t *testing.T) {
+	k := Kafka{Config: Config{Topic: "findings"}}
+	msg := []map[string]interface{}{
+		{"node_id": "account-1", "scan_id": "scan-1", "control_id": "s3.1", "resource": "arn:aws:s3:::a", "severity": "high"},
+		{"node_id": "account-1", "scan_id": "scan-2", "control_id": "s3.1", "resource": "arn:aws:s3:::a", "severity": "high"},
+		{"node_id": "account-1", "scan_id": "scan-2", "control_id": "s3.1", "resource": "arn:aws:s3:::b", "severity": "high"},
+	}
+
+	records, err := k.records("CloudCompliance", msg)
+	assert.NilError(t, err)
+	assert.Equal(t, string(records[0].Key), string(records[1].Key))
+	assert.Assert(t, string(records[1].Key) != string(records[2].Key))
+	assert.Equal(t, header(records[0], "severity"), "high")
+}
+
+func TestSendNotificationEmpty(t *testing.T) {
+	k := Kafka{Config: Config{Brokers: []string{"localhost:1"}, Topic: "findings"}}
+	assert.NilError(t, k.SendNotification(context.Background(), `[]`, nil))
+	assert.ErrorContains(t, k.SendNotification(context.Background(), `{`, nil), "unexpected EOF")
+}
+
+func TestClientOpts(t *testing.T) {
+	for _, mechanism := range []string{"", "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512"} {
+		k := Kafka{Config: Config{Brokers: []string{"localhost:9092"}, SASLMechanism: mechanism, Username: "u", Password: "p"}}
+		_, err := k.clientOpts()
+		assert.NilError(t, err, mechanism)
+	}
+
+	k := Kafka{Config: Config{Brokers: []string{"localhost:9092"}, SASLMechanism: "GSSAPI"}}
+	_, err := k.clientOpts()
+	assert.ErrorContains(t, err, "unsupported sasl mechanism GSSAPI")
+}
@@ -0,0 +1,29 @@
+package kafka
+
+import (
+	"github.com/deepfence/ThreatMapper/deepfence_server/reporters"
+	"github.com/go-playground/validator/v10"
+)
+
+type Kafka struct {
+	Config           Config                  `json:"config"`
+	IntegrationType  string                  `json:"integration_type"`
+	NotificationType string                  `json:"notification_type"`
+	Filters          reporters.FieldsFilters `json:"filters"`
+	Message          string                  `json:"message"`
+	Resource         string                  `json:"resource"`
+}
+
+type Config struct {
+	Brokers            []string `json:"brokers" validate:"required,min=1,dive,hostname_port" required:"true"`
+	Topic              string   `json:"topic" validate:"required,min=1,max=249" required:"true"`
+	TLS                bool     `json:"tls"`
+	InsecureSkipVerify bool     `json:"insecure_skip_verify"`
+	SASLMechanism      string   `json:"sasl_mechanism" validate:"omitempty,oneof=PLAIN SCRAM-SHA-256 SCRAM-SHA-512" enum:"PLAIN,SCRAM-SHA-256,SCRAM-SHA-512"`
+	Username           string   `json:"username" validate:"required_with=SASLMechanism"`
+	Password           string   `json:"password" validate:"required_with=SASLMechanism"`
+}
+
+func (k Kafka) ValidateConfig(validate *validator.Validate) error {
+	return validate.Struct(k.Config)
+}
@@ -0,0 +1,269 @@
+// Package msgtemplate renders the per integration message template, template
+// controls the title and summary of a notification, fields of each finding
+// shown and how findings are grouped into notifications
+package msgtemplate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
+)
+
+// group by options
+const (
+	GroupByNone     = ""
+	GroupByNode     = "node"
+	GroupBySeverity = "severity"
+	GroupByScan     = "scan"
+)
+
+// extras keys set from the rendered template
+const (
+	TitleKey   = "title"
+	SummaryKey = "summary"
+)
+
+// fields which are always kept, integrations use them for dedup and colors
+var requiredFields = append([]string{"scan_id"}, finding.Fields()...)
+
+type Template struct {
+	// Title and Summary are go text/template, see Data for the fields
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+	// Fields of each finding shown in the notification, all if empty
+	Fields  []string `json:"fields"`
+	GroupBy string   `json:"group_by" validate:"omitempty,oneof=node severity scan" enum:"node,severity,scan"`
+}
+
+// Data is passed to the templates when rendering a group of findings
+type Data struct {
+	Resource   string
+	GroupBy    string
+	GroupKey   string
+	Count      int
+	Severities map[string]int
+	Nodes      []string
+	Scan       map[string]interface{}
+	Findings   []map[string]interface{}
+}
+
+// Group is one notification rendered from the template
+type Group struct {
+	Key      string                   `json:"key"`
+	Title    string                   `json:"title"`
+	Summary  string                   `json:"summary"`
+	Findings []map[string]interface{} `json:"findings"`
+}
+
+var funcs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// Parse unmarshals template stored with the integration, empty template
+// returns nil
+func Parse(b []byte) (*Template, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	t := Template{}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, err
+	}
+	if t.IsEmpty() {
+		return nil, nil
+	}
+	return &t, nil
+}
+
+func (t Template) IsEmpty() bool {
+	return t.Title == "" && t.Summary == "" && len(t.Fields) == 0 && t.GroupBy == GroupByNone
+}
+
+// Validate checks that title and summary are valid templates
+func (t Template) Validate() error {
+	if _, err := template.New("title").Funcs(funcs).Parse(t.Title); err != nil {
+		return err
+	}
+	if _, err := template.New("summary").Funcs(funcs).Parse(t.Summary); err != nil {
+		return err
+	}
+	switch t.GroupBy {
+	case GroupByNone, GroupByNode, GroupBySeverity, GroupByScan:
+	default:
+		return fmt.Errorf("invalid group_by %s", t.GroupBy)
+	}
+	return nil
+}
+
+// Render groups the findings and renders title and summary for each group
+func (t Template) Render(resource string, findings []map[string]interface{}, extras map[string]interface{}) ([]Group, error) {
+	title, err := template.New("title").Funcs(funcs).Parse(t.Title)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := template.New("summary").Funcs(funcs).Parse(t.Summary)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []Group{}
+	for _, key := range t.groupKeys(findings) {
+		data := t.data(resource, key, findings, extras)
+
+		g := Group{Key: key}
+		if g.Title, err = execute(title, data); err != nil {
+			return nil, err
+		}
+		if g.Summary, err = execute(summary, data); err != nil {
+			return nil, err
+		}
+		for _, f := range data.Findings {
+			g.Findings = append(g.Findings, t.project(f))
+		}
+		groups = append(groups, g)
+	}
+
+	return groups, nil
+}
+
+// Message is the findings and extras of one notification
+type Message struct {
+	Findings []map[string]interface{}
+	Extras   map[string]interface{}
+}
+
+// Messages renders the findings with the template stored with the
+// integration, one message per group, or one message with all the findings
+// when the integration has no template
+func Messages(stored []byte, resource string, findings []map[string]interface{},
+	extras map[string]interface{}) ([]Message, error) {
+
+	t, err := Parse(stored)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return []Message{{Findings: findings, Extras: extras}}, nil
+	}
+	groups, err := t.Render(resource, findings, extras)
+	if err != nil {
+		return nil, err
+	}
+	messages := []Message{}
+	for _, g := range groups {
+		messages = append(messages, Message{Findings: g.Findings, Extras: g.Extras(extras)})
+	}
+	return messages, nil
+}
+
+// Extras returns a copy of extras with the rendered title and summary of the
+// group, integrations fallback to their default layout if not set
+func (g Group) Extras(extras map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(extras)+2)
+	for k, v := range extras {
+		res[k] = v
+	}
+	if g.Title != "" {
+		res[TitleKey] = g.Title
+	}
+	if g.Summary != "" {
+		res[SummaryKey] = g.Summary
+	}
+	return res
+}
+
+// Title returns the rendered title from extras or the default
+func Title(extras map[string]interface{}, defaultTitle string) string {
+	if t, ok := extras[TitleKey].(string); ok && t != "" {
+		return t
+	}
+	return defaultTitle
+}
+
+// Summary returns the rendered summary from extras, empty if not set
+func Summary(extras map[string]interface{}) string {
+	s, _ := extras[SummaryKey].(string)
+	return s
+}
+
+func (t Template) groupKey(m map[string]interface{}) string {
+	switch t.GroupBy {
+	case GroupByNode:
+		return finding.NodeName(m)
+	case GroupBySeverity:
+		return finding.Severity(m)
+	case GroupByScan:
+		return fmt.Sprint(m["scan_id"])
+	}
+	return ""
+}
+
+// groupKeys returns distinct group keys in the order of first occurrence
+func (t Template) groupKeys(findings []map[string]interface{}) []string {
+	keys := []string{}
+	seen := map[string]struct{}{}
+	for _, f := range findings {
+		k := t.groupKey(f)
+		if _, ok := seen[k]; !ok {
+			seen[k] = struct{}{}
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (t Template) data(resource, key string, findings []map[string]interface{}, extras map[string]interface{}) Data {
+	d := Data{
+		Resource:   resource,
+		GroupBy:    t.GroupBy,
+		GroupKey:   key,
+		Severities: map[string]int{},
+		Scan:       extras,
+	}
+	nodes := map[string]struct{}{}
+	for _, f := range findings {
+		if t.groupKey(f) != key {
+			continue
+		}
+		d.Findings = append(d.Findings, f)
+		d.Severities[finding.Severity(f)]++
+		nodes[finding.NodeName(f)] = struct{}{}
+	}
+	for n := range nodes {
+		d.Nodes = append(d.Nodes, n)
+	}
+	sort.Strings(d.Nodes)
+	d.Count = len(d.Findings)
+	return d
+}
+
+// project keeps only the template fields of the finding
+func (t Template) project(m map[string]interface{}) map[string]interface{} {
+	if len(t.Fields) == 0 {
+		return m
+	}
+	res := map[string]interface{}{}
+	for _, fields := range [][]string{requiredFields, t.Fields} {
+		for _, f := range fields {
+			if v, ok := m[f]; ok {
+				res[f] = v
+			}
+		}
+	}
+	return res
+}
+
+func execute(t *template.Template, data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
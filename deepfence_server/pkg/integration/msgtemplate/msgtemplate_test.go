@@ -0,0 +1,115 @@
+package msgtemplate
+
+import (
+	"testing"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
+	"gotest.tools/assert"
+)
+
+func findings() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"node_id": "n1", "node_name": "host-1", "scan_id": "s1", "cve_id": "CVE-1", "cve_severity": "critical"},
+		{"node_id": "n1", "node_name": "host-1", "scan_id": "s1", "cve_id": "CVE-2", "cve_severity": "high"},
+		{"node_id": "n2", "node_name": "host-2", "scan_id": "s2", "cve_id": "CVE-3", "cve_severity": "critical"},
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	tmpl, err := Parse(nil)
+	assert.NilError(t, err)
+	assert.Assert(t, tmpl == nil)
+
+	tmpl, err = Parse([]byte(`{}`))
+	assert.NilError(t, err)
+	assert.Assert(t, tmpl == nil)
+
+	tmpl, err = Parse([]byte(`{"group_by":"node"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, tmpl.GroupBy, GroupByNode)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NilError(t, Template{Title: "{{.Resource}}"}.Validate())
+	assert.Assert(t, Template{Title: "{{.Resource"}.Validate() != nil)
+	assert.Assert(t, Template{GroupBy: "cluster"}.Validate() != nil)
+}
+
+func TestRenderGroupByNode(t *testing.T) {
+	tmpl := Template{
+		Title:   "{{upper .Resource}} on {{.GroupKey}}",
+		Summary: "{{.Count}} findings, {{index .Severities \"critical\"}} critical",
+		Fields:  []string{"node_name"},
+		GroupBy: GroupByNode,
+	}
+	groups, err := tmpl.Render("Vulnerability", findings(), map[string]interface{}{"scan_id": "s1"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(groups), 2)
+
+	assert.Equal(t, groups[0].Key, "host-1")
+	assert.Equal(t, groups[0].Title, "VULNERABILITY on host-1")
+	assert.Equal(t, groups[0].Summary, "2 findings, 1 critical")
+	assert.Equal(t, len(groups[0].Findings), 2)
+	// id and severity are kept for the integrations even if not in fields
+	assert.DeepEqual(t, groups[0].Findings[0], map[string]interface{}{
+		"node_id": "n1", "node_name": "host-1", "scan_id": "s1", "cve_id": "CVE-1", "cve_severity": "critical",
+	})
+
+	assert.Equal(t, groups[1].Key, "host-2")
+	assert.Equal(t, groups[1].Summary, "1 findings, 1 critical")
+}
+
+func TestRenderGroupBySeverity(t *testing.T) {
+	tmpl := Template{Title: "{{.GroupKey}}: {{join .Nodes \", \"}}", GroupBy: GroupBySeverity}
+	groups, err := tmpl.Render("Vulnerability", findings(), nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(groups), 2)
+	assert.Equal(t, groups[0].Title, "critical: host-1, host-2")
+	assert.Equal(t, groups[1].Title, "high: host-1")
+	// all fields are kept when fields are not set
+	assert.Equal(t, groups[0].Findings[0]["cve_severity"], "critical")
+}
+
+func TestGroupExtras(t *testing.T) {
+	extras := map[string]interface{}{"scan_id": "s1"}
+	res := Group{Title: "title"}.Extras(extras)
+	assert.Equal(t, Title(res, "default"), "title")
+	assert.Equal(t, Summary(res), "")
+	assert.Equal(t, Title(extras, "default"), "default")
+	_, ok := extras[TitleKey]
+	assert.Assert(t, !ok)
+}
+
+func TestProjectKeepsFindingIdentity(t *testing.T) {
+	tmpl := Template{Fields: []string{"node_name"}}
+	for _, f := range []map[string]interface{}{
+		{"node_id": "n1", "node_name": "host-1", "rule_id": 12, "full_filename": "/etc/a.pem", "level": "high", "matched_content": "secret"},
+		{"node_id": "n1", "node_name": "host-1", "rule_id": "eicar", "complete_filename": "/tmp/x", "file_severity": "medium", "summary": "test"},
+		{"node_id": "a1", "node_name": "account", "control_id": "s3.1", "resource": "arn:aws:s3:::b", "severity": "low", "reason": "public"},
+		{"node_id": "n1", "node_name": "host-1", "cve_id": "CVE-1", "finding_status": "resolved", "cve_description": "desc"},
+	} {
+		p := tmpl.project(f)
+		assert.Equal(t, finding.DedupKey(p), finding.DedupKey(f))
+		assert.Equal(t, finding.Severity(p), finding.Severity(f))
+		assert.Equal(t, p[finding.StatusKey], f[finding.StatusKey])
+		assert.Equal(t, len(p), len(f)-1, "fields not in the template are dropped")
+	}
+}
+
+func TestMessages(t *testing.T) {
+	extras := map[string]interface{}{"scan_id": "s1"}
+	messages, err := Messages(nil, "Vulnerability", findings(), extras)
+	assert.NilError(t, err)
+	assert.Equal(t, len(messages), 1)
+	assert.Equal(t, len(messages[0].Findings), 3)
+	assert.DeepEqual(t, messages[0].Extras, extras)
+
+	messages, err = Messages([]byte(`{"title":"{{.GroupKey}}","group_by":"node"}`), "Vulnerability", findings(), extras)
+	assert.NilError(t, err)
+	assert.Equal(t, len(messages), 2)
+	assert.Equal(t, Title(messages[0].Extras, ""), "host-1")
+	assert.Equal(t, len(messages[1].Findings), 1)
+
+	_, err = Messages([]byte(`{"title":"{{.GroupKey"}`), "Vulnerability", findings(), extras)
+	assert.Assert(t, err != nil)
+}
@@ -0,0 +1,134 @@
+package opsgenie
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+)
+
+const (
+	usAPIURL = "https://api.opsgenie.com"
+	euAPIURL = "https://api.eu.opsgenie.com"
+
+	// opsgenie limits
+	maxMessageLength     = 130
+	maxDescriptionLength = 15000
+)
+
+var opsgeniePriorityMapping = map[string]string{
+	"critical": "P1",
+	"high":     "P2",
+	"medium":   "P3",
+	"low":      "P4",
+}
+
+func New(ctx context.Context, b []byte) (*Opsgenie, error) {
+	o := Opsgenie{}
+	err := json.Unmarshal(b, &o)
+	if err != nil {
+		return &o, err
+	}
+	return &o, nil
+}
+
+func (o Opsgenie) apiURL() string {
+	if o.Config.APIURL != "" {
+		return strings.TrimSuffix(o.Config.APIURL, "/")
+	}
+	if o.Config.Region == "eu" {
+		return euAPIURL
+	}
+	return usAPIURL
+}
+
+// SendNotification creates one alert per finding, alias is the finding dedup
+// key so opsgenie increments the count of an open alert instead of a new one
+func (o Opsgenie) SendNotification(ctx context.Context, message string, extras map[string]interface{}) error {
+	var msg []map[string]interface{}
+	d := json.NewDecoder(strings.NewReader(message))
+	d.UseNumber()
+	if err := d.Decode(&msg); err != nil {
+		return err
+	}
+
+	for _, m := range msg {
+		if err := o.createAlert(ctx, o.FormatMessage(m)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o Opsgenie) FormatMessage(m map[string]interface{}) Alert {
+	priority := opsgeniePriorityMapping[finding.Severity(m)]
+	if priority == "" {
+		priority = "P5"
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	details := map[string]string{}
+	var description string
+	for _, k := range keys {
+		v := fmt.Sprint(m[k])
+		details[k] = v
+		description += fmt.Sprintf("%s: %s\n", k, v)
+	}
+
+	return Alert{
+		Message:     truncate("Deepfence - "+finding.Title(o.Resource, m), maxMessageLength),
+		Alias:       finding.DedupKey(m),
+		Description: truncate(description, maxDescriptionLength),
+		Tags:        append([]string{"deepfence", strings.ToLower(o.Resource)}, o.Config.Tags...),
+		Details:     details,
+		Entity:      finding.NodeName(m),
+		Source:      "deepfence",
+		Priority:    priority,
+	}
+}
+
+func (o Opsgenie) createAlert(ctx context.Context, alert Alert) error {
+	payloadBytes, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.apiURL()+"/v2/alerts", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.Config.APIKey)
+
+	client := utils.GetHttpClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("opsgenie: unexpected response status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,47 @@
+package opsgenie
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSendNotification(t *testing.T) {
+	var alerts []Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/v2/alerts")
+		assert.Equal(t, r.Header.Get("Authorization"), "GenieKey key")
+		var alert Alert
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&alert))
+		alerts = append(alerts, alert)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	o := Opsgenie{Config: Config{APIKey: "key", APIURL: server.URL}, Resource: "Vulnerability"}
+	message := `[{"node_id":"host-1","cve_id":"CVE-1","cve_severity":"critical"},
+		{"node_id":"host-1","cve_id":"CVE-1","cve_severity":"critical"},
+		{"node_id":"host-2","cve_id":"CVE-1","cve_severity":"low"}]`
+	assert.NilError(t, o.SendNotification(context.Background(), message, nil))
+
+	assert.Equal(t, len(alerts), 3)
+	assert.Equal(t, alerts[0].Priority, "P1")
+	assert.Equal(t, alerts[2].Priority, "P4")
+	assert.Equal(t, alerts[0].Alias, alerts[1].Alias, "same finding on same node should be deduplicated")
+	assert.Assert(t, alerts[0].Alias != alerts[2].Alias, "same finding on another node is a new alert")
+}
+
+func TestSendNotificationError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	o := Opsgenie{Config: Config{APIKey: "key", APIURL: server.URL}}
+	err := o.SendNotification(context.Background(), `[{"node_id":"host-1","rule_id":"1"}]`, nil)
+	assert.ErrorContains(t, err, "401")
+}
@@ -0,0 +1,39 @@
+package opsgenie
+
+import (
+	"github.com/deepfence/ThreatMapper/deepfence_server/reporters"
+	"github.com/go-playground/validator/v10"
+)
+
+type Opsgenie struct {
+	Config           Config                  `json:"config"`
+	IntegrationType  string                  `json:"integration_type"`
+	NotificationType string                  `json:"notification_type"`
+	Filters          reporters.FieldsFilters `json:"filters"`
+	Message          string                  `json:"message"`
+	Resource         string                  `json:"resource"`
+}
+
+type Config struct {
+	APIKey string `json:"api_key" validate:"required,min=1" required:"true"`
+	// Region is us or eu, defaults to us
+	Region string `json:"region" validate:"omitempty,oneof=us eu"`
+	// APIURL overrides the region based api url
+	APIURL string   `json:"api_url" validate:"omitempty,url"`
+	Tags   []string `json:"tags"`
+}
+
+type Alert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Entity      string            `json:"entity,omitempty"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+}
+
+func (o Opsgenie) ValidateConfig(validate *validator.Validate) error {
+	return validate.Struct(o.Config)
+}
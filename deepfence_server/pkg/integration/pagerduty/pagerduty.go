@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/PagerDuty/go-pagerduty"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/msgtemplate"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	"net/http"
@@ -60,16 +61,21 @@ func (p PagerDuty) SendNotification(ctx context.Context, message string, extras
 		sev = "info"
 	}
 
+	details := map[string]string{
+		"alert": m,
+	}
+	if summary := msgtemplate.Summary(extras); summary != "" {
+		details["summary"] = summary
+	}
+
 	incident := pagerduty.V2Event{
 		RoutingKey: p.Config.ServiceKey,
 		Action:     "trigger",
 		Payload: &pagerduty.V2Payload{
-			Summary:  fmt.Sprintf("Deepfence - %s Subscription", p.Resource),
+			Summary:  msgtemplate.Title(extras, fmt.Sprintf("Deepfence - %s Subscription", p.Resource)),
 			Source:   "deepfence",
 			Severity: sev,
-			Details: map[string]string{
-				"alert": m,
-			},
+			Details:  details,
 		},
 	}
 
@@ -0,0 +1,187 @@
+package servicenow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+)
+
+const (
+	defaultTable = "incident"
+
+	// servicenow field limits
+	maxShortDescriptionLength = 160
+	maxCorrelationIDLength    = 100
+)
+
+// urgency and impact: 1 - High, 2 - Medium, 3 - Low
+var servicenowSeverityMapping = map[string]string{
+	"critical": "1",
+	"high":     "1",
+	"medium":   "2",
+	"low":      "3",
+}
+
+func New(ctx context.Context, b []byte) (*ServiceNow, error) {
+	s := ServiceNow{}
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return &s, err
+	}
+	return &s, nil
+}
+
+func (s ServiceNow) tableURL() string {
+	table := s.Config.Table
+	if table == "" {
+		table = defaultTable
+	}
+	return strings.TrimSuffix(s.Config.InstanceURL, "/") + "/api/now/table/" + url.PathEscape(table)
+}
+
+// SendNotification creates one ticket per finding, correlation_id is the
+// finding dedup key, if an active ticket exists a work note is added instead
+func (s ServiceNow) SendNotification(ctx context.Context, message string, extras map[string]interface{}) error {
+	var msg []map[string]interface{}
+	d := json.NewDecoder(strings.NewReader(message))
+	d.UseNumber()
+	if err := d.Decode(&msg); err != nil {
+		return err
+	}
+
+	for _, m := range msg {
+		record := s.FormatMessage(m)
+
+		sysID, err := s.findActiveRecord(ctx, record.CorrelationID)
+		if err != nil {
+			return err
+		}
+		if sysID != "" {
+			err = s.do(ctx, http.MethodPatch, s.tableURL()+"/"+sysID,
+				Record{WorkNotes: "Finding reported again by Deepfence\n\n" + record.Description}, http.StatusOK)
+		} else {
+			err = s.do(ctx, http.MethodPost, s.tableURL(), record, http.StatusCreated)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s ServiceNow) FormatMessage(m map[string]interface{}) Record {
+	severity := servicenowSeverityMapping[finding.Severity(m)]
+	if severity == "" {
+		severity = "3"
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var description string
+	for _, k := range keys {
+		description += fmt.Sprintf("%s: %v\n", k, m[k])
+	}
+
+	return Record{
+		ShortDescription:   truncate("Deepfence - "+finding.Title(s.Resource, m), maxShortDescriptionLength),
+		Description:        description,
+		Urgency:            severity,
+		Impact:             severity,
+		Category:           "security",
+		CorrelationID:      truncate(finding.DedupKey(m), maxCorrelationIDLength),
+		CorrelationDisplay: "deepfence",
+		AssignmentGroup:    s.Config.AssignmentGroup,
+	}
+}
+
+func (s ServiceNow) findActiveRecord(ctx context.Context, correlationID string) (string, error) {
+	query := url.Values{}
+	query.Set("sysparm_query", "active=true^correlation_id="+correlationID)
+	query.Set("sysparm_fields", "sys_id")
+	query.Set("sysparm_limit", "1")
+
+	req, err := s.newRequest(ctx, http.MethodGet, s.tableURL()+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	client := utils.GetHttpClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+
+	var result tableResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if len(result.Result) == 0 {
+		return "", nil
+	}
+	return result.Result[0].SysID, nil
+}
+
+func (s ServiceNow) do(ctx context.Context, method, endpoint string, record Record, expectedStatus int) error {
+	payloadBytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	req, err := s.newRequest(ctx, method, endpoint, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return err
+	}
+
+	client := utils.GetHttpClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func (s ServiceNow) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(s.Config.Username, s.Config.Password)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("servicenow: unexpected response status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
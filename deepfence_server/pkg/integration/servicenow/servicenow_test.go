@@ -0,0 +1,62 @@
+package servicenow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSendNotificationDeduplicates(t *testing.T) {
+	records := map[string]Record{}
+	var created, updated int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.Assert(t, ok && user == "admin" && pass == "secret")
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query().Get("sysparm_query")
+			result := []map[string]string{}
+			for id, rec := range records {
+				if strings.HasSuffix(query, "correlation_id="+rec.CorrelationID) {
+					result = append(result, map[string]string{"sys_id": id})
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+		case http.MethodPost:
+			assert.Equal(t, r.URL.Path, "/api/now/table/incident")
+			var rec Record
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&rec))
+			records[rec.CorrelationID] = rec
+			created++
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodPatch:
+			id := strings.TrimPrefix(r.URL.Path, "/api/now/table/incident/")
+			_, ok := records[id]
+			assert.Assert(t, ok, "should update existing record")
+			updated++
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	s := ServiceNow{
+		Config:   Config{InstanceURL: server.URL, Username: "admin", Password: "secret"},
+		Resource: "Secret",
+	}
+	message := `[{"node_id":"host-1","rule_id":10,"level":"high"},{"node_id":"host-1","rule_id":10,"level":"high"}]`
+	assert.NilError(t, s.SendNotification(context.Background(), message, nil))
+
+	assert.Equal(t, created, 1)
+	assert.Equal(t, updated, 1)
+	for _, rec := range records {
+		assert.Equal(t, rec.Urgency, "1")
+	}
+}
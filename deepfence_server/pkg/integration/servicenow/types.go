@@ -0,0 +1,47 @@
+package servicenow
+
+import (
+	"github.com/deepfence/ThreatMapper/deepfence_server/reporters"
+	"github.com/go-playground/validator/v10"
+)
+
+type ServiceNow struct {
+	Config           Config                  `json:"config"`
+	IntegrationType  string                  `json:"integration_type"`
+	NotificationType string                  `json:"notification_type"`
+	Filters          reporters.FieldsFilters `json:"filters"`
+	Message          string                  `json:"message"`
+	Resource         string                  `json:"resource"`
+}
+
+type Config struct {
+	InstanceURL string `json:"instance_url" validate:"required,url" required:"true"`
+	Username    string `json:"username" validate:"required,min=1" required:"true"`
+	Password    string `json:"password" validate:"required,min=1" required:"true"`
+	// Table defaults to incident
+	Table string `json:"table" validate:"omitempty,max=80"`
+	// AssignmentGroup is the sys_id of the group tickets are assigned to
+	AssignmentGroup string `json:"assignment_group"`
+}
+
+type Record struct {
+	ShortDescription   string `json:"short_description,omitempty"`
+	Description        string `json:"description,omitempty"`
+	Urgency            string `json:"urgency,omitempty"`
+	Impact             string `json:"impact,omitempty"`
+	Category           string `json:"category,omitempty"`
+	CorrelationID      string `json:"correlation_id,omitempty"`
+	CorrelationDisplay string `json:"correlation_display,omitempty"`
+	AssignmentGroup    string `json:"assignment_group,omitempty"`
+	WorkNotes          string `json:"work_notes,omitempty"`
+}
+
+type tableResponse struct {
+	Result []struct {
+		SysID string `json:"sys_id"`
+	} `json:"result"`
+}
+
+func (s ServiceNow) ValidateConfig(validate *validator.Validate) error {
+	return validate.Struct(s.Config)
+}
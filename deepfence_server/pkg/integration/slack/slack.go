@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/msgtemplate"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	"net/http"
 	"strings"
@@ -21,13 +22,17 @@ func New(ctx context.Context, b []byte) (*Slack, error) {
 	return &s, nil
 }
 
-func (s Slack) FormatMessage(message []map[string]interface{}, index int) []map[string]interface{} {
+func (s Slack) FormatMessage(message []map[string]interface{}, index int, title, summary string) []map[string]interface{} {
+	header := fmt.Sprintf("*%s*\n", title)
+	if summary != "" {
+		header = fmt.Sprintf("%s%s\n", header, summary)
+	}
 	blocks := []map[string]interface{}{
 		{
 			"type": "section",
 			"text": map[string]interface{}{
 				"type": "mrkdwn",
-				"text": fmt.Sprintf("*%s*\n", s.Resource),
+				"text": header,
 			},
 		},
 	}
@@ -117,6 +122,11 @@ func (s Slack) FormatMessage(message []map[string]interface{}, index int) []map[
 	return blocks
 }
 
+// MessageCount returns the number of messages the findings are split into
+func (s Slack) MessageCount(findings int) int {
+	return (findings + BatchSize - 1) / BatchSize
+}
+
 func (s Slack) SendNotification(ctx context.Context, message string, extras map[string]interface{}) error {
 	// formatting: unmarshal into payload
 	var msg []map[string]interface{}
@@ -139,7 +149,7 @@ func (s Slack) SendNotification(ctx context.Context, message string, extras map[
 
 		batchMsg := msg[startIdx:endIdx]
 
-		m := s.FormatMessage(batchMsg, startIdx+1)
+		m := s.FormatMessage(batchMsg, startIdx+1, msgtemplate.Title(extras, s.Resource), msgtemplate.Summary(extras))
 		payload := map[string]interface{}{
 			"blocks": m,
 		}
@@ -0,0 +1,174 @@
+package syslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
+)
+
+const (
+	vendor        = "Deepfence"
+	product       = "ThreatMapper"
+	deviceVersion = "2.0"
+)
+
+var facilities = map[string]int{
+	"user":   1,
+	"local0": 16,
+	"local1": 17,
+	"local2": 18,
+	"local3": 19,
+	"local4": 20,
+	"local5": 21,
+	"local6": 22,
+	"local7": 23,
+}
+
+// syslog severity: 2 critical, 3 error, 4 warning, 5 notice, 6 informational
+var syslogSeverityMapping = map[string]int{
+	"critical": 2,
+	"high":     3,
+	"medium":   4,
+	"low":      5,
+}
+
+// cef and leef severity: 0 - 10
+var cefSeverityMapping = map[string]int{
+	"critical": 10,
+	"high":     8,
+	"medium":   5,
+	"low":      3,
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+	leefEscaper         = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+)
+
+// FormatRFC5424 frames the message body as a RFC 5424 syslog message
+func FormatRFC5424(facility string, severity string, hostname, appName, msgID, body string, ts time.Time) string {
+	fac, ok := facilities[facility]
+	if !ok {
+		fac = facilities["user"]
+	}
+	sev, ok := syslogSeverityMapping[severity]
+	if !ok {
+		sev = 6
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s",
+		fac*8+sev,
+		ts.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		headerField(hostname, 255),
+		headerField(appName, 48),
+		headerField(msgID, 32),
+		body,
+	)
+}
+
+// FormatCEF returns the finding as an ArcSight common event format message
+func FormatCEF(resource string, m map[string]interface{}) string {
+	sev, ok := cefSeverityMapping[finding.Severity(m)]
+	if !ok {
+		sev = 1
+	}
+
+	ext := []string{}
+	for _, k := range sortedKeys(m) {
+		ext = append(ext, fmt.Sprintf("%s=%s", cefKey(k), cefExtensionEscaper.Replace(value(m[k]))))
+	}
+
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		vendor, product, deviceVersion,
+		cefHeaderEscaper.Replace(resource+":"+finding.ID(m)),
+		cefHeaderEscaper.Replace(finding.Title(resource, m)),
+		sev,
+		strings.Join(ext, " "),
+	)
+}
+
+// FormatLEEF returns the finding as an IBM QRadar log event extended format
+// 1.0 message, attributes are tab separated
+func FormatLEEF(resource string, m map[string]interface{}) string {
+	sev, ok := cefSeverityMapping[finding.Severity(m)]
+	if !ok {
+		sev = 1
+	}
+
+	attrs := []string{fmt.Sprintf("sev=%d", sev), "cat=" + leefEscaper.Replace(resource)}
+	for _, k := range sortedKeys(m) {
+		attrs = append(attrs, fmt.Sprintf("%s=%s", cefKey(k), leefEscaper.Replace(value(m[k]))))
+	}
+
+	return fmt.Sprintf("LEEF:1.0|%s|%s|%s|%s|%s",
+		vendor, product, deviceVersion,
+		cefHeaderEscaper.Replace(resource+":"+finding.ID(m)),
+		strings.Join(attrs, "\t"),
+	)
+}
+
+// FormatJSON returns the finding as a json object
+func FormatJSON(resource string, m map[string]interface{}) (string, error) {
+	event := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		event[k] = v
+	}
+	event["event_type"] = resource
+	b, err := json.Marshal(event)
+	return string(b), err
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func value(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []interface{}, map[string]interface{}:
+		b, _ := json.Marshal(t)
+		return string(b)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// cefKey keeps only alphanumeric characters, keys can not have spaces or =
+func cefKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return -1
+	}, k)
+}
+
+// headerField replaces empty values with nil value and strips characters not
+// allowed in RFC 5424 header fields
+func headerField(s string, maxLen int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
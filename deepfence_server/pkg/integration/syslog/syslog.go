@@ -0,0 +1,112 @@
+package syslog
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
+)
+
+const (
+	defaultAppName = "deepfence"
+	dialTimeout    = 10 * time.Second
+	writeTimeout   = 30 * time.Second
+)
+
+func New(ctx context.Context, b []byte) (*Syslog, error) {
+	s := Syslog{}
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return &s, err
+	}
+	return &s, nil
+}
+
+// SendNotification writes one syslog message per finding
+func (s Syslog) SendNotification(ctx context.Context, message string, extras map[string]interface{}) error {
+	var msg []map[string]interface{}
+	d := json.NewDecoder(strings.NewReader(message))
+	d.UseNumber()
+	if err := d.Decode(&msg); err != nil {
+		return err
+	}
+	if len(msg) == 0 {
+		return nil
+	}
+
+	resource := s.Resource
+	if resource == "" {
+		resource = fmt.Sprint(extras["scan_type"])
+	}
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	hostname, _ := os.Hostname()
+	appName := s.Config.AppName
+	if appName == "" {
+		appName = defaultAppName
+	}
+
+	for _, m := range msg {
+		body, err := s.FormatMessage(resource, m)
+		if err != nil {
+			return err
+		}
+		line := FormatRFC5424(s.Config.Facility, finding.Severity(m), hostname, appName, resource, body, time.Now())
+
+		// udp sends one message per datagram, stream transports use octet
+		// counting framing as per RFC 6587
+		if s.Config.Protocol != "udp" {
+			line = fmt.Sprintf("%d %s", len(line), line)
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if _, err := conn.Write([]byte(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s Syslog) FormatMessage(resource string, m map[string]interface{}) (string, error) {
+	switch s.Config.Format {
+	case "cef":
+		return FormatCEF(resource, m), nil
+	case "leef":
+		return FormatLEEF(resource, m), nil
+	default:
+		return FormatJSON(resource, m)
+	}
+}
+
+func (s Syslog) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	switch s.Config.Protocol {
+	case "udp", "tcp":
+		return dialer.DialContext(ctx, s.Config.Protocol, s.Config.Address)
+	case "tls":
+		tlsConfig := &tls.Config{InsecureSkipVerify: s.Config.InsecureSkipVerify}
+		if s.Config.CACert != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(s.Config.CACert)) {
+				return nil, errors.New("invalid ca certificate")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConfig}
+		return tlsDialer.DialContext(ctx, "tcp", s.Config.Address)
+	}
+	return nil, fmt.Errorf("invalid syslog protocol %s", s.Config.Protocol)
+}
@@ -0,0 +1,76 @@
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestFormatRFC5424(t *testing.T) {
+	ts := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	line := FormatRFC5424("local0", "high", "console", "deepfence", "Vulnerability", "body", ts)
+	assert.Equal(t, line, "<131>1 2023-01-02T03:04:05.000000Z console deepfence - Vulnerability - body")
+}
+
+func TestFormatCEF(t *testing.T) {
+	m := map[string]interface{}{
+		"cve_id":       "CVE-1",
+		"cve_severity": "critical",
+		"node_name":    "host|1",
+		"description":  "a=b\nc",
+	}
+	assert.Equal(t, FormatCEF("Vulnerability", m),
+		`CEF:0|Deepfence|ThreatMapper|2.0|Vulnerability:CVE-1|Vulnerability CVE-1 on host\|1|10|`+
+			`cve_id=CVE-1 cve_severity=critical description=a\=b\nc node_name=host|1`)
+}
+
+func TestFormatLEEF(t *testing.T) {
+	m := map[string]interface{}{"rule_id": 7, "level": "low"}
+	assert.Equal(t, FormatLEEF("Secret", m),
+		"LEEF:1.0|Deepfence|ThreatMapper|2.0|Secret:7|sev=3\tcat=Secret\tlevel=low\trule_id=7")
+}
+
+func TestSendNotificationTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		lines := []string{}
+		for i := 0; i < 2; i++ {
+			size, _ := r.ReadString(' ')
+			n, _ := strconv.Atoi(strings.TrimSpace(size))
+			buf := make([]byte, n)
+			_, err := io.ReadFull(r, buf)
+			if err != nil {
+				break
+			}
+			lines = append(lines, string(buf))
+		}
+		received <- lines
+	}()
+
+	s := Syslog{Config: Config{Address: ln.Addr().String(), Protocol: "tcp", Format: "cef"}, Resource: "Malware"}
+	err = s.SendNotification(context.Background(),
+		`[{"rule_id":"r1","file_severity":"high"},{"rule_id":"r2","file_severity":"low"}]`, nil)
+	assert.NilError(t, err)
+
+	lines := <-received
+	assert.Equal(t, len(lines), 2)
+	assert.Assert(t, strings.HasPrefix(lines[0], "<11>1 "))
+	assert.Assert(t, strings.Contains(lines[1], "CEF:0|Deepfence|ThreatMapper|2.0|Malware:r2|"))
+}
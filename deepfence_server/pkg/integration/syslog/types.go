@@ -0,0 +1,32 @@
+package syslog
+
+import (
+	"github.com/deepfence/ThreatMapper/deepfence_server/reporters"
+	"github.com/go-playground/validator/v10"
+)
+
+type Syslog struct {
+	Config           Config                  `json:"config"`
+	IntegrationType  string                  `json:"integration_type"`
+	NotificationType string                  `json:"notification_type"`
+	Filters          reporters.FieldsFilters `json:"filters"`
+	Message          string                  `json:"message"`
+	Resource         string                  `json:"resource"`
+}
+
+type Config struct {
+	Address  string `json:"address" validate:"required,hostname_port" required:"true"`
+	Protocol string `json:"protocol" validate:"required,oneof=udp tcp tls" required:"true" enum:"udp,tcp,tls"`
+	// Format of the syslog message body, defaults to json
+	Format string `json:"format" validate:"omitempty,oneof=json cef leef" enum:"json,cef,leef"`
+	// Facility defaults to user
+	Facility string `json:"facility" validate:"omitempty,oneof=user local0 local1 local2 local3 local4 local5 local6 local7"`
+	AppName  string `json:"app_name" validate:"omitempty,max=48,printascii,excludes= "`
+	// CACert is a PEM encoded certificate used to verify the server in tls mode
+	CACert             string `json:"ca_cert"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+func (s Syslog) ValidateConfig(validate *validator.Validate) error {
+	return validate.Struct(s.Config)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/msgtemplate"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	"net/http"
 	"strings"
@@ -21,10 +22,10 @@ func New(ctx context.Context, b []byte) (*Teams, error) {
 	return &t, nil
 }
 
-func (t Teams) FormatMessage(message map[string]interface{}, position int) string {
+func (t Teams) FormatMessage(message map[string]interface{}, position int, header string) string {
 	entiremsg := ""
 	if position == 1 {
-		entiremsg = "**" + t.Resource + "**<br><br>"
+		entiremsg = header
 	}
 	entiremsg = entiremsg + fmt.Sprintf("**#%d**<br>", position)
 	for key, val := range message {
@@ -34,6 +35,14 @@ func (t Teams) FormatMessage(message map[string]interface{}, position int) strin
 	return entiremsg
 }
 
+// MessageCount returns the number of messages the findings are split into
+func (t Teams) MessageCount(findings int) int {
+	if findings <= BatchSize {
+		return 1
+	}
+	return (findings + BatchSize - 1) / BatchSize
+}
+
 func (t Teams) SendNotification(ctx context.Context, message string, extras map[string]interface{}) error {
 	var msg []map[string]interface{}
 	d := json.NewDecoder(strings.NewReader(message))
@@ -47,7 +56,11 @@ func (t Teams) SendNotification(ctx context.Context, message string, extras map[
 	if endIndex > len(msg) {
 		endIndex = len(msg)
 	}
-	if err := t.sendNotification(msg[startIndex:endIndex]); err != nil {
+	header := "**" + msgtemplate.Title(extras, t.Resource) + "**<br><br>"
+	if summary := msgtemplate.Summary(extras); summary != "" {
+		header += summary + "<br><br>"
+	}
+	if err := t.sendNotification(msg[startIndex:endIndex], header); err != nil {
 		return err
 	}
 	for endIndex < len(msg) {
@@ -56,17 +69,17 @@ func (t Teams) SendNotification(ctx context.Context, message string, extras map[
 		if endIndex > len(msg) {
 			endIndex = len(msg)
 		}
-		if err := t.sendNotification(msg[startIndex:endIndex]); err != nil {
+		if err := t.sendNotification(msg[startIndex:endIndex], header); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (t Teams) sendNotification(payloads []map[string]interface{}) error {
+func (t Teams) sendNotification(payloads []map[string]interface{}, header string) error {
 	message := ""
 	for index, msgMap := range payloads {
-		message += t.FormatMessage(msgMap, index+1)
+		message += t.FormatMessage(msgMap, index+1, header)
 	}
 	payload := Payload{
 		Text:       message,
@@ -0,0 +1,28 @@
+package webex
+
+import (
+	"github.com/deepfence/ThreatMapper/deepfence_server/reporters"
+	"github.com/go-playground/validator/v10"
+)
+
+type Webex struct {
+	Config           Config                  `json:"config"`
+	IntegrationType  string                  `json:"integration_type"`
+	NotificationType string                  `json:"notification_type"`
+	Filters          reporters.FieldsFilters `json:"filters"`
+	Message          string                  `json:"message"`
+	Resource         string                  `json:"resource"`
+}
+
+// Config webhook url is the url of a webex incoming webhook
+type Config struct {
+	WebhookURL string `json:"webhook_url" validate:"required,url" required:"true"`
+}
+
+type Payload struct {
+	Markdown string `json:"markdown"`
+}
+
+func (w Webex) ValidateConfig(validate *validator.Validate) error {
+	return validate.Struct(w.Config)
+}
@@ -0,0 +1,115 @@
+package webex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/msgtemplate"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+)
+
+const (
+	BatchSize = 5
+
+	// webex rejects messages larger than 7439 bytes
+	maxMessageLength = 7000
+)
+
+func New(ctx context.Context, b []byte) (*Webex, error) {
+	w := Webex{}
+	err := json.Unmarshal(b, &w)
+	if err != nil {
+		return &w, err
+	}
+	return &w, nil
+}
+
+func (w Webex) FormatMessage(message []map[string]interface{}, index int, title, summary string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**%s**\n\n", title))
+	if summary != "" {
+		sb.WriteString(summary + "\n\n")
+	}
+
+	for _, m := range message {
+		sb.WriteString(fmt.Sprintf("**#%d %s**\n", index, finding.Title(w.Resource, m)))
+
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("- **%s**: %v\n", k, m[k]))
+		}
+		sb.WriteString("\n")
+		index++
+	}
+
+	text := sb.String()
+	if len(text) > maxMessageLength {
+		text = text[:maxMessageLength] + "\n..."
+	}
+	return text
+}
+
+// MessageCount returns the number of messages the findings are split into
+func (w Webex) MessageCount(findings int) int {
+	return (findings + BatchSize - 1) / BatchSize
+}
+
+func (w Webex) SendNotification(ctx context.Context, message string, extras map[string]interface{}) error {
+	var msg []map[string]interface{}
+	d := json.NewDecoder(strings.NewReader(message))
+	d.UseNumber()
+	if err := d.Decode(&msg); err != nil {
+		return err
+	}
+
+	for startIdx := 0; startIdx < len(msg); startIdx += BatchSize {
+		endIdx := startIdx + BatchSize
+		if endIdx > len(msg) {
+			endIdx = len(msg)
+		}
+
+		payload := Payload{Markdown: w.FormatMessage(msg[startIdx:endIdx], startIdx+1,
+			msgtemplate.Title(extras, w.Resource), msgtemplate.Summary(extras))}
+		if err := w.send(ctx, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w Webex) send(ctx context.Context, payload Payload) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.Config.WebhookURL, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := utils.GetHttpClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webex: unexpected response status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package webex
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSendNotificationBatches(t *testing.T) {
+	var payloads []Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p Payload
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&p))
+		payloads = append(payloads, p)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	wx := Webex{Config: Config{WebhookURL: server.URL}, Resource: "Malware"}
+	findings := make([]string, 7)
+	for i := range findings {
+		findings[i] = `{"node_id":"host-1","rule_id":"r","file_severity":"high"}`
+	}
+	message := "[" + strings.Join(findings, ",") + "]"
+	assert.NilError(t, wx.SendNotification(context.Background(), message, nil))
+
+	assert.Equal(t, len(payloads), 2)
+	assert.Equal(t, wx.MessageCount(len(findings)), len(payloads), "rate limit counts every message sent")
+	assert.Assert(t, strings.Contains(payloads[1].Markdown, "#6 Malware r on host-1"))
+}
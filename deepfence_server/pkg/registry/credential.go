@@ -0,0 +1,49 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+)
+
+// CredentialExpiry is implemented by registries whose credentials advertise
+// an expiry, the zero time if they don't expire. Renewable credentials are
+// issued again on every sync.
+type CredentialExpiry interface {
+	CredentialExpiry() (expiresAt time.Time, renewable bool, err error)
+}
+
+// CheckCredential validates the decrypted credentials of the registry and
+// looks up their expiry
+func CheckCredential(r Registry) model.RegistryCredentialStatus {
+	now := time.Now()
+	status := model.RegistryCredentialStatus{Valid: true, CheckedAt: now.UnixMilli()}
+
+	if !r.IsValidCredential() {
+		status.Valid = false
+		status.LastError = "registry rejected the credentials"
+		return status
+	}
+
+	e, ok := r.(CredentialExpiry)
+	if !ok {
+		return status
+	}
+	expiresAt, renewable, err := e.CredentialExpiry()
+	if err != nil {
+		status.Valid = false
+		status.LastError = err.Error()
+		return status
+	}
+	if expiresAt.IsZero() {
+		return status
+	}
+	status.ExpiresAt = expiresAt.UnixMilli()
+	status.Renewable = renewable
+	if !renewable && expiresAt.Before(now) {
+		status.Valid = false
+		status.LastError = fmt.Sprintf("credentials expired at %s", expiresAt.UTC().Format(time.RFC3339))
+	}
+	return status
+}
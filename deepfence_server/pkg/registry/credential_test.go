@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+// fakeRegistry implements the credential checks only
+type fakeRegistry struct {
+	Registry
+	valid bool
+}
+
+func (f fakeRegistry) IsValidCredential() bool {
+	return f.valid
+}
+
+type expiringRegistry struct {
+	fakeRegistry
+	expiresAt time.Time
+	renewable bool
+	err       error
+}
+
+func (e expiringRegistry) CredentialExpiry() (time.Time, bool, error) {
+	return e.expiresAt, e.renewable, e.err
+}
+
+func TestCheckCredential(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		registry  Registry
+		valid     bool
+		lastError string
+		expiresAt time.Time
+	}{
+		{"rejected", fakeRegistry{valid: false}, false, "registry rejected the credentials", time.Time{}},
+		{"no expiry", fakeRegistry{valid: true}, true, "", time.Time{}},
+		{"never expires", expiringRegistry{fakeRegistry: fakeRegistry{valid: true}}, true, "", time.Time{}},
+		{"expiry lookup failed", expiringRegistry{fakeRegistry: fakeRegistry{valid: true}, err: errors.New("token revoked")},
+			false, "token revoked", time.Time{}},
+		{"expires later", expiringRegistry{fakeRegistry: fakeRegistry{valid: true}, expiresAt: now.Add(time.Hour)},
+			true, "", now.Add(time.Hour)},
+		{"expired", expiringRegistry{fakeRegistry: fakeRegistry{valid: true}, expiresAt: now.Add(-time.Hour)},
+			false, "credentials expired at", now.Add(-time.Hour)},
+		{"expired renewable", expiringRegistry{fakeRegistry: fakeRegistry{valid: true}, expiresAt: now.Add(-time.Hour), renewable: true},
+			true, "", now.Add(-time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := CheckCredential(tt.registry)
+			assert.Equal(t, status.Valid, tt.valid)
+			if tt.lastError == "" {
+				assert.Equal(t, status.LastError, "")
+			} else {
+				assert.Assert(t, strings.HasPrefix(status.LastError, tt.lastError), status.LastError)
+			}
+			if tt.expiresAt.IsZero() {
+				assert.Equal(t, status.ExpiresAt, int64(0))
+			} else {
+				assert.Equal(t, status.ExpiresAt, tt.expiresAt.UnixMilli())
+			}
+			assert.Assert(t, status.CheckedAt >= now.UnixMilli())
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package dockerprivate
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
@@ -44,6 +47,102 @@ func listImagesRegistryV2(url, userName, password string) ([]model.IngestedConta
 	return images, nil
 }
 
+// listImagesRegistryV2Incremental fetches the manifests of the repositories
+// whose tags changed since the previous sync
+func listImagesRegistryV2Incremental(url, userName, password string, filter *model.RegistrySyncFilter,
+	state *model.RegistrySyncState) ([]model.IngestedContainerImage, error) {
+
+	var (
+		images []model.IngestedContainerImage
+	)
+
+	repos, err := listCatalogRegistryV2(url, userName, password)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		return nil, err
+	}
+
+	selected := []string{}
+	for _, repo := range repos {
+		if filter.MatchRepository(repo) {
+			selected = append(selected, repo)
+		}
+	}
+
+	for i, repo := range selected {
+		state.Progress(i, len(selected))
+
+		repoTags, err := listRepoTagsV2(url, userName, password, repo)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		tags := filter.Tags(repoTags.Tags)
+
+		version, err := tagsVersionV2(url, userName, password, repo, tags)
+		if err != nil {
+			log.Warn().Msgf("unable to get digests of %s, fetching all manifests: %v", repo, err)
+		}
+		if !state.Changed(repo, version) {
+			continue
+		}
+
+		repoTags.Tags = tags
+		repoImages := getImageWithTags(url, userName, password, repo, repoTags)
+		if len(repoImages) < len(tags) {
+			state.Forget(repo)
+		}
+		images = append(images, repoImages...)
+	}
+	state.Progress(len(selected), len(selected))
+
+	return images, nil
+}
+
+// tagsVersionV2 returns a digest of the tags of the repository and of their
+// manifest digests, HEAD requests are much cheaper than fetching manifests
+func tagsVersionV2(url, userName, password, repoName string, tags []string) (string, error) {
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, tag := range sorted {
+		digest, err := headManifestV2(url, userName, password, repoName, tag)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s@%s\n", tag, digest)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func headManifestV2(url, userName, password, repoName, tag string) (string, error) {
+	getManifestsURL := "%s/v2/%s/manifests/%s"
+	queryURL := fmt.Sprintf(getManifestsURL, url, repoName, tag)
+	req, err := http.NewRequest(http.MethodHead, queryURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.list.v2+json")
+	req.SetBasicAuth(userName, password)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error bad status code %d", resp.StatusCode)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("no digest for %s:%s", repoName, tag)
+	}
+	return digest, nil
+}
+
 func listCatalogRegistryV2(url, userName, password string) ([]string, error) {
 	var (
 		repositories []string
@@ -204,9 +303,29 @@ func getImageWithTags(url, userName, password, repoName string, repoTags RepoTag
 				"digest":       digest,
 				"last_updated": comp.Created.Unix(),
 			},
+			Platform:  model.FormatImagePlatform(comp.Os, comp.Architecture, ""),
+			Platforms: manifestListPlatforms(manifest),
 		}
 		imageAndTag = append(imageAndTag, tt)
 	}
 
 	return imageAndTag
 }
+
+// manifestListPlatforms returns the platform images of a manifest list,
+// attestations pushed along the images have an unknown platform
+func manifestListPlatforms(manifest Manifest) []model.ImagePlatform {
+	var platforms []model.ImagePlatform
+	for _, m := range manifest.Manifests {
+		if m.Platform.OS == "" || m.Platform.OS == "unknown" {
+			continue
+		}
+		platforms = append(platforms, model.ImagePlatform{
+			Digest:       m.Digest,
+			OS:           m.Platform.OS,
+			Architecture: m.Platform.Architecture,
+			Variant:      m.Platform.Variant,
+		})
+	}
+	return platforms
+}
@@ -78,6 +78,21 @@ func (d *RegistryDockerPrivate) FetchImagesFromRegistry() ([]model.IngestedConta
 	return listImagesRegistryV2(d.NonSecret.DockerRegistryURL, d.NonSecret.DockerUsername, d.Secret.DockerPassword)
 }
 
+func (d *RegistryDockerPrivate) FetchImagesIncremental(filter *model.RegistrySyncFilter,
+	state *model.RegistrySyncState) ([]model.IngestedContainerImage, error) {
+	return listImagesRegistryV2Incremental(d.NonSecret.DockerRegistryURL, d.NonSecret.DockerUsername,
+		d.Secret.DockerPassword, filter, state)
+}
+
+func (d *RegistryDockerPrivate) FetchImage(repository, tag string) ([]model.IngestedContainerImage, error) {
+	images := getImageWithTags(d.NonSecret.DockerRegistryURL, d.NonSecret.DockerUsername,
+		d.Secret.DockerPassword, repository, RepoTagsResp{Tags: []string{tag}})
+	if len(images) == 0 {
+		return nil, fmt.Errorf("image %s:%s not found", repository, tag)
+	}
+	return images, nil
+}
+
 // getters
 func (d *RegistryDockerPrivate) GetSecret() map[string]interface{} {
 	var secret map[string]interface{}
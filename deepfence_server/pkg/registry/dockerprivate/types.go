@@ -30,14 +30,28 @@ type RepoTagsResp struct {
 }
 
 type Manifest struct {
-	SchemaVersion int          `json:"schemaVersion"`
-	Name          string       `json:"name"`
-	Tag           string       `json:"tag"`
-	Architecture  string       `json:"architecture"`
-	FsLayers      []FsLayers   `json:"fsLayers"`
-	History       []History    `json:"history"`
-	Signatures    []Signatures `json:"signatures"`
+	SchemaVersion int           `json:"schemaVersion"`
+	MediaType     string        `json:"mediaType"`
+	Manifests     []ManifestRef `json:"manifests"`
+	Name          string        `json:"name"`
+	Tag           string        `json:"tag"`
+	Architecture  string        `json:"architecture"`
+	FsLayers      []FsLayers    `json:"fsLayers"`
+	History       []History     `json:"history"`
+	Signatures    []Signatures  `json:"signatures"`
 }
+
+// ManifestRef is a platform image of a manifest list
+type ManifestRef struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Platform  struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+		Variant      string `json:"variant"`
+	} `json:"platform"`
+}
+
 type FsLayers struct {
 	BlobSum string `json:"blobSum"`
 }
@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecrpublic"
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
 )
 
@@ -87,3 +90,41 @@ func getAWSAccountID() (string, error) {
 
 	return awsSelfQuery.AccountID, nil
 }
+
+// authorizationTokenExpiry requests an authorization token like docker login
+// does, the token is valid for 12 hours and requested again on every sync
+func authorizationTokenExpiry(e *RegistryECR) (time.Time, error) {
+	awsConfig := aws.Config{
+		Region: aws.String(e.NonSecret.AWSRegionName),
+	}
+	if e.NonSecret.UseIAMRole != "true" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(e.NonSecret.AWSAccessKeyID, e.Secret.AWSSecretAccessKey, "")
+	}
+	sess, err := session.NewSession(&awsConfig)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error creating session: %v", err)
+	}
+	if e.NonSecret.UseIAMRole == "true" && e.NonSecret.TargetAccountRoleARN != "" {
+		awsConfig.Credentials = stscreds.NewCredentials(sess, e.NonSecret.TargetAccountRoleARN)
+	}
+
+	if e.NonSecret.IsPublic == "true" {
+		out, err := ecrpublic.New(sess, &awsConfig).GetAuthorizationToken(&ecrpublic.GetAuthorizationTokenInput{})
+		if err != nil {
+			return time.Time{}, fmt.Errorf("error getting authorization token: %v", err)
+		}
+		if out.AuthorizationData == nil || out.AuthorizationData.ExpiresAt == nil {
+			return time.Time{}, nil
+		}
+		return *out.AuthorizationData.ExpiresAt, nil
+	}
+
+	out, err := ecr.New(sess, &awsConfig).GetAuthorizationToken(&ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error getting authorization token: %v", err)
+	}
+	if len(out.AuthorizationData) == 0 || out.AuthorizationData[0].ExpiresAt == nil {
+		return time.Time{}, nil
+	}
+	return *out.AuthorizationData[0].ExpiresAt, nil
+}
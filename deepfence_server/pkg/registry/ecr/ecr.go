@@ -2,6 +2,7 @@ package ecr
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/encryption"
@@ -25,6 +26,13 @@ func (e *RegistryECR) IsValidCredential() bool {
 	return true
 }
 
+// CredentialExpiry returns the expiry of a new authorization token, failing
+// when the access key or the role can't get one
+func (e *RegistryECR) CredentialExpiry() (time.Time, bool, error) {
+	expiresAt, err := authorizationTokenExpiry(e)
+	return expiresAt, true, err
+}
+
 func (e *RegistryECR) EncryptSecret(aes encryption.AES) error {
 	var err error
 	e.Secret.AWSSecretAccessKey, err = aes.Encrypt(e.Secret.AWSSecretAccessKey)
@@ -0,0 +1,70 @@
+package gcr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	iamKeyURL  = "https://iam.googleapis.com/v1/projects/-/serviceAccounts/%s/keys/%s"
+	cloudScope = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+type serviceAccountKey struct {
+	Name            string `json:"name"`
+	ValidAfterTime  string `json:"validAfterTime"`
+	ValidBeforeTime string `json:"validBeforeTime"`
+	Disabled        bool   `json:"disabled"`
+}
+
+// getKeyExpiry returns the expiry of the service account key, keys created
+// without an expiry are valid until the end of time and reported as zero.
+// The expiry is left unknown when the account can't read its own keys.
+func getKeyExpiry(serviceAccountJson string) (time.Time, error) {
+	var sa ServiceAccountJson
+	if err := json.Unmarshal([]byte(serviceAccountJson), &sa); err != nil {
+		return time.Time{}, fmt.Errorf("invalid service account json: %v", err)
+	}
+	jwtConfig, err := google.JWTConfigFromJSON([]byte(serviceAccountJson), cloudScope)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid service account json: %v", err)
+	}
+
+	// the access token of the service account is fetched on the first
+	// request, deleted and disabled keys are rejected with invalid_grant
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	resp, err := jwtConfig.Client(ctx).Get(
+		fmt.Sprintf(iamKeyURL, url.PathEscape(sa.ClientEmail), url.PathEscape(sa.PrivateKeyID)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("service account key rejected: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return time.Time{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("error bad status code %d", resp.StatusCode)
+	}
+
+	var key serviceAccountKey
+	if err := json.NewDecoder(resp.Body).Decode(&key); err != nil {
+		return time.Time{}, err
+	}
+	if key.Disabled {
+		return time.Time{}, fmt.Errorf("service account key %s is disabled", sa.PrivateKeyID)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, key.ValidBeforeTime)
+	if err != nil || strings.HasPrefix(key.ValidBeforeTime, "9999") {
+		return time.Time{}, nil
+	}
+	return expiresAt, nil
+}
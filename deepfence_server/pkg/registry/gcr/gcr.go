@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/constants"
@@ -55,6 +56,11 @@ func (d *RegistryGCR) IsValidCredential() bool {
 	return resp.StatusCode == http.StatusOK
 }
 
+func (d *RegistryGCR) CredentialExpiry() (time.Time, bool, error) {
+	expiresAt, err := getKeyExpiry(d.Extras.ServiceAccountJson)
+	return expiresAt, false, err
+}
+
 func (d *RegistryGCR) EncryptSecret(aes encryption.AES) error {
 	var err error
 	d.Secret.PrivateKeyId, err = aes.Encrypt(d.Secret.PrivateKeyId)
@@ -156,3 +156,46 @@ func getTagDetail(gitlabServerURL, tagName, accessToken string, projectID, repoI
 
 	return tagDetail, nil
 }
+
+// getTokenExpiry returns the expiry of the access token, the zero time if it
+// doesn't expire or if the server is too old to report it
+func getTokenExpiry(gitlabServerURL, accessToken string) (time.Time, error) {
+	url := fmt.Sprintf("%s/api/v4/personal_access_tokens/self", gitlabServerURL)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return time.Time{}, nil
+	case http.StatusUnauthorized:
+		return time.Time{}, fmt.Errorf("access token is invalid, revoked or expired")
+	default:
+		return time.Time{}, fmt.Errorf("error bad status code %d", resp.StatusCode)
+	}
+
+	var token AccessToken
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if token.Revoked || !token.Active {
+		return time.Time{}, fmt.Errorf("access token %s is not active", token.Name)
+	}
+	if token.ExpiresAt == "" {
+		return time.Time{}, nil
+	}
+	// tokens expire at the start of the expiry date
+	return time.Parse(time.DateOnly, token.ExpiresAt)
+}
@@ -0,0 +1,49 @@
+package gitlab
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestGetTokenExpiry(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		expiresAt time.Time
+		err       string
+	}{
+		{"expires", http.StatusOK, `{"name":"ci","active":true,"expires_at":"2026-03-01"}`,
+			time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), ""},
+		{"never expires", http.StatusOK, `{"name":"ci","active":true,"expires_at":null}`, time.Time{}, ""},
+		{"revoked", http.StatusOK, `{"name":"ci","active":false,"revoked":true,"expires_at":"2026-03-01"}`,
+			time.Time{}, "access token ci is not active"},
+		{"old server", http.StatusNotFound, ``, time.Time{}, ""},
+		{"unauthorized", http.StatusUnauthorized, ``, time.Time{}, "access token is invalid, revoked or expired"},
+		{"invalid date", http.StatusOK, `{"name":"ci","active":true,"expires_at":"March 1st"}`, time.Time{}, "cannot parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.URL.Path, "/api/v4/personal_access_tokens/self")
+				assert.Equal(t, r.Header.Get("Authorization"), "Bearer token")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			expiresAt, err := getTokenExpiry(srv.URL, "token")
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Assert(t, expiresAt.Equal(tt.expiresAt), expiresAt)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package gitlab
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/encryption"
@@ -25,6 +26,11 @@ func (e *RegistryGitlab) IsValidCredential() bool {
 	return true
 }
 
+func (e *RegistryGitlab) CredentialExpiry() (time.Time, bool, error) {
+	expiresAt, err := getTokenExpiry(e.NonSecret.GitlabServerURL, e.Secret.GitlabToken)
+	return expiresAt, false, err
+}
+
 func (e *RegistryGitlab) EncryptSecret(aes encryption.AES) error {
 	var err error
 	e.Secret.GitlabToken, err = aes.Encrypt(e.Secret.GitlabToken)
@@ -15,3 +15,11 @@ type NonSecret struct {
 type Secret struct {
 	GitlabToken string `json:"gitlab_access_token" validate:"required,min=2"`
 }
+
+type AccessToken struct {
+	Name      string   `json:"name"`
+	Revoked   bool     `json:"revoked"`
+	Active    bool     `json:"active"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expires_at"`
+}
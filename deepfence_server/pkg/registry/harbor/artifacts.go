@@ -0,0 +1,60 @@
+package harbor
+
+import (
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+)
+
+// getArtifactsWithTags returns the tagged artifacts which are not images,
+// harbor tells them apart with the artifact type
+func getArtifactsWithTags(repo Repository, artifacts []Artifact, filter *model.RegistrySyncFilter) []model.IngestedRegistryArtifact {
+	res := []model.IngestedRegistryArtifact{}
+	for _, artifact := range artifacts {
+		if artifact.Type == "IMAGE" {
+			continue
+		}
+		artifactType := model.ArtifactTypeFromMediaType(artifact.MediaType)
+		if artifact.Type == "CHART" {
+			artifactType = model.ArtifactTypeHelmChart
+		}
+		for _, tag := range artifact.Tags {
+			if !filter.MatchTag(tag.Name) {
+				continue
+			}
+			res = append(res, model.IngestedRegistryArtifact{
+				Name:         repo.Name,
+				Tag:          tag.Name,
+				Digest:       artifact.Digest,
+				ArtifactType: artifactType,
+				MediaType:    artifact.MediaType,
+				Size:         int64(artifact.Size),
+				PushedAt:     tag.PushTime.Unix(),
+			})
+		}
+	}
+	return res
+}
+
+func (d *RegistryHarbor) FetchArtifactsFromRegistry(filter *model.RegistrySyncFilter) ([]model.IngestedRegistryArtifact, error) {
+	url, project := d.NonSecret.HarborRegistryURL, d.NonSecret.HarborProjectName
+	username, password := d.NonSecret.HarborUsername, d.Secret.HarborPassword
+
+	repos, err := listRepos(url, project, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []model.IngestedRegistryArtifact{}
+	for _, repo := range repos {
+		if !filter.MatchRepository(repo.Name) {
+			continue
+		}
+		artifacts, err := listArtifacts(url, username, password, project, repo.Name)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		res = append(res, getArtifactsWithTags(repo, artifacts, filter)...)
+	}
+	return res, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -13,6 +14,9 @@ import (
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
 )
 
+// max page size of the harbor api
+const pageSize = 100
+
 var client = &http.Client{
 	Timeout: 10 * time.Second,
 	Transport: &http.Transport{
@@ -45,44 +49,83 @@ func listImages(url, project, username, password string) ([]model.IngestedContai
 	return images, nil
 }
 
-func listRepos(url, project, username, password string) ([]Repository, error) {
+// listImagesIncremental lists the artifacts of the repositories updated
+// since the previous sync
+func listImagesIncremental(url, project, username, password string, filter *model.RegistrySyncFilter,
+	state *model.RegistrySyncState) ([]model.IngestedContainerImage, error) {
+
 	var (
-		repositories []Repository
-		err          error
+		images []model.IngestedContainerImage
 	)
 
-	listReposUrl := "%s/api/v2.0/projects/%s/repositories"
-	queryURl := fmt.Sprintf(listReposUrl, url, project)
-	req, err := http.NewRequest(http.MethodGet, queryURl, nil)
+	repos, err := listRepos(url, project, username, password)
 	if err != nil {
 		log.Error().Msg(err.Error())
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(username, password)
 
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Error().Msg(err.Error())
-		return repositories, err
+	selected := []Repository{}
+	for _, repo := range repos {
+		if filter.MatchRepository(repo.Name) {
+			selected = append(selected, repo)
+		}
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Error().Msg(err.Error())
-		return repositories, err
+	for i, repo := range selected {
+		state.Progress(i, len(selected))
+
+		version := fmt.Sprintf("%d/%d", repo.UpdateTime.UnixNano(), repo.ArtifactCount)
+		if !state.Changed(repo.Name, version) {
+			continue
+		}
+		artifacts, err := listArtifacts(url, username, password, project, repo.Name)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			state.Forget(repo.Name)
+			continue
+		}
+		images = append(images, getImageWithTags(repo, artifacts)...)
 	}
+	state.Progress(len(selected), len(selected))
 
-	if resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("error bad status code %d", resp.StatusCode)
-		log.Error().Msg(err.Error())
-		return repositories, err
+	return images, nil
+}
+
+// getImage fetches the repository and the artifact of a single tag
+func getImage(registryURL, project, username, password, repo, tag string) ([]model.IngestedContainerImage, error) {
+	var (
+		repository Repository
+		artifact   Artifact
+	)
+	repoURL := fmt.Sprintf("%s/api/v2.0/projects/%s/repositories/%s",
+		registryURL, project, artifactRepository(project, repo))
+	if err := getJSON(repoURL, username, password, &repository); err != nil {
+		return nil, err
+	}
+	if err := getJSON(repoURL+"/artifacts/"+url.PathEscape(tag), username, password, &artifact); err != nil {
+		return nil, err
 	}
+	return getImageWithTags(repository, []Artifact{artifact}), nil
+}
 
-	if err := json.Unmarshal(body, &repositories); err != nil {
-		log.Error().Msg(err.Error())
-		return repositories, err
+func listRepos(url, project, username, password string) ([]Repository, error) {
+	var (
+		repositories []Repository
+	)
+
+	for page := 1; ; page++ {
+		var repos []Repository
+		listReposUrl := "%s/api/v2.0/projects/%s/repositories?page=%d&page_size=%d"
+		queryURl := fmt.Sprintf(listReposUrl, url, project, page, pageSize)
+		err := getJSON(queryURl, username, password, &repos)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			return repositories, err
+		}
+		repositories = append(repositories, repos...)
+		if len(repos) < pageSize {
+			break
+		}
 	}
 
 	return repositories, nil
@@ -90,45 +133,51 @@ func listRepos(url, project, username, password string) ([]Repository, error) {
 
 func listArtifacts(url, username, password, project, repo string) ([]Artifact, error) {
 	var (
-		err       error
 		artifacts []Artifact
 	)
 
-	listRepoTagsUrl := "%s/api/v2.0/projects/%s/repositories/%s/artifacts"
-	queryURl := fmt.Sprintf(listRepoTagsUrl, url, project, strings.TrimPrefix(repo, project))
+	for page := 1; ; page++ {
+		var pageArtifacts []Artifact
+		listRepoTagsUrl := "%s/api/v2.0/projects/%s/repositories/%s/artifacts?page=%d&page_size=%d"
+		queryURl := fmt.Sprintf(listRepoTagsUrl, url, project, strings.TrimPrefix(repo, project), page, pageSize)
+		err := getJSON(queryURl, username, password, &pageArtifacts)
+		if err != nil {
+			log.Error().Err(err).Msgf("list artifacts of %s", repo)
+			return artifacts, err
+		}
+		artifacts = append(artifacts, pageArtifacts...)
+		if len(pageArtifacts) < pageSize {
+			break
+		}
+	}
+
+	return artifacts, nil
+}
+
+func getJSON(queryURl, username, password string, v interface{}) error {
 	req, err := http.NewRequest(http.MethodGet, queryURl, nil)
 	if err != nil {
-		log.Error().Msg(err.Error())
-		return artifacts, err
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.SetBasicAuth(username, password)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Error().Err(err).Msgf("response: %+v", resp)
-		return artifacts, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Error().Err(err).Msgf("response: %+v", resp)
-		return artifacts, err
+		return err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("error bad status code %d", resp.StatusCode)
-		log.Error().Err(err).Msgf("response: %+v", resp)
-		return artifacts, err
+		return fmt.Errorf("error bad status code %d", resp.StatusCode)
 	}
 
-	if err := json.Unmarshal(body, &artifacts); err != nil {
-		log.Error().Err(err).Msgf("response: %+v", resp)
-		return artifacts, err
-	}
-
-	return artifacts, err
+	return json.Unmarshal(body, v)
 }
 
 func getImageWithTags(repo Repository, artifacts []Artifact) []model.IngestedContainerImage {
@@ -153,6 +202,7 @@ func getImageWithTags(repo Repository, artifacts []Artifact) []model.IngestedCon
 					"creation_time": repo.CreationTime,
 					"last_updated":  tag.PushTime,
 				},
+				Platforms: artifactPlatforms(artifact),
 			}
 			imageAndTag = append(imageAndTag, tt)
 		}
@@ -160,3 +210,20 @@ func getImageWithTags(repo Repository, artifacts []Artifact) []model.IngestedCon
 
 	return imageAndTag
 }
+
+// artifactPlatforms returns the platform images of an image index artifact
+func artifactPlatforms(artifact Artifact) []model.ImagePlatform {
+	var platforms []model.ImagePlatform
+	for _, ref := range artifact.References {
+		if ref.Platform == nil || ref.Platform.OS == "" || ref.Platform.OS == "unknown" {
+			continue
+		}
+		platforms = append(platforms, model.ImagePlatform{
+			Digest:       ref.ChildDigest,
+			OS:           ref.Platform.OS,
+			Architecture: ref.Platform.Architecture,
+			Variant:      ref.Platform.Variant,
+		})
+	}
+	return platforms
+}
@@ -79,6 +79,17 @@ func (d *RegistryHarbor) FetchImagesFromRegistry() ([]model.IngestedContainerIma
 		d.NonSecret.HarborUsername, d.Secret.HarborPassword)
 }
 
+func (d *RegistryHarbor) FetchImagesIncremental(filter *model.RegistrySyncFilter,
+	state *model.RegistrySyncState) ([]model.IngestedContainerImage, error) {
+	return listImagesIncremental(d.NonSecret.HarborRegistryURL, d.NonSecret.HarborProjectName,
+		d.NonSecret.HarborUsername, d.Secret.HarborPassword, filter, state)
+}
+
+func (d *RegistryHarbor) FetchImage(repository, tag string) ([]model.IngestedContainerImage, error) {
+	return getImage(d.NonSecret.HarborRegistryURL, d.NonSecret.HarborProjectName,
+		d.NonSecret.HarborUsername, d.Secret.HarborPassword, repository, tag)
+}
+
 // getters
 func (d *RegistryHarbor) GetSecret() map[string]interface{} {
 	var secret map[string]interface{}
@@ -43,6 +43,17 @@ type Artifact struct {
 	Size              int       `json:"size"`
 	Tags              []Tags    `json:"tags"`
 	Type              string    `json:"type"`
+	// platform images of image indexes
+	References []Reference `json:"references"`
+}
+
+type Reference struct {
+	ChildDigest string `json:"child_digest"`
+	Platform    *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+		Variant      string `json:"variant"`
+	} `json:"platform"`
 }
 
 type Tags struct {
@@ -55,3 +66,29 @@ type Tags struct {
 	RepositoryID int       `json:"repository_id"`
 	Signed       bool      `json:"signed"`
 }
+
+type VulnerabilityReport struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Scanner     struct {
+		Name    string `json:"name"`
+		Vendor  string `json:"vendor"`
+		Version string `json:"version"`
+	} `json:"scanner"`
+	Vulnerabilities []VulnerabilityItem `json:"vulnerabilities"`
+}
+
+type VulnerabilityItem struct {
+	ID            string   `json:"id"`
+	Package       string   `json:"package"`
+	Version       string   `json:"version"`
+	FixVersion    string   `json:"fix_version"`
+	Severity      string   `json:"severity"`
+	Description   string   `json:"description"`
+	Links         []string `json:"links"`
+	PreferredCVSS *struct {
+		ScoreV3  float64 `json:"score_v3"`
+		ScoreV2  float64 `json:"score_v2"`
+		VectorV3 string  `json:"vector_v3"`
+		VectorV2 string  `json:"vector_v2"`
+	} `json:"preferred_cvss"`
+}
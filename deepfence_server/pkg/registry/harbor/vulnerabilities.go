@@ -0,0 +1,126 @@
+package harbor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+)
+
+// mime types of the vulnerability reports of harbor scanners, the first one
+// is the native report of the Trivy adapter
+const acceptVulnerabilities = "application/vnd.security.vulnerability.report; version=1.1, " +
+	"application/vnd.scanner.adapter.vuln.report.harbor+json; version=1.0"
+
+var errNotFound = errors.New("not found")
+
+// artifactRepository returns the repository name in the artifact api, names
+// with a slash are encoded twice
+func artifactRepository(project, repo string) string {
+	name := strings.TrimPrefix(repo, project+"/")
+	return url.PathEscape(url.PathEscape(name))
+}
+
+func getVulnerabilityReport(registryURL, username, password, project, repo, digest string) (*VulnerabilityReport, error) {
+	queryURL := fmt.Sprintf("%s/api/v2.0/projects/%s/repositories/%s/artifacts/%s/additions/vulnerabilities",
+		registryURL, project, artifactRepository(project, repo), digest)
+	req, err := http.NewRequest(http.MethodGet, queryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Accept-Vulnerabilities", acceptVulnerabilities)
+	req.SetBasicAuth(username, password)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error bad status code %d", resp.StatusCode)
+	}
+
+	// reports are keyed by their mime type, empty if the artifact is not
+	// scanned yet
+	var reports map[string]VulnerabilityReport
+	if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
+		return nil, err
+	}
+	for _, report := range reports {
+		return &report, nil
+	}
+	return nil, nil
+}
+
+func toVulnerabilities(source string, image model.IngestedContainerImage, report VulnerabilityReport) []model.Vulnerability {
+	vulnerabilities := make([]model.Vulnerability, 0, len(report.Vulnerabilities))
+	for _, v := range report.Vulnerabilities {
+		vulnerability := model.Vulnerability{
+			NodeId:                v.ID + "_" + v.Package + ":" + v.Version,
+			Cve_id:                v.ID,
+			Cve_severity:          model.NormalizeNativeSeverity(v.Severity),
+			Cve_caused_by_package: v.Package + ":" + v.Version,
+			Cve_fixed_in:          v.FixVersion,
+			Cve_description:       v.Description,
+			URLs:                  []interface{}{},
+			Resources:             []string{image.ID},
+			Source:                source,
+		}
+		for _, link := range v.Links {
+			vulnerability.URLs = append(vulnerability.URLs, link)
+		}
+		if len(v.Links) > 0 {
+			vulnerability.Cve_link = v.Links[0]
+		}
+		if cvss := v.PreferredCVSS; cvss != nil {
+			vulnerability.Cve_cvss_score = cvss.ScoreV3
+			vulnerability.Cve_attack_vector = cvss.VectorV3
+			if cvss.ScoreV3 == 0 {
+				vulnerability.Cve_cvss_score = cvss.ScoreV2
+				vulnerability.Cve_attack_vector = cvss.VectorV2
+			}
+			vulnerability.Cve_overall_score = vulnerability.Cve_cvss_score
+			vulnerability.ParsedAttackVector = model.ParseCVSSAttackVector(vulnerability.Cve_attack_vector)
+		}
+		vulnerabilities = append(vulnerabilities, vulnerability)
+	}
+	return vulnerabilities
+}
+
+func (d *RegistryHarbor) FetchNativeVulnerabilities(image model.IngestedContainerImage) (*model.NativeVulnerabilityReport, error) {
+	digest, _ := image.Metadata["digest"].(string)
+	if digest == "" {
+		return nil, nil
+	}
+	report, err := getVulnerabilityReport(d.NonSecret.HarborRegistryURL, d.NonSecret.HarborUsername,
+		d.Secret.HarborPassword, d.NonSecret.HarborProjectName, image.Name, digest)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, nil
+	}
+
+	scanner := strings.TrimSpace(report.Scanner.Name + " " + report.Scanner.Version)
+	res := &model.NativeVulnerabilityReport{
+		ImageName:       image.Name,
+		Digest:          digest,
+		Source:          d.GetRegistryType(),
+		Scanner:         scanner,
+		Vulnerabilities: toVulnerabilities(d.GetRegistryType(), image, *report),
+	}
+	if !report.GeneratedAt.IsZero() {
+		res.GeneratedAt = report.GeneratedAt.UnixMilli()
+	}
+	return res, nil
+}
@@ -0,0 +1,652 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+)
+
+const (
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeOCIImageConfig     = "application/vnd.oci.image.config.v1+json"
+	MediaTypeDockerImageConfig  = "application/vnd.docker.container.image.v1+json"
+
+	pageSize       = 100
+	catalogScope   = "registry:catalog:*"
+	maxManifestLen = 4 << 20
+)
+
+var acceptManifests = strings.Join([]string{
+	MediaTypeOCIIndex,
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeDockerManifest,
+}, ", ")
+
+var errUnauthorized = errors.New("unauthorized")
+
+// client talks to registries implementing the OCI distribution spec, the
+// bearer tokens issued after a WWW-Authenticate challenge are cached per scope
+type client struct {
+	url      string
+	username string
+	password string
+	http     *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func newHTTPClient(caCertificate, clientCertificate, clientKey string, insecure bool) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure}
+
+	if caCertificate != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(caCertificate)) {
+			return nil, errors.New("no certificates found in the ca bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if clientCertificate != "" || clientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(clientCertificate), []byte(clientKey))
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}, nil
+}
+
+func newClient(registryURL, username, password string, httpClient *http.Client) *client {
+	return &client{
+		url:      strings.TrimSuffix(registryURL, "/"),
+		username: username,
+		password: password,
+		http:     httpClient,
+		tokens:   map[string]string{},
+	}
+}
+
+func repositoryScope(repo string) string {
+	return "repository:" + repo + ":pull"
+}
+
+// parseChallenge parses a WWW-Authenticate header like
+// Bearer realm="https://auth.example.com/token",service="registry",scope="repository:app:pull"
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimLeft(value, " ")
+
+		if strings.HasPrefix(value, `"`) {
+			// quoted values may contain commas, like scopes with several actions
+			var b strings.Builder
+			i := 1
+			for ; i < len(value); i++ {
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+					b.WriteByte(value[i])
+					continue
+				}
+				if value[i] == '"' {
+					break
+				}
+				b.WriteByte(value[i])
+			}
+			params[key] = b.String()
+			if i < len(value) {
+				i++
+			}
+			rest = value[i:]
+		} else {
+			v, r, _ := strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(v)
+			rest = r
+		}
+	}
+	return strings.ToLower(scheme), params
+}
+
+// nextLink returns the url of the next page from a Link header like
+// </v2/_catalog?last=app&n=100>; rel="next", relative to the current url
+func nextLink(header string, current *url.URL) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, found := strings.Cut(link, ";")
+		if !found {
+			continue
+		}
+		target = strings.TrimSpace(target)
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		rel := strings.ReplaceAll(strings.ReplaceAll(params, " ", ""), `"`, "")
+		if !strings.Contains(rel, "rel=next") {
+			continue
+		}
+		next, err := current.Parse(strings.Trim(target, "<>"))
+		if err != nil {
+			return ""
+		}
+		return next.String()
+	}
+	return ""
+}
+
+func (c *client) cachedToken(scope string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens[scope]
+}
+
+// fetchToken requests a token for the scope from the realm of the challenge,
+// with the credentials of the registry if any
+func (c *client) fetchToken(params map[string]string, scope string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if s := params["scope"]; s != "" {
+		scope = s
+	}
+	if scope != "" {
+		query.Set("scope", scope)
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return "", errUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token server bad status code %d", resp.StatusCode)
+	}
+
+	var token TokenResp
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return "", errors.New("token server returned an empty token")
+	}
+	return token.Token, nil
+}
+
+func (c *client) newRequest(method, queryURL, accept, token string) (*http.Request, error) {
+	req, err := http.NewRequest(method, queryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return req, nil
+}
+
+// do sends the request with the cached token of the scope, on a bearer
+// challenge a new token is fetched and the request is sent again, expired
+// tokens are renewed the same way
+func (c *client) do(method, queryURL, scope, accept string) (*http.Response, error) {
+	req, err := c.newRequest(method, queryURL, accept, c.cachedToken(scope))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if scheme != "bearer" {
+		return nil, errUnauthorized
+	}
+
+	token, err := c.fetchToken(params, scope)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.tokens[scope] = token
+	c.mu.Unlock()
+
+	req, err = c.newRequest(method, queryURL, accept, token)
+	if err != nil {
+		return nil, err
+	}
+	resp, err = c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, errUnauthorized
+	}
+	return resp, nil
+}
+
+// getJSON decodes the response of the url and returns the url of the next page
+func (c *client) getJSON(queryURL, scope string, v interface{}) (string, error) {
+	resp, err := c.do(http.MethodGet, queryURL, scope, "application/json")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error bad status code %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", err
+	}
+	return nextLink(resp.Header.Get("Link"), resp.Request.URL), nil
+}
+
+// ping checks the registry implements the distribution api and accepts the
+// credentials
+func (c *client) ping() error {
+	resp, err := c.do(http.MethodGet, c.url+"/v2/", "", "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error bad status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *client) listCatalog() ([]string, error) {
+	repositories := []string{}
+	next := fmt.Sprintf("%s/v2/_catalog?n=%d", c.url, pageSize)
+	for next != "" {
+		var (
+			repos ReposResp
+			err   error
+		)
+		next, err = c.getJSON(next, catalogScope, &repos)
+		if err != nil {
+			return repositories, err
+		}
+		repositories = append(repositories, repos.Repositories...)
+	}
+	return repositories, nil
+}
+
+func (c *client) listTags(repo string) ([]string, error) {
+	tags := []string{}
+	next := fmt.Sprintf("%s/v2/%s/tags/list?n=%d", c.url, repo, pageSize)
+	for next != "" {
+		var (
+			repoTags RepoTagsResp
+			err      error
+		)
+		next, err = c.getJSON(next, repositoryScope(repo), &repoTags)
+		if err != nil {
+			return tags, err
+		}
+		tags = append(tags, repoTags.Tags...)
+	}
+	return tags, nil
+}
+
+// headManifest returns the digest of the manifest of the reference
+func (c *client) headManifest(repo, reference string) (string, error) {
+	queryURL := fmt.Sprintf("%s/v2/%s/manifests/%s", c.url, repo, reference)
+	resp, err := c.do(http.MethodHead, queryURL, repositoryScope(repo), acceptManifests)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error bad status code %d", resp.StatusCode)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("no digest for %s:%s", repo, reference)
+	}
+	return digest, nil
+}
+
+// getManifest returns the manifest of the reference and its digest, the
+// digest is computed from the content for registries not sending the header
+func (c *client) getManifest(repo, reference string) (string, Manifest, error) {
+	var manifest Manifest
+
+	queryURL := fmt.Sprintf("%s/v2/%s/manifests/%s", c.url, repo, reference)
+	resp, err := c.do(http.MethodGet, queryURL, repositoryScope(repo), acceptManifests)
+	if err != nil {
+		return "", manifest, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", manifest, fmt.Errorf("error bad status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestLen))
+	if err != nil {
+		return "", manifest, err
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return "", manifest, err
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType, _, _ = strings.Cut(resp.Header.Get("Content-Type"), ";")
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		sum := sha256.Sum256(body)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	return digest, manifest, nil
+}
+
+func (c *client) getConfig(repo string, config Descriptor) (ImageConfig, error) {
+	var imageConfig ImageConfig
+
+	queryURL := fmt.Sprintf("%s/v2/%s/blobs/%s", c.url, repo, config.Digest)
+	resp, err := c.do(http.MethodGet, queryURL, repositoryScope(repo), "")
+	if err != nil {
+		return imageConfig, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return imageConfig, fmt.Errorf("error bad status code %d", resp.StatusCode)
+	}
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxManifestLen)).Decode(&imageConfig)
+	return imageConfig, err
+}
+
+func isIndex(mediaType string) bool {
+	return mediaType == MediaTypeOCIIndex || mediaType == MediaTypeDockerManifestList
+}
+
+// indexPlatforms returns the platform images of an index, attestations and
+// other artifacts stored in indexes have an unknown platform
+func indexPlatforms(manifest Manifest) []model.ImagePlatform {
+	platforms := []model.ImagePlatform{}
+	for _, m := range manifest.Manifests {
+		if m.Platform == nil || m.Platform.OS == "unknown" || m.Platform.Architecture == "unknown" {
+			continue
+		}
+		if m.Annotations["vnd.docker.reference.type"] != "" {
+			continue
+		}
+		platforms = append(platforms, model.ImagePlatform{
+			Digest:       m.Digest,
+			OS:           m.Platform.OS,
+			Architecture: m.Platform.Architecture,
+			Variant:      m.Platform.Variant,
+		})
+	}
+	return platforms
+}
+
+// artifactMediaType returns the media type of manifests which are not
+// images, like helm charts, and an empty string for images
+func artifactMediaType(manifest Manifest) string {
+	if isIndex(manifest.MediaType) {
+		return manifest.ArtifactType
+	}
+	if manifest.ArtifactType != "" {
+		return manifest.ArtifactType
+	}
+	switch manifest.Config.MediaType {
+	case "", MediaTypeOCIImageConfig, MediaTypeDockerImageConfig:
+		return ""
+	}
+	return manifest.Config.MediaType
+}
+
+func (c *client) getImagesWithTags(repo string, tags []string) []model.IngestedContainerImage {
+	var images []model.IngestedContainerImage
+
+	for _, tag := range tags {
+		digest, manifest, err := c.getManifest(repo, tag)
+		if err != nil {
+			log.Error().Msgf("manifest of %s:%s: %v", repo, tag, err)
+			continue
+		}
+		if _, hash, _ := strings.Cut(digest, ":"); len(hash) < 12 {
+			log.Error().Msgf("invalid digest %q of %s:%s", digest, repo, tag)
+			continue
+		}
+		if artifactMediaType(manifest) != "" {
+			continue
+		}
+
+		var (
+			imageConfig ImageConfig
+			platforms   []model.ImagePlatform
+		)
+		if isIndex(manifest.MediaType) {
+			platforms = indexPlatforms(manifest)
+		} else if manifest.Config.Digest != "" {
+			imageConfig, err = c.getConfig(repo, manifest.Config)
+			if err != nil {
+				log.Warn().Msgf("config of %s:%s: %v", repo, tag, err)
+			}
+		}
+
+		var lastUpdated int64
+		if !imageConfig.Created.IsZero() {
+			lastUpdated = imageConfig.Created.Unix()
+		}
+
+		imageID, shortImageID := model.DigestToID(digest)
+		images = append(images, model.IngestedContainerImage{
+			ID:            imageID,
+			DockerImageID: imageID,
+			ShortImageID:  shortImageID,
+			Name:          repo,
+			Tag:           tag,
+			Size:          "",
+			Metadata: model.Metadata{
+				"created":      imageConfig.Created,
+				"digest":       digest,
+				"last_updated": lastUpdated,
+			},
+			Platform:  model.FormatImagePlatform(imageConfig.Os, imageConfig.Architecture, imageConfig.Variant),
+			Platforms: platforms,
+		})
+	}
+
+	return images
+}
+
+func (c *client) listImages() ([]model.IngestedContainerImage, error) {
+	var images []model.IngestedContainerImage
+
+	repos, err := c.listCatalog()
+	if err != nil {
+		log.Error().Msg(err.Error())
+		return nil, err
+	}
+	for _, repo := range repos {
+		tags, err := c.listTags(repo)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		images = append(images, c.getImagesWithTags(repo, tags)...)
+	}
+
+	return images, nil
+}
+
+// listArtifacts fetches the manifests of the tags matching the filter and
+// returns the ones which are not images
+func (c *client) listArtifacts(filter *model.RegistrySyncFilter) ([]model.IngestedRegistryArtifact, error) {
+	artifacts := []model.IngestedRegistryArtifact{}
+
+	repos, err := c.listCatalog()
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range repos {
+		if !filter.MatchRepository(repo) {
+			continue
+		}
+		tags, err := c.listTags(repo)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		for _, tag := range filter.Tags(tags) {
+			digest, manifest, err := c.getManifest(repo, tag)
+			if err != nil {
+				log.Error().Msgf("manifest of %s:%s: %v", repo, tag, err)
+				continue
+			}
+			mediaType := artifactMediaType(manifest)
+			if mediaType == "" {
+				continue
+			}
+			var size int64
+			for _, layer := range manifest.Layers {
+				size += layer.Size
+			}
+			artifacts = append(artifacts, model.IngestedRegistryArtifact{
+				Name:         repo,
+				Tag:          tag,
+				Digest:       digest,
+				ArtifactType: model.ArtifactTypeFromMediaType(mediaType),
+				MediaType:    mediaType,
+				Size:         size,
+			})
+		}
+	}
+	return artifacts, nil
+}
+
+// listImagesIncremental fetches the manifests of the repositories whose tags
+// changed since the previous sync
+func (c *client) listImagesIncremental(filter *model.RegistrySyncFilter,
+	state *model.RegistrySyncState) ([]model.IngestedContainerImage, error) {
+
+	var images []model.IngestedContainerImage
+
+	repos, err := c.listCatalog()
+	if err != nil {
+		log.Error().Msg(err.Error())
+		return nil, err
+	}
+
+	selected := []string{}
+	for _, repo := range repos {
+		if filter.MatchRepository(repo) {
+			selected = append(selected, repo)
+		}
+	}
+
+	for i, repo := range selected {
+		state.Progress(i, len(selected))
+
+		repoTags, err := c.listTags(repo)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		tags := filter.Tags(repoTags)
+
+		version, err := c.tagsVersion(repo, tags)
+		if err != nil {
+			log.Warn().Msgf("unable to get digests of %s, fetching all manifests: %v", repo, err)
+		}
+		if !state.Changed(repo, version) {
+			continue
+		}
+
+		repoImages := c.getImagesWithTags(repo, tags)
+		if len(repoImages) < len(tags) {
+			state.Forget(repo)
+		}
+		images = append(images, repoImages...)
+	}
+	state.Progress(len(selected), len(selected))
+
+	return images, nil
+}
+
+// tagsVersion returns a digest of the tags of the repository and of their
+// manifest digests
+func (c *client) tagsVersion(repo string, tags []string) (string, error) {
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, tag := range sorted {
+		digest, err := c.headManifest(repo, tag)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s@%s\n", tag, digest)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
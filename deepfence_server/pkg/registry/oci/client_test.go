@@ -0,0 +1,260 @@
+package oci
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/app:pull,push"`)
+	assert.Equal(t, scheme, "bearer")
+	assert.Equal(t, params["realm"], "https://auth.docker.io/token")
+	assert.Equal(t, params["service"], "registry.docker.io")
+	assert.Equal(t, params["scope"], "repository:library/app:pull,push")
+
+	scheme, params = parseChallenge(`Basic realm=registry`)
+	assert.Equal(t, scheme, "basic")
+	assert.Equal(t, params["realm"], "registry")
+}
+
+func TestNextLink(t *testing.T) {
+	current, _ := url.Parse("https://registry.local:5000/v2/_catalog?n=2")
+	assert.Equal(t, nextLink(`</v2/_catalog?last=b&n=2>; rel="next"`, current),
+		"https://registry.local:5000/v2/_catalog?last=b&n=2")
+	assert.Equal(t, nextLink(`<https://other/v2/_catalog?last=b>; rel=next`, current),
+		"https://other/v2/_catalog?last=b")
+	assert.Equal(t, nextLink(`</v2/_catalog?last=b>; rel="prev"`, current), "")
+	assert.Equal(t, nextLink("", current), "")
+}
+
+func testDigest(repo string) string {
+	sum := sha256.Sum256([]byte(repo))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// fakeRegistry serves two pages of repositories behind distribution token auth
+func fakeRegistry(t *testing.T) *httptest.Server {
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(TokenResp{Token: "token:" + r.URL.Query().Get("scope")})
+	})
+
+	authorized := func(w http.ResponseWriter, r *http.Request, scope string) bool {
+		if r.Header.Get("Authorization") == "Bearer token:"+scope {
+			return true
+		}
+		w.Header().Set("WWW-Authenticate",
+			fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="%s"`, srv.URL, scope))
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+
+	mux.HandleFunc("/v2/_catalog", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r, catalogScope) {
+			return
+		}
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/_catalog?last=app&n=1>; rel="next"`)
+			json.NewEncoder(w).Encode(ReposResp{Repositories: []string{"app"}})
+			return
+		}
+		json.NewEncoder(w).Encode(ReposResp{Repositories: []string{"group/web"}})
+	})
+
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			if authorized(w, r, "") {
+				w.WriteHeader(http.StatusOK)
+			}
+			return
+		}
+		var repo string
+		switch r.URL.Path {
+		case "/v2/app/tags/list", "/v2/app/manifests/latest":
+			repo = "app"
+		case "/v2/group/web/tags/list", "/v2/group/web/manifests/latest":
+			repo = "group/web"
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !authorized(w, r, repositoryScope(repo)) {
+			return
+		}
+		if r.URL.Path == "/v2/"+repo+"/tags/list" {
+			json.NewEncoder(w).Encode(RepoTagsResp{Name: repo, Tags: []string{"latest"}})
+			return
+		}
+		w.Header().Set("Content-Type", MediaTypeOCIIndex)
+		w.Header().Set("Docker-Content-Digest", testDigest(repo))
+		json.NewEncoder(w).Encode(Manifest{SchemaVersion: 2, MediaType: MediaTypeOCIIndex, Manifests: []Descriptor{
+			{Digest: testDigest(repo + "amd64"), Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: testDigest(repo + "arm64"), Platform: &Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+			{Digest: testDigest(repo + "attestation"), Platform: &Platform{OS: "unknown", Architecture: "unknown"}},
+		}})
+	})
+
+	srv = httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientTokenAuth(t *testing.T) {
+	srv := fakeRegistry(t)
+
+	c := newClient(srv.URL+"/", "user", "pass", srv.Client())
+	assert.NilError(t, c.ping())
+
+	repos, err := c.listCatalog()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, repos, []string{"app", "group/web"})
+
+	images, err := c.listImages()
+	assert.NilError(t, err)
+	assert.Equal(t, len(images), 2)
+	assert.Equal(t, images[1].Name, "group/web")
+	assert.Equal(t, images[1].Metadata["digest"], testDigest("group/web"))
+	assert.Equal(t, len(images[1].Platforms), 2)
+	assert.Equal(t, images[1].Platforms[1].String(), "linux/arm64/v8")
+	assert.Equal(t, images[1].Platforms[1].Digest, testDigest("group/webarm64"))
+
+	bad := newClient(srv.URL, "user", "wrong", srv.Client())
+	assert.Equal(t, bad.ping(), errUnauthorized)
+}
+
+func TestHTTPClientCA(t *testing.T) {
+	srv := fakeRegistry(t)
+
+	// the test server certificate is self signed
+	c := newClient(srv.URL, "user", "pass", srv.Client())
+	untrusted, err := newHTTPClient("", "", "", false)
+	assert.NilError(t, err)
+	c.http = untrusted
+	assert.Assert(t, c.ping() != nil)
+
+	insecure, err := newHTTPClient("", "", "", true)
+	assert.NilError(t, err)
+	c.http = insecure
+	assert.NilError(t, c.ping())
+
+	_, err = newHTTPClient("not a certificate", "", "", false)
+	assert.Assert(t, err != nil)
+}
+
+// clientCertificate returns a self signed client certificate and its key
+func clientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "scanner"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NilError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+	return cert,
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+}
+
+func TestHTTPClientMTLS(t *testing.T) {
+	cert, certPEM, keyPEM := clientCertificate(t)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	withoutCert, err := newHTTPClient(serverCA, "", "", false)
+	assert.NilError(t, err)
+	_, err = withoutCert.Get(srv.URL)
+	assert.Assert(t, err != nil, "server requires a client certificate")
+
+	withCert, err := newHTTPClient(serverCA, certPEM, keyPEM, false)
+	assert.NilError(t, err)
+	resp, err := withCert.Get(srv.URL)
+	assert.NilError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	_, err = newHTTPClient(serverCA, certPEM, "", false)
+	assert.Assert(t, err != nil, "client certificate without key")
+}
+
+func TestArtifactMediaType(t *testing.T) {
+	assert.Equal(t, artifactMediaType(Manifest{
+		MediaType: MediaTypeOCIManifest,
+		Config:    Descriptor{MediaType: MediaTypeOCIImageConfig},
+	}), "")
+	assert.Equal(t, artifactMediaType(Manifest{
+		MediaType: MediaTypeDockerManifestList,
+		Manifests: []Descriptor{{MediaType: MediaTypeDockerManifest}},
+	}), "")
+	assert.Equal(t, artifactMediaType(Manifest{
+		MediaType: MediaTypeOCIManifest,
+		Config:    Descriptor{MediaType: "application/vnd.cncf.helm.config.v1+json"},
+	}), "application/vnd.cncf.helm.config.v1+json")
+	assert.Equal(t, artifactMediaType(Manifest{
+		MediaType:    MediaTypeOCIManifest,
+		ArtifactType: "application/vnd.dev.sigstore.bundle.v0.3+json",
+		Config:       Descriptor{MediaType: "application/vnd.oci.empty.v1+json"},
+	}), "application/vnd.dev.sigstore.bundle.v0.3+json")
+}
+
+func TestFetchImage(t *testing.T) {
+	srv := fakeRegistry(t)
+
+	d := &RegistryOCI{}
+	d.NonSecret.OCIRegistryURL = srv.URL
+	d.NonSecret.OCIUsername = "user"
+	d.NonSecret.OCIInsecure = "true"
+	d.Secret.OCIPassword = "pass"
+
+	images, err := d.FetchImage("group/web", "latest")
+	assert.NilError(t, err)
+	assert.Equal(t, len(images), 1)
+	assert.Equal(t, images[0].Name, "group/web")
+	assert.Equal(t, images[0].Tag, "latest")
+
+	_, err = d.FetchImage("group/web", "missing")
+	assert.ErrorContains(t, err, "not found")
+}
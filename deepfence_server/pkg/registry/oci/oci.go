@@ -0,0 +1,173 @@
+package oci
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/encryption"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/go-playground/validator/v10"
+)
+
+func New(requestByte []byte) (*RegistryOCI, error) {
+	r := RegistryOCI{}
+	err := json.Unmarshal(requestByte, &r)
+	if err != nil {
+		return &r, err
+	}
+	return &r, nil
+}
+
+func (d *RegistryOCI) ValidateFields(v *validator.Validate) error {
+	if err := v.Struct(d); err != nil {
+		return err
+	}
+	if d.Secret.OCIPassword != "" && d.NonSecret.OCIUsername == "" {
+		return errors.New("oci_username:username is required with a password")
+	}
+	if d.NonSecret.OCIClientCertificate != "" && d.Secret.OCIClientKey == "" {
+		return errors.New("oci_client_key:client key is required with a client certificate")
+	}
+	if d.Secret.OCIClientKey != "" && d.NonSecret.OCIClientCertificate == "" {
+		return errors.New("oci_client_certificate:client certificate is required with a client key")
+	}
+	if _, err := newHTTPClient(d.NonSecret.OCICACertificate, "", "", false); err != nil {
+		return errors.New("oci_ca_certificate:" + err.Error())
+	}
+	if d.NonSecret.OCIClientCertificate != "" {
+		_, err := tls.X509KeyPair([]byte(d.NonSecret.OCIClientCertificate), []byte(d.Secret.OCIClientKey))
+		if err != nil {
+			return errors.New("oci_client_certificate:" + err.Error())
+		}
+	}
+	return nil
+}
+
+func (d *RegistryOCI) isInsecure() bool {
+	return d.NonSecret.OCIInsecure == "true"
+}
+
+func (d *RegistryOCI) registryClient() (*client, error) {
+	httpClient, err := newHTTPClient(d.NonSecret.OCICACertificate, d.NonSecret.OCIClientCertificate,
+		d.Secret.OCIClientKey, d.isInsecure())
+	if err != nil {
+		return nil, err
+	}
+	return newClient(d.NonSecret.OCIRegistryURL, d.NonSecret.OCIUsername, d.Secret.OCIPassword, httpClient), nil
+}
+
+func (d *RegistryOCI) IsValidCredential() bool {
+	if d.NonSecret.OCIRegistryURL == "" {
+		return false
+	}
+
+	c, err := d.registryClient()
+	if err != nil {
+		log.Error().Msg(err.Error())
+		return false
+	}
+	if err := c.ping(); err != nil {
+		log.Error().Msgf("failed to authenticate with %s: %v", d.NonSecret.OCIRegistryURL, err)
+		return false
+	}
+	return true
+}
+
+func (d *RegistryOCI) EncryptSecret(aes encryption.AES) error {
+	var err error
+	d.Secret.OCIPassword, err = aes.Encrypt(d.Secret.OCIPassword)
+	if err != nil {
+		return err
+	}
+	d.Secret.OCIClientKey, err = aes.Encrypt(d.Secret.OCIClientKey)
+	return err
+}
+
+func (d *RegistryOCI) DecryptSecret(aes encryption.AES) error {
+	var err error
+	d.Secret.OCIPassword, err = aes.Decrypt(d.Secret.OCIPassword)
+	if err != nil {
+		return err
+	}
+	d.Secret.OCIClientKey, err = aes.Decrypt(d.Secret.OCIClientKey)
+	return err
+}
+
+func (d *RegistryOCI) EncryptExtras(aes encryption.AES) error {
+	return nil
+}
+
+func (d *RegistryOCI) DecryptExtras(aes encryption.AES) error {
+	return nil
+}
+
+func (d *RegistryOCI) FetchImagesFromRegistry() ([]model.IngestedContainerImage, error) {
+	c, err := d.registryClient()
+	if err != nil {
+		return nil, err
+	}
+	return c.listImages()
+}
+
+func (d *RegistryOCI) FetchImagesIncremental(filter *model.RegistrySyncFilter,
+	state *model.RegistrySyncState) ([]model.IngestedContainerImage, error) {
+	c, err := d.registryClient()
+	if err != nil {
+		return nil, err
+	}
+	return c.listImagesIncremental(filter, state)
+}
+
+func (d *RegistryOCI) FetchImage(repository, tag string) ([]model.IngestedContainerImage, error) {
+	c, err := d.registryClient()
+	if err != nil {
+		return nil, err
+	}
+	images := c.getImagesWithTags(repository, []string{tag})
+	if len(images) == 0 {
+		return nil, fmt.Errorf("image %s:%s not found", repository, tag)
+	}
+	return images, nil
+}
+
+func (d *RegistryOCI) FetchArtifactsFromRegistry(filter *model.RegistrySyncFilter) ([]model.IngestedRegistryArtifact, error) {
+	c, err := d.registryClient()
+	if err != nil {
+		return nil, err
+	}
+	return c.listArtifacts(filter)
+}
+
+// getters
+func (d *RegistryOCI) GetSecret() map[string]interface{} {
+	var secret map[string]interface{}
+	b, _ := json.Marshal(d.Secret)
+	json.Unmarshal(b, &secret)
+	return secret
+}
+
+func (d *RegistryOCI) GetExtras() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+// GetNamespace returns the host of the registry, the username is optional
+// and can't tell apart registries
+func (d *RegistryOCI) GetNamespace() string {
+	u, err := url.Parse(d.NonSecret.OCIRegistryURL)
+	if err != nil || u.Host == "" {
+		return d.NonSecret.OCIRegistryURL
+	}
+	return u.Host
+}
+
+func (d *RegistryOCI) GetRegistryType() string {
+	return d.RegistryType
+}
+
+func (d *RegistryOCI) GetUsername() string {
+	return d.NonSecret.OCIUsername
+}
@@ -0,0 +1,78 @@
+package oci
+
+import (
+	"time"
+)
+
+type RegistryOCI struct {
+	Name         string    `json:"name" validate:"required,min=2,max=64"`
+	NonSecret    NonSecret `json:"non_secret"`
+	Secret       Secret    `json:"secret"`
+	RegistryType string    `json:"registry_type" validate:"required"`
+}
+
+type NonSecret struct {
+	OCIRegistryURL string `json:"oci_registry_url" validate:"required,url"`
+	// username is optional, registries allowing anonymous pulls are synced
+	// without credentials
+	OCIUsername string `json:"oci_username" validate:"omitempty,min=2"`
+	// skip the verification of the registry certificate
+	OCIInsecure string `json:"oci_insecure" validate:"omitempty,oneof=true false"`
+	// PEM encoded CA bundle trusted in addition to the system CAs
+	OCICACertificate string `json:"oci_ca_certificate" validate:"omitempty,max=65536"`
+	// PEM encoded client certificate for registries requiring mTLS
+	OCIClientCertificate string `json:"oci_client_certificate" validate:"omitempty,max=65536"`
+}
+
+type Secret struct {
+	OCIPassword  string `json:"oci_password"`
+	OCIClientKey string `json:"oci_client_key" validate:"omitempty,max=65536"`
+}
+
+type ReposResp struct {
+	Repositories []string `json:"repositories"`
+}
+
+type RepoTagsResp struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// TokenResp is the response of the token server of the registry, older
+// servers send token and oauth2 compatible servers send access_token
+type TokenResp struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant"`
+}
+
+// Manifest is an image manifest or an image index, docker schema 2
+// manifests and manifest lists have the same layout
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	ArtifactType  string       `json:"artifactType"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+	Manifests     []Descriptor `json:"manifests"`
+}
+
+type ImageConfig struct {
+	Architecture string    `json:"architecture"`
+	Os           string    `json:"os"`
+	Variant      string    `json:"variant"`
+	Created      time.Time `json:"created"`
+}
@@ -58,3 +58,36 @@ type Tag struct {
 	LastModified   string `json:"last_modified"`
 	ManifestDigest string `json:"manifest_digest"`
 }
+
+type SecurityResp struct {
+	// scanned, queued, failed or unsupported
+	Status string `json:"status"`
+	Data   *struct {
+		Layer struct {
+			Features []Feature `json:"Features"`
+		} `json:"Layer"`
+	} `json:"data"`
+}
+
+type Feature struct {
+	Name            string                 `json:"Name"`
+	Version         string                 `json:"Version"`
+	AddedBy         string                 `json:"AddedBy"`
+	Vulnerabilities []FeatureVulnerability `json:"Vulnerabilities"`
+}
+
+type FeatureVulnerability struct {
+	Name        string `json:"Name"`
+	Severity    string `json:"Severity"`
+	Description string `json:"Description"`
+	Link        string `json:"Link"`
+	FixedBy     string `json:"FixedBy"`
+	Metadata    *struct {
+		NVD *struct {
+			CVSSv3 *struct {
+				Vectors string  `json:"Vectors"`
+				Score   float64 `json:"Score"`
+			} `json:"CVSSv3"`
+		} `json:"NVD"`
+	} `json:"Metadata"`
+}
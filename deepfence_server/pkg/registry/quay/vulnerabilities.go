@@ -0,0 +1,101 @@
+package quay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+)
+
+const securityScanned = "scanned"
+
+func getSecurity(registryURL, namespace, token, repoName, digest string) (SecurityResp, error) {
+	var security SecurityResp
+
+	queryURL := fmt.Sprintf("%s/api/v1/repository/%s/%s/manifest/%s/security?vulnerabilities=true",
+		registryURL, namespace, repoName, digest)
+	req, err := http.NewRequest(http.MethodGet, queryURL, nil)
+	if err != nil {
+		return security, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return security, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return security, fmt.Errorf("error bad status code %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&security)
+	return security, err
+}
+
+func toVulnerabilities(source string, image model.IngestedContainerImage, security SecurityResp) []model.Vulnerability {
+	vulnerabilities := []model.Vulnerability{}
+	if security.Data == nil {
+		return vulnerabilities
+	}
+	for _, feature := range security.Data.Layer.Features {
+		pkg := feature.Name + ":" + feature.Version
+		for _, v := range feature.Vulnerabilities {
+			vulnerability := model.Vulnerability{
+				NodeId:                v.Name + "_" + pkg,
+				Cve_id:                v.Name,
+				Cve_severity:          model.NormalizeNativeSeverity(v.Severity),
+				Cve_caused_by_package: pkg,
+				Cve_container_layer:   feature.AddedBy,
+				Cve_fixed_in:          v.FixedBy,
+				Cve_description:       v.Description,
+				URLs:                  []interface{}{},
+				Resources:             []string{image.ID},
+				Source:                source,
+			}
+			// links of clair are space separated
+			for _, link := range strings.Fields(v.Link) {
+				vulnerability.URLs = append(vulnerability.URLs, link)
+			}
+			if len(vulnerability.URLs) > 0 {
+				vulnerability.Cve_link, _ = vulnerability.URLs[0].(string)
+			}
+			if v.Metadata != nil && v.Metadata.NVD != nil && v.Metadata.NVD.CVSSv3 != nil {
+				vulnerability.Cve_cvss_score = v.Metadata.NVD.CVSSv3.Score
+				vulnerability.Cve_overall_score = v.Metadata.NVD.CVSSv3.Score
+				vulnerability.Cve_attack_vector = v.Metadata.NVD.CVSSv3.Vectors
+				vulnerability.ParsedAttackVector = model.ParseCVSSAttackVector(v.Metadata.NVD.CVSSv3.Vectors)
+			}
+			vulnerabilities = append(vulnerabilities, vulnerability)
+		}
+	}
+	return vulnerabilities
+}
+
+func (d *RegistryQuay) FetchNativeVulnerabilities(image model.IngestedContainerImage) (*model.NativeVulnerabilityReport, error) {
+	digest, _ := image.Metadata["digest"].(string)
+	if digest == "" {
+		return nil, nil
+	}
+	security, err := getSecurity(d.NonSecret.QuayRegistryURL, d.NonSecret.QuayNamespace,
+		d.Secret.QuayAccessToken, image.Name, digest)
+	if err != nil {
+		return nil, err
+	}
+	// queued, failed and unsupported manifests have no report
+	if security.Status != securityScanned {
+		return nil, nil
+	}
+
+	return &model.NativeVulnerabilityReport{
+		ImageName:       image.Name,
+		Digest:          digest,
+		Source:          d.GetRegistryType(),
+		Scanner:         "Clair",
+		Vulnerabilities: toVulnerabilities(d.GetRegistryType(), image, security),
+	}, nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/gitlab"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/harbor"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/jfrog"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/oci"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/quay"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/encryption"
 	postgresql_db "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
@@ -44,6 +45,8 @@ func GetRegistry(rType string, requestByte []byte) (Registry, error) {
 		r, err = ecr.New(requestByte)
 	case constants.GITLAB:
 		r, err = gitlab.New(requestByte)
+	case constants.OCI:
+		r, err = oci.New(requestByte)
 	}
 
 	return r, err
@@ -177,6 +180,33 @@ func GetRegistryWithRegistryRow(row postgresql_db.GetContainerRegistriesRow) (Re
 			},
 		}
 		return r, nil
+	case constants.OCI:
+		var nonSecret map[string]string
+		var secret map[string]string
+		err := json.Unmarshal(row.NonSecret, &nonSecret)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(row.EncryptedSecret, &secret)
+		if err != nil {
+			return nil, err
+		}
+		r = &oci.RegistryOCI{
+			RegistryType: row.RegistryType,
+			Name:         row.Name,
+			NonSecret: oci.NonSecret{
+				OCIRegistryURL:       nonSecret["oci_registry_url"],
+				OCIUsername:          nonSecret["oci_username"],
+				OCIInsecure:          nonSecret["oci_insecure"],
+				OCICACertificate:     nonSecret["oci_ca_certificate"],
+				OCIClientCertificate: nonSecret["oci_client_certificate"],
+			},
+			Secret: oci.Secret{
+				OCIPassword:  secret["oci_password"],
+				OCIClientKey: secret["oci_client_key"],
+			},
+		}
+		return r, nil
 	case constants.HARBOR:
 		var nonSecret map[string]string
 		var secret map[string]string
@@ -363,6 +393,24 @@ func GetRegistryWithRegistrySafeRow(row postgresql_db.GetContainerRegistriesSafe
 			},
 		}
 		return r, nil
+	case constants.OCI:
+		var nonSecret map[string]string
+		err := json.Unmarshal(row.NonSecret, &nonSecret)
+		if err != nil {
+			return nil, err
+		}
+		r = &oci.RegistryOCI{
+			RegistryType: row.RegistryType,
+			Name:         row.Name,
+			NonSecret: oci.NonSecret{
+				OCIRegistryURL:       nonSecret["oci_registry_url"],
+				OCIUsername:          nonSecret["oci_username"],
+				OCIInsecure:          nonSecret["oci_insecure"],
+				OCICACertificate:     nonSecret["oci_ca_certificate"],
+				OCIClientCertificate: nonSecret["oci_client_certificate"],
+			},
+		}
+		return r, nil
 	case constants.HARBOR:
 		var nonSecret map[string]string
 		err := json.Unmarshal(row.NonSecret, &nonSecret)
@@ -448,3 +496,28 @@ type Registry interface {
 	GetRegistryType() string
 	GetUsername() string
 }
+
+// IncrementalRegistry is implemented by registries which can apply the sync
+// filters while listing and skip the repositories unchanged since last sync
+type IncrementalRegistry interface {
+	FetchImagesIncremental(filter *model.RegistrySyncFilter, state *model.RegistrySyncState) ([]model.IngestedContainerImage, error)
+}
+
+// ImageFetcher is implemented by registries which can fetch the images of a
+// single tag, pushes reported by registry webhooks are synced without
+// listing the whole registry
+type ImageFetcher interface {
+	FetchImage(repository, tag string) ([]model.IngestedContainerImage, error)
+}
+
+// NativeVulnerabilityReporter is implemented by registries scanning their
+// images, it returns nil if the registry has no report for the image yet
+type NativeVulnerabilityReporter interface {
+	FetchNativeVulnerabilities(image model.IngestedContainerImage) (*model.NativeVulnerabilityReport, error)
+}
+
+// ArtifactLister is implemented by registries which can tell images apart
+// from helm charts and other OCI artifacts
+type ArtifactLister interface {
+	FetchArtifactsFromRegistry(filter *model.RegistrySyncFilter) ([]model.IngestedRegistryArtifact, error)
+}
@@ -0,0 +1,100 @@
+package registrysync
+
+import (
+	"context"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+func RegistryArtifactsToMaps(registryId string, artifacts []model.IngestedRegistryArtifact) []map[string]interface{} {
+	res := []map[string]interface{}{}
+	for _, a := range artifacts {
+		res = append(res, map[string]interface{}{
+			"node_id":           model.GetRegistryArtifactID(registryId, a.Name, a.Tag),
+			"node_name":         a.Name + ":" + a.Tag,
+			"docker_image_name": a.Name,
+			"docker_image_tag":  a.Tag,
+			"digest":            a.Digest,
+			"artifact_type":     a.ArtifactType,
+			"media_type":        a.MediaType,
+			"size":              a.Size,
+			"pushed_at":         a.PushedAt,
+		})
+	}
+	return res
+}
+
+// discoverArtifacts upserts the helm charts and other artifacts of the
+// registry, when enabled in the sync options, and removes the ones deleted
+// from the registry or not matching the filters anymore
+func discoverArtifacts(ctx context.Context, pgClient *postgresqlDb.Queries, r registry.Registry,
+	pgId int32, filter *model.RegistrySyncFilter) error {
+
+	al, ok := r.(registry.ArtifactLister)
+	if !ok {
+		return nil
+	}
+	options, err := syncOptions(ctx, pgClient, pgId)
+	if err != nil {
+		return err
+	}
+	registryId := model.GetRegistryID(r.GetRegistryType(), r.GetNamespace())
+
+	artifacts := []model.IngestedRegistryArtifact{}
+	if options.DiscoverArtifacts {
+		artifacts, err = al.FetchArtifactsFromRegistry(filter)
+		if err != nil {
+			return err
+		}
+		artifacts = filter.ApplyArtifacts(artifacts)
+	}
+
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(120 * time.Second))
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	batch := RegistryArtifactsToMaps(registryId, artifacts)
+	_, err = tx.Run(`
+		UNWIND $batch as row
+		MERGE (n:RegistryArtifact{node_id:row.node_id})
+		MERGE (m:RegistryAccount{node_id:$registry_id})
+		MERGE (m) -[:HOSTS]-> (n)
+		SET n+= row, n.updated_at = TIMESTAMP(),
+		n.node_type='registry_artifact',
+		n.active=true`,
+		map[string]interface{}{"batch": batch, "registry_id": registryId})
+	if err != nil {
+		return err
+	}
+
+	ids := []string{}
+	for _, row := range batch {
+		ids = append(ids, row["node_id"].(string))
+	}
+	_, err = tx.Run(`
+		MATCH (m:RegistryAccount{node_id:$registry_id}) -[:HOSTS]-> (n:RegistryArtifact)
+		WHERE NOT n.node_id IN $ids
+		DETACH DELETE n`,
+		map[string]interface{}{"ids": ids, "registry_id": registryId})
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("sync registry id=%d found %d artifacts", pgId, len(artifacts))
+	return tx.Commit()
+}
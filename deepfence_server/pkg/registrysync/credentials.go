@@ -0,0 +1,65 @@
+package registrysync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+)
+
+// CheckRegistryCredentials validates the credentials of the registry, saves
+// the result on the registry account and publishes an event when they become
+// invalid or are about to expire
+func CheckRegistryCredentials(ctx context.Context, pgClient *postgresqlDb.Queries, r registry.Registry,
+	name string) (model.RegistryCredentialStatus, error) {
+
+	err := decryptRegistry(ctx, pgClient, r)
+	if err != nil {
+		return model.RegistryCredentialStatus{}, err
+	}
+
+	registryId := model.GetRegistryID(r.GetRegistryType(), r.GetNamespace())
+	previous, err := model.GetRegistryCredentialStatus(ctx, registryId)
+	if err != nil {
+		log.Warn().Msgf("no credential status for registry %s: %v", registryId, err)
+	}
+
+	status := registry.CheckCredential(r)
+	if err := model.SetRegistryCredentialStatus(ctx, registryId, status); err != nil {
+		return status, err
+	}
+
+	details := map[string]interface{}{
+		"registry_name": name,
+		"registry_type": r.GetRegistryType(),
+	}
+	switch {
+	case !status.Valid && previous.Valid:
+		log.Warn().Msgf("credentials of registry %s are invalid: %s", registryId, status.LastError)
+		details["error"] = status.LastError
+		notification.Publish(ctx, notification.Event{
+			EventType: notification.RegistryCredentialsInvalid,
+			NodeID:    registryId,
+			NodeType:  utils.NodeTypeRegistryAccount,
+			Message:   fmt.Sprintf("credentials of registry %s (%s) are invalid: %s", name, r.GetRegistryType(), status.LastError),
+			Details:   details,
+		})
+	case status.Valid && status.ExpiresSoon() && (!previous.ExpiresSoon() || previous.ExpiresAt != status.ExpiresAt):
+		expiresAt := time.UnixMilli(status.ExpiresAt).UTC().Format(time.RFC3339)
+		details["expires_at"] = status.ExpiresAt
+		notification.Publish(ctx, notification.Event{
+			EventType: notification.RegistryCredentialsExpiring,
+			NodeID:    registryId,
+			NodeType:  utils.NodeTypeRegistryAccount,
+			Message:   fmt.Sprintf("credentials of registry %s (%s) expire at %s", name, r.GetRegistryType(), expiresAt),
+			Details:   details,
+		})
+	}
+	return status, nil
+}
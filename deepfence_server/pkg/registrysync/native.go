@@ -0,0 +1,215 @@
+package registrysync
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// nativeReportMaxAge is how long an imported report is used before the
+// registry is asked for it again, registries rescan with new advisories
+const nativeReportMaxAge = 24 * time.Hour
+
+// importNativeVulnerabilities saves the vulnerability reports computed by
+// the registry for the images, when enabled in the sync options. Without
+// images the reports of all the images stored for the registry are imported,
+// incremental syncs don't return the images of unchanged repositories.
+func importNativeVulnerabilities(ctx context.Context, pgClient *postgresqlDb.Queries, r registry.Registry,
+	pgId int32, images []model.IngestedContainerImage) error {
+
+	nr, ok := r.(registry.NativeVulnerabilityReporter)
+	if !ok {
+		return nil
+	}
+	options, err := syncOptions(ctx, pgClient, pgId)
+	if err != nil {
+		return err
+	}
+	if !options.ImportNativeVulnerabilities {
+		return nil
+	}
+
+	registryId := model.GetRegistryID(r.GetRegistryType(), r.GetNamespace())
+	if images == nil {
+		images, err = getRegistryImages(ctx, registryId)
+		if err != nil {
+			return err
+		}
+	}
+	rows, err := pgClient.GetNativeVulnerabilityReportFetchTimes(ctx, registryId)
+	if err != nil {
+		return err
+	}
+	fetched := map[string]time.Time{}
+	for _, row := range rows {
+		fetched[row.Digest] = row.FetchedAt
+	}
+
+	var (
+		errs     []error
+		imported int
+	)
+	for _, image := range images {
+		digest, _ := image.Metadata["digest"].(string)
+		if digest == "" {
+			continue
+		}
+		if fetchedAt, ok := fetched[digest]; ok && time.Since(fetchedAt) < nativeReportMaxAge {
+			continue
+		}
+		// tags of the same digest share the report
+		fetched[digest] = time.Now()
+
+		report, err := nr.FetchNativeVulnerabilities(image)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%s: %w", image.Name, image.Tag, err))
+			continue
+		}
+		if report == nil {
+			continue
+		}
+		vulnerabilities, err := json.Marshal(report.Vulnerabilities)
+		if err != nil {
+			return err
+		}
+		generatedAt := sql.NullTime{}
+		if report.GeneratedAt > 0 {
+			generatedAt = sql.NullTime{Time: time.UnixMilli(report.GeneratedAt), Valid: true}
+		}
+		err = pgClient.UpsertNativeVulnerabilityReport(ctx, postgresqlDb.UpsertNativeVulnerabilityReportParams{
+			RegistryID:      registryId,
+			ImageName:       report.ImageName,
+			Digest:          report.Digest,
+			Source:          report.Source,
+			Scanner:         report.Scanner,
+			Vulnerabilities: vulnerabilities,
+			GeneratedAt:     generatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		imported++
+	}
+	log.Info().Msgf("imported %d native vulnerability reports of registry id=%d", imported, pgId)
+	return errors.Join(errs...)
+}
+
+// getRegistryImages returns the active images hosted by the registry account
+// with the fields the native vulnerability reporters need
+func getRegistryImages(ctx context.Context, registryId string) ([]model.IngestedContainerImage, error) {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(60 * time.Second))
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	res, err := tx.Run(`
+		MATCH (m:RegistryAccount{node_id:$registry_id}) -[:HOSTS]-> (n:ContainerImage)
+		WHERE n.active = true
+		AND n.docker_image_digest IS NOT NULL
+		RETURN n.node_id, n.docker_image_name, n.docker_image_tag, n.docker_image_digest`,
+		map[string]interface{}{"registry_id": registryId})
+	if err != nil {
+		return nil, err
+	}
+	records, err := res.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	images := []model.IngestedContainerImage{}
+	for _, rec := range records {
+		id, _ := rec.Values[0].(string)
+		name, _ := rec.Values[1].(string)
+		tag, _ := rec.Values[2].(string)
+		digest, _ := rec.Values[3].(string)
+		images = append(images, model.IngestedContainerImage{
+			ID:       id,
+			Name:     name,
+			Tag:      tag,
+			Metadata: model.Metadata{"digest": digest},
+		})
+	}
+	return images, nil
+}
+
+// vulnerabilityKey identifies a vulnerability of a package regardless of the
+// package version format of the scanner
+func vulnerabilityKey(v model.Vulnerability) string {
+	pkg := v.Cve_caused_by_package
+	if i := strings.LastIndex(pkg, ":"); i > 0 {
+		pkg = pkg[:i]
+	}
+	return strings.ToUpper(v.Cve_id) + "|" + strings.ToLower(pkg)
+}
+
+// CompareNativeVulnerabilities splits the vulnerabilities reported by the
+// registry and by ThreatMapper for the same digest into the ones reported by
+// both and the ones reported by only one of them
+func CompareNativeVulnerabilities(report model.NativeVulnerabilityReport,
+	vulnerabilities []model.Vulnerability) model.NativeVulnerabilityComparison {
+
+	comparison := model.NativeVulnerabilityComparison{
+		RegistryId:       report.RegistryId,
+		Source:           report.Source,
+		Scanner:          report.Scanner,
+		FetchedAt:        report.FetchedAt,
+		Common:           []model.NativeVulnerabilityMatch{},
+		RegistryOnly:     []model.Vulnerability{},
+		ThreatMapperOnly: []model.Vulnerability{},
+	}
+
+	found := map[string]model.Vulnerability{}
+	for _, v := range vulnerabilities {
+		if _, ok := found[vulnerabilityKey(v)]; !ok {
+			found[vulnerabilityKey(v)] = v
+		}
+	}
+
+	matched := map[string]bool{}
+	for _, v := range report.Vulnerabilities {
+		key := vulnerabilityKey(v)
+		if matched[key] {
+			continue
+		}
+		tm, ok := found[key]
+		if !ok {
+			comparison.RegistryOnly = append(comparison.RegistryOnly, v)
+			continue
+		}
+		matched[key] = true
+		comparison.Common = append(comparison.Common, model.NativeVulnerabilityMatch{
+			CveId:                v.Cve_id,
+			Package:              v.Cve_caused_by_package,
+			RegistrySeverity:     v.Cve_severity,
+			ThreatMapperSeverity: tm.Cve_severity,
+		})
+	}
+
+	for _, v := range vulnerabilities {
+		if matched[vulnerabilityKey(v)] {
+			continue
+		}
+		v.Source = model.VulnerabilitySourceThreatMapper
+		comparison.ThreatMapperOnly = append(comparison.ThreatMapperOnly, v)
+	}
+	return comparison
+}
@@ -0,0 +1,54 @@
+package registrysync
+
+import (
+	"testing"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"gotest.tools/assert"
+)
+
+func TestCompareNativeVulnerabilities(t *testing.T) {
+	report := model.NativeVulnerabilityReport{
+		RegistryId: "harbor-registry",
+		Source:     "harbor",
+		Scanner:    "Trivy v0.37.2",
+		Vulnerabilities: []model.Vulnerability{
+			{Cve_id: "CVE-2023-0286", Cve_caused_by_package: "libssl1.1:1.1.1n-0+deb11u3", Cve_severity: "high"},
+			{Cve_id: "CVE-2022-3715", Cve_caused_by_package: "bash:5.1-2+b3", Cve_severity: "high"},
+		},
+	}
+	vulnerabilities := []model.Vulnerability{
+		{Cve_id: "cve-2023-0286", Cve_caused_by_package: "libssl1.1:1.1.1n-0+deb11u4", Cve_severity: "critical"},
+		{Cve_id: "CVE-2023-0286", Cve_caused_by_package: "libssl1.1:1.1.1n-0+deb11u4", Cve_severity: "critical"},
+		{Cve_id: "CVE-2022-42898", Cve_caused_by_package: "libkrb5-3:1.18.3-6", Cve_severity: "medium"},
+	}
+
+	got := CompareNativeVulnerabilities(report, vulnerabilities)
+
+	assert.Equal(t, got.Source, "harbor")
+	assert.DeepEqual(t, got.Common, []model.NativeVulnerabilityMatch{{
+		CveId:                "CVE-2023-0286",
+		Package:              "libssl1.1:1.1.1n-0+deb11u3",
+		RegistrySeverity:     "high",
+		ThreatMapperSeverity: "critical",
+	}})
+	assert.Equal(t, len(got.RegistryOnly), 1)
+	assert.Equal(t, got.RegistryOnly[0].Cve_id, "CVE-2022-3715")
+	assert.Equal(t, len(got.ThreatMapperOnly), 1)
+	assert.Equal(t, got.ThreatMapperOnly[0].Cve_id, "CVE-2022-42898")
+	assert.Equal(t, got.ThreatMapperOnly[0].Source, model.VulnerabilitySourceThreatMapper)
+}
+
+func TestNormalizeNativeSeverity(t *testing.T) {
+	for severity, want := range map[string]string{
+		"Critical":   "critical",
+		"Defcon1":    "critical",
+		"HIGH":       "high",
+		"Medium":     "medium",
+		"Negligible": "low",
+		"Unknown":    "unknown",
+		"":           "unknown",
+	} {
+		assert.Equal(t, model.NormalizeNativeSeverity(severity), want, severity)
+	}
+}
@@ -0,0 +1,130 @@
+package registrysync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	ingestersUtil "github.com/deepfence/ThreatMapper/deepfence_utils/utils/ingesters"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// PolicyImage is an image of the registry with its latest scan of one type
+type PolicyImage struct {
+	NodeId    string
+	Name      string
+	Tag       string
+	Status    string
+	ScannedAt int64
+}
+
+func isScanRunning(status string) bool {
+	switch status {
+	case "", utils.SCAN_STATUS_SUCCESS, utils.SCAN_STATUS_FAILED, utils.SCAN_STATUS_CANCELLED:
+		return false
+	}
+	return true
+}
+
+// GetPolicyImages returns the images hosted by the registry with the status
+// and start time of their latest scan of the given type
+func GetPolicyImages(ctx context.Context, registryId string, scanType utils.Neo4jScanType) ([]PolicyImage, error) {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	query := fmt.Sprintf(`
+	MATCH (m:RegistryAccount{node_id: $id}) -[:HOSTS]-> (n:ContainerImage)
+	OPTIONAL MATCH (s:%s{node_id: n.%s})
+	RETURN n.node_id, COALESCE(n.docker_image_name, ""), COALESCE(n.docker_image_tag, ""),
+		COALESCE(s.status, ""), COALESCE(s.created_at, 0)`,
+		scanType, ingestersUtil.LatestScanIdField[scanType])
+	r, err := tx.Run(query, map[string]interface{}{"id": registryId})
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := r.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]PolicyImage, 0, len(records))
+	for _, record := range records {
+		image := PolicyImage{}
+		image.NodeId, _ = record.Values[0].(string)
+		image.Name, _ = record.Values[1].(string)
+		image.Tag, _ = record.Values[2].(string)
+		image.Status, _ = record.Values[3].(string)
+		image.ScannedAt, _ = record.Values[4].(int64)
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// SelectPolicyImages returns the node ids of the images the policy should
+// scan now. Images never scanned come first, then the ones scanned longest
+// ago. Scans already running on the registry count against the maximum
+// number of concurrent scans of the policy.
+func SelectPolicyImages(policy model.RegistryScanPolicy, images []PolicyImage, now time.Time) ([]string, error) {
+	filter, err := policy.Filters.Filter()
+	if err != nil {
+		return nil, err
+	}
+
+	running := 0
+	candidates := []PolicyImage{}
+	rescanBefore := now.AddDate(0, 0, -int(policy.RescanAfterDays)).UnixMilli()
+	for _, image := range images {
+		if isScanRunning(image.Status) {
+			running++
+			continue
+		}
+		if !filter.MatchRepository(image.Name) || !filter.MatchTag(image.Tag) {
+			continue
+		}
+		// failed and cancelled scans are retried like images never scanned
+		if image.Status == utils.SCAN_STATUS_SUCCESS &&
+			(policy.RescanAfterDays == 0 || image.ScannedAt > rescanBefore) {
+			continue
+		}
+		candidates = append(candidates, image)
+	}
+
+	available := int(policy.MaxConcurrentScans) - running
+	if available <= 0 {
+		return []string{}, nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iScanned := candidates[i].Status == utils.SCAN_STATUS_SUCCESS
+		jScanned := candidates[j].Status == utils.SCAN_STATUS_SUCCESS
+		if iScanned != jScanned {
+			return !iScanned
+		}
+		return candidates[i].ScannedAt < candidates[j].ScannedAt
+	})
+	if len(candidates) > available {
+		candidates = candidates[:available]
+	}
+
+	nodeIds := make([]string, 0, len(candidates))
+	for _, image := range candidates {
+		nodeIds = append(nodeIds, image.NodeId)
+	}
+	return nodeIds, nil
+}
@@ -0,0 +1,82 @@
+package registrysync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"gotest.tools/assert"
+)
+
+func TestSelectPolicyImages(t *testing.T) {
+	now := time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) int64 {
+		return now.AddDate(0, 0, -days).UnixMilli()
+	}
+	images := []PolicyImage{
+		{NodeId: "app:v1", Name: "deepfence/app", Tag: "v1", Status: utils.SCAN_STATUS_SUCCESS, ScannedAt: daysAgo(10)},
+		{NodeId: "app:v2", Name: "deepfence/app", Tag: "v2"},
+		{NodeId: "app:v3", Name: "deepfence/app", Tag: "v3", Status: utils.SCAN_STATUS_SUCCESS, ScannedAt: daysAgo(2)},
+		{NodeId: "app:v4", Name: "deepfence/app", Tag: "v4", Status: utils.SCAN_STATUS_FAILED, ScannedAt: daysAgo(1)},
+		{NodeId: "db:v1", Name: "deepfence/db", Tag: "v1", Status: utils.SCAN_STATUS_INPROGRESS, ScannedAt: daysAgo(0)},
+		{NodeId: "app:old", Name: "deepfence/app", Tag: "old", Status: utils.SCAN_STATUS_SUCCESS, ScannedAt: daysAgo(30)},
+	}
+
+	tests := []struct {
+		name    string
+		policy  model.RegistryScanPolicy
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "never scanned only",
+			policy: model.RegistryScanPolicy{MaxConcurrentScans: 10},
+			want:   []string{"app:v2", "app:v4"},
+		},
+		{
+			name:   "rescan older than a week",
+			policy: model.RegistryScanPolicy{RescanAfterDays: 7, MaxConcurrentScans: 10},
+			want:   []string{"app:v2", "app:v4", "app:old", "app:v1"},
+		},
+		{
+			name:   "running scans count against the maximum",
+			policy: model.RegistryScanPolicy{RescanAfterDays: 7, MaxConcurrentScans: 3},
+			want:   []string{"app:v2", "app:v4"},
+		},
+		{
+			name:   "maximum reached",
+			policy: model.RegistryScanPolicy{MaxConcurrentScans: 1},
+			want:   []string{},
+		},
+		{
+			name: "tag filters",
+			policy: model.RegistryScanPolicy{RescanAfterDays: 7, MaxConcurrentScans: 10,
+				Filters: model.RegistryScanPolicyFilters{IncludeTags: "^v", ExcludeTags: "^v4$"}},
+			want: []string{"app:v2", "app:v1"},
+		},
+		{
+			name: "repository filters",
+			policy: model.RegistryScanPolicy{MaxConcurrentScans: 10,
+				Filters: model.RegistryScanPolicyFilters{IncludeRepositories: "deepfence/db"}},
+			want: []string{},
+		},
+		{
+			name: "invalid filter",
+			policy: model.RegistryScanPolicy{MaxConcurrentScans: 10,
+				Filters: model.RegistryScanPolicyFilters{IncludeTags: "["}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectPolicyImages(tt.policy, images, now)
+			if tt.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
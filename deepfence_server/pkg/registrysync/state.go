@@ -0,0 +1,177 @@
+package registrysync
+
+import (
+	"context"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// progress is saved on the registry account every progressStep percent
+const progressStep = 5
+
+func syncOptions(ctx context.Context, pgClient *postgresqlDb.Queries, pgId int32) (model.RegistrySyncOptions, error) {
+	row, err := pgClient.GetContainerRegistry(ctx, pgId)
+	if err != nil {
+		return model.RegistrySyncOptions{}, err
+	}
+	return model.ParseRegistrySyncOptions(row.SyncOptions)
+}
+
+func syncFilter(ctx context.Context, pgClient *postgresqlDb.Queries, pgId int32) (*model.RegistrySyncFilter, error) {
+	options, err := syncOptions(ctx, pgClient, pgId)
+	if err != nil {
+		return nil, err
+	}
+	return options.Filter()
+}
+
+func progressUpdater(ctx context.Context, r registry.Registry) func(done, total int) {
+	last := 0
+	return func(done, total int) {
+		if total == 0 {
+			return
+		}
+		progress := done * 100 / total
+		if progress < last+progressStep && done != total {
+			return
+		}
+		last = progress
+		err := setRegistryAccountProperties(ctx, r, map[string]interface{}{"sync_progress": progress})
+		if err != nil {
+			log.Error().Msgf("failed to update sync progress: %v", err)
+		}
+	}
+}
+
+func setRegistryAccountSynced(ctx context.Context, r registry.Registry, duration time.Duration,
+	images, skipped int) error {
+
+	return setRegistryAccountProperties(ctx, r, map[string]interface{}{
+		"sync_progress":                  100,
+		"last_synced_at":                 time.Now().UnixMilli(),
+		"last_sync_duration":             duration.Milliseconds(),
+		"last_sync_images":               images,
+		"last_sync_skipped_repositories": skipped,
+	})
+}
+
+// getRepositoryVersions returns the repository versions saved on the image
+// stubs of the registry account by the previous sync
+func getRepositoryVersions(ctx context.Context, r registry.Registry) (map[string]string, error) {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(60 * time.Second))
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	res, err := tx.Run(`
+		MATCH (m:RegistryAccount{node_id:$registry_id}) -[:HOSTS]-> (s:ImageStub)
+		WHERE s.sync_version IS NOT NULL
+		RETURN s.docker_image_name, s.sync_version`,
+		map[string]interface{}{
+			"registry_id": model.GetRegistryID(r.GetRegistryType(), r.GetNamespace()),
+		})
+	if err != nil {
+		return nil, err
+	}
+	records, err := res.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := map[string]string{}
+	for _, rec := range records {
+		name, _ := rec.Values[0].(string)
+		version, _ := rec.Values[1].(string)
+		versions[name] = version
+	}
+	return versions, nil
+}
+
+func saveRepositoryVersions(ctx context.Context, r registry.Registry, versions map[string]string) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	batch := []map[string]interface{}{}
+	for name, version := range versions {
+		batch = append(batch, map[string]interface{}{"name": name, "version": version})
+	}
+
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(120 * time.Second))
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	_, err = tx.Run(`
+		UNWIND $batch as row
+		MATCH (m:RegistryAccount{node_id:$registry_id}) -[:HOSTS]-> (s:ImageStub{docker_image_name: row.name})
+		SET s.sync_version = row.version`,
+		map[string]interface{}{
+			"batch":       batch,
+			"registry_id": model.GetRegistryID(r.GetRegistryType(), r.GetNamespace()),
+		})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// touchRepositories refreshes the images of the repositories skipped by an
+// incremental sync, so the registry clean up doesn't deactivate them and drop
+// their image stubs along with the saved versions
+func touchRepositories(ctx context.Context, r registry.Registry, repositories []string) error {
+	if len(repositories) == 0 {
+		return nil
+	}
+
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(120 * time.Second))
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	_, err = tx.Run(`
+		UNWIND $names as name
+		MATCH (m:RegistryAccount{node_id:$registry_id}) -[:HOSTS]-> (s:ImageStub{docker_image_name: name})
+		SET s.updated_at = TIMESTAMP()
+		WITH m, s
+		MATCH (m) -[:HOSTS]-> (n:ContainerImage) -[:IS]-> (s)
+		WHERE n.active = true
+		SET n.updated_at = TIMESTAMP()`,
+		map[string]interface{}{
+			"names":       repositories,
+			"registry_id": model.GetRegistryID(r.GetRegistryType(), r.GetNamespace()),
+		})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
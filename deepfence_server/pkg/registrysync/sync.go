@@ -3,6 +3,8 @@ package registrysync
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
@@ -10,12 +12,16 @@ import (
 
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registrywebhook"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/encryption"
 	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 )
 
 func SyncRegistry(ctx context.Context, pgClient *postgresqlDb.Queries, r registry.Registry, pgId int32) error {
+	start := time.Now()
+
 	// set registry account syncing
 	err := setRegistryAccountSyncing(ctx, true, r)
 	if err != nil {
@@ -29,35 +35,164 @@ func SyncRegistry(ctx context.Context, pgClient *postgresqlDb.Queries, r registr
 		}
 	}()
 
-	// decrypt secret
-	aesValue, err := model.GetAESValueForEncryption(ctx, pgClient)
+	err = decryptRegistry(ctx, pgClient, r)
 	if err != nil {
 		return err
 	}
 
-	// note: we'll decrypt the secret in registry interface object
-	aes := encryption.AES{}
-	err = json.Unmarshal(aesValue, &aes)
+	filter, err := syncFilter(ctx, pgClient, pgId)
 	if err != nil {
 		return err
 	}
 
-	err = r.DecryptSecret(aes)
+	var (
+		list  []model.IngestedContainerImage
+		state *model.RegistrySyncState
+	)
+	if ir, ok := r.(registry.IncrementalRegistry); ok {
+		versions, err := getRepositoryVersions(ctx, r)
+		if err != nil {
+			log.Warn().Msgf("sync registry id=%d without previous versions: %v", pgId, err)
+		}
+		state = model.NewRegistrySyncState(filter, versions, progressUpdater(ctx, r))
+		list, err = ir.FetchImagesIncremental(filter, state)
+		if err != nil {
+			return err
+		}
+	} else {
+		list, err = r.FetchImagesFromRegistry()
+		if err != nil {
+			return err
+		}
+	}
+	list = filter.Apply(list)
+	log.Info().Msgf("sync registry id=%d type=%s found %d images", pgId, r.GetRegistryType(), len(list))
+
+	err = insertToNeo4j(ctx, list, r, pgId)
+	if err != nil {
+		return err
+	}
+
+	err = recordTagHistory(ctx, pgClient, model.GetRegistryID(r.GetRegistryType(), r.GetNamespace()), list)
+	if err != nil {
+		return err
+	}
+
+	if err := importNativeVulnerabilities(ctx, pgClient, r, pgId, nil); err != nil {
+		log.Error().Msgf("failed to import native vulnerabilities of registry id=%d: %v", pgId, err)
+	}
+
+	if err := discoverArtifacts(ctx, pgClient, r, pgId, filter); err != nil {
+		log.Error().Msgf("failed to discover artifacts of registry id=%d: %v", pgId, err)
+	}
+
+	skipped := 0
+	if state != nil {
+		skipped = state.Skipped()
+		if err := touchRepositories(ctx, r, state.Unchanged()); err != nil {
+			log.Error().Msgf("failed to refresh unchanged repositories of registry id=%d: %v", pgId, err)
+		}
+		if err := saveRepositoryVersions(ctx, r, state.Versions()); err != nil {
+			log.Error().Msgf("failed to save repository versions of registry id=%d: %v", pgId, err)
+		}
+	}
+	log.Info().Msgf("sync registry id=%d took %s, %d unchanged repositories skipped",
+		pgId, time.Since(start), skipped)
+	return setRegistryAccountSynced(ctx, r, time.Since(start), len(list), skipped)
+}
+
+// SyncRegistryImages upserts only the images pushed to the registry, as
+// reported by a registry webhook, and returns the images upserted
+func SyncRegistryImages(ctx context.Context, pgClient *postgresqlDb.Queries, r registry.Registry,
+	pgId int32, pushed []utils.RegistryPushedImage) ([]model.IngestedContainerImage, error) {
+
+	err := decryptRegistry(ctx, pgClient, r)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := syncFilter(ctx, pgClient, pgId)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := fetchPushedImages(r, pushed)
+	if err != nil {
+		return nil, err
+	}
+
+	images := []model.IngestedContainerImage{}
+	for _, image := range filter.Apply(list) {
+		digest, _ := image.Metadata["digest"].(string)
+		for _, p := range pushed {
+			if registrywebhook.Matches(p, image.Name, image.Tag, digest) {
+				images = append(images, image)
+				break
+			}
+		}
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("pushed images %v not found in registry", pushed)
+	}
+	log.Info().Msgf("sync registry id=%d type=%s pushed %d images", pgId, r.GetRegistryType(), len(images))
+	if err := insertToNeo4j(ctx, images, r, pgId); err != nil {
+		return nil, err
+	}
+	registryId := model.GetRegistryID(r.GetRegistryType(), r.GetNamespace())
+	if err := recordTagHistory(ctx, pgClient, registryId, images); err != nil {
+		return images, err
+	}
+	if err := importNativeVulnerabilities(ctx, pgClient, r, pgId, images); err != nil {
+		log.Error().Msgf("failed to import native vulnerabilities of registry id=%d: %v", pgId, err)
+	}
+	return images, nil
+}
+
+// fetchPushedImages fetches the images of the pushed tags only, the whole
+// registry is listed if it can't fetch single images or a push has no tag
+func fetchPushedImages(r registry.Registry, pushed []utils.RegistryPushedImage) ([]model.IngestedContainerImage, error) {
+	fetcher, ok := r.(registry.ImageFetcher)
+	if !ok {
+		return r.FetchImagesFromRegistry()
+	}
+	for _, p := range pushed {
+		if p.Tag == "" {
+			// only the registry listing maps a digest back to its tags
+			return r.FetchImagesFromRegistry()
+		}
+	}
+
+	images := []model.IngestedContainerImage{}
+	for _, p := range pushed {
+		list, err := fetcher.FetchImage(p.Repository, p.Tag)
+		if err != nil {
+			log.Error().Msgf("failed to fetch pushed image %s:%s: %v", p.Repository, p.Tag, err)
+			continue
+		}
+		images = append(images, list...)
+	}
+	return images, nil
+}
+
+func decryptRegistry(ctx context.Context, pgClient *postgresqlDb.Queries, r registry.Registry) error {
+	aesValue, err := model.GetAESValueForEncryption(ctx, pgClient)
 	if err != nil {
 		return err
 	}
 
-	err = r.DecryptExtras(aes)
+	// note: we'll decrypt the secret in registry interface object
+	aes := encryption.AES{}
+	err = json.Unmarshal(aesValue, &aes)
 	if err != nil {
 		return err
 	}
 
-	list, err := r.FetchImagesFromRegistry()
+	err = r.DecryptSecret(aes)
 	if err != nil {
 		return err
 	}
-	log.Info().Msgf("sync registry id=%d type=%s found %d images", pgId, r.GetRegistryType(), len(list))
-	return insertToNeo4j(ctx, list, r, pgId)
+
+	return r.DecryptExtras(aes)
 }
 
 func insertToNeo4j(ctx context.Context, images []model.IngestedContainerImage, r registry.Registry, pgId int32) error {
@@ -106,9 +241,62 @@ func insertToNeo4j(ctx context.Context, images []model.IngestedContainerImage, r
 		return err
 	}
 
+	platformMap := RegistryImagePlatformsToMaps(images)
+	if len(platformMap) > 0 {
+		// platform images are reached from their manifest list, they are
+		// not hosted by the registry so image lists don't show them twice
+		_, err = tx.Run(`
+		UNWIND $batch as row
+		MATCH (p:ContainerImage{node_id:row.parent_image_id})
+		MERGE (n:ContainerImage{node_id:row.node_id})
+		MERGE (p) -[:HAS_PLATFORM]-> (n)
+		SET n+= row, n.updated_at = TIMESTAMP(),
+		n.node_type='container_image',
+		n.pseudo=false,
+		n.active=true,
+		n.docker_image_tag_list = REDUCE(distinctElements = [], element IN COALESCE(n.docker_image_tag_list, []) + (row.docker_image_name+":"+row.docker_image_tag) | CASE WHEN NOT element in distinctElements THEN distinctElements + element ELSE distinctElements END),
+		n.node_name=n.docker_image_name+":"+n.docker_image_tag+" ("+n.platform+")",
+		p.is_manifest_list=true`,
+			map[string]interface{}{"batch": platformMap})
+		if err != nil {
+			return err
+		}
+	}
+
 	return tx.Commit()
 }
 
+// RegistryImagePlatformsToMaps returns the platform images of the manifest
+// lists, tagged like their manifest list and pulled by digest when scanned
+func RegistryImagePlatformsToMaps(ms []model.IngestedContainerImage) []map[string]interface{} {
+	res := []map[string]interface{}{}
+	for _, v := range ms {
+		for _, p := range v.Platforms {
+			if _, hash, _ := strings.Cut(p.Digest, ":"); len(hash) < 12 {
+				log.Warn().Msgf("invalid digest %q of %s:%s %s", p.Digest, v.Name, v.Tag, p)
+				continue
+			}
+			imageID, shortImageID := model.DigestToID(p.Digest)
+			metadata, _ := json.Marshal(model.Metadata{"digest": p.Digest})
+			res = append(res, map[string]interface{}{
+				"node_id":             imageID,
+				"docker_image_id":     imageID,
+				"short_image_id":      shortImageID,
+				"docker_image_name":   v.Name,
+				"docker_image_tag":    v.Tag,
+				"parent_image_id":     v.ID,
+				"platform":            p.String(),
+				"os":                  p.OS,
+				"architecture":        p.Architecture,
+				"variant":             p.Variant,
+				"docker_image_digest": p.Digest,
+				"metadata":            string(metadata),
+			})
+		}
+	}
+	return res
+}
+
 func RegistryImagesToMaps(ms []model.IngestedContainerImage) []map[string]interface{} {
 	res := []map[string]interface{}{}
 	for _, v := range ms {
@@ -126,6 +314,8 @@ func toMap(i model.IngestedContainerImage) map[string]interface{} {
 	_ = json.Unmarshal(out, &bb)
 	bb = convertStructFieldToJSONString(bb, "metrics")
 	bb = convertStructFieldToJSONString(bb, "metadata")
+	delete(bb, "platforms")
+	bb["docker_image_digest"] = imageDigest(i)
 	return bb
 }
 
@@ -142,6 +332,15 @@ func convertStructFieldToJSONString(bb map[string]interface{}, key string) map[s
 }
 
 func setRegistryAccountSyncing(ctx context.Context, syncing bool, r registry.Registry) error {
+	props := map[string]interface{}{"syncing": syncing}
+	if syncing {
+		props["sync_progress"] = 0
+		props["sync_started_at"] = time.Now().UnixMilli()
+	}
+	return setRegistryAccountProperties(ctx, r, props)
+}
+
+func setRegistryAccountProperties(ctx context.Context, r registry.Registry, props map[string]interface{}) error {
 	driver, err := directory.Neo4jClient(ctx)
 	if err != nil {
 		return err
@@ -161,10 +360,10 @@ func setRegistryAccountSyncing(ctx context.Context, syncing bool, r registry.Reg
 	registryId := model.GetRegistryID(r.GetRegistryType(), r.GetNamespace())
 	_, err = tx.Run(`
 		MATCH (m:RegistryAccount{node_id:$registry_id})
-		SET m.syncing=$syncing`,
+		SET m += $props`,
 		map[string]interface{}{
 			"registry_id": registryId,
-			"syncing":     syncing,
+			"props":       props,
 		})
 	if err != nil {
 		return err
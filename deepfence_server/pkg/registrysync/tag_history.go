@@ -0,0 +1,158 @@
+package registrysync
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// imageDigest returns the content digest of the manifest the tag points to
+func imageDigest(image model.IngestedContainerImage) string {
+	if digest, ok := image.Metadata["digest"].(string); ok && digest != "" {
+		return digest
+	}
+	if image.ID == "" {
+		return ""
+	}
+	return model.IDToDigest(image.ID)
+}
+
+// recordTagHistory saves the digest every synced tag points to, a tag
+// pointing to a new digest closes its previous history entry. Tags missing
+// from the images are left as is, incremental and webhook syncs only see
+// part of the registry. The history is updated in a single transaction.
+func recordTagHistory(ctx context.Context, pgClient *postgresqlDb.Queries, registryId string,
+	images []model.IngestedContainerImage) error {
+
+	tx, txClient, err := pgClient.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	moved, err := updateTagHistory(ctx, txClient, registryId, images)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if len(moved) == 0 {
+		return nil
+	}
+	return untagMovedImages(ctx, registryId, moved)
+}
+
+// updateTagHistory records the tags of the images and returns the tags which
+// moved to another digest
+func updateTagHistory(ctx context.Context, pgClient *postgresqlDb.Queries, registryId string,
+	images []model.IngestedContainerImage) ([]map[string]interface{}, error) {
+
+	rows, err := pgClient.GetCurrentImageTags(ctx, registryId)
+	if err != nil {
+		return nil, err
+	}
+	current := map[string]postgresqlDb.ImageTagHistory{}
+	for _, row := range rows {
+		current[row.ImageName+":"+row.Tag] = row
+	}
+
+	seen := []int64{}
+	moved := []map[string]interface{}{}
+	for _, image := range images {
+		digest := imageDigest(image)
+		if _, hash, _ := strings.Cut(digest, ":"); len(hash) < 12 {
+			continue
+		}
+		ref := image.Name + ":" + image.Tag
+		row, found := current[ref]
+		if found && row.Digest == digest {
+			if row.ID != 0 {
+				seen = append(seen, row.ID)
+			}
+			continue
+		}
+
+		previous := ""
+		if found {
+			previous = row.Digest
+			if err := pgClient.ReplaceImageTagHistory(ctx, row.ID); err != nil {
+				return nil, err
+			}
+			log.Info().Msgf("registry %s tag %s moved from %s to %s", registryId, ref, previous, digest)
+		}
+		err := pgClient.CreateImageTagHistory(ctx, postgresqlDb.CreateImageTagHistoryParams{
+			RegistryID:     registryId,
+			ImageName:      image.Name,
+			Tag:            image.Tag,
+			Digest:         digest,
+			PreviousDigest: previous,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// the same digest can be tagged twice in one sync
+		current[ref] = postgresqlDb.ImageTagHistory{Digest: digest}
+
+		if found {
+			moved = append(moved, map[string]interface{}{
+				"tag_ref":  ref,
+				"tag":      image.Tag,
+				"image_id": image.ID,
+			})
+		}
+	}
+
+	if len(seen) > 0 {
+		if err := pgClient.UpdateImageTagHistoriesLastSeen(ctx, seen); err != nil {
+			return nil, err
+		}
+	}
+	return moved, nil
+}
+
+// untagMovedImages drops the moved tags from the images they pointed to
+// before, so scans by tag and tag lookups resolve to the new content only
+func untagMovedImages(ctx context.Context, registryId string, moved []map[string]interface{}) error {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(120 * time.Second))
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	_, err = tx.Run(`
+		UNWIND $batch as row
+		MATCH (m:RegistryAccount{node_id:$registry_id}) -[:HOSTS]-> (p:ContainerImage) -[:HAS_PLATFORM*0..1]-> (n:ContainerImage)
+		WHERE p.node_id <> row.image_id
+		AND row.tag_ref IN n.docker_image_tag_list
+		WITH DISTINCT n, row, [t IN n.docker_image_tag_list WHERE t <> row.tag_ref] as remaining
+		SET n.docker_image_tag_list = remaining,
+		n.docker_image_tag = CASE WHEN n.docker_image_tag <> row.tag THEN n.docker_image_tag
+			WHEN size(remaining) > 0 THEN last(split(remaining[0], ":"))
+			ELSE "<none>" END,
+		n.node_name = n.docker_image_name+":"+n.docker_image_tag+" ("+
+			CASE WHEN n.parent_image_id IS NULL THEN n.short_image_id ELSE n.platform END+")"`,
+		map[string]interface{}{
+			"batch":       moved,
+			"registry_id": registryId,
+		})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,46 @@
+package registrywebhook
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// headers registries can be configured to send the webhook token in
+var tokenHeaders = []string{"Authorization", "X-Gitlab-Token", "X-JFrog-Event-Auth"}
+
+// NewToken returns a webhook token, webhooks are not authenticated with a
+// user so the namespace is found from the stored hash of the token
+func NewToken() string {
+	return uuid.New().String()
+}
+
+// HashToken returns the hash of the token saved in the database
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func VerifyToken(token, hash string) bool {
+	if token == "" || hash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
+}
+
+// TokenFromRequest returns the token from the request headers, or else the
+// token in the url path for registries which can not send custom headers,
+// like Docker Hub and Quay, headers are preferred since urls end up in proxy
+// and access logs
+func TokenFromRequest(r *http.Request, pathToken string) string {
+	for _, header := range tokenHeaders {
+		if token := r.Header.Get(header); token != "" {
+			return strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))
+		}
+	}
+	return pathToken
+}
@@ -0,0 +1,197 @@
+package registrywebhook
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+)
+
+// payload formats of the supported registries, gitlab and other registries
+// built on the distribution project send distribution notifications
+const (
+	SourceDockerHub    = "docker_hub"
+	SourceHarbor       = "harbor"
+	SourceQuay         = "quay"
+	SourceJFrog        = "jfrog"
+	SourceDistribution = "distribution"
+)
+
+var ErrUnknownPayload = errors.New("unknown registry webhook payload")
+
+type dockerHubPayload struct {
+	PushData struct {
+		Tag string `json:"tag"`
+	} `json:"push_data"`
+	Repository struct {
+		RepoName string `json:"repo_name"`
+	} `json:"repository"`
+}
+
+type harborPayload struct {
+	Type      string `json:"type"`
+	EventData struct {
+		Resources []struct {
+			Digest string `json:"digest"`
+			Tag    string `json:"tag"`
+		} `json:"resources"`
+		Repository struct {
+			RepoFullName string `json:"repo_full_name"`
+		} `json:"repository"`
+	} `json:"event_data"`
+}
+
+type quayPayload struct {
+	Repository  string   `json:"repository"`
+	UpdatedTags []string `json:"updated_tags"`
+}
+
+type jfrogPayload struct {
+	Domain    string `json:"domain"`
+	EventType string `json:"event_type"`
+	Data      struct {
+		ImageName string `json:"image_name"`
+		Tag       string `json:"tag"`
+		Sha256    string `json:"sha256"`
+	} `json:"data"`
+}
+
+type distributionPayload struct {
+	Events []struct {
+		Action string `json:"action"`
+		Target struct {
+			MediaType  string `json:"mediaType"`
+			Digest     string `json:"digest"`
+			Repository string `json:"repository"`
+			Tag        string `json:"tag"`
+		} `json:"target"`
+	} `json:"events"`
+}
+
+// Parse detects the registry of a webhook payload and returns its source
+// along with the images pushed, events other than pushes are ignored
+func Parse(body []byte) (string, []utils.RegistryPushedImage, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return "", nil, err
+	}
+
+	has := func(key string) bool {
+		_, ok := keys[key]
+		return ok
+	}
+
+	switch {
+	case has("events"):
+		images, err := parseDistribution(body)
+		return SourceDistribution, images, err
+	case has("push_data"):
+		images, err := parseDockerHub(body)
+		return SourceDockerHub, images, err
+	case has("event_data"):
+		images, err := parseHarbor(body)
+		return SourceHarbor, images, err
+	case has("updated_tags"):
+		images, err := parseQuay(body)
+		return SourceQuay, images, err
+	case has("domain") && has("data"):
+		images, err := parseJFrog(body)
+		return SourceJFrog, images, err
+	}
+	return "", nil, ErrUnknownPayload
+}
+
+func parseDockerHub(body []byte) ([]utils.RegistryPushedImage, error) {
+	var p dockerHubPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	if p.Repository.RepoName == "" || p.PushData.Tag == "" {
+		return nil, nil
+	}
+	return []utils.RegistryPushedImage{
+		{Repository: p.Repository.RepoName, Tag: p.PushData.Tag},
+	}, nil
+}
+
+func parseHarbor(body []byte) ([]utils.RegistryPushedImage, error) {
+	var p harborPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	if p.Type != "PUSH_ARTIFACT" && p.Type != "pushImage" {
+		return nil, nil
+	}
+	images := []utils.RegistryPushedImage{}
+	for _, r := range p.EventData.Resources {
+		images = append(images, utils.RegistryPushedImage{
+			Repository: p.EventData.Repository.RepoFullName,
+			Tag:        r.Tag,
+			Digest:     r.Digest,
+		})
+	}
+	return images, nil
+}
+
+func parseQuay(body []byte) ([]utils.RegistryPushedImage, error) {
+	var p quayPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	images := []utils.RegistryPushedImage{}
+	for _, tag := range p.UpdatedTags {
+		images = append(images, utils.RegistryPushedImage{Repository: p.Repository, Tag: tag})
+	}
+	return images, nil
+}
+
+func parseJFrog(body []byte) ([]utils.RegistryPushedImage, error) {
+	var p jfrogPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	if p.Domain != "docker" || p.EventType != "pushed" {
+		return nil, nil
+	}
+	image := utils.RegistryPushedImage{Repository: p.Data.ImageName, Tag: p.Data.Tag}
+	if p.Data.Sha256 != "" {
+		image.Digest = "sha256:" + p.Data.Sha256
+	}
+	return []utils.RegistryPushedImage{image}, nil
+}
+
+func parseDistribution(body []byte) ([]utils.RegistryPushedImage, error) {
+	var p distributionPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	images := []utils.RegistryPushedImage{}
+	for _, e := range p.Events {
+		// layer uploads are sent as push events too
+		if e.Action != "push" || !strings.Contains(e.Target.MediaType, "manifest") {
+			continue
+		}
+		images = append(images, utils.RegistryPushedImage{
+			Repository: e.Target.Repository,
+			Tag:        e.Target.Tag,
+			Digest:     e.Target.Digest,
+		})
+	}
+	return images, nil
+}
+
+// Matches reports if an image synced from the registry is the pushed image,
+// registries prefix repository names with their namespace inconsistently so
+// the names are matched on path boundaries
+func Matches(pushed utils.RegistryPushedImage, name, tag, digest string) bool {
+	if pushed.Tag != "" && pushed.Tag != tag {
+		return false
+	}
+	if pushed.Tag == "" && (pushed.Digest == "" || pushed.Digest != digest) {
+		return false
+	}
+	return name == pushed.Repository ||
+		strings.HasSuffix(name, "/"+pushed.Repository) ||
+		strings.HasSuffix(pushed.Repository, "/"+name)
+}
@@ -0,0 +1,123 @@
+package registrywebhook
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"gotest.tools/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		source  string
+		pushed  []utils.RegistryPushedImage
+		wantErr bool
+	}{
+		{
+			name:   "docker hub",
+			body:   `{"push_data":{"tag":"v1","pusher":"df"},"repository":{"repo_name":"deepfence/app","namespace":"deepfence","name":"app"}}`,
+			source: SourceDockerHub,
+			pushed: []utils.RegistryPushedImage{{Repository: "deepfence/app", Tag: "v1"}},
+		},
+		{
+			name: "harbor",
+			body: `{"type":"PUSH_ARTIFACT","event_data":{"resources":[{"digest":"sha256:abc","tag":"latest"}],
+				"repository":{"name":"app","namespace":"library","repo_full_name":"library/app"}}}`,
+			source: SourceHarbor,
+			pushed: []utils.RegistryPushedImage{{Repository: "library/app", Tag: "latest", Digest: "sha256:abc"}},
+		},
+		{
+			name:   "harbor delete",
+			body:   `{"type":"DELETE_ARTIFACT","event_data":{"resources":[{"tag":"latest"}],"repository":{"repo_full_name":"library/app"}}}`,
+			source: SourceHarbor,
+		},
+		{
+			name:   "quay",
+			body:   `{"repository":"deepfence/app","namespace":"deepfence","name":"app","updated_tags":["v1","v2"]}`,
+			source: SourceQuay,
+			pushed: []utils.RegistryPushedImage{
+				{Repository: "deepfence/app", Tag: "v1"},
+				{Repository: "deepfence/app", Tag: "v2"},
+			},
+		},
+		{
+			name: "jfrog",
+			body: `{"domain":"docker","event_type":"pushed","data":{"repo_key":"docker-local","image_name":"app",
+				"tag":"1.0","sha256":"abc"}}`,
+			source: SourceJFrog,
+			pushed: []utils.RegistryPushedImage{{Repository: "app", Tag: "1.0", Digest: "sha256:abc"}},
+		},
+		{
+			name: "distribution",
+			body: `{"events":[
+				{"action":"push","target":{"mediaType":"application/octet-stream","digest":"sha256:layer","repository":"group/app"}},
+				{"action":"push","target":{"mediaType":"application/vnd.docker.distribution.manifest.v2+json",
+					"digest":"sha256:abc","repository":"group/app","tag":"main"}},
+				{"action":"pull","target":{"mediaType":"application/vnd.docker.distribution.manifest.v2+json",
+					"digest":"sha256:abc","repository":"group/app","tag":"main"}}]}`,
+			source: SourceDistribution,
+			pushed: []utils.RegistryPushedImage{{Repository: "group/app", Tag: "main", Digest: "sha256:abc"}},
+		},
+		{
+			name:    "unknown",
+			body:    `{"hello":"world"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			body:    `[`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, pushed, err := Parse([]byte(tt.body))
+			if tt.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, source, tt.source)
+			assert.Equal(t, len(pushed), len(tt.pushed))
+			for i := range tt.pushed {
+				assert.DeepEqual(t, pushed[i], tt.pushed[i])
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	pushed := utils.RegistryPushedImage{Repository: "deepfence/app", Tag: "v1"}
+	assert.Assert(t, Matches(pushed, "deepfence/app", "v1", ""))
+	assert.Assert(t, Matches(pushed, "app", "v1", ""))
+	assert.Assert(t, Matches(pushed, "registry/deepfence/app", "v1", ""))
+	assert.Assert(t, !Matches(pushed, "deepfence/app", "v2", ""))
+	assert.Assert(t, !Matches(pushed, "myapp", "v1", ""))
+
+	byDigest := utils.RegistryPushedImage{Repository: "app", Digest: "sha256:abc"}
+	assert.Assert(t, Matches(byDigest, "app", "latest", "sha256:abc"))
+	assert.Assert(t, !Matches(byDigest, "app", "latest", "sha256:def"))
+}
+
+func TestToken(t *testing.T) {
+	token := NewToken()
+	hash := HashToken(token)
+	assert.Assert(t, VerifyToken(token, hash))
+	assert.Assert(t, !VerifyToken(NewToken(), hash))
+	assert.Assert(t, !VerifyToken("", hash))
+
+	r := httptest.NewRequest("POST", "/webhook?token=abc", nil)
+	assert.Equal(t, TokenFromRequest(r, ""), "")
+	r = httptest.NewRequest("POST", "/webhook", nil)
+	r.Header.Set("Authorization", "Bearer abc")
+	assert.Equal(t, TokenFromRequest(r, "def"), "abc")
+	r = httptest.NewRequest("POST", "/webhook", nil)
+	r.Header.Set("X-Gitlab-Token", "abc")
+	assert.Equal(t, TokenFromRequest(r, ""), "abc")
+	// docker hub and quay send the token in the url path
+	r = httptest.NewRequest("POST", "/webhook/def", nil)
+	assert.Equal(t, TokenFromRequest(r, "def"), "def")
+}
@@ -0,0 +1,216 @@
+// Package threatintel parses the EPSS scores and the CISA known exploited
+// vulnerabilities catalog used to prioritize vulnerabilities, the feeds are
+// uploaded to the console so air gapped consoles are enriched too
+package threatintel
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	FeedEPSS = "epss"
+	FeedKEV  = "kev"
+
+	// EPSSThreshold is the probability of exploitation in the next 30 days
+	// above which a vulnerability is considered likely exploited
+	EPSSThreshold = 0.1
+)
+
+var (
+	ThreatIntelStore = "threatintel"
+
+	ErrInvalidEPSS = errors.New("invalid EPSS file, expected the cve,epss,percentile csv")
+	ErrInvalidKEV  = errors.New("invalid KEV file, expected the CISA known exploited vulnerabilities json")
+)
+
+type UploadRequest struct {
+	EPSS multipart.File `formData:"epss" json:"epss"`
+	KEV  multipart.File `formData:"kev" json:"kev"`
+}
+
+type EPSS struct {
+	CVE        string  `json:"cve"`
+	Score      float64 `json:"epss_score"`
+	Percentile float64 `json:"epss_percentile"`
+}
+
+type KEV struct {
+	CVE           string `json:"cve"`
+	VendorProject string `json:"vendor_project"`
+	Product       string `json:"product"`
+	Name          string `json:"name"`
+	DateAdded     string `json:"date_added"`
+	DueDate       string `json:"due_date"`
+	Ransomware    bool   `json:"ransomware"`
+}
+
+type kevCatalog struct {
+	CatalogVersion  string `json:"catalogVersion"`
+	Vulnerabilities []struct {
+		CveID                      string `json:"cveID"`
+		VendorProject              string `json:"vendorProject"`
+		Product                    string `json:"product"`
+		VulnerabilityName          string `json:"vulnerabilityName"`
+		DateAdded                  string `json:"dateAdded"`
+		DueDate                    string `json:"dueDate"`
+		KnownRansomwareCampaignUse string `json:"knownRansomwareCampaignUse"`
+	} `json:"vulnerabilities"`
+}
+
+// maybeGunzip decompresses gzip files, the EPSS scores are published as
+// csv.gz and the KEV catalog as plain json
+func maybeGunzip(data []byte) (io.Reader, error) {
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		return gzip.NewReader(bytes.NewReader(data))
+	}
+	return bytes.NewReader(data), nil
+}
+
+// ParseEPSS parses the daily EPSS scores csv, the model version comment
+// preceding the header is skipped
+func ParseEPSS(data []byte) ([]EPSS, error) {
+	r, err := maybeGunzip(data)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil || b[0] != '#' {
+			break
+		}
+		if _, err := br.ReadString('\n'); err != nil {
+			return nil, ErrInvalidEPSS
+		}
+	}
+
+	cr := csv.NewReader(br)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, ErrInvalidEPSS
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.TrimSpace(h)] = i
+	}
+	cveCol, ok1 := cols["cve"]
+	scoreCol, ok2 := cols["epss"]
+	percentileCol, ok3 := cols["percentile"]
+	if !ok1 || !ok2 || !ok3 {
+		return nil, ErrInvalidEPSS
+	}
+
+	res := []EPSS{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEPSS, err)
+		}
+		score, err := strconv.ParseFloat(rec[scoreCol], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s score %v", ErrInvalidEPSS, rec[cveCol], err)
+		}
+		percentile, err := strconv.ParseFloat(rec[percentileCol], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s percentile %v", ErrInvalidEPSS, rec[cveCol], err)
+		}
+		res = append(res, EPSS{CVE: rec[cveCol], Score: score, Percentile: percentile})
+	}
+	return res, nil
+}
+
+// ParseKEV parses the CISA known exploited vulnerabilities catalog
+func ParseKEV(data []byte) ([]KEV, error) {
+	r, err := maybeGunzip(data)
+	if err != nil {
+		return nil, err
+	}
+	var catalog kevCatalog
+	if err := json.NewDecoder(r).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKEV, err)
+	}
+	if catalog.CatalogVersion == "" {
+		return nil, ErrInvalidKEV
+	}
+	res := []KEV{}
+	for _, v := range catalog.Vulnerabilities {
+		res = append(res, KEV{
+			CVE:           v.CveID,
+			VendorProject: v.VendorProject,
+			Product:       v.Product,
+			Name:          v.VulnerabilityName,
+			DateAdded:     v.DateAdded,
+			DueDate:       v.DueDate,
+			Ransomware:    strings.EqualFold(v.KnownRansomwareCampaignUse, "known"),
+		})
+	}
+	return res, nil
+}
+
+// Validate checks an uploaded feed can be parsed before it replaces the
+// stored one, the number of entries is returned
+func Validate(feed string, data []byte) (int, error) {
+	switch feed {
+	case FeedEPSS:
+		scores, err := ParseEPSS(data)
+		return len(scores), err
+	case FeedKEV:
+		kevs, err := ParseKEV(data)
+		return len(kevs), err
+	}
+	return 0, fmt.Errorf("unknown threat intel feed %s", feed)
+}
+
+func feedPath(feed string) string {
+	return path.Join(ThreatIntelStore, feed)
+}
+
+// Store replaces the feed in the file server, the feeds are shared by all
+// tenants like the vulnerability database
+func Store(ctx context.Context, feed string, data []byte) error {
+	ctx = directory.WithDatabaseContext(ctx)
+	mc, err := directory.MinioClient(ctx)
+	if err != nil {
+		return err
+	}
+	err = mc.DeleteFile(ctx, feedPath(feed), true, minio.RemoveObjectOptions{ForceDelete: true})
+	if err != nil {
+		return err
+	}
+	_, err = mc.UploadFile(ctx, feedPath(feed), data, minio.PutObjectOptions{})
+	return err
+}
+
+// Load returns the stored feed, nil when it was never uploaded
+func Load(ctx context.Context, feed string) ([]byte, error) {
+	ctx = directory.WithDatabaseContext(ctx)
+	mc, err := directory.MinioClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := mc.DownloadFileContexts(ctx, feedPath(feed), minio.GetObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,70 @@
+package threatintel
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const epssCSV = `#model_version:v2023.03.01,score_date:2023-12-01T00:00:00+0000
+cve,epss,percentile
+CVE-2021-44228,0.97565,0.99995
+CVE-2023-0001,0.00043,0.07829
+`
+
+const kevJSON = `{
+  "title": "CISA Catalog of Known Exploited Vulnerabilities",
+  "catalogVersion": "2023.12.01",
+  "count": 1,
+  "vulnerabilities": [
+    {
+      "cveID": "CVE-2021-44228",
+      "vendorProject": "Apache",
+      "product": "Log4j2",
+      "vulnerabilityName": "Apache Log4j2 Remote Code Execution Vulnerability",
+      "dateAdded": "2021-12-10",
+      "dueDate": "2021-12-24",
+      "knownRansomwareCampaignUse": "Known"
+    }
+  ]
+}`
+
+func TestParseEPSS(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, err := w.Write([]byte(epssCSV))
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+
+	for _, data := range [][]byte{[]byte(epssCSV), gz.Bytes()} {
+		scores, err := ParseEPSS(data)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, scores, []EPSS{
+			{CVE: "CVE-2021-44228", Score: 0.97565, Percentile: 0.99995},
+			{CVE: "CVE-2023-0001", Score: 0.00043, Percentile: 0.07829},
+		})
+	}
+
+	_, err = ParseEPSS([]byte("cve,score\nCVE-2023-0001,0.1\n"))
+	assert.Assert(t, errors.Is(err, ErrInvalidEPSS))
+}
+
+func TestParseKEV(t *testing.T) {
+	kevs, err := ParseKEV([]byte(kevJSON))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, kevs, []KEV{{
+		CVE:           "CVE-2021-44228",
+		VendorProject: "Apache",
+		Product:       "Log4j2",
+		Name:          "Apache Log4j2 Remote Code Execution Vulnerability",
+		DateAdded:     "2021-12-10",
+		DueDate:       "2021-12-24",
+		Ransomware:    true,
+	}})
+
+	_, err = ParseKEV([]byte(`{"vulnerabilities": []}`))
+	assert.Assert(t, errors.Is(err, ErrInvalidKEV))
+}
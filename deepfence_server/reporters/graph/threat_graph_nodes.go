@@ -17,6 +17,8 @@ type IndividualThreatGraphRequest struct {
 	GraphType string   `json:"graph_type" validate:"required,oneof=most_vulnerable_attack_paths direct_internet_exposure indirect_internet_exposure" required:"true" enum:"most_vulnerable_attack_paths,direct_internet_exposure,indirect_internet_exposure"`
 	NodeIds   []string `json:"node_ids" required:"false"`
 	IssueType string   `json:"issue_type" validate:"required,oneof=vulnerability secret malware compliance cloud_compliance" required:"true" enum:"vulnerability,secret,malware,compliance,cloud_compliance"`
+	// only known exploited vulnerabilities, for the vulnerability issue type
+	CisaKevOnly bool `json:"cisa_kev_only" required:"false"`
 }
 
 type IndividualThreatGraph struct {
@@ -58,7 +60,7 @@ func getNeo4jNodeIDField(v interface{}) string {
 	return "error_thread_graph_nodes"
 }
 
-func GetIndividualThreatGraph[T reporters.Cypherable](ctx context.Context, graphType string, selected_node_ids []string) ([]IndividualThreatGraph, error) {
+func GetIndividualThreatGraph[T reporters.Cypherable](ctx context.Context, graphType string, selected_node_ids []string, kevOnly bool) ([]IndividualThreatGraph, error) {
 	individualThreatGraph := []IndividualThreatGraph{}
 
 	driver, err := directory.Neo4jClient(ctx)
@@ -123,6 +125,12 @@ func GetIndividualThreatGraph[T reporters.Cypherable](ctx context.Context, graph
 		return individualThreatGraph, nil
 	}
 
+	resultFilter := ""
+	if kevOnly {
+		resultFilter = "WHERE v.cisa_kev = true"
+	}
+	// the most exploitable results first, likely exploited ones per EPSS
+	// break the ties of vulnerabilities
 	query = fmt.Sprintf(`
 		MATCH (n)
 		WHERE (n:Node OR n:Container) AND n.node_id IN $node_ids
@@ -130,12 +138,14 @@ func GetIndividualThreatGraph[T reporters.Cypherable](ctx context.Context, graph
 		CALL {
 		    WITH n
 		    MATCH (n) -[:SCANNED]- () -[:DETECTED]- (v:%s)
-		    WITH v limit 3
+		    %s
+		    WITH DISTINCT v
+		    ORDER BY v.exploitability_score DESC, v.epss_score DESC
+		    LIMIT 3
 		    RETURN v
-		    ORDER by v.exploitability_score DESC
 		}
 		RETURN n.node_id, collect(v.%s)
-	`, dummy.NodeType(), getNeo4jNodeIDField(dummy))
+	`, dummy.NodeType(), resultFilter, getNeo4jNodeIDField(dummy))
 
 	log.Debug().Msgf("q: %s", query)
 	res, err = tx.Run(query, map[string]interface{}{"node_ids": node_ids})
@@ -190,6 +200,9 @@ func GetIndividualThreatGraph[T reporters.Cypherable](ctx context.Context, graph
 	}
 
 	for _, node_id := range node_ids {
+		if kevOnly && len(cve_ids[node_id]) == 0 {
+			continue
+		}
 		output_ports := []interface{}{}
 		for port := range ports[node_id] {
 			output_ports = append(output_ports, port)
@@ -199,6 +199,21 @@ func GetContainerImagesReport(ctx context.Context, filter LookupFilter) ([]model
 		}
 	}
 
+	getPlatforms := true
+	if len(filter.InFieldFilter) > 0 {
+		getPlatforms = utils.InSlice("platforms", filter.InFieldFilter)
+	}
+
+	if getPlatforms == true {
+		platforms, matched, err := getContainerImagePlatforms(ctx, imagesIds)
+		if err == nil {
+			for _, platform := range platforms {
+				index = imageIdIndex[matched[platform.ID]]
+				images[index].Platforms = append(images[index].Platforms, platform)
+			}
+		}
+	}
+
 	return images, nil
 }
 
@@ -575,6 +590,14 @@ func getContainerImageContainers(ctx context.Context, ids []string) ([]model.Con
 		ids)
 }
 
+func getContainerImagePlatforms(ctx context.Context, ids []string) ([]model.ContainerImagePlatform, map[string]string, error) {
+	return getIndirectFromIDs[model.ContainerImagePlatform](ctx, `
+		MATCH (n:ContainerImage) -[:HAS_PLATFORM]-> (m:ContainerImage)
+		WHERE n.node_id IN $ids
+		RETURN m, n.node_id`,
+		ids)
+}
+
 func getClusterHosts(ctx context.Context, ids []string) ([]model.Host, map[string]string, error) {
 	return getIndirectFromIDs[model.Host](ctx, `
 		MATCH (n:KubernetesCluster) -[:INSTANCIATE]-> (m:Node)
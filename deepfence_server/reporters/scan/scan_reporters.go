@@ -70,7 +70,8 @@ func GetScanStatus(ctx context.Context, scan_type utils.Neo4jScanType, scan_ids
 	res, err := tx.Run(fmt.Sprintf(`
 		MATCH (m:%s) -[:SCANNED]-> (n)
 		WHERE m.node_id IN $scan_ids
-		RETURN m.node_id, m.status, m.status_message, n.node_id, n.node_name, labels(n) as node_type, m.updated_at`, scan_type),
+		RETURN m.node_id, m.status, m.status_message, n.node_id, n.node_name, labels(n) as node_type, m.updated_at,
+		COALESCE(m.image_digest, "")`, scan_type),
 		map[string]interface{}{"scan_ids": scan_ids})
 	if err != nil {
 		return model.ScanStatusResp{}, err
@@ -95,6 +96,7 @@ func extractStatuses(recs []*db.Record) map[string]model.ScanInfo {
 			NodeName:      rec.Values[4].(string),
 			NodeType:      Labels2NodeType(rec.Values[5].([]interface{})),
 			UpdatedAt:     rec.Values[6].(int64),
+			ImageDigest:   rec.Values[7].(string),
 		}
 		statuses[rec.Values[0].(string)] = info
 	}
@@ -218,6 +220,83 @@ func GetRegistriesImageIDs(ctx context.Context, registryIds []model.NodeIdentifi
 	return res, nil
 }
 
+// GetImagePlatformIDs replaces the manifest lists in the nodes with their
+// images of the platform, or of every platform if platform is all. Single
+// platform images are kept when their platform matches or is unknown.
+func GetImagePlatformIDs(ctx context.Context, nodes []model.NodeIdentifier, platform string) ([]model.NodeIdentifier, error) {
+	res := []model.NodeIdentifier{}
+	imageIds := []string{}
+	for _, node := range nodes {
+		if node.NodeType == "image" {
+			imageIds = append(imageIds, node.NodeId)
+		} else {
+			res = append(res, node)
+		}
+	}
+	if len(imageIds) == 0 {
+		return res, nil
+	}
+
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	if err != nil {
+		return res, err
+	}
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return res, err
+	}
+	defer tx.Close()
+
+	nres, err := tx.Run(`
+		MATCH (n:ContainerImage)
+		WHERE n.node_id IN $node_ids
+		OPTIONAL MATCH (n) -[:HAS_PLATFORM]-> (p:ContainerImage)
+		WITH n, collect(p) as platforms
+		WITH n, platforms, [p IN platforms WHERE $platform = 'all' OR p.platform = $platform | p.node_id] as matched
+		RETURN n.node_id, size(platforms) > 0, COALESCE(n.platform, ''), matched`,
+		map[string]interface{}{"node_ids": imageIds, "platform": platform})
+	if err != nil {
+		return res, err
+	}
+
+	recs, err := nres.Collect()
+	if err != nil {
+		return res, err
+	}
+
+	seen := map[string]struct{}{}
+	add := func(id string) {
+		if _, has := seen[id]; has {
+			return
+		}
+		seen[id] = struct{}{}
+		res = append(res, model.NodeIdentifier{NodeId: id, NodeType: "image"})
+	}
+	for _, rec := range recs {
+		id := rec.Values[0].(string)
+		isManifestList := rec.Values[1].(bool)
+		if !isManifestList {
+			imagePlatform := rec.Values[2].(string)
+			if platform == "all" || imagePlatform == "" || imagePlatform == platform {
+				add(id)
+			}
+			continue
+		}
+		for _, m := range rec.Values[3].([]interface{}) {
+			add(m.(string))
+		}
+	}
+
+	return res, nil
+}
+
 func GetKubernetesImageIDs(ctx context.Context, k8sIds []model.NodeIdentifier) ([]model.NodeIdentifier, error) {
 	res := []model.NodeIdentifier{}
 	driver, err := directory.Neo4jClient(ctx)
@@ -487,13 +566,15 @@ func GetScansList(ctx context.Context, scan_type utils.Neo4jScanType, node_ids [
 			WHERE n.node_id IN $node_ids
 			AND (` + strings.Join(node_types_str, " OR ") + `)
 			` + reporters.ParseFieldFilters2CypherWhereConditions("m", mo.Some(ff), false) + `
-			RETURN m.node_id, m.status, m.status_message, m.updated_at, n.node_id, n.node_name, labels(n) as node_type
+			RETURN m.node_id, m.status, m.status_message, m.updated_at, n.node_id, n.node_name, labels(n) as node_type,
+			COALESCE(m.image_digest, "")
 			ORDER BY m.updated_at ` + fw.FetchWindow2CypherQuery()
 	} else {
 		query = `
 			MATCH (m:` + string(scan_type) + `) -[:SCANNED]-> (n)
 			` + reporters.ParseFieldFilters2CypherWhereConditions("m", mo.Some(ff), true) + `
-			RETURN m.node_id, m.status, m.status_message, m.updated_at, n.node_id, n.node_name, labels(n) as node_type
+			RETURN m.node_id, m.status, m.status_message, m.updated_at, n.node_id, n.node_name, labels(n) as node_type,
+			COALESCE(m.image_digest, "")
 			ORDER BY m.updated_at ` + fw.FetchWindow2CypherQuery()
 	}
 	scansInfo, err = processScansListQuery(query, node_ids_str, tx)
@@ -525,6 +606,7 @@ func processScansListQuery(query string, nodeIds []string, tx neo4j.Transaction)
 			NodeId:        rec.Values[4].(string),
 			NodeName:      rec.Values[5].(string),
 			NodeType:      Labels2NodeType(rec.Values[6].([]interface{})),
+			ImageDigest:   rec.Values[7].(string),
 		}
 		scansInfo = append(scansInfo, tmp)
 	}
@@ -568,48 +650,78 @@ func GetCloudCompliancePendingScansList(ctx context.Context, scanType utils.Neo4
 	}, nil
 }
 
-func GetScanResultDiff[T any](ctx context.Context, scan_type utils.Neo4jScanType, baseScanID, compareToScanID string, ff reporters.FieldsFilters, fw model.FetchWindow) ([]T, error) {
-	res := []T{}
-	driver, err := directory.Neo4jClient(ctx)
-	if err != nil {
-		return res, err
-	}
-
-	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
-	if err != nil {
-		return res, err
-	}
-	defer session.Close()
-
-	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
-	if err != nil {
-		return res, err
-	}
-	defer tx.Close()
-
+func scanExists(tx neo4j.Transaction, scan_type utils.Neo4jScanType, scanID string) error {
 	query := fmt.Sprintf(`
-		OPTIONAL MATCH (n:%s{node_id:$base_scan_id})
+		OPTIONAL MATCH (n:%s{node_id:$scan_id})
 		RETURN n IS NOT NULL AS Exists`,
 		scan_type)
 	log.Debug().Msgf("query: %v", query)
 	r, err := tx.Run(query,
 		map[string]interface{}{
-			"base_scan_id": baseScanID,
+			"scan_id": scanID,
 		})
 	if err != nil {
-		return res, err
+		return err
 	}
 
 	rec, err := r.Single()
 	if err != nil {
-		return res, err
+		return err
 	}
 
 	if !rec.Values[0].(bool) {
-		return res, &NodeNotFoundError{
-			node_id: baseScanID,
+		return &NodeNotFoundError{
+			node_id: scanID,
 		}
 	}
+	return nil
+}
+
+// scanResultKey returns the cypher expression identifying a result across
+// scans, vulnerabilities, secrets and malware are shared result nodes keyed on
+// package or file and rule, compliance results are created per node and cloud
+// compliance results per scan, they are keyed on their benchmark, control and
+// resource instead as benchmarks reuse control numbers
+func scanResultKey(scan_type utils.Neo4jScanType, node string) string {
+	field := func(k string) string {
+		return "coalesce(toString(" + node + "." + k + "), '')"
+	}
+	switch scan_type {
+	case utils.NEO4J_COMPLIANCE_SCAN:
+		return field("compliance_check_type") + " + '--' + " + field("test_number") + " + '--' + " + field("resource")
+	case utils.NEO4J_CLOUD_COMPLIANCE_SCAN:
+		return field("compliance_check_type") + " + '--' + " + field("control_id") + " + '--' + " + field("resource")
+	}
+	return node + ".node_id"
+}
+
+// scanResultsDiffQuery matches the filtered results of $scan_id merged with
+// their rule as d, which have a result of the same key in $other_scan_id when
+// detected, or none otherwise, only the keys of the other scan are collected
+func scanResultsDiffQuery(scan_type utils.Neo4jScanType, ff reporters.FieldsFilters, detected bool) string {
+	not := "NOT "
+	if detected {
+		not = ""
+	}
+	return `
+	MATCH (:` + string(scan_type) + `{node_id: $other_scan_id}) -[ro:DETECTED]-> (o)
+	OPTIONAL MATCH (o) -[:IS]-> (eo)
+	WITH apoc.map.merge( eo{.*}, o{.*, masked: coalesce(o.masked or ro.masked, false), name: coalesce(eo.name, o.name, '')}) AS o` +
+		reporters.ParseFieldFilters2CypherWhereConditions("o", mo.Some(ff), true) + `
+	WITH collect(DISTINCT ` + scanResultKey(scan_type, "o") + `) AS keys
+	MATCH (:` + string(scan_type) + `{node_id: $scan_id}) -[r:DETECTED]-> (d)
+	OPTIONAL MATCH (d) -[:IS]-> (e)
+	WITH keys, apoc.map.merge( e{.*}, d{.*, masked: coalesce(d.masked or r.masked, false), name: coalesce(e.name, d.name, '')}) AS d
+	WHERE ` + not + scanResultKey(scan_type, "d") + ` IN keys` +
+		reporters.ParseFieldFilters2CypherWhereConditions("d", mo.Some(ff), false)
+}
+
+// getScanResultsDiff returns the window of the results of the scan with, or
+// without, a result of the same key in the other scan
+func getScanResultsDiff[T any](tx neo4j.Transaction, scan_type utils.Neo4jScanType, scanID, otherScanID string,
+	detected bool, ff reporters.FieldsFilters, fw model.FetchWindow) ([]T, error) {
+
+	res := []T{}
 
 	ffCondition := reporters.OrderFilter2CypherCondition("d", ff.OrderFilter, nil)
 
@@ -621,19 +733,14 @@ func GetScanResultDiff[T any](ctx context.Context, scan_type utils.Neo4jScanType
 		}
 	}
 
-	query = `
-	MATCH (n:` + string(scan_type) + `{node_id: $base_scan_id}) -[r:DETECTED]-> (d)
-	WHERE NOT EXISTS {MATCH (m:` + string(scan_type) + `{node_id: $compare_to_scan_id}) -[:DETECTED]-> (d)}
-	OPTIONAL MATCH (d) -[:IS]-> (e)
-	WITH apoc.map.merge( e{.*}, d{.*, masked: coalesce(d.masked or r.masked, false), name: coalesce(e.name, d.name, '')}) AS d` +
-		reporters.ParseFieldFilters2CypherWhereConditions("d", mo.Some(ff), true) +
+	query := scanResultsDiffQuery(scan_type, ff, detected) +
 		ffCondition + ` RETURN d ` +
 		fw.FetchWindow2CypherQuery()
 	log.Debug().Msgf("diff query: %v", query)
 	nres, err := tx.Run(query,
 		map[string]interface{}{
-			"base_scan_id":       baseScanID,
-			"compare_to_scan_id": compareToScanID,
+			"scan_id":       scanID,
+			"other_scan_id": otherScanID,
 		})
 	if err != nil {
 		return res, err
@@ -649,10 +756,209 @@ func GetScanResultDiff[T any](ctx context.Context, scan_type utils.Neo4jScanType
 		utils.FromMap(rec.Values[0].(map[string]interface{}), &tmp)
 		res = append(res, tmp)
 	}
+	return res, nil
+}
+
+// countScanResultsDiff counts the results of getScanResultsDiff per severity
+func countScanResultsDiff(tx neo4j.Transaction, scan_type utils.Neo4jScanType, scanID, otherScanID string,
+	detected bool, ff reporters.FieldsFilters) (map[string]int32, error) {
+
+	res := map[string]int32{}
+
+	query := scanResultsDiffQuery(scan_type, ff, detected) + `
+	RETURN coalesce(toString(d.` + type2sev_field(scan_type) + `), ''), count(*)`
+	log.Debug().Msgf("diff count query: %v", query)
+	nres, err := tx.Run(query,
+		map[string]interface{}{
+			"scan_id":       scanID,
+			"other_scan_id": otherScanID,
+		})
+	if err != nil {
+		return res, err
+	}
+
+	recs, err := nres.Collect()
+	if err != nil {
+		return res, err
+	}
+
+	for _, rec := range recs {
+		res[rec.Values[0].(string)] = int32(rec.Values[1].(int64))
+	}
+	return res, nil
+}
+
+// GetScanResultDiff returns the results of the base scan with no result of
+// the same key in the compare to scan
+func GetScanResultDiff[T any](ctx context.Context, scan_type utils.Neo4jScanType, baseScanID, compareToScanID string, ff reporters.FieldsFilters, fw model.FetchWindow) ([]T, error) {
+	res := []T{}
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	if err != nil {
+		return res, err
+	}
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return res, err
+	}
+	defer tx.Close()
+
+	if err := scanExists(tx, scan_type, baseScanID); err != nil {
+		return res, err
+	}
+
+	return getScanResultsDiff[T](tx, scan_type, baseScanID, compareToScanID, false, ff, fw)
+}
+
+// GetScanResultComparison compares the results of two scans on their key,
+// scans of different nodes can be compared, added results are detected by the
+// to scan only, removed ones by the base scan only, and unchanged are the
+// results of the to scan also detected by the base scan, the window applies to
+// each list while the severity counts are of all results
+func GetScanResultComparison[T any](ctx context.Context, scan_type utils.Neo4jScanType, baseScanID, toScanID string, ff reporters.FieldsFilters, fw model.FetchWindow) (model.ScanComparisonRes[T], error) {
+	res := model.ScanComparisonRes[T]{
+		BaseScanID: baseScanID,
+		ToScanID:   toScanID,
+	}
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return res, err
+	}
+	defer tx.Close()
+
+	for _, scanID := range []string{baseScanID, toScanID} {
+		if err := scanExists(tx, scan_type, scanID); err != nil {
+			return res, err
+		}
+	}
 
+	if res.Added, err = getScanResultsDiff[T](tx, scan_type, toScanID, baseScanID, false, ff, fw); err != nil {
+		return res, err
+	}
+	if res.Removed, err = getScanResultsDiff[T](tx, scan_type, baseScanID, toScanID, false, ff, fw); err != nil {
+		return res, err
+	}
+	if res.Unchanged, err = getScanResultsDiff[T](tx, scan_type, toScanID, baseScanID, true, ff, fw); err != nil {
+		return res, err
+	}
+	if res.AddedSeverityCounts, err = countScanResultsDiff(tx, scan_type, toScanID, baseScanID, false, ff); err != nil {
+		return res, err
+	}
+	if res.RemovedSeverityCounts, err = countScanResultsDiff(tx, scan_type, baseScanID, toScanID, false, ff); err != nil {
+		return res, err
+	}
+	if res.UnchangedSeverityCounts, err = countScanResultsDiff(tx, scan_type, toScanID, baseScanID, true, ff); err != nil {
+		return res, err
+	}
 	return res, nil
 }
 
+// GetLatestScanID returns the latest completed scan of the node
+func GetLatestScanID(ctx context.Context, scan_type utils.Neo4jScanType, node_id string) (string, error) {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return "", err
+	}
+	defer tx.Close()
+
+	query := `
+	MATCH (s:` + string(scan_type) + `{status: $status}) -[:SCANNED]-> (n{node_id: $node_id})
+	RETURN s.node_id
+	ORDER BY s.updated_at DESC
+	LIMIT 1`
+	log.Debug().Msgf("latest scan query: %v", query)
+	res, err := tx.Run(query,
+		map[string]interface{}{
+			"node_id": node_id,
+			"status":  utils.SCAN_STATUS_SUCCESS,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	recs, err := res.Collect()
+	if err != nil {
+		return "", err
+	}
+	if len(recs) == 0 {
+		return "", &NodeNotFoundError{
+			node_id: node_id,
+		}
+	}
+
+	return recs[0].Values[0].(string), nil
+}
+
+// GetPreviousScanID returns the latest completed scan of the same node
+// before the given scan, empty if there is none
+func GetPreviousScanID(ctx context.Context, scan_type utils.Neo4jScanType, scan_id string) (string, error) {
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	tx, err := session.BeginTransaction(neo4j.WithTxTimeout(30 * time.Second))
+	if err != nil {
+		return "", err
+	}
+	defer tx.Close()
+
+	query := `
+	MATCH (s:` + string(scan_type) + `{node_id: $scan_id}) -[:SCANNED]-> (n)
+	MATCH (p:` + string(scan_type) + `{status: $status}) -[:SCANNED]-> (n)
+	WHERE p.updated_at < s.updated_at
+	RETURN p.node_id
+	ORDER BY p.updated_at DESC
+	LIMIT 1`
+	log.Debug().Msgf("previous scan query: %v", query)
+	res, err := tx.Run(query,
+		map[string]interface{}{
+			"scan_id": scan_id,
+			"status":  utils.SCAN_STATUS_SUCCESS,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	recs, err := res.Collect()
+	if err != nil {
+		return "", err
+	}
+	if len(recs) == 0 {
+		return "", nil
+	}
+
+	return recs[0].Values[0].(string), nil
+}
+
 func GetScanResults[T any](ctx context.Context, scan_type utils.Neo4jScanType, scan_id string, ff reporters.FieldsFilters, fw model.FetchWindow) ([]T, model.ScanResultsCommon, error) {
 	res := []T{}
 	common := model.ScanResultsCommon{}
@@ -1059,7 +1365,8 @@ func GetBulkScans(ctx context.Context, scan_type utils.Neo4jScanType, scan_id st
 
 	neo_res, err := tx.Run(`
 		MATCH (m:Bulk`+string(scan_type)+`{node_id:$scan_id}) -[:BATCH]-> (d:`+string(scan_type)+`) -[:SCANNED]-> (n)
-		RETURN d.node_id as scan_id, d.status, d.status_message, n.node_id as node_id, n.node_name, labels(n) as node_type, d.updated_at`,
+		RETURN d.node_id as scan_id, d.status, d.status_message, n.node_id as node_id, n.node_name, labels(n) as node_type, d.updated_at,
+		COALESCE(d.image_digest, "")`,
 		map[string]interface{}{"scan_id": scan_id})
 	if err != nil {
 		return scan_ids, err
@@ -0,0 +1,44 @@
+package reporters_scan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/reporters"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"gotest.tools/assert"
+)
+
+func TestScanResultKey(t *testing.T) {
+	// shared result nodes are the same across scans
+	assert.Equal(t, scanResultKey(utils.NEO4J_VULNERABILITY_SCAN, "d"), "d.node_id")
+	assert.Equal(t, scanResultKey(utils.NEO4J_SECRET_SCAN, "o"), "o.node_id")
+
+	// compliance results are created per node or scan, benchmarks reuse
+	// test numbers on the same resource
+	assert.Equal(t, scanResultKey(utils.NEO4J_COMPLIANCE_SCAN, "d"),
+		"coalesce(toString(d.compliance_check_type), '') + '--' + "+
+			"coalesce(toString(d.test_number), '') + '--' + coalesce(toString(d.resource), '')")
+	assert.Equal(t, scanResultKey(utils.NEO4J_CLOUD_COMPLIANCE_SCAN, "o"),
+		"coalesce(toString(o.compliance_check_type), '') + '--' + "+
+			"coalesce(toString(o.control_id), '') + '--' + coalesce(toString(o.resource), '')")
+}
+
+func TestScanResultsDiffQuery(t *testing.T) {
+	ff := reporters.FieldsFilters{
+		ContainsFilter: reporters.ContainsFilter{
+			FieldsValues: map[string][]interface{}{"cve_severity": {"critical"}},
+		},
+	}
+
+	query := scanResultsDiffQuery(utils.NEO4J_VULNERABILITY_SCAN, ff, false)
+	// only the keys of the other scan are collected, not its results
+	assert.Assert(t, strings.Contains(query, "WITH collect(DISTINCT o.node_id) AS keys"), query)
+	assert.Assert(t, strings.Contains(query, "WHERE NOT d.node_id IN keys"), query)
+	// filters apply to both scans
+	assert.Assert(t, strings.Contains(query, "o.cve_severity"), query)
+	assert.Assert(t, strings.Contains(query, "d.cve_severity"), query)
+
+	query = scanResultsDiffQuery(utils.NEO4J_VULNERABILITY_SCAN, reporters.FieldsFilters{}, true)
+	assert.Assert(t, strings.Contains(query, "WHERE d.node_id IN keys"), query)
+}
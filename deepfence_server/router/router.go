@@ -165,6 +165,10 @@ func SetupRoutes(r *chi.Mux, serverPort string, serveOpenapiDocs bool, ingestC c
 
 			r.Get("/end-user-license-agreement", dfHandler.EULAHandler)
 
+			// registry push events, authenticated with the webhook token
+			r.Post("/registry-webhook/{registry_id}", dfHandler.RegistryWebhookHandler)
+			r.Post("/registry-webhook/{registry_id}/{token}", dfHandler.RegistryWebhookHandler)
+
 			if serveOpenapiDocs {
 				log.Info().Msgf("OpenAPI documentation: http://0.0.0.0%s/deepfence/openapi.json", serverPort)
 				log.Info().Msgf("Swagger UI : http://0.0.0.0%s/deepfence/swagger-ui/", serverPort)
@@ -217,6 +221,12 @@ func SetupRoutes(r *chi.Mux, serverPort string, serveOpenapiDocs bool, ingestC c
 				r.Post("/email", dfHandler.AuthHandler(ResourceSettings, PermissionWrite, dfHandler.AddEmailConfiguration))
 				r.Get("/email", dfHandler.AuthHandler(ResourceSettings, PermissionRead, dfHandler.GetEmailConfiguration))
 				r.Delete("/email/{config_id}", dfHandler.AuthHandler(ResourceSettings, PermissionDelete, dfHandler.DeleteEmailConfiguration))
+				r.Route("/sla-policies", func(r chi.Router) {
+					r.Get("/", dfHandler.AuthHandler(ResourceSettings, PermissionRead, dfHandler.ListSLAPolicies))
+					r.Post("/", dfHandler.AuthHandler(ResourceSettings, PermissionWrite, dfHandler.AddSLAPolicy))
+					r.Put("/{policy_id}", dfHandler.AuthHandler(ResourceSettings, PermissionWrite, dfHandler.UpdateSLAPolicy))
+					r.Delete("/{policy_id}", dfHandler.AuthHandler(ResourceSettings, PermissionDelete, dfHandler.DeleteSLAPolicy))
+				})
 			})
 
 			r.Route("/graph", func(r chi.Router) {
@@ -407,6 +417,14 @@ func SetupRoutes(r *chi.Mux, serverPort string, serveOpenapiDocs bool, ingestC c
 				r.Post("/cloud-compliance", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.DiffAddCloudComplianceScan))
 			})
 
+			r.Route("/diff", func(r chi.Router) {
+				r.Post("/vulnerability", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.CompareVulnerabilityScans))
+				r.Post("/secret", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.CompareSecretScans))
+				r.Post("/compliance", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.CompareComplianceScans))
+				r.Post("/malware", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.CompareMalwareScans))
+				r.Post("/cloud-compliance", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.CompareCloudComplianceScans))
+			})
+
 			r.Route("/filters", func(r chi.Router) {
 				r.Post("/cloud-compliance", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.CloudComplianceFiltersHandler))
 				r.Post("/compliance", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.ComplianceFiltersHandler))
@@ -419,6 +437,19 @@ func SetupRoutes(r *chi.Mux, serverPort string, serveOpenapiDocs bool, ingestC c
 				r.Post("/notify", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.ScanResultNotifyHandler))
 			})
 
+			r.Route("/scan/results/exceptions", func(r chi.Router) {
+				r.Get("/", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.ListScanResultExceptions))
+				r.Post("/", dfHandler.AuthHandler(ResourceScanReport, PermissionWrite, dfHandler.AddScanResultException))
+				r.Get("/export", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.ExportScanResultExceptions))
+				r.Put("/{exception_id}", dfHandler.AuthHandler(ResourceScanReport, PermissionWrite, dfHandler.UpdateScanResultException))
+				r.Delete("/{exception_id}", dfHandler.AuthHandler(ResourceScanReport, PermissionDelete, dfHandler.DeleteScanResultException))
+			})
+
+			r.Route("/scan/results/lifecycle", func(r chi.Router) {
+				r.Post("/", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.ListFindingLifecycles))
+				r.Post("/mttr", dfHandler.AuthHandler(ResourceScanReport, PermissionRead, dfHandler.GetFindingMTTR))
+			})
+
 			r.Post("/scans/bulk/delete", dfHandler.AuthHandler(ResourceScanReport, PermissionDelete, dfHandler.BulkDeleteScans))
 
 			r.Route("/scan/{scan_type}/{scan_id}", func(r chi.Router) {
@@ -443,9 +474,29 @@ func SetupRoutes(r *chi.Mux, serverPort string, serveOpenapiDocs bool, ingestC c
 					r.Delete("/", dfHandler.AuthHandler(ResourceRegistry, PermissionDelete, dfHandler.DeleteRegistry))
 					r.Get("/summary", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.RegistrySummary))
 					r.Post("/sync", dfHandler.AuthHandler(ResourceRegistry, PermissionWrite, dfHandler.RefreshRegistry))
+					r.Put("/sync-options", dfHandler.AuthHandler(ResourceRegistry, PermissionWrite, dfHandler.UpdateRegistrySyncOptions))
+					r.Post("/tag-history", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.GetImageTagHistory))
+					r.Get("/credentials", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.GetRegistryCredentialStatus))
+					r.Post("/credentials/check", dfHandler.AuthHandler(ResourceRegistry, PermissionWrite, dfHandler.CheckRegistryCredentials))
+					r.Get("/artifacts", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.ListRegistryArtifacts))
+					r.Route("/scan-policies", func(r chi.Router) {
+						r.Get("/", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.ListRegistryScanPolicies))
+						r.Post("/", dfHandler.AuthHandler(ResourceRegistry, PermissionWrite, dfHandler.CreateRegistryScanPolicy))
+						r.Put("/{policy_id}", dfHandler.AuthHandler(ResourceRegistry, PermissionWrite, dfHandler.UpdateRegistryScanPolicy))
+						r.Delete("/{policy_id}", dfHandler.AuthHandler(ResourceRegistry, PermissionDelete, dfHandler.DeleteRegistryScanPolicy))
+					})
+					r.Route("/webhook", func(r chi.Router) {
+						r.Get("/", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.GetRegistryWebhook))
+						r.Post("/", dfHandler.AuthHandler(ResourceRegistry, PermissionWrite, dfHandler.CreateRegistryWebhook))
+						r.Delete("/", dfHandler.AuthHandler(ResourceRegistry, PermissionDelete, dfHandler.DeleteRegistryWebhook))
+					})
 				})
 				r.Post("/images", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.ListImages))
 				r.Post("/stubs", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.ListImageStubs))
+				r.Post("/images/digest", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.GetImagesByDigest))
+				r.Post("/images/native-vulnerabilities", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.CompareNativeVulnerabilities))
+				r.Post("/artifacts/scan", dfHandler.AuthHandler(ResourceScan, PermissionStart, dfHandler.ScanRegistryArtifacts))
+				r.Get("/tag-history/{history_id}/changes", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.GetImageTagChange))
 				// count api
 				r.Route("/count", func(r chi.Router) {
 					r.Post("/images", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.CountImages))
@@ -453,6 +504,12 @@ func SetupRoutes(r *chi.Mux, serverPort string, serveOpenapiDocs bool, ingestC c
 				})
 			})
 
+			r.Route("/source-repository", func(r chi.Router) {
+				r.Get("/", dfHandler.AuthHandler(ResourceRegistry, PermissionRead, dfHandler.ListSourceRepositories))
+				r.Post("/", dfHandler.AuthHandler(ResourceRegistry, PermissionWrite, dfHandler.AddSourceRepository))
+				r.Delete("/{repository_id}", dfHandler.AuthHandler(ResourceRegistry, PermissionDelete, dfHandler.DeleteSourceRepository))
+			})
+
 			r.Route("/diagnosis", func(r chi.Router) {
 				r.Get("/notification", dfHandler.AuthHandler(ResourceDiagnosis, PermissionRead, dfHandler.DiagnosticNotification))
 				r.Post("/console-logs", dfHandler.AuthHandler(ResourceDiagnosis, PermissionGenerate, dfHandler.GenerateConsoleDiagnosticLogs))
@@ -481,15 +538,18 @@ func SetupRoutes(r *chi.Mux, serverPort string, serveOpenapiDocs bool, ingestC c
 			r.Route("/integration", func(r chi.Router) {
 				r.Post("/", dfHandler.AuthHandler(ResourceIntegration, PermissionWrite, dfHandler.AddIntegration))
 				r.Get("/", dfHandler.AuthHandler(ResourceIntegration, PermissionRead, dfHandler.GetIntegrations))
+				r.Post("/preview", dfHandler.AuthHandler(ResourceIntegration, PermissionRead, dfHandler.PreviewIntegrationTemplate))
 				r.Route("/{integration_id}", func(r chi.Router) {
 					r.Delete("/", dfHandler.AuthHandler(ResourceIntegration, PermissionDelete, dfHandler.DeleteIntegration))
-					r.Put("/", dfHandler.AuthHandler(ResourceIntegration, PermissionUpdate, doNothingHandler))
+					r.Put("/", dfHandler.AuthHandler(ResourceIntegration, PermissionUpdate, dfHandler.UpdateIntegration))
+					r.Post("/test", dfHandler.AuthHandler(ResourceIntegration, PermissionWrite, dfHandler.TestIntegration))
 				})
 			})
 
 			// vulnerability db management
 			r.Route("/database", func(r chi.Router) {
 				r.Put("/vulnerability", dfHandler.AuthHandler(ResourceSettings, PermissionWrite, dfHandler.UploadVulnerabilityDB))
+				r.Put("/threat-intel", dfHandler.AuthHandler(ResourceSettings, PermissionWrite, dfHandler.UploadThreatIntel))
 			})
 
 		})
@@ -30,6 +30,8 @@ const (
 	KubernetesCluster
 	RegistryAccount
 	Pod
+	SourceRepository
+	RegistryArtifact
 )
 
 func ResourceTypeToNeo4j(t ScanResource) string {
@@ -48,6 +50,10 @@ func ResourceTypeToNeo4j(t ScanResource) string {
 		return "RegistryAccount"
 	case Pod:
 		return "Pod"
+	case SourceRepository:
+		return "SourceRepository"
+	case RegistryArtifact:
+		return "RegistryArtifact"
 	}
 	return ""
 }
@@ -68,6 +74,10 @@ func ResourceTypeToString(t ScanResource) string {
 		return "registry"
 	case Pod:
 		return "pod"
+	case SourceRepository:
+		return "source_repository"
+	case RegistryArtifact:
+		return "registry_artifact"
 	}
 	return ""
 }
@@ -90,6 +100,10 @@ func StringToResourceType(s string) ScanResource {
 		return CloudAccount
 	case "pod":
 		return Pod
+	case "source_repository":
+		return SourceRepository
+	case "registry_artifact":
+		return RegistryArtifact
 	}
 	return -1
 }
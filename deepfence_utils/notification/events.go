@@ -0,0 +1,109 @@
+// Package notification records operational events, integrations subscribed
+// to the Event resource send them on the next notification run
+package notification
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// EventResource is the integration notification type for events
+const EventResource = "Event"
+
+// event types
+const (
+	ScanFailed                  = "scan_failed"
+	AgentInactive               = "agent_inactive"
+	AgentUpgradeFailed          = "agent_upgrade_failed"
+	RegistrySyncFailed          = "registry_sync_failed"
+	RegistryCredentialsInvalid  = "registry_credentials_invalid"
+	RegistryCredentialsExpiring = "registry_credentials_expiring"
+	VulnerabilityDBUpdateFailed = "vulnerability_db_update_failed"
+	ExceptionExpired            = "exception_expired"
+	SLABreached                 = "sla_breached"
+)
+
+var EventTypes = []string{
+	ScanFailed,
+	AgentInactive,
+	AgentUpgradeFailed,
+	RegistrySyncFailed,
+	RegistryCredentialsInvalid,
+	RegistryCredentialsExpiring,
+	VulnerabilityDBUpdateFailed,
+	ExceptionExpired,
+	SLABreached,
+}
+
+type Event struct {
+	EventType string
+	NodeID    string
+	NodeType  string
+	Message   string
+	Details   map[string]interface{}
+}
+
+// Publish records the event in the namespace of the context, errors are
+// logged as events must not fail the operation reporting them
+func Publish(ctx context.Context, e Event) {
+	if err := publish(ctx, e); err != nil {
+		log.Error().Msgf("failed to publish %s event: %v", e.EventType, err)
+	}
+}
+
+// PublishAll records the event in every namespace, used for events of
+// resources shared by all tenants like the vulnerability database
+func PublishAll(e Event) {
+	directory.ForEachNamespace(func(ctx context.Context) (string, error) {
+		return "publish " + e.EventType + " event", publish(ctx, e)
+	})
+}
+
+// PublishRecords publishes an event per record of a query, node_id,
+// node_type and message columns fill the event, other columns are details
+func PublishRecords(ctx context.Context, eventType string, recs []*neo4j.Record) {
+	for _, rec := range recs {
+		e := Event{EventType: eventType, Details: map[string]interface{}{}}
+		for i, key := range rec.Keys {
+			switch key {
+			case "node_id":
+				e.NodeID, _ = rec.Values[i].(string)
+			case "node_type":
+				e.NodeType, _ = rec.Values[i].(string)
+			case "message":
+				e.Message, _ = rec.Values[i].(string)
+			default:
+				e.Details[key] = rec.Values[i]
+			}
+		}
+		Publish(ctx, e)
+	}
+}
+
+func publish(ctx context.Context, e Event) error {
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if e.Details == nil {
+		e.Details = map[string]interface{}{}
+	}
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return err
+	}
+
+	return pgClient.CreateNotificationEvent(ctx, postgresqlDb.CreateNotificationEventParams{
+		EventType: e.EventType,
+		NodeID:    e.NodeID,
+		NodeType:  e.NodeType,
+		Message:   e.Message,
+		Details:   details,
+	})
+}
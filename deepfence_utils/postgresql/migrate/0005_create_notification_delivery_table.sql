@@ -0,0 +1,52 @@
+-- +goose Up
+
+-- +goose StatementBegin
+ALTER TABLE integration
+    ADD COLUMN last_scan_cursor bigint NULL;
+-- last_scan_cursor: updated_at (epoch ms) of the newest scan queued for delivery
+
+-- existing integrations were already notified by the look-back window, start
+-- them from now instead of replaying every scan since they were created
+UPDATE integration
+SET last_scan_cursor = (extract(epoch from now()) * 1000)::bigint;
+
+CREATE TABLE notification_delivery
+(
+    id              BIGSERIAL PRIMARY KEY,
+    integration_id  integer                                            NOT NULL,
+    scan_id         character varying(1024)                            NOT NULL,
+    resource        character varying(32)                              NOT NULL,
+    -- resource: Vulnerability / Secret / Malware / Compliance / CloudCompliance
+    status          character varying(32)                              NOT NULL,
+    -- status: pending / sent / failed
+    attempts        integer                  DEFAULT 0                 NOT NULL,
+    next_attempt_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    last_error      text                                               NULL,
+    sent_at         timestamp with time zone                           NULL,
+    created_at      timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at      timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT notification_delivery_unique UNIQUE (integration_id, scan_id),
+    CONSTRAINT fk_integration_id
+        FOREIGN KEY (integration_id)
+            REFERENCES integration (id)
+            ON DELETE CASCADE
+);
+
+CREATE INDEX notification_delivery_due_idx
+    ON notification_delivery (integration_id, status, next_attempt_at);
+
+CREATE TRIGGER notification_delivery_updated_at
+    BEFORE UPDATE
+    ON notification_delivery
+    FOR EACH ROW
+EXECUTE PROCEDURE update_modified_column();
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notification_delivery;
+
+ALTER TABLE integration
+    DROP COLUMN IF EXISTS last_scan_cursor;
+-- +goose StatementEnd
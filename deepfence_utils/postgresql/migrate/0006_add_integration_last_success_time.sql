@@ -0,0 +1,13 @@
+-- +goose Up
+
+-- +goose StatementBegin
+ALTER TABLE integration
+    ADD COLUMN last_success_time timestamp with time zone NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+ALTER TABLE integration
+    DROP COLUMN IF EXISTS last_success_time;
+-- +goose StatementEnd
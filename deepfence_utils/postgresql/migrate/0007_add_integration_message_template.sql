@@ -0,0 +1,13 @@
+-- +goose Up
+
+-- +goose StatementBegin
+ALTER TABLE integration
+    ADD COLUMN message_template jsonb DEFAULT '{}'::jsonb NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+ALTER TABLE integration
+    DROP COLUMN IF EXISTS message_template;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- +goose StatementBegin
+ALTER TABLE integration
+    ADD COLUMN digest_interval  character varying(16) DEFAULT ''  NOT NULL,
+    ADD COLUMN rate_limit       integer               DEFAULT 0   NOT NULL,
+    ADD COLUMN last_digest_time timestamp with time zone          NULL;
+-- digest_interval: '' (immediate) / hourly / daily
+-- rate_limit: max messages sent per hour, 0 is unlimited
+
+ALTER TABLE notification_delivery
+    ADD COLUMN messages integer DEFAULT 0 NOT NULL;
+-- messages: number of messages sent for the scan, counted by the rate limit
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+ALTER TABLE notification_delivery
+    DROP COLUMN IF EXISTS messages;
+
+ALTER TABLE integration
+    DROP COLUMN IF EXISTS digest_interval,
+    DROP COLUMN IF EXISTS rate_limit,
+    DROP COLUMN IF EXISTS last_digest_time;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- +goose StatementBegin
+ALTER TABLE integration
+    ADD COLUMN new_findings_only boolean DEFAULT false NOT NULL,
+    ADD COLUMN notify_resolved   boolean DEFAULT false NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+ALTER TABLE integration
+    DROP COLUMN IF EXISTS new_findings_only,
+    DROP COLUMN IF EXISTS notify_resolved;
+-- +goose StatementEnd
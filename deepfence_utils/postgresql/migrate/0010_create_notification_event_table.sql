@@ -0,0 +1,36 @@
+-- +goose Up
+
+-- +goose StatementBegin
+CREATE TABLE notification_event
+(
+    id         BIGSERIAL PRIMARY KEY,
+    event_type character varying(64)                              NOT NULL,
+    -- event_type: scan_failed / agent_inactive / agent_upgrade_failed / registry_sync_failed / vulnerability_db_update_failed
+    node_id    character varying(1024) DEFAULT ''                 NOT NULL,
+    node_type  character varying(64)   DEFAULT ''                 NOT NULL,
+    message    text                    DEFAULT ''                 NOT NULL,
+    details    jsonb                   DEFAULT '{}'::jsonb        NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX notification_event_created_at_idx
+    ON notification_event (created_at);
+
+ALTER TABLE integration
+    ADD COLUMN event_attempts        integer DEFAULT 0 NOT NULL,
+    ADD COLUMN event_next_attempt_at timestamp with time zone NULL,
+    ADD COLUMN last_event_id         bigint NULL;
+-- event_attempts: failed deliveries of the events after last_event_id, retried with backoff
+-- last_event_id: id of the last event sent, last_scan_cursor is epoch ms of scans
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+ALTER TABLE integration
+    DROP COLUMN IF EXISTS event_attempts,
+    DROP COLUMN IF EXISTS event_next_attempt_at,
+    DROP COLUMN IF EXISTS last_event_id;
+
+DROP TABLE IF EXISTS notification_event;
+-- +goose StatementEnd
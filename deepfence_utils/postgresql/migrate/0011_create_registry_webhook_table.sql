@@ -0,0 +1,35 @@
+-- +goose Up
+
+-- +goose StatementBegin
+CREATE TABLE registry_webhook
+(
+    id                    SERIAL PRIMARY KEY,
+    container_registry_id integer                                            NOT NULL,
+    token_hash            character varying(64)                              NOT NULL,
+    scan_types            jsonb                    DEFAULT '[]'::jsonb       NOT NULL,
+    -- scan_types: VulnerabilityScan / SecretScan / MalwareScan started on push
+    created_at            timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at            timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT registry_webhook_container_registry_id_unique UNIQUE (container_registry_id),
+    CONSTRAINT fk_container_registry_id
+        FOREIGN KEY (container_registry_id)
+            REFERENCES container_registry (id)
+            ON DELETE CASCADE
+);
+
+-- webhooks are looked up by token before the namespace is known
+CREATE INDEX registry_webhook_token_hash_idx
+    ON registry_webhook (token_hash);
+
+CREATE TRIGGER registry_webhook_updated_at
+    BEFORE UPDATE
+    ON registry_webhook
+    FOR EACH ROW
+EXECUTE PROCEDURE update_modified_column();
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS registry_webhook;
+-- +goose StatementEnd
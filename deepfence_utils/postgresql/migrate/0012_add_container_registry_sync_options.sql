@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- +goose StatementBegin
+ALTER TABLE container_registry
+    ADD COLUMN sync_options jsonb DEFAULT '{}'::jsonb NOT NULL;
+-- sync_options: repository/tag include and exclude regex, latest tags limit
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+ALTER TABLE container_registry
+    DROP COLUMN IF EXISTS sync_options;
+-- +goose StatementEnd
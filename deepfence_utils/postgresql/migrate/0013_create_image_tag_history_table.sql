@@ -0,0 +1,33 @@
+-- +goose Up
+
+-- +goose StatementBegin
+CREATE TABLE image_tag_history
+(
+    id              BIGSERIAL PRIMARY KEY,
+    registry_id     character varying(1024)                            NOT NULL,
+    -- registry_id: node_id of the registry account, rows are kept after the registry is deleted for audit
+    image_name      text                                               NOT NULL,
+    tag             character varying(1024)                            NOT NULL,
+    digest          character varying(255)                             NOT NULL,
+    previous_digest character varying(255) DEFAULT ''                  NOT NULL,
+    first_seen_at   timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    last_seen_at    timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    replaced_at     timestamp with time zone
+);
+
+CREATE INDEX image_tag_history_tag_idx
+    ON image_tag_history (registry_id, image_name, tag);
+
+CREATE INDEX image_tag_history_digest_idx
+    ON image_tag_history (digest);
+
+CREATE UNIQUE INDEX image_tag_history_current_idx
+    ON image_tag_history (registry_id, image_name, tag)
+    WHERE replaced_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS image_tag_history;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+
+-- +goose StatementBegin
+CREATE TABLE registry_scan_policy
+(
+    id                    SERIAL PRIMARY KEY,
+    container_registry_id integer                                            NOT NULL,
+    name                  character varying(64)                              NOT NULL,
+    scan_types            jsonb                    DEFAULT '[]'::jsonb       NOT NULL,
+    -- scan_types: VulnerabilityScan / SecretScan / MalwareScan
+    filters               jsonb                    DEFAULT '{}'::jsonb       NOT NULL,
+    -- filters: repository/tag include and exclude regex
+    rescan_after_days     integer                  DEFAULT 0                 NOT NULL,
+    -- rescan_after_days: 0 scans only the images never scanned
+    max_concurrent_scans  integer                  DEFAULT 10                NOT NULL,
+    is_enabled            boolean                  DEFAULT true              NOT NULL,
+    last_evaluated_at     timestamp with time zone,
+    last_status           text                     DEFAULT ''                NOT NULL,
+    created_at            timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at            timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT fk_container_registry_id
+        FOREIGN KEY (container_registry_id)
+            REFERENCES container_registry (id)
+            ON DELETE CASCADE
+);
+
+CREATE TRIGGER registry_scan_policy_updated_at
+    BEFORE UPDATE
+    ON registry_scan_policy
+    FOR EACH ROW
+EXECUTE PROCEDURE update_modified_column();
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS registry_scan_policy;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+
+-- +goose StatementBegin
+CREATE TABLE native_vulnerability_report
+(
+    id              BIGSERIAL PRIMARY KEY,
+    registry_id     character varying(1024)                            NOT NULL,
+    -- registry_id: node_id of the registry account the report was imported from
+    image_name      text                                               NOT NULL,
+    digest          character varying(255)                             NOT NULL,
+    source          character varying(64)                              NOT NULL,
+    -- source: registry type computing the report, harbor / quay
+    scanner         text                     DEFAULT ''                NOT NULL,
+    vulnerabilities jsonb                    DEFAULT '[]'::jsonb       NOT NULL,
+    generated_at    timestamp with time zone,
+    fetched_at      timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT native_vulnerability_report_digest_key UNIQUE (registry_id, digest)
+);
+
+CREATE INDEX native_vulnerability_report_digest_idx
+    ON native_vulnerability_report (digest);
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS native_vulnerability_report;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+
+-- +goose StatementBegin
+CREATE TABLE source_repository
+(
+    id               SERIAL PRIMARY KEY,
+    name             character varying(64)                              NOT NULL,
+    provider         character varying(32)                              NOT NULL,
+    -- provider: github / gitlab / gitea / git
+    url              text                                               NOT NULL,
+    branch           text                     DEFAULT ''                NOT NULL,
+    -- branch: empty to scan the history of all branches and tags
+    auth_type        character varying(16)    DEFAULT 'none'            NOT NULL,
+    -- auth_type: none / token / ssh_key
+    username         text                     DEFAULT ''                NOT NULL,
+    known_hosts      text                     DEFAULT ''                NOT NULL,
+    -- known_hosts: pinned host keys of ssh urls, in known_hosts format
+    encrypted_secret jsonb                    DEFAULT '{}'::jsonb       NOT NULL,
+    created_at       timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at       timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT source_repository_unique UNIQUE (url, branch)
+);
+
+CREATE TRIGGER source_repository_updated_at
+    BEFORE UPDATE
+    ON source_repository
+    FOR EACH ROW
+EXECUTE PROCEDURE update_modified_column();
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS source_repository;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+
+-- +goose StatementBegin
+CREATE TABLE scan_result_exception
+(
+    id          BIGSERIAL PRIMARY KEY,
+    scan_type   character varying(64)                              NOT NULL,
+    result_id   text                                               NOT NULL,
+    -- result_id: cve id, secret / malware rule id, compliance test number or control id
+    scope_type  character varying(32)                              NOT NULL,
+    -- scope_type: node / image_name / kubernetes_namespace / global
+    scope_value text                     DEFAULT ''                NOT NULL,
+    reason      text                                               NOT NULL,
+    owner       text                                               NOT NULL,
+    created_by  text                                               NOT NULL,
+    expires_at  timestamp with time zone,
+    expired     boolean                  DEFAULT false             NOT NULL,
+    created_at  timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at  timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX scan_result_exception_scan_type_idx
+    ON scan_result_exception (scan_type, expired);
+
+CREATE TRIGGER scan_result_exception_updated_at
+    BEFORE UPDATE
+    ON scan_result_exception
+    FOR EACH ROW
+EXECUTE PROCEDURE update_modified_column();
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scan_result_exception;
+-- +goose StatementEnd
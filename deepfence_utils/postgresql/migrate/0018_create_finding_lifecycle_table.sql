@@ -0,0 +1,42 @@
+-- +goose Up
+
+-- +goose StatementBegin
+CREATE TABLE finding_lifecycle
+(
+    id           BIGSERIAL PRIMARY KEY,
+    scan_type    character varying(64)                              NOT NULL,
+    node_id      text                                               NOT NULL,
+    node_type    character varying(64)                              NOT NULL,
+    result_id    text                                               NOT NULL,
+    -- result_id: vulnerability / secret / malware node id, compliance test number or control id and resource
+    severity     character varying(32)    DEFAULT ''                NOT NULL,
+    labels       jsonb                    DEFAULT '{}'::jsonb       NOT NULL,
+    first_seen   timestamp with time zone                           NOT NULL,
+    opened_at    timestamp with time zone                           NOT NULL,
+    -- opened_at: first_seen, or the last time the finding was reopened
+    last_seen    timestamp with time zone                           NOT NULL,
+    resolved_at  timestamp with time zone,
+    reopened     integer                  DEFAULT 0                 NOT NULL,
+    last_scan_id text                                               NOT NULL,
+    created_at   timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at   timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX finding_lifecycle_finding_idx
+    ON finding_lifecycle (scan_type, node_id, result_id);
+
+CREATE INDEX finding_lifecycle_resolved_at_idx
+    ON finding_lifecycle (resolved_at);
+
+CREATE TRIGGER finding_lifecycle_updated_at
+    BEFORE UPDATE
+    ON finding_lifecycle
+    FOR EACH ROW
+EXECUTE PROCEDURE update_modified_column();
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS finding_lifecycle;
+-- +goose StatementEnd
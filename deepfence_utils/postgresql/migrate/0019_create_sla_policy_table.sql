@@ -0,0 +1,52 @@
+-- +goose Up
+
+-- +goose StatementBegin
+CREATE TABLE sla_policy
+(
+    id            BIGSERIAL PRIMARY KEY,
+    name          text                                               NOT NULL,
+    scan_type     character varying(64)                              NOT NULL,
+    severity      character varying(32)                              NOT NULL,
+    days          integer                                            NOT NULL,
+    due_soon_days integer                  DEFAULT 0                 NOT NULL,
+    scope_type    character varying(32)                              NOT NULL,
+    -- scope_type: global / label / cloud_account
+    scope_key     text                     DEFAULT ''                NOT NULL,
+    scope_value   text                     DEFAULT ''                NOT NULL,
+    created_by    text                                               NOT NULL,
+    created_at    timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at    timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX sla_policy_scan_type_idx
+    ON sla_policy (scan_type, severity);
+
+CREATE TRIGGER sla_policy_updated_at
+    BEFORE UPDATE
+    ON sla_policy
+    FOR EACH ROW
+EXECUTE PROCEDURE update_modified_column();
+
+ALTER TABLE finding_lifecycle
+    ADD COLUMN cloud_account text                  DEFAULT '' NOT NULL,
+    ADD COLUMN sla_policy_id bigint REFERENCES sla_policy (id) ON DELETE SET NULL,
+    ADD COLUMN sla_due_at    timestamp with time zone,
+    ADD COLUMN sla_status    character varying(16) DEFAULT '' NOT NULL;
+-- sla_status: within_sla / due_soon / breached, empty when no policy applies
+
+CREATE INDEX finding_lifecycle_sla_status_idx
+    ON finding_lifecycle (sla_status)
+    WHERE resolved_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+ALTER TABLE finding_lifecycle
+    DROP COLUMN IF EXISTS cloud_account,
+    DROP COLUMN IF EXISTS sla_policy_id,
+    DROP COLUMN IF EXISTS sla_due_at,
+    DROP COLUMN IF EXISTS sla_status;
+
+DROP TABLE IF EXISTS sla_policy;
+-- +goose StatementEnd
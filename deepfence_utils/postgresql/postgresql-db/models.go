@@ -53,20 +53,103 @@ type ContainerRegistry struct {
 	Extras          json.RawMessage `json:"extras"`
 	CreatedAt       time.Time       `json:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at"`
+	SyncOptions     json.RawMessage `json:"sync_options"`
+}
+
+type FindingLifecycle struct {
+	ID           int64           `json:"id"`
+	ScanType     string          `json:"scan_type"`
+	NodeID       string          `json:"node_id"`
+	NodeType     string          `json:"node_type"`
+	ResultID     string          `json:"result_id"`
+	Severity     string          `json:"severity"`
+	Labels       json.RawMessage `json:"labels"`
+	FirstSeen    time.Time       `json:"first_seen"`
+	OpenedAt     time.Time       `json:"opened_at"`
+	LastSeen     time.Time       `json:"last_seen"`
+	ResolvedAt   sql.NullTime    `json:"resolved_at"`
+	Reopened     int32           `json:"reopened"`
+	LastScanID   string          `json:"last_scan_id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	CloudAccount string          `json:"cloud_account"`
+	SlaPolicyID  sql.NullInt64   `json:"sla_policy_id"`
+	SlaDueAt     sql.NullTime    `json:"sla_due_at"`
+	SlaStatus    string          `json:"sla_status"`
+}
+
+type ImageTagHistory struct {
+	ID             int64        `json:"id"`
+	RegistryID     string       `json:"registry_id"`
+	ImageName      string       `json:"image_name"`
+	Tag            string       `json:"tag"`
+	Digest         string       `json:"digest"`
+	PreviousDigest string       `json:"previous_digest"`
+	FirstSeenAt    time.Time    `json:"first_seen_at"`
+	LastSeenAt     time.Time    `json:"last_seen_at"`
+	ReplacedAt     sql.NullTime `json:"replaced_at"`
 }
 
 type Integration struct {
-	ID              int32           `json:"id"`
-	Resource        string          `json:"resource"`
-	Filters         json.RawMessage `json:"filters"`
-	IntegrationType string          `json:"integration_type"`
-	IntervalMinutes int32           `json:"interval_minutes"`
-	LastSentTime    sql.NullTime    `json:"last_sent_time"`
-	Config          json.RawMessage `json:"config"`
-	ErrorMsg        sql.NullString  `json:"error_msg"`
-	CreatedByUserID int64           `json:"created_by_user_id"`
-	CreatedAt       time.Time       `json:"created_at"`
-	UpdatedAt       time.Time       `json:"updated_at"`
+	ID                 int32           `json:"id"`
+	Resource           string          `json:"resource"`
+	Filters            json.RawMessage `json:"filters"`
+	IntegrationType    string          `json:"integration_type"`
+	IntervalMinutes    int32           `json:"interval_minutes"`
+	LastSentTime       sql.NullTime    `json:"last_sent_time"`
+	Config             json.RawMessage `json:"config"`
+	ErrorMsg           sql.NullString  `json:"error_msg"`
+	CreatedByUserID    int64           `json:"created_by_user_id"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	LastScanCursor     sql.NullInt64   `json:"last_scan_cursor"`
+	LastSuccessTime    sql.NullTime    `json:"last_success_time"`
+	MessageTemplate    json.RawMessage `json:"message_template"`
+	DigestInterval     string          `json:"digest_interval"`
+	RateLimit          int32           `json:"rate_limit"`
+	LastDigestTime     sql.NullTime    `json:"last_digest_time"`
+	NewFindingsOnly    bool            `json:"new_findings_only"`
+	NotifyResolved     bool            `json:"notify_resolved"`
+	EventAttempts      int32           `json:"event_attempts"`
+	EventNextAttemptAt sql.NullTime    `json:"event_next_attempt_at"`
+	LastEventID        sql.NullInt64   `json:"last_event_id"`
+}
+
+type NativeVulnerabilityReport struct {
+	ID              int64           `json:"id"`
+	RegistryID      string          `json:"registry_id"`
+	ImageName       string          `json:"image_name"`
+	Digest          string          `json:"digest"`
+	Source          string          `json:"source"`
+	Scanner         string          `json:"scanner"`
+	Vulnerabilities json.RawMessage `json:"vulnerabilities"`
+	GeneratedAt     sql.NullTime    `json:"generated_at"`
+	FetchedAt       time.Time       `json:"fetched_at"`
+}
+
+type NotificationDelivery struct {
+	ID            int64          `json:"id"`
+	IntegrationID int32          `json:"integration_id"`
+	ScanID        string         `json:"scan_id"`
+	Resource      string         `json:"resource"`
+	Status        string         `json:"status"`
+	Attempts      int32          `json:"attempts"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	LastError     sql.NullString `json:"last_error"`
+	SentAt        sql.NullTime   `json:"sent_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	Messages      int32          `json:"messages"`
+}
+
+type NotificationEvent struct {
+	ID        int64           `json:"id"`
+	EventType string          `json:"event_type"`
+	NodeID    string          `json:"node_id"`
+	NodeType  string          `json:"node_type"`
+	Message   string          `json:"message"`
+	Details   json.RawMessage `json:"details"`
+	CreatedAt time.Time       `json:"created_at"`
 }
 
 type PasswordReset struct {
@@ -78,6 +161,30 @@ type PasswordReset struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type RegistryScanPolicy struct {
+	ID                  int32           `json:"id"`
+	ContainerRegistryID int32           `json:"container_registry_id"`
+	Name                string          `json:"name"`
+	ScanTypes           json.RawMessage `json:"scan_types"`
+	Filters             json.RawMessage `json:"filters"`
+	RescanAfterDays     int32           `json:"rescan_after_days"`
+	MaxConcurrentScans  int32           `json:"max_concurrent_scans"`
+	IsEnabled           bool            `json:"is_enabled"`
+	LastEvaluatedAt     sql.NullTime    `json:"last_evaluated_at"`
+	LastStatus          string          `json:"last_status"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+}
+
+type RegistryWebhook struct {
+	ID                  int32           `json:"id"`
+	ContainerRegistryID int32           `json:"container_registry_id"`
+	TokenHash           string          `json:"token_hash"`
+	ScanTypes           json.RawMessage `json:"scan_types"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+}
+
 type Role struct {
 	ID        int32     `json:"id"`
 	Name      string    `json:"name"`
@@ -85,6 +192,21 @@ type Role struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type ScanResultException struct {
+	ID         int64        `json:"id"`
+	ScanType   string       `json:"scan_type"`
+	ResultID   string       `json:"result_id"`
+	ScopeType  string       `json:"scope_type"`
+	ScopeValue string       `json:"scope_value"`
+	Reason     string       `json:"reason"`
+	Owner      string       `json:"owner"`
+	CreatedBy  string       `json:"created_by"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	Expired    bool         `json:"expired"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
 type Scheduler struct {
 	ID          int64           `json:"id"`
 	Action      string          `json:"action"`
@@ -108,6 +230,35 @@ type Setting struct {
 	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
+type SlaPolicy struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	ScanType    string    `json:"scan_type"`
+	Severity    string    `json:"severity"`
+	Days        int32     `json:"days"`
+	DueSoonDays int32     `json:"due_soon_days"`
+	ScopeType   string    `json:"scope_type"`
+	ScopeKey    string    `json:"scope_key"`
+	ScopeValue  string    `json:"scope_value"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type SourceRepository struct {
+	ID              int32           `json:"id"`
+	Name            string          `json:"name"`
+	Provider        string          `json:"provider"`
+	Url             string          `json:"url"`
+	Branch          string          `json:"branch"`
+	AuthType        string          `json:"auth_type"`
+	Username        string          `json:"username"`
+	KnownHosts      string          `json:"known_hosts"`
+	EncryptedSecret json.RawMessage `json:"encrypted_secret"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
 type User struct {
 	ID                  int64           `json:"id"`
 	FirstName           string          `json:"first_name"`
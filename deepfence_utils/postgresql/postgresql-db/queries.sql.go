@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const countActiveAdminUsers = `-- name: CountActiveAdminUsers :one
@@ -66,6 +67,27 @@ func (q *Queries) CountContainerRegistry(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const countNotificationMessagesSentSince = `-- name: CountNotificationMessagesSentSince :one
+SELECT coalesce(sum(messages), 0)::bigint AS messages
+FROM notification_delivery
+WHERE integration_id = $1
+  AND status = $2
+  AND sent_at > $3
+`
+
+type CountNotificationMessagesSentSinceParams struct {
+	IntegrationID int32        `json:"integration_id"`
+	Status        string       `json:"status"`
+	SentAt        sql.NullTime `json:"sent_at"`
+}
+
+func (q *Queries) CountNotificationMessagesSentSince(ctx context.Context, arg CountNotificationMessagesSentSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNotificationMessagesSentSince, arg.IntegrationID, arg.Status, arg.SentAt)
+	var messages int64
+	err := row.Scan(&messages)
+	return messages, err
+}
+
 const countUsers = `-- name: CountUsers :one
 SELECT count(*)
 FROM users
@@ -174,7 +196,7 @@ func (q *Queries) CreateCompany(ctx context.Context, arg CreateCompanyParams) (C
 const createContainerRegistry = `-- name: CreateContainerRegistry :one
 INSERT INTO container_registry (name, registry_type, encrypted_secret, non_secret, extras)
 VALUES ($1, $2, $3, $4, $5)
-RETURNING id, name, registry_type, encrypted_secret, non_secret, extras, created_at, updated_at
+RETURNING id, name, registry_type, encrypted_secret, non_secret, extras, created_at, updated_at, sync_options
 `
 
 type CreateContainerRegistryParams struct {
@@ -203,14 +225,40 @@ func (q *Queries) CreateContainerRegistry(ctx context.Context, arg CreateContain
 		&i.Extras,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SyncOptions,
 	)
 	return i, err
 }
 
+const createImageTagHistory = `-- name: CreateImageTagHistory :exec
+INSERT INTO image_tag_history (registry_id, image_name, tag, digest, previous_digest)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateImageTagHistoryParams struct {
+	RegistryID     string `json:"registry_id"`
+	ImageName      string `json:"image_name"`
+	Tag            string `json:"tag"`
+	Digest         string `json:"digest"`
+	PreviousDigest string `json:"previous_digest"`
+}
+
+func (q *Queries) CreateImageTagHistory(ctx context.Context, arg CreateImageTagHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, createImageTagHistory,
+		arg.RegistryID,
+		arg.ImageName,
+		arg.Tag,
+		arg.Digest,
+		arg.PreviousDigest,
+	)
+	return err
+}
+
 const createIntegration = `-- name: CreateIntegration :one
-INSERT INTO integration (resource, filters, integration_type, interval_minutes, config, created_by_user_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, resource, filters, integration_type, interval_minutes, last_sent_time, config, error_msg, created_by_user_id, created_at, updated_at
+INSERT INTO integration (resource, filters, integration_type, interval_minutes, config, created_by_user_id,
+                         message_template, digest_interval, rate_limit, new_findings_only, notify_resolved)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, resource, filters, integration_type, interval_minutes, last_sent_time, config, error_msg, created_by_user_id, created_at, updated_at, last_scan_cursor, last_success_time, message_template, digest_interval, rate_limit, last_digest_time, new_findings_only, notify_resolved, event_attempts, event_next_attempt_at, last_event_id
 `
 
 type CreateIntegrationParams struct {
@@ -220,6 +268,11 @@ type CreateIntegrationParams struct {
 	IntervalMinutes int32           `json:"interval_minutes"`
 	Config          json.RawMessage `json:"config"`
 	CreatedByUserID int64           `json:"created_by_user_id"`
+	MessageTemplate json.RawMessage `json:"message_template"`
+	DigestInterval  string          `json:"digest_interval"`
+	RateLimit       int32           `json:"rate_limit"`
+	NewFindingsOnly bool            `json:"new_findings_only"`
+	NotifyResolved  bool            `json:"notify_resolved"`
 }
 
 func (q *Queries) CreateIntegration(ctx context.Context, arg CreateIntegrationParams) (Integration, error) {
@@ -230,6 +283,11 @@ func (q *Queries) CreateIntegration(ctx context.Context, arg CreateIntegrationPa
 		arg.IntervalMinutes,
 		arg.Config,
 		arg.CreatedByUserID,
+		arg.MessageTemplate,
+		arg.DigestInterval,
+		arg.RateLimit,
+		arg.NewFindingsOnly,
+		arg.NotifyResolved,
 	)
 	var i Integration
 	err := row.Scan(
@@ -244,10 +302,68 @@ func (q *Queries) CreateIntegration(ctx context.Context, arg CreateIntegrationPa
 		&i.CreatedByUserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastScanCursor,
+		&i.LastSuccessTime,
+		&i.MessageTemplate,
+		&i.DigestInterval,
+		&i.RateLimit,
+		&i.LastDigestTime,
+		&i.NewFindingsOnly,
+		&i.NotifyResolved,
+		&i.EventAttempts,
+		&i.EventNextAttemptAt,
+		&i.LastEventID,
 	)
 	return i, err
 }
 
+const createNotificationDelivery = `-- name: CreateNotificationDelivery :exec
+INSERT INTO notification_delivery (integration_id, scan_id, resource, status)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (integration_id, scan_id) DO NOTHING
+`
+
+type CreateNotificationDeliveryParams struct {
+	IntegrationID int32  `json:"integration_id"`
+	ScanID        string `json:"scan_id"`
+	Resource      string `json:"resource"`
+	Status        string `json:"status"`
+}
+
+func (q *Queries) CreateNotificationDelivery(ctx context.Context, arg CreateNotificationDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, createNotificationDelivery,
+		arg.IntegrationID,
+		arg.ScanID,
+		arg.Resource,
+		arg.Status,
+	)
+	return err
+}
+
+const createNotificationEvent = `-- name: CreateNotificationEvent :exec
+INSERT INTO notification_event (event_type, node_id, node_type, message, details)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateNotificationEventParams struct {
+	EventType string          `json:"event_type"`
+	NodeID    string          `json:"node_id"`
+	NodeType  string          `json:"node_type"`
+	Message   string          `json:"message"`
+	Details   json.RawMessage `json:"details"`
+}
+
+func (q *Queries) CreateNotificationEvent(ctx context.Context, arg CreateNotificationEventParams) error {
+	_, err := q.db.ExecContext(ctx, createNotificationEvent,
+		arg.EventType,
+		arg.NodeID,
+		arg.NodeType,
+		arg.Message,
+		arg.Details,
+	)
+	return err
+}
+
 const createPasswordReset = `-- name: CreatePasswordReset :one
 INSERT INTO password_reset (code, expiry, user_id)
 VALUES ($1, $2, $3)
@@ -274,6 +390,51 @@ func (q *Queries) CreatePasswordReset(ctx context.Context, arg CreatePasswordRes
 	return i, err
 }
 
+const createRegistryScanPolicy = `-- name: CreateRegistryScanPolicy :one
+INSERT INTO registry_scan_policy (container_registry_id, name, scan_types, filters, rescan_after_days,
+                                  max_concurrent_scans, is_enabled)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, container_registry_id, name, scan_types, filters, rescan_after_days, max_concurrent_scans, is_enabled, last_evaluated_at, last_status, created_at, updated_at
+`
+
+type CreateRegistryScanPolicyParams struct {
+	ContainerRegistryID int32           `json:"container_registry_id"`
+	Name                string          `json:"name"`
+	ScanTypes           json.RawMessage `json:"scan_types"`
+	Filters             json.RawMessage `json:"filters"`
+	RescanAfterDays     int32           `json:"rescan_after_days"`
+	MaxConcurrentScans  int32           `json:"max_concurrent_scans"`
+	IsEnabled           bool            `json:"is_enabled"`
+}
+
+func (q *Queries) CreateRegistryScanPolicy(ctx context.Context, arg CreateRegistryScanPolicyParams) (RegistryScanPolicy, error) {
+	row := q.db.QueryRowContext(ctx, createRegistryScanPolicy,
+		arg.ContainerRegistryID,
+		arg.Name,
+		arg.ScanTypes,
+		arg.Filters,
+		arg.RescanAfterDays,
+		arg.MaxConcurrentScans,
+		arg.IsEnabled,
+	)
+	var i RegistryScanPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.ContainerRegistryID,
+		&i.Name,
+		&i.ScanTypes,
+		&i.Filters,
+		&i.RescanAfterDays,
+		&i.MaxConcurrentScans,
+		&i.IsEnabled,
+		&i.LastEvaluatedAt,
+		&i.LastStatus,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const createRole = `-- name: CreateRole :one
 INSERT INTO role (name)
 VALUES ($1)
@@ -292,6 +453,100 @@ func (q *Queries) CreateRole(ctx context.Context, name string) (Role, error) {
 	return i, err
 }
 
+const createSLAPolicy = `-- name: CreateSLAPolicy :one
+INSERT INTO sla_policy (name, scan_type, severity, days, due_soon_days, scope_type, scope_key, scope_value, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, name, scan_type, severity, days, due_soon_days, scope_type, scope_key, scope_value, created_by, created_at, updated_at
+`
+
+type CreateSLAPolicyParams struct {
+	Name        string `json:"name"`
+	ScanType    string `json:"scan_type"`
+	Severity    string `json:"severity"`
+	Days        int32  `json:"days"`
+	DueSoonDays int32  `json:"due_soon_days"`
+	ScopeType   string `json:"scope_type"`
+	ScopeKey    string `json:"scope_key"`
+	ScopeValue  string `json:"scope_value"`
+	CreatedBy   string `json:"created_by"`
+}
+
+func (q *Queries) CreateSLAPolicy(ctx context.Context, arg CreateSLAPolicyParams) (SlaPolicy, error) {
+	row := q.db.QueryRowContext(ctx, createSLAPolicy,
+		arg.Name,
+		arg.ScanType,
+		arg.Severity,
+		arg.Days,
+		arg.DueSoonDays,
+		arg.ScopeType,
+		arg.ScopeKey,
+		arg.ScopeValue,
+		arg.CreatedBy,
+	)
+	var i SlaPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ScanType,
+		&i.Severity,
+		&i.Days,
+		&i.DueSoonDays,
+		&i.ScopeType,
+		&i.ScopeKey,
+		&i.ScopeValue,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createScanResultException = `-- name: CreateScanResultException :one
+INSERT INTO scan_result_exception (scan_type, result_id, scope_type, scope_value, reason, owner, created_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, scan_type, result_id, scope_type, scope_value, reason, owner, created_by, expires_at, expired, created_at, updated_at
+`
+
+type CreateScanResultExceptionParams struct {
+	ScanType   string       `json:"scan_type"`
+	ResultID   string       `json:"result_id"`
+	ScopeType  string       `json:"scope_type"`
+	ScopeValue string       `json:"scope_value"`
+	Reason     string       `json:"reason"`
+	Owner      string       `json:"owner"`
+	CreatedBy  string       `json:"created_by"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+}
+
+func (q *Queries) CreateScanResultException(ctx context.Context, arg CreateScanResultExceptionParams) (ScanResultException, error) {
+	row := q.db.QueryRowContext(ctx, createScanResultException,
+		arg.ScanType,
+		arg.ResultID,
+		arg.ScopeType,
+		arg.ScopeValue,
+		arg.Reason,
+		arg.Owner,
+		arg.CreatedBy,
+		arg.ExpiresAt,
+	)
+	var i ScanResultException
+	err := row.Scan(
+		&i.ID,
+		&i.ScanType,
+		&i.ResultID,
+		&i.ScopeType,
+		&i.ScopeValue,
+		&i.Reason,
+		&i.Owner,
+		&i.CreatedBy,
+		&i.ExpiresAt,
+		&i.Expired,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const createSchedule = `-- name: CreateSchedule :one
 INSERT INTO scheduler (action, description, cron_expr, payload, is_enabled, is_system, status)
 VALUES ($1, $2, $3, $4, $5, $6, '')
@@ -359,6 +614,51 @@ func (q *Queries) CreateSetting(ctx context.Context, arg CreateSettingParams) (S
 	return i, err
 }
 
+const createSourceRepository = `-- name: CreateSourceRepository :one
+INSERT INTO source_repository (name, provider, url, branch, auth_type, username, known_hosts, encrypted_secret)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, name, provider, url, branch, auth_type, username, known_hosts, encrypted_secret, created_at, updated_at
+`
+
+type CreateSourceRepositoryParams struct {
+	Name            string          `json:"name"`
+	Provider        string          `json:"provider"`
+	Url             string          `json:"url"`
+	Branch          string          `json:"branch"`
+	AuthType        string          `json:"auth_type"`
+	Username        string          `json:"username"`
+	KnownHosts      string          `json:"known_hosts"`
+	EncryptedSecret json.RawMessage `json:"encrypted_secret"`
+}
+
+func (q *Queries) CreateSourceRepository(ctx context.Context, arg CreateSourceRepositoryParams) (SourceRepository, error) {
+	row := q.db.QueryRowContext(ctx, createSourceRepository,
+		arg.Name,
+		arg.Provider,
+		arg.Url,
+		arg.Branch,
+		arg.AuthType,
+		arg.Username,
+		arg.KnownHosts,
+		arg.EncryptedSecret,
+	)
+	var i SourceRepository
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Provider,
+		&i.Url,
+		&i.Branch,
+		&i.AuthType,
+		&i.Username,
+		&i.KnownHosts,
+		&i.EncryptedSecret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (first_name, last_name, email, role_id, group_ids, company_id, password_hash, is_active,
                    password_invalidated)
@@ -559,6 +859,41 @@ func (q *Queries) DeleteIntegrationByUserID(ctx context.Context, createdByUserID
 	return err
 }
 
+const deleteNotificationDeliveriesOlderThan30days = `-- name: DeleteNotificationDeliveriesOlderThan30days :one
+WITH deleted AS (
+    DELETE
+        FROM notification_delivery
+            WHERE status = $1
+                AND updated_at < (now() - interval '30 days')
+            RETURNING id, integration_id, scan_id, resource, status, attempts, next_attempt_at, last_error, sent_at, created_at, updated_at, messages)
+SELECT count(*)
+FROM deleted
+`
+
+func (q *Queries) DeleteNotificationDeliveriesOlderThan30days(ctx context.Context, status string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, deleteNotificationDeliveriesOlderThan30days, status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteNotificationEventsOlderThan30days = `-- name: DeleteNotificationEventsOlderThan30days :one
+WITH deleted AS (
+    DELETE
+        FROM notification_event
+            WHERE created_at < (now() - interval '30 days')
+            RETURNING id, event_type, node_id, node_type, message, details, created_at)
+SELECT count(*)
+FROM deleted
+`
+
+func (q *Queries) DeleteNotificationEventsOlderThan30days(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, deleteNotificationEventsOlderThan30days)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const deletePasswordResetByExpiry = `-- name: DeletePasswordResetByExpiry :exec
 DELETE
 FROM password_reset
@@ -583,6 +918,50 @@ func (q *Queries) DeletePasswordResetByUserEmail(ctx context.Context, email stri
 	return err
 }
 
+const deleteRegistryScanPolicy = `-- name: DeleteRegistryScanPolicy :exec
+DELETE
+FROM registry_scan_policy
+WHERE id = $1
+`
+
+func (q *Queries) DeleteRegistryScanPolicy(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteRegistryScanPolicy, id)
+	return err
+}
+
+const deleteRegistryWebhook = `-- name: DeleteRegistryWebhook :exec
+DELETE
+FROM registry_webhook
+WHERE container_registry_id = $1
+`
+
+func (q *Queries) DeleteRegistryWebhook(ctx context.Context, containerRegistryID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteRegistryWebhook, containerRegistryID)
+	return err
+}
+
+const deleteSLAPolicy = `-- name: DeleteSLAPolicy :exec
+DELETE
+FROM sla_policy
+WHERE id = $1
+`
+
+func (q *Queries) DeleteSLAPolicy(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSLAPolicy, id)
+	return err
+}
+
+const deleteScanResultException = `-- name: DeleteScanResultException :exec
+DELETE
+FROM scan_result_exception
+WHERE id = $1
+`
+
+func (q *Queries) DeleteScanResultException(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteScanResultException, id)
+	return err
+}
+
 const deleteSchedule = `-- name: DeleteSchedule :exec
 DELETE
 FROM scheduler
@@ -605,6 +984,17 @@ func (q *Queries) DeleteSettingByID(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteSourceRepository = `-- name: DeleteSourceRepository :exec
+DELETE
+FROM source_repository
+WHERE id = $1
+`
+
+func (q *Queries) DeleteSourceRepository(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteSourceRepository, id)
+	return err
+}
+
 const deleteUser = `-- name: DeleteUser :exec
 DELETE
 FROM users
@@ -638,6 +1028,129 @@ func (q *Queries) DeleteUserInviteByUserID(ctx context.Context, createdByUserID
 	return err
 }
 
+const evaluateFindingSLAs = `-- name: EvaluateFindingSLAs :many
+WITH evaluated AS (SELECT DISTINCT ON (f.id) f.id,
+                                            f.sla_status                                AS previous_status,
+                                            p.id                                        AS policy_id,
+                                            f.opened_at + make_interval(days => p.days) AS due_at,
+                                            (CASE
+                                                 WHEN p.id IS NULL THEN ''
+                                                 WHEN f.opened_at + make_interval(days => p.days) <= now()
+                                                     THEN 'breached'
+                                                 WHEN f.opened_at + make_interval(days => p.days - p.due_soon_days) <= now()
+                                                     THEN 'due_soon'
+                                                 ELSE 'within_sla' END)::text           AS status
+                   FROM finding_lifecycle f
+                            LEFT JOIN sla_policy p
+                                      ON p.scan_type = f.scan_type
+                                          AND p.severity = lower(f.severity)
+                                          AND (p.scope_type = 'global'
+                                              OR (p.scope_type = 'label' AND f.labels ->> p.scope_key = p.scope_value)
+                                              OR (p.scope_type = 'cloud_account' AND f.cloud_account = p.scope_value))
+                   WHERE f.resolved_at IS NULL
+                   ORDER BY f.id, p.scope_type = 'global', p.days)
+UPDATE finding_lifecycle f
+SET sla_policy_id = e.policy_id,
+    sla_due_at    = e.due_at,
+    sla_status    = e.status
+FROM evaluated e
+WHERE f.id = e.id
+  AND (f.sla_status <> e.status
+    OR f.sla_due_at IS DISTINCT FROM e.due_at
+    OR f.sla_policy_id IS DISTINCT FROM e.policy_id)
+RETURNING f.id, f.scan_type, f.node_id, f.node_type, f.result_id, f.severity, f.sla_due_at, f.sla_status, e.previous_status
+`
+
+type EvaluateFindingSLAsRow struct {
+	ID             int64        `json:"id"`
+	ScanType       string       `json:"scan_type"`
+	NodeID         string       `json:"node_id"`
+	NodeType       string       `json:"node_type"`
+	ResultID       string       `json:"result_id"`
+	Severity       string       `json:"severity"`
+	SlaDueAt       sql.NullTime `json:"sla_due_at"`
+	SlaStatus      string       `json:"sla_status"`
+	PreviousStatus string       `json:"previous_status"`
+}
+
+func (q *Queries) EvaluateFindingSLAs(ctx context.Context) ([]EvaluateFindingSLAsRow, error) {
+	rows, err := q.db.QueryContext(ctx, evaluateFindingSLAs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EvaluateFindingSLAsRow
+	for rows.Next() {
+		var i EvaluateFindingSLAsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ScanType,
+			&i.NodeID,
+			&i.NodeType,
+			&i.ResultID,
+			&i.Severity,
+			&i.SlaDueAt,
+			&i.SlaStatus,
+			&i.PreviousStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveScanResultExceptions = `-- name: GetActiveScanResultExceptions :many
+SELECT id, scan_type, result_id, scope_type, scope_value, reason, owner, created_by, expires_at, expired, created_at, updated_at
+FROM scan_result_exception
+WHERE scan_type = $1
+  AND expired = false
+  AND (expires_at IS NULL OR expires_at > now())
+ORDER BY id
+`
+
+func (q *Queries) GetActiveScanResultExceptions(ctx context.Context, scanType string) ([]ScanResultException, error) {
+	rows, err := q.db.QueryContext(ctx, getActiveScanResultExceptions, scanType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScanResultException
+	for rows.Next() {
+		var i ScanResultException
+		if err := rows.Scan(
+			&i.ID,
+			&i.ScanType,
+			&i.ResultID,
+			&i.ScopeType,
+			&i.ScopeValue,
+			&i.Reason,
+			&i.Owner,
+			&i.CreatedBy,
+			&i.ExpiresAt,
+			&i.Expired,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getActiveSchedules = `-- name: GetActiveSchedules :many
 SELECT id, action, description, cron_expr, payload, is_enabled, is_system, status, last_ran_at, created_at, updated_at
 FROM scheduler
@@ -1214,7 +1727,8 @@ SELECT container_registry.id,
        container_registry.non_secret,
        container_registry.created_at,
        container_registry.updated_at,
-       container_registry.extras
+       container_registry.extras,
+       container_registry.sync_options
 FROM container_registry
 `
 
@@ -1227,6 +1741,7 @@ type GetContainerRegistriesRow struct {
 	CreatedAt       time.Time       `json:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at"`
 	Extras          json.RawMessage `json:"extras"`
+	SyncOptions     json.RawMessage `json:"sync_options"`
 }
 
 func (q *Queries) GetContainerRegistries(ctx context.Context) ([]GetContainerRegistriesRow, error) {
@@ -1247,6 +1762,7 @@ func (q *Queries) GetContainerRegistries(ctx context.Context) ([]GetContainerReg
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Extras,
+			&i.SyncOptions,
 		); err != nil {
 			return nil, err
 		}
@@ -1267,7 +1783,8 @@ SELECT container_registry.id,
        container_registry.registry_type,
        container_registry.non_secret,
        container_registry.created_at,
-       container_registry.updated_at
+       container_registry.updated_at,
+       container_registry.sync_options
 FROM container_registry
 `
 
@@ -1278,6 +1795,7 @@ type GetContainerRegistriesSafeRow struct {
 	NonSecret    json.RawMessage `json:"non_secret"`
 	CreatedAt    time.Time       `json:"created_at"`
 	UpdatedAt    time.Time       `json:"updated_at"`
+	SyncOptions  json.RawMessage `json:"sync_options"`
 }
 
 func (q *Queries) GetContainerRegistriesSafe(ctx context.Context) ([]GetContainerRegistriesSafeRow, error) {
@@ -1296,6 +1814,7 @@ func (q *Queries) GetContainerRegistriesSafe(ctx context.Context) ([]GetContaine
 			&i.NonSecret,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.SyncOptions,
 		); err != nil {
 			return nil, err
 		}
@@ -1318,7 +1837,8 @@ SELECT container_registry.id,
        container_registry.non_secret,
        container_registry.created_at,
        container_registry.updated_at,
-       container_registry.extras
+       container_registry.extras,
+       container_registry.sync_options
 FROM container_registry
 WHERE container_registry.id = $1
 LIMIT 1
@@ -1333,6 +1853,7 @@ type GetContainerRegistryRow struct {
 	CreatedAt       time.Time       `json:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at"`
 	Extras          json.RawMessage `json:"extras"`
+	SyncOptions     json.RawMessage `json:"sync_options"`
 }
 
 func (q *Queries) GetContainerRegistry(ctx context.Context, id int32) (GetContainerRegistryRow, error) {
@@ -1347,6 +1868,7 @@ func (q *Queries) GetContainerRegistry(ctx context.Context, id int32) (GetContai
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Extras,
+		&i.SyncOptions,
 	)
 	return i, err
 }
@@ -1489,58 +2011,458 @@ func (q *Queries) GetContainerRegistrySafe(ctx context.Context, id int32) (GetCo
 	return i, err
 }
 
-const getIntegrationFromID = `-- name: GetIntegrationFromID :one
-SELECT id, resource, filters, integration_type, interval_minutes, last_sent_time, config, error_msg, created_by_user_id, created_at, updated_at
-FROM integration
-WHERE id = $1
-LIMIT 1
-`
-
-func (q *Queries) GetIntegrationFromID(ctx context.Context, id int32) (Integration, error) {
-	row := q.db.QueryRowContext(ctx, getIntegrationFromID, id)
-	var i Integration
-	err := row.Scan(
-		&i.ID,
-		&i.Resource,
-		&i.Filters,
-		&i.IntegrationType,
-		&i.IntervalMinutes,
-		&i.LastSentTime,
-		&i.Config,
-		&i.ErrorMsg,
-		&i.CreatedByUserID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
-}
-
-const getIntegrations = `-- name: GetIntegrations :many
-SELECT id, resource, filters, integration_type, interval_minutes, last_sent_time, config, error_msg, created_by_user_id, created_at, updated_at
-FROM integration
+const getCurrentImageTags = `-- name: GetCurrentImageTags :many
+SELECT id, registry_id, image_name, tag, digest, previous_digest, first_seen_at, last_seen_at, replaced_at
+FROM image_tag_history
+WHERE registry_id = $1
+  AND replaced_at IS NULL
 `
 
-func (q *Queries) GetIntegrations(ctx context.Context) ([]Integration, error) {
-	rows, err := q.db.QueryContext(ctx, getIntegrations)
+func (q *Queries) GetCurrentImageTags(ctx context.Context, registryID string) ([]ImageTagHistory, error) {
+	rows, err := q.db.QueryContext(ctx, getCurrentImageTags, registryID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Integration
+	var items []ImageTagHistory
 	for rows.Next() {
-		var i Integration
+		var i ImageTagHistory
 		if err := rows.Scan(
 			&i.ID,
-			&i.Resource,
-			&i.Filters,
-			&i.IntegrationType,
-			&i.IntervalMinutes,
-			&i.LastSentTime,
-			&i.Config,
-			&i.ErrorMsg,
-			&i.CreatedByUserID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.RegistryID,
+			&i.ImageName,
+			&i.Tag,
+			&i.Digest,
+			&i.PreviousDigest,
+			&i.FirstSeenAt,
+			&i.LastSeenAt,
+			&i.ReplacedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCurrentImageTagsByDigest = `-- name: GetCurrentImageTagsByDigest :many
+SELECT id, registry_id, image_name, tag, digest, previous_digest, first_seen_at, last_seen_at, replaced_at
+FROM image_tag_history
+WHERE digest = $1
+  AND replaced_at IS NULL
+ORDER BY registry_id, image_name, tag
+`
+
+func (q *Queries) GetCurrentImageTagsByDigest(ctx context.Context, digest string) ([]ImageTagHistory, error) {
+	rows, err := q.db.QueryContext(ctx, getCurrentImageTagsByDigest, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ImageTagHistory
+	for rows.Next() {
+		var i ImageTagHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RegistryID,
+			&i.ImageName,
+			&i.Tag,
+			&i.Digest,
+			&i.PreviousDigest,
+			&i.FirstSeenAt,
+			&i.LastSeenAt,
+			&i.ReplacedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDueNotificationDeliveries = `-- name: GetDueNotificationDeliveries :many
+SELECT id, integration_id, scan_id, resource, status, attempts, next_attempt_at, last_error, sent_at, created_at, updated_at, messages
+FROM notification_delivery
+WHERE integration_id = $1
+  AND status = $2
+  AND next_attempt_at <= now()
+ORDER BY id
+LIMIT $3
+`
+
+type GetDueNotificationDeliveriesParams struct {
+	IntegrationID int32  `json:"integration_id"`
+	Status        string `json:"status"`
+	Limit         int32  `json:"limit"`
+}
+
+func (q *Queries) GetDueNotificationDeliveries(ctx context.Context, arg GetDueNotificationDeliveriesParams) ([]NotificationDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, getDueNotificationDeliveries, arg.IntegrationID, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationDelivery
+	for rows.Next() {
+		var i NotificationDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.IntegrationID,
+			&i.ScanID,
+			&i.Resource,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.SentAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Messages,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDueScanResultExceptions = `-- name: GetDueScanResultExceptions :many
+SELECT id, scan_type, result_id, scope_type, scope_value, reason, owner, created_by, expires_at, expired, created_at, updated_at
+FROM scan_result_exception
+WHERE expired = false
+  AND expires_at <= now()
+ORDER BY id
+`
+
+func (q *Queries) GetDueScanResultExceptions(ctx context.Context) ([]ScanResultException, error) {
+	rows, err := q.db.QueryContext(ctx, getDueScanResultExceptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScanResultException
+	for rows.Next() {
+		var i ScanResultException
+		if err := rows.Scan(
+			&i.ID,
+			&i.ScanType,
+			&i.ResultID,
+			&i.ScopeType,
+			&i.ScopeValue,
+			&i.Reason,
+			&i.Owner,
+			&i.CreatedBy,
+			&i.ExpiresAt,
+			&i.Expired,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFindingLifecycles = `-- name: GetFindingLifecycles :many
+SELECT id, scan_type, node_id, node_type, result_id, severity, labels, first_seen, opened_at, last_seen, resolved_at, reopened, last_scan_id, created_at, updated_at, cloud_account, sla_policy_id, sla_due_at, sla_status
+FROM finding_lifecycle
+WHERE scan_type = $1
+  AND node_id = $2
+ORDER BY resolved_at IS NOT NULL, first_seen DESC, result_id
+LIMIT $3 OFFSET $4
+`
+
+type GetFindingLifecyclesParams struct {
+	ScanType string `json:"scan_type"`
+	NodeID   string `json:"node_id"`
+	Limit    int32  `json:"limit"`
+	Offset   int32  `json:"offset"`
+}
+
+func (q *Queries) GetFindingLifecycles(ctx context.Context, arg GetFindingLifecyclesParams) ([]FindingLifecycle, error) {
+	rows, err := q.db.QueryContext(ctx, getFindingLifecycles,
+		arg.ScanType,
+		arg.NodeID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindingLifecycle
+	for rows.Next() {
+		var i FindingLifecycle
+		if err := rows.Scan(
+			&i.ID,
+			&i.ScanType,
+			&i.NodeID,
+			&i.NodeType,
+			&i.ResultID,
+			&i.Severity,
+			&i.Labels,
+			&i.FirstSeen,
+			&i.OpenedAt,
+			&i.LastSeen,
+			&i.ResolvedAt,
+			&i.Reopened,
+			&i.LastScanID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CloudAccount,
+			&i.SlaPolicyID,
+			&i.SlaDueAt,
+			&i.SlaStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFindingMTTR = `-- name: GetFindingMTTR :many
+SELECT date_trunc('day', resolved_at, 'UTC')::timestamptz        AS resolved_day,
+       (CASE $1::text
+            WHEN 'severity' THEN lower(severity)
+            WHEN 'node_type' THEN node_type
+            ELSE coalesce(labels ->> $2::text, '') END)::text AS group_value,
+       count(*)                                                  AS resolved_count,
+       sum(extract(epoch FROM resolved_at - opened_at))::float8  AS resolved_seconds
+FROM finding_lifecycle
+WHERE resolved_at >= $3
+  AND resolved_at < $4
+  AND ($5::text = '' OR scan_type = $5)
+GROUP BY resolved_day, group_value
+ORDER BY resolved_day, group_value
+`
+
+type GetFindingMTTRParams struct {
+	GroupBy      string       `json:"group_by"`
+	Label        string       `json:"label"`
+	ResolvedFrom sql.NullTime `json:"resolved_from"`
+	ResolvedTo   sql.NullTime `json:"resolved_to"`
+	ScanType     string       `json:"scan_type"`
+}
+
+type GetFindingMTTRRow struct {
+	ResolvedDay     time.Time `json:"resolved_day"`
+	GroupValue      string    `json:"group_value"`
+	ResolvedCount   int64     `json:"resolved_count"`
+	ResolvedSeconds float64   `json:"resolved_seconds"`
+}
+
+func (q *Queries) GetFindingMTTR(ctx context.Context, arg GetFindingMTTRParams) ([]GetFindingMTTRRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFindingMTTR,
+		arg.GroupBy,
+		arg.Label,
+		arg.ResolvedFrom,
+		arg.ResolvedTo,
+		arg.ScanType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFindingMTTRRow
+	for rows.Next() {
+		var i GetFindingMTTRRow
+		if err := rows.Scan(
+			&i.ResolvedDay,
+			&i.GroupValue,
+			&i.ResolvedCount,
+			&i.ResolvedSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getImageTagHistory = `-- name: GetImageTagHistory :many
+SELECT id, registry_id, image_name, tag, digest, previous_digest, first_seen_at, last_seen_at, replaced_at
+FROM image_tag_history
+WHERE registry_id = $1
+  AND image_name = $2
+  AND tag = $3
+ORDER BY first_seen_at DESC, id DESC
+`
+
+type GetImageTagHistoryParams struct {
+	RegistryID string `json:"registry_id"`
+	ImageName  string `json:"image_name"`
+	Tag        string `json:"tag"`
+}
+
+func (q *Queries) GetImageTagHistory(ctx context.Context, arg GetImageTagHistoryParams) ([]ImageTagHistory, error) {
+	rows, err := q.db.QueryContext(ctx, getImageTagHistory, arg.RegistryID, arg.ImageName, arg.Tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ImageTagHistory
+	for rows.Next() {
+		var i ImageTagHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RegistryID,
+			&i.ImageName,
+			&i.Tag,
+			&i.Digest,
+			&i.PreviousDigest,
+			&i.FirstSeenAt,
+			&i.LastSeenAt,
+			&i.ReplacedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getImageTagHistoryByID = `-- name: GetImageTagHistoryByID :one
+SELECT id, registry_id, image_name, tag, digest, previous_digest, first_seen_at, last_seen_at, replaced_at
+FROM image_tag_history
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetImageTagHistoryByID(ctx context.Context, id int64) (ImageTagHistory, error) {
+	row := q.db.QueryRowContext(ctx, getImageTagHistoryByID, id)
+	var i ImageTagHistory
+	err := row.Scan(
+		&i.ID,
+		&i.RegistryID,
+		&i.ImageName,
+		&i.Tag,
+		&i.Digest,
+		&i.PreviousDigest,
+		&i.FirstSeenAt,
+		&i.LastSeenAt,
+		&i.ReplacedAt,
+	)
+	return i, err
+}
+
+const getIntegrationFromID = `-- name: GetIntegrationFromID :one
+SELECT id, resource, filters, integration_type, interval_minutes, last_sent_time, config, error_msg, created_by_user_id, created_at, updated_at, last_scan_cursor, last_success_time, message_template, digest_interval, rate_limit, last_digest_time, new_findings_only, notify_resolved, event_attempts, event_next_attempt_at, last_event_id
+FROM integration
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetIntegrationFromID(ctx context.Context, id int32) (Integration, error) {
+	row := q.db.QueryRowContext(ctx, getIntegrationFromID, id)
+	var i Integration
+	err := row.Scan(
+		&i.ID,
+		&i.Resource,
+		&i.Filters,
+		&i.IntegrationType,
+		&i.IntervalMinutes,
+		&i.LastSentTime,
+		&i.Config,
+		&i.ErrorMsg,
+		&i.CreatedByUserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastScanCursor,
+		&i.LastSuccessTime,
+		&i.MessageTemplate,
+		&i.DigestInterval,
+		&i.RateLimit,
+		&i.LastDigestTime,
+		&i.NewFindingsOnly,
+		&i.NotifyResolved,
+		&i.EventAttempts,
+		&i.EventNextAttemptAt,
+		&i.LastEventID,
+	)
+	return i, err
+}
+
+const getIntegrations = `-- name: GetIntegrations :many
+SELECT id, resource, filters, integration_type, interval_minutes, last_sent_time, config, error_msg, created_by_user_id, created_at, updated_at, last_scan_cursor, last_success_time, message_template, digest_interval, rate_limit, last_digest_time, new_findings_only, notify_resolved, event_attempts, event_next_attempt_at, last_event_id
+FROM integration
+`
+
+func (q *Queries) GetIntegrations(ctx context.Context) ([]Integration, error) {
+	rows, err := q.db.QueryContext(ctx, getIntegrations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Integration
+	for rows.Next() {
+		var i Integration
+		if err := rows.Scan(
+			&i.ID,
+			&i.Resource,
+			&i.Filters,
+			&i.IntegrationType,
+			&i.IntervalMinutes,
+			&i.LastSentTime,
+			&i.Config,
+			&i.ErrorMsg,
+			&i.CreatedByUserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastScanCursor,
+			&i.LastSuccessTime,
+			&i.MessageTemplate,
+			&i.DigestInterval,
+			&i.RateLimit,
+			&i.LastDigestTime,
+			&i.NewFindingsOnly,
+			&i.NotifyResolved,
+			&i.EventAttempts,
+			&i.EventNextAttemptAt,
+			&i.LastEventID,
 		); err != nil {
 			return nil, err
 		}
@@ -1556,32 +2478,263 @@ func (q *Queries) GetIntegrations(ctx context.Context) ([]Integration, error) {
 }
 
 const getIntegrationsFromType = `-- name: GetIntegrationsFromType :many
-SELECT id, resource, filters, integration_type, interval_minutes, last_sent_time, config, error_msg, created_by_user_id, created_at, updated_at
+SELECT id, resource, filters, integration_type, interval_minutes, last_sent_time, config, error_msg, created_by_user_id, created_at, updated_at, last_scan_cursor, last_success_time, message_template, digest_interval, rate_limit, last_digest_time, new_findings_only, notify_resolved, event_attempts, event_next_attempt_at, last_event_id
 FROM integration
 WHERE integration_type = $1
 `
 
-func (q *Queries) GetIntegrationsFromType(ctx context.Context, integrationType string) ([]Integration, error) {
-	rows, err := q.db.QueryContext(ctx, getIntegrationsFromType, integrationType)
+func (q *Queries) GetIntegrationsFromType(ctx context.Context, integrationType string) ([]Integration, error) {
+	rows, err := q.db.QueryContext(ctx, getIntegrationsFromType, integrationType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Integration
+	for rows.Next() {
+		var i Integration
+		if err := rows.Scan(
+			&i.ID,
+			&i.Resource,
+			&i.Filters,
+			&i.IntegrationType,
+			&i.IntervalMinutes,
+			&i.LastSentTime,
+			&i.Config,
+			&i.ErrorMsg,
+			&i.CreatedByUserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastScanCursor,
+			&i.LastSuccessTime,
+			&i.MessageTemplate,
+			&i.DigestInterval,
+			&i.RateLimit,
+			&i.LastDigestTime,
+			&i.NewFindingsOnly,
+			&i.NotifyResolved,
+			&i.EventAttempts,
+			&i.EventNextAttemptAt,
+			&i.LastEventID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNativeVulnerabilityReportFetchTimes = `-- name: GetNativeVulnerabilityReportFetchTimes :many
+SELECT digest, fetched_at
+FROM native_vulnerability_report
+WHERE registry_id = $1
+`
+
+type GetNativeVulnerabilityReportFetchTimesRow struct {
+	Digest    string    `json:"digest"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+func (q *Queries) GetNativeVulnerabilityReportFetchTimes(ctx context.Context, registryID string) ([]GetNativeVulnerabilityReportFetchTimesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNativeVulnerabilityReportFetchTimes, registryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNativeVulnerabilityReportFetchTimesRow
+	for rows.Next() {
+		var i GetNativeVulnerabilityReportFetchTimesRow
+		if err := rows.Scan(
+			&i.Digest,
+			&i.FetchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNativeVulnerabilityReportsByDigest = `-- name: GetNativeVulnerabilityReportsByDigest :many
+SELECT id, registry_id, image_name, digest, source, scanner, vulnerabilities, generated_at, fetched_at
+FROM native_vulnerability_report
+WHERE digest = $1
+ORDER BY registry_id
+`
+
+func (q *Queries) GetNativeVulnerabilityReportsByDigest(ctx context.Context, digest string) ([]NativeVulnerabilityReport, error) {
+	rows, err := q.db.QueryContext(ctx, getNativeVulnerabilityReportsByDigest, digest)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Integration
+	var items []NativeVulnerabilityReport
 	for rows.Next() {
-		var i Integration
+		var i NativeVulnerabilityReport
+		if err := rows.Scan(
+			&i.ID,
+			&i.RegistryID,
+			&i.ImageName,
+			&i.Digest,
+			&i.Source,
+			&i.Scanner,
+			&i.Vulnerabilities,
+			&i.GeneratedAt,
+			&i.FetchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationDeliveriesByStatus = `-- name: GetNotificationDeliveriesByStatus :many
+SELECT id, integration_id, scan_id, resource, status, attempts, next_attempt_at, last_error, sent_at, created_at, updated_at, messages
+FROM notification_delivery
+WHERE integration_id = $1
+  AND status = $2
+ORDER BY id
+LIMIT $3
+`
+
+type GetNotificationDeliveriesByStatusParams struct {
+	IntegrationID int32  `json:"integration_id"`
+	Status        string `json:"status"`
+	Limit         int32  `json:"limit"`
+}
+
+func (q *Queries) GetNotificationDeliveriesByStatus(ctx context.Context, arg GetNotificationDeliveriesByStatusParams) ([]NotificationDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, getNotificationDeliveriesByStatus, arg.IntegrationID, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationDelivery
+	for rows.Next() {
+		var i NotificationDelivery
 		if err := rows.Scan(
 			&i.ID,
+			&i.IntegrationID,
+			&i.ScanID,
 			&i.Resource,
-			&i.Filters,
-			&i.IntegrationType,
-			&i.IntervalMinutes,
-			&i.LastSentTime,
-			&i.Config,
-			&i.ErrorMsg,
-			&i.CreatedByUserID,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.SentAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Messages,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationEventsAfter = `-- name: GetNotificationEventsAfter :many
+SELECT id, event_type, node_id, node_type, message, details, created_at
+FROM notification_event
+WHERE id > $1
+  AND created_at >= $2
+ORDER BY id
+LIMIT $3
+`
+
+type GetNotificationEventsAfterParams struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Limit     int32     `json:"limit"`
+}
+
+func (q *Queries) GetNotificationEventsAfter(ctx context.Context, arg GetNotificationEventsAfterParams) ([]NotificationEvent, error) {
+	rows, err := q.db.QueryContext(ctx, getNotificationEventsAfter, arg.ID, arg.CreatedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationEvent
+	for rows.Next() {
+		var i NotificationEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.NodeID,
+			&i.NodeType,
+			&i.Message,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOpenFindingSLACounts = `-- name: GetOpenFindingSLACounts :many
+SELECT node_id,
+       node_type,
+       count(*) FILTER (WHERE sla_status = 'breached') AS breached_count,
+       count(*) FILTER (WHERE sla_status = 'due_soon') AS due_soon_count
+FROM finding_lifecycle
+WHERE resolved_at IS NULL
+  AND sla_status IN ('breached', 'due_soon')
+GROUP BY node_id, node_type
+`
+
+type GetOpenFindingSLACountsRow struct {
+	NodeID        string `json:"node_id"`
+	NodeType      string `json:"node_type"`
+	BreachedCount int64  `json:"breached_count"`
+	DueSoonCount  int64  `json:"due_soon_count"`
+}
+
+func (q *Queries) GetOpenFindingSLACounts(ctx context.Context) ([]GetOpenFindingSLACountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getOpenFindingSLACounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOpenFindingSLACountsRow
+	for rows.Next() {
+		var i GetOpenFindingSLACountsRow
+		if err := rows.Scan(
+			&i.NodeID,
+			&i.NodeType,
+			&i.BreachedCount,
+			&i.DueSoonCount,
 		); err != nil {
 			return nil, err
 		}
@@ -1682,6 +2835,133 @@ func (q *Queries) GetPasswordResetById(ctx context.Context, id int32) (GetPasswo
 	return i, err
 }
 
+const getRegistryScanPolicies = `-- name: GetRegistryScanPolicies :many
+SELECT id, container_registry_id, name, scan_types, filters, rescan_after_days, max_concurrent_scans, is_enabled, last_evaluated_at, last_status, created_at, updated_at
+FROM registry_scan_policy
+WHERE container_registry_id = $1
+ORDER BY id
+`
+
+func (q *Queries) GetRegistryScanPolicies(ctx context.Context, containerRegistryID int32) ([]RegistryScanPolicy, error) {
+	rows, err := q.db.QueryContext(ctx, getRegistryScanPolicies, containerRegistryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RegistryScanPolicy
+	for rows.Next() {
+		var i RegistryScanPolicy
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContainerRegistryID,
+			&i.Name,
+			&i.ScanTypes,
+			&i.Filters,
+			&i.RescanAfterDays,
+			&i.MaxConcurrentScans,
+			&i.IsEnabled,
+			&i.LastEvaluatedAt,
+			&i.LastStatus,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRegistryScanPolicy = `-- name: GetRegistryScanPolicy :one
+SELECT id, container_registry_id, name, scan_types, filters, rescan_after_days, max_concurrent_scans, is_enabled, last_evaluated_at, last_status, created_at, updated_at
+FROM registry_scan_policy
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetRegistryScanPolicy(ctx context.Context, id int32) (RegistryScanPolicy, error) {
+	row := q.db.QueryRowContext(ctx, getRegistryScanPolicy, id)
+	var i RegistryScanPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.ContainerRegistryID,
+		&i.Name,
+		&i.ScanTypes,
+		&i.Filters,
+		&i.RescanAfterDays,
+		&i.MaxConcurrentScans,
+		&i.IsEnabled,
+		&i.LastEvaluatedAt,
+		&i.LastStatus,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRegistryWebhook = `-- name: GetRegistryWebhook :one
+SELECT id, container_registry_id, token_hash, scan_types, created_at, updated_at
+FROM registry_webhook
+WHERE container_registry_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetRegistryWebhook(ctx context.Context, containerRegistryID int32) (RegistryWebhook, error) {
+	row := q.db.QueryRowContext(ctx, getRegistryWebhook, containerRegistryID)
+	var i RegistryWebhook
+	err := row.Scan(
+		&i.ID,
+		&i.ContainerRegistryID,
+		&i.TokenHash,
+		&i.ScanTypes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRegistryWebhooksFromTokenHash = `-- name: GetRegistryWebhooksFromTokenHash :many
+SELECT id, container_registry_id, token_hash, scan_types, created_at, updated_at
+FROM registry_webhook
+WHERE token_hash = $1
+`
+
+func (q *Queries) GetRegistryWebhooksFromTokenHash(ctx context.Context, tokenHash string) ([]RegistryWebhook, error) {
+	rows, err := q.db.QueryContext(ctx, getRegistryWebhooksFromTokenHash, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RegistryWebhook
+	for rows.Next() {
+		var i RegistryWebhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.ContainerRegistryID,
+			&i.TokenHash,
+			&i.ScanTypes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRoleByID = `-- name: GetRoleByID :one
 SELECT id, name, created_at, updated_at
 FROM role
@@ -1720,24 +3000,162 @@ func (q *Queries) GetRoleByName(ctx context.Context, name string) (Role, error)
 	return i, err
 }
 
-const getRoles = `-- name: GetRoles :many
-SELECT id, name, created_at, updated_at
-FROM role
-ORDER BY name
+const getRoles = `-- name: GetRoles :many
+SELECT id, name, created_at, updated_at
+FROM role
+ORDER BY name
+`
+
+func (q *Queries) GetRoles(ctx context.Context) ([]Role, error) {
+	rows, err := q.db.QueryContext(ctx, getRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Role
+	for rows.Next() {
+		var i Role
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSLAPolicies = `-- name: GetSLAPolicies :many
+SELECT id, name, scan_type, severity, days, due_soon_days, scope_type, scope_key, scope_value, created_by, created_at, updated_at
+FROM sla_policy
+ORDER BY scan_type, severity, id
+`
+
+func (q *Queries) GetSLAPolicies(ctx context.Context) ([]SlaPolicy, error) {
+	rows, err := q.db.QueryContext(ctx, getSLAPolicies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SlaPolicy
+	for rows.Next() {
+		var i SlaPolicy
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ScanType,
+			&i.Severity,
+			&i.Days,
+			&i.DueSoonDays,
+			&i.ScopeType,
+			&i.ScopeKey,
+			&i.ScopeValue,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSLAPolicy = `-- name: GetSLAPolicy :one
+SELECT id, name, scan_type, severity, days, due_soon_days, scope_type, scope_key, scope_value, created_by, created_at, updated_at
+FROM sla_policy
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetSLAPolicy(ctx context.Context, id int64) (SlaPolicy, error) {
+	row := q.db.QueryRowContext(ctx, getSLAPolicy, id)
+	var i SlaPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ScanType,
+		&i.Severity,
+		&i.Days,
+		&i.DueSoonDays,
+		&i.ScopeType,
+		&i.ScopeKey,
+		&i.ScopeValue,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getScanResultException = `-- name: GetScanResultException :one
+SELECT id, scan_type, result_id, scope_type, scope_value, reason, owner, created_by, expires_at, expired, created_at, updated_at
+FROM scan_result_exception
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetScanResultException(ctx context.Context, id int64) (ScanResultException, error) {
+	row := q.db.QueryRowContext(ctx, getScanResultException, id)
+	var i ScanResultException
+	err := row.Scan(
+		&i.ID,
+		&i.ScanType,
+		&i.ResultID,
+		&i.ScopeType,
+		&i.ScopeValue,
+		&i.Reason,
+		&i.Owner,
+		&i.CreatedBy,
+		&i.ExpiresAt,
+		&i.Expired,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getScanResultExceptions = `-- name: GetScanResultExceptions :many
+SELECT id, scan_type, result_id, scope_type, scope_value, reason, owner, created_by, expires_at, expired, created_at, updated_at
+FROM scan_result_exception
+ORDER BY id DESC
 `
 
-func (q *Queries) GetRoles(ctx context.Context) ([]Role, error) {
-	rows, err := q.db.QueryContext(ctx, getRoles)
+func (q *Queries) GetScanResultExceptions(ctx context.Context) ([]ScanResultException, error) {
+	rows, err := q.db.QueryContext(ctx, getScanResultExceptions)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Role
+	var items []ScanResultException
 	for rows.Next() {
-		var i Role
+		var i ScanResultException
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
+			&i.ScanType,
+			&i.ResultID,
+			&i.ScopeType,
+			&i.ScopeValue,
+			&i.Reason,
+			&i.Owner,
+			&i.CreatedBy,
+			&i.ExpiresAt,
+			&i.Expired,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -1878,6 +3296,73 @@ func (q *Queries) GetSettings(ctx context.Context) ([]Setting, error) {
 	return items, nil
 }
 
+const getSourceRepositories = `-- name: GetSourceRepositories :many
+SELECT id, name, provider, url, branch, auth_type, username, known_hosts, encrypted_secret, created_at, updated_at
+FROM source_repository
+ORDER BY name
+`
+
+func (q *Queries) GetSourceRepositories(ctx context.Context) ([]SourceRepository, error) {
+	rows, err := q.db.QueryContext(ctx, getSourceRepositories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SourceRepository
+	for rows.Next() {
+		var i SourceRepository
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Provider,
+			&i.Url,
+			&i.Branch,
+			&i.AuthType,
+			&i.Username,
+			&i.KnownHosts,
+			&i.EncryptedSecret,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSourceRepository = `-- name: GetSourceRepository :one
+SELECT id, name, provider, url, branch, auth_type, username, known_hosts, encrypted_secret, created_at, updated_at
+FROM source_repository
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetSourceRepository(ctx context.Context, id int32) (SourceRepository, error) {
+	row := q.db.QueryRowContext(ctx, getSourceRepository, id)
+	var i SourceRepository
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Provider,
+		&i.Url,
+		&i.Branch,
+		&i.AuthType,
+		&i.Username,
+		&i.KnownHosts,
+		&i.EncryptedSecret,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getUser = `-- name: GetUser :one
 SELECT users.id,
        users.first_name,
@@ -2304,6 +3789,74 @@ func (q *Queries) GetVisibleSettings(ctx context.Context) ([]Setting, error) {
 	return items, nil
 }
 
+const replaceImageTagHistory = `-- name: ReplaceImageTagHistory :exec
+UPDATE image_tag_history
+SET replaced_at = now()
+WHERE id = $1
+  AND replaced_at IS NULL
+`
+
+func (q *Queries) ReplaceImageTagHistory(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, replaceImageTagHistory, id)
+	return err
+}
+
+const resolveFindingLifecycles = `-- name: ResolveFindingLifecycles :exec
+UPDATE finding_lifecycle
+SET resolved_at = $1
+WHERE scan_type = $2
+  AND node_id = $3
+  AND resolved_at IS NULL
+  AND last_seen < $1
+  AND NOT (result_id = ANY ($4::text[]))
+`
+
+type ResolveFindingLifecyclesParams struct {
+	ResolvedAt sql.NullTime `json:"resolved_at"`
+	ScanType   string       `json:"scan_type"`
+	NodeID     string       `json:"node_id"`
+	ResultIds  []string     `json:"result_ids"`
+}
+
+func (q *Queries) ResolveFindingLifecycles(ctx context.Context, arg ResolveFindingLifecyclesParams) error {
+	_, err := q.db.ExecContext(ctx, resolveFindingLifecycles,
+		arg.ResolvedAt,
+		arg.ScanType,
+		arg.NodeID,
+		pq.Array(arg.ResultIds),
+	)
+	return err
+}
+
+const setNotificationDeliveryStatus = `-- name: SetNotificationDeliveryStatus :exec
+UPDATE notification_delivery
+SET status  = $2,
+    sent_at = $3
+WHERE id = $1
+`
+
+type SetNotificationDeliveryStatusParams struct {
+	ID     int64        `json:"id"`
+	Status string       `json:"status"`
+	SentAt sql.NullTime `json:"sent_at"`
+}
+
+func (q *Queries) SetNotificationDeliveryStatus(ctx context.Context, arg SetNotificationDeliveryStatusParams) error {
+	_, err := q.db.ExecContext(ctx, setNotificationDeliveryStatus, arg.ID, arg.Status, arg.SentAt)
+	return err
+}
+
+const setScanResultExceptionExpired = `-- name: SetScanResultExceptionExpired :exec
+UPDATE scan_result_exception
+SET expired = true
+WHERE id = $1
+`
+
+func (q *Queries) SetScanResultExceptionExpired(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, setScanResultExceptionExpired, id)
+	return err
+}
+
 const updateContainerRegistry = `-- name: UpdateContainerRegistry :one
 UPDATE container_registry
 SET name=$1,
@@ -2312,7 +3865,7 @@ SET name=$1,
     non_secret=$4,
     extras=$5
 WHERE id = $6
-RETURNING id, name, registry_type, encrypted_secret, non_secret, extras, created_at, updated_at
+RETURNING id, name, registry_type, encrypted_secret, non_secret, extras, created_at, updated_at, sync_options
 `
 
 type UpdateContainerRegistryParams struct {
@@ -2343,10 +3896,160 @@ func (q *Queries) UpdateContainerRegistry(ctx context.Context, arg UpdateContain
 		&i.Extras,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SyncOptions,
 	)
 	return i, err
 }
 
+const updateContainerRegistrySyncOptions = `-- name: UpdateContainerRegistrySyncOptions :exec
+UPDATE container_registry
+SET sync_options=$1
+WHERE id = $2
+`
+
+type UpdateContainerRegistrySyncOptionsParams struct {
+	SyncOptions json.RawMessage `json:"sync_options"`
+	ID          int32           `json:"id"`
+}
+
+func (q *Queries) UpdateContainerRegistrySyncOptions(ctx context.Context, arg UpdateContainerRegistrySyncOptionsParams) error {
+	_, err := q.db.ExecContext(ctx, updateContainerRegistrySyncOptions, arg.SyncOptions, arg.ID)
+	return err
+}
+
+const updateImageTagHistoriesLastSeen = `-- name: UpdateImageTagHistoriesLastSeen :exec
+UPDATE image_tag_history
+SET last_seen_at = now()
+WHERE id = ANY ($1::bigint[])
+`
+
+func (q *Queries) UpdateImageTagHistoriesLastSeen(ctx context.Context, ids []int64) error {
+	_, err := q.db.ExecContext(ctx, updateImageTagHistoriesLastSeen, pq.Array(ids))
+	return err
+}
+
+const updateIntegration = `-- name: UpdateIntegration :exec
+UPDATE integration
+SET resource          = $2,
+    filters           = $3,
+    config            = $4,
+    message_template  = $5,
+    digest_interval   = $6,
+    rate_limit        = $7,
+    new_findings_only = $8,
+    notify_resolved   = $9,
+    error_msg         = NULL,
+    last_scan_cursor      = CASE WHEN resource = $2 THEN last_scan_cursor ELSE (extract(epoch from now()) * 1000)::bigint END,
+    last_event_id         = CASE WHEN resource = $2 THEN last_event_id ELSE (SELECT max(id) FROM notification_event) END,
+    event_attempts        = CASE WHEN resource = $2 THEN event_attempts ELSE 0 END,
+    event_next_attempt_at = CASE WHEN resource = $2 THEN event_next_attempt_at ELSE NULL END
+WHERE id = $1
+`
+
+type UpdateIntegrationParams struct {
+	ID              int32           `json:"id"`
+	Resource        string          `json:"resource"`
+	Filters         json.RawMessage `json:"filters"`
+	Config          json.RawMessage `json:"config"`
+	MessageTemplate json.RawMessage `json:"message_template"`
+	DigestInterval  string          `json:"digest_interval"`
+	RateLimit       int32           `json:"rate_limit"`
+	NewFindingsOnly bool            `json:"new_findings_only"`
+	NotifyResolved  bool            `json:"notify_resolved"`
+}
+
+func (q *Queries) UpdateIntegration(ctx context.Context, arg UpdateIntegrationParams) error {
+	_, err := q.db.ExecContext(ctx, updateIntegration,
+		arg.ID,
+		arg.Resource,
+		arg.Filters,
+		arg.Config,
+		arg.MessageTemplate,
+		arg.DigestInterval,
+		arg.RateLimit,
+		arg.NewFindingsOnly,
+		arg.NotifyResolved,
+	)
+	return err
+}
+
+const updateIntegrationEventCursor = `-- name: UpdateIntegrationEventCursor :exec
+UPDATE integration
+SET last_event_id = $2
+WHERE id = $1
+`
+
+type UpdateIntegrationEventCursorParams struct {
+	ID          int32         `json:"id"`
+	LastEventID sql.NullInt64 `json:"last_event_id"`
+}
+
+func (q *Queries) UpdateIntegrationEventCursor(ctx context.Context, arg UpdateIntegrationEventCursorParams) error {
+	_, err := q.db.ExecContext(ctx, updateIntegrationEventCursor, arg.ID, arg.LastEventID)
+	return err
+}
+
+const updateIntegrationEventRetry = `-- name: UpdateIntegrationEventRetry :exec
+UPDATE integration
+SET event_attempts        = $2,
+    event_next_attempt_at = $3
+WHERE id = $1
+`
+
+type UpdateIntegrationEventRetryParams struct {
+	ID                 int32        `json:"id"`
+	EventAttempts      int32        `json:"event_attempts"`
+	EventNextAttemptAt sql.NullTime `json:"event_next_attempt_at"`
+}
+
+func (q *Queries) UpdateIntegrationEventRetry(ctx context.Context, arg UpdateIntegrationEventRetryParams) error {
+	_, err := q.db.ExecContext(ctx, updateIntegrationEventRetry, arg.ID, arg.EventAttempts, arg.EventNextAttemptAt)
+	return err
+}
+
+const updateIntegrationLastDigest = `-- name: UpdateIntegrationLastDigest :exec
+UPDATE integration
+SET last_digest_time = $2
+WHERE id = $1
+`
+
+type UpdateIntegrationLastDigestParams struct {
+	ID             int32        `json:"id"`
+	LastDigestTime sql.NullTime `json:"last_digest_time"`
+}
+
+func (q *Queries) UpdateIntegrationLastDigest(ctx context.Context, arg UpdateIntegrationLastDigestParams) error {
+	_, err := q.db.ExecContext(ctx, updateIntegrationLastDigest, arg.ID, arg.LastDigestTime)
+	return err
+}
+
+const updateIntegrationLastSuccess = `-- name: UpdateIntegrationLastSuccess :exec
+UPDATE integration
+SET last_success_time = now()
+WHERE id = $1
+`
+
+func (q *Queries) UpdateIntegrationLastSuccess(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, updateIntegrationLastSuccess, id)
+	return err
+}
+
+const updateIntegrationScanCursor = `-- name: UpdateIntegrationScanCursor :exec
+UPDATE integration
+SET last_scan_cursor = $2
+WHERE id = $1
+`
+
+type UpdateIntegrationScanCursorParams struct {
+	ID             int32         `json:"id"`
+	LastScanCursor sql.NullInt64 `json:"last_scan_cursor"`
+}
+
+func (q *Queries) UpdateIntegrationScanCursor(ctx context.Context, arg UpdateIntegrationScanCursorParams) error {
+	_, err := q.db.ExecContext(ctx, updateIntegrationScanCursor, arg.ID, arg.LastScanCursor)
+	return err
+}
+
 const updateIntegrationStatus = `-- name: UpdateIntegrationStatus :exec
 UPDATE integration
 SET error_msg      = $2,
@@ -2364,6 +4067,38 @@ func (q *Queries) UpdateIntegrationStatus(ctx context.Context, arg UpdateIntegra
 	return err
 }
 
+const updateNotificationDeliveryStatus = `-- name: UpdateNotificationDeliveryStatus :exec
+UPDATE notification_delivery
+SET status          = $2,
+    attempts        = attempts + 1,
+    last_error      = $3,
+    next_attempt_at = $4,
+    sent_at         = $5,
+    messages        = $6
+WHERE id = $1
+`
+
+type UpdateNotificationDeliveryStatusParams struct {
+	ID            int64          `json:"id"`
+	Status        string         `json:"status"`
+	LastError     sql.NullString `json:"last_error"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	SentAt        sql.NullTime   `json:"sent_at"`
+	Messages      int32          `json:"messages"`
+}
+
+func (q *Queries) UpdateNotificationDeliveryStatus(ctx context.Context, arg UpdateNotificationDeliveryStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateNotificationDeliveryStatus,
+		arg.ID,
+		arg.Status,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.SentAt,
+		arg.Messages,
+	)
+	return err
+}
+
 const updatePassword = `-- name: UpdatePassword :exec
 UPDATE users
 SET password_hash = $1
@@ -2380,6 +4115,168 @@ func (q *Queries) UpdatePassword(ctx context.Context, arg UpdatePasswordParams)
 	return err
 }
 
+const updateRegistryScanPolicy = `-- name: UpdateRegistryScanPolicy :one
+UPDATE registry_scan_policy
+SET name=$1,
+    scan_types=$2,
+    filters=$3,
+    rescan_after_days=$4,
+    max_concurrent_scans=$5,
+    is_enabled=$6
+WHERE id = $7
+RETURNING id, container_registry_id, name, scan_types, filters, rescan_after_days, max_concurrent_scans, is_enabled, last_evaluated_at, last_status, created_at, updated_at
+`
+
+type UpdateRegistryScanPolicyParams struct {
+	Name               string          `json:"name"`
+	ScanTypes          json.RawMessage `json:"scan_types"`
+	Filters            json.RawMessage `json:"filters"`
+	RescanAfterDays    int32           `json:"rescan_after_days"`
+	MaxConcurrentScans int32           `json:"max_concurrent_scans"`
+	IsEnabled          bool            `json:"is_enabled"`
+	ID                 int32           `json:"id"`
+}
+
+func (q *Queries) UpdateRegistryScanPolicy(ctx context.Context, arg UpdateRegistryScanPolicyParams) (RegistryScanPolicy, error) {
+	row := q.db.QueryRowContext(ctx, updateRegistryScanPolicy,
+		arg.Name,
+		arg.ScanTypes,
+		arg.Filters,
+		arg.RescanAfterDays,
+		arg.MaxConcurrentScans,
+		arg.IsEnabled,
+		arg.ID,
+	)
+	var i RegistryScanPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.ContainerRegistryID,
+		&i.Name,
+		&i.ScanTypes,
+		&i.Filters,
+		&i.RescanAfterDays,
+		&i.MaxConcurrentScans,
+		&i.IsEnabled,
+		&i.LastEvaluatedAt,
+		&i.LastStatus,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateRegistryScanPolicyStatus = `-- name: UpdateRegistryScanPolicyStatus :exec
+UPDATE registry_scan_policy
+SET last_status=$1,
+    last_evaluated_at=now()
+WHERE id = $2
+`
+
+type UpdateRegistryScanPolicyStatusParams struct {
+	LastStatus string `json:"last_status"`
+	ID         int32  `json:"id"`
+}
+
+func (q *Queries) UpdateRegistryScanPolicyStatus(ctx context.Context, arg UpdateRegistryScanPolicyStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateRegistryScanPolicyStatus, arg.LastStatus, arg.ID)
+	return err
+}
+
+const updateSLAPolicy = `-- name: UpdateSLAPolicy :one
+UPDATE sla_policy
+SET name          = $1,
+    severity      = $2,
+    days          = $3,
+    due_soon_days = $4,
+    scope_type    = $5,
+    scope_key     = $6,
+    scope_value   = $7
+WHERE id = $8
+RETURNING id, name, scan_type, severity, days, due_soon_days, scope_type, scope_key, scope_value, created_by, created_at, updated_at
+`
+
+type UpdateSLAPolicyParams struct {
+	Name        string `json:"name"`
+	Severity    string `json:"severity"`
+	Days        int32  `json:"days"`
+	DueSoonDays int32  `json:"due_soon_days"`
+	ScopeType   string `json:"scope_type"`
+	ScopeKey    string `json:"scope_key"`
+	ScopeValue  string `json:"scope_value"`
+	ID          int64  `json:"id"`
+}
+
+func (q *Queries) UpdateSLAPolicy(ctx context.Context, arg UpdateSLAPolicyParams) (SlaPolicy, error) {
+	row := q.db.QueryRowContext(ctx, updateSLAPolicy,
+		arg.Name,
+		arg.Severity,
+		arg.Days,
+		arg.DueSoonDays,
+		arg.ScopeType,
+		arg.ScopeKey,
+		arg.ScopeValue,
+		arg.ID,
+	)
+	var i SlaPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ScanType,
+		&i.Severity,
+		&i.Days,
+		&i.DueSoonDays,
+		&i.ScopeType,
+		&i.ScopeKey,
+		&i.ScopeValue,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateScanResultException = `-- name: UpdateScanResultException :one
+UPDATE scan_result_exception
+SET reason     = $1,
+    owner      = $2,
+    expires_at = $3,
+    expired    = false
+WHERE id = $4
+RETURNING id, scan_type, result_id, scope_type, scope_value, reason, owner, created_by, expires_at, expired, created_at, updated_at
+`
+
+type UpdateScanResultExceptionParams struct {
+	Reason    string       `json:"reason"`
+	Owner     string       `json:"owner"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	ID        int64        `json:"id"`
+}
+
+func (q *Queries) UpdateScanResultException(ctx context.Context, arg UpdateScanResultExceptionParams) (ScanResultException, error) {
+	row := q.db.QueryRowContext(ctx, updateScanResultException,
+		arg.Reason,
+		arg.Owner,
+		arg.ExpiresAt,
+		arg.ID,
+	)
+	var i ScanResultException
+	err := row.Scan(
+		&i.ID,
+		&i.ScanType,
+		&i.ResultID,
+		&i.ScopeType,
+		&i.ScopeValue,
+		&i.Reason,
+		&i.Owner,
+		&i.CreatedBy,
+		&i.ExpiresAt,
+		&i.Expired,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const updateSchedule = `-- name: UpdateSchedule :exec
 UPDATE scheduler
 SET description = $1,
@@ -2561,3 +4458,124 @@ func (q *Queries) UpdateUserInvite(ctx context.Context, arg UpdateUserInvitePara
 	)
 	return i, err
 }
+
+const upsertFindingLifecycles = `-- name: UpsertFindingLifecycles :exec
+INSERT INTO finding_lifecycle (scan_type, node_id, node_type, labels, result_id, severity, first_seen, opened_at,
+                               last_seen, last_scan_id, cloud_account)
+SELECT $1,
+       $2,
+       $3,
+       $4,
+       unnest($5::text[]),
+       unnest($6::text[]),
+       $7,
+       $7,
+       $7,
+       $8,
+       $9
+ON CONFLICT (scan_type, node_id, result_id) DO UPDATE
+    SET node_type     = excluded.node_type,
+        labels        = excluded.labels,
+        cloud_account = excluded.cloud_account,
+        severity      = excluded.severity,
+        last_seen     = excluded.last_seen,
+        last_scan_id  = excluded.last_scan_id,
+        opened_at     = CASE WHEN finding_lifecycle.resolved_at IS NULL THEN finding_lifecycle.opened_at ELSE excluded.last_seen END,
+        reopened      = finding_lifecycle.reopened + CASE WHEN finding_lifecycle.resolved_at IS NULL THEN 0 ELSE 1 END,
+        sla_policy_id = CASE WHEN finding_lifecycle.resolved_at IS NULL THEN finding_lifecycle.sla_policy_id END,
+        sla_due_at    = CASE WHEN finding_lifecycle.resolved_at IS NULL THEN finding_lifecycle.sla_due_at END,
+        sla_status    = CASE WHEN finding_lifecycle.resolved_at IS NULL THEN finding_lifecycle.sla_status ELSE '' END,
+        resolved_at   = NULL
+WHERE finding_lifecycle.last_seen <= excluded.last_seen
+`
+
+type UpsertFindingLifecyclesParams struct {
+	ScanType     string          `json:"scan_type"`
+	NodeID       string          `json:"node_id"`
+	NodeType     string          `json:"node_type"`
+	Labels       json.RawMessage `json:"labels"`
+	ResultIds    []string        `json:"result_ids"`
+	Severities   []string        `json:"severities"`
+	SeenAt       time.Time       `json:"seen_at"`
+	ScanID       string          `json:"scan_id"`
+	CloudAccount string          `json:"cloud_account"`
+}
+
+func (q *Queries) UpsertFindingLifecycles(ctx context.Context, arg UpsertFindingLifecyclesParams) error {
+	_, err := q.db.ExecContext(ctx, upsertFindingLifecycles,
+		arg.ScanType,
+		arg.NodeID,
+		arg.NodeType,
+		arg.Labels,
+		pq.Array(arg.ResultIds),
+		pq.Array(arg.Severities),
+		arg.SeenAt,
+		arg.ScanID,
+		arg.CloudAccount,
+	)
+	return err
+}
+
+const upsertNativeVulnerabilityReport = `-- name: UpsertNativeVulnerabilityReport :exec
+INSERT INTO native_vulnerability_report (registry_id, image_name, digest, source, scanner, vulnerabilities, generated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (registry_id, digest) DO UPDATE
+    SET image_name      = $2,
+        source          = $4,
+        scanner         = $5,
+        vulnerabilities = $6,
+        generated_at    = $7,
+        fetched_at      = now()
+`
+
+type UpsertNativeVulnerabilityReportParams struct {
+	RegistryID      string          `json:"registry_id"`
+	ImageName       string          `json:"image_name"`
+	Digest          string          `json:"digest"`
+	Source          string          `json:"source"`
+	Scanner         string          `json:"scanner"`
+	Vulnerabilities json.RawMessage `json:"vulnerabilities"`
+	GeneratedAt     sql.NullTime    `json:"generated_at"`
+}
+
+func (q *Queries) UpsertNativeVulnerabilityReport(ctx context.Context, arg UpsertNativeVulnerabilityReportParams) error {
+	_, err := q.db.ExecContext(ctx, upsertNativeVulnerabilityReport,
+		arg.RegistryID,
+		arg.ImageName,
+		arg.Digest,
+		arg.Source,
+		arg.Scanner,
+		arg.Vulnerabilities,
+		arg.GeneratedAt,
+	)
+	return err
+}
+
+const upsertRegistryWebhook = `-- name: UpsertRegistryWebhook :one
+INSERT INTO registry_webhook (container_registry_id, token_hash, scan_types)
+VALUES ($1, $2, $3)
+ON CONFLICT (container_registry_id) DO UPDATE
+    SET token_hash = $2,
+        scan_types = $3
+RETURNING id, container_registry_id, token_hash, scan_types, created_at, updated_at
+`
+
+type UpsertRegistryWebhookParams struct {
+	ContainerRegistryID int32           `json:"container_registry_id"`
+	TokenHash           string          `json:"token_hash"`
+	ScanTypes           json.RawMessage `json:"scan_types"`
+}
+
+func (q *Queries) UpsertRegistryWebhook(ctx context.Context, arg UpsertRegistryWebhookParams) (RegistryWebhook, error) {
+	row := q.db.QueryRowContext(ctx, upsertRegistryWebhook, arg.ContainerRegistryID, arg.TokenHash, arg.ScanTypes)
+	var i RegistryWebhook
+	err := row.Scan(
+		&i.ID,
+		&i.ContainerRegistryID,
+		&i.TokenHash,
+		&i.ScanTypes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
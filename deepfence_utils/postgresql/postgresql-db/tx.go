@@ -0,0 +1,23 @@
+package postgresql_db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var ErrNoDatabase = errors.New("queries are not bound to a database")
+
+// BeginTx starts a transaction on the database of the queries and returns
+// the queries running in it
+func (q *Queries) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, *Queries, error) {
+	db, ok := q.db.(*sql.DB)
+	if !ok {
+		return nil, nil, ErrNoDatabase
+	}
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, q.WithTx(tx), nil
+}
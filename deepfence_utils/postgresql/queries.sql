@@ -443,7 +443,8 @@ SELECT container_registry.id,
        container_registry.non_secret,
        container_registry.created_at,
        container_registry.updated_at,
-       container_registry.extras
+       container_registry.extras,
+       container_registry.sync_options
 FROM container_registry
 WHERE container_registry.id = $1
 LIMIT 1;
@@ -467,7 +468,8 @@ SELECT container_registry.id,
        container_registry.non_secret,
        container_registry.created_at,
        container_registry.updated_at,
-       container_registry.extras
+       container_registry.extras,
+       container_registry.sync_options
 FROM container_registry;
 
 -- name: GetContainerRegistriesSafe :many
@@ -476,7 +478,8 @@ SELECT container_registry.id,
        container_registry.registry_type,
        container_registry.non_secret,
        container_registry.created_at,
-       container_registry.updated_at
+       container_registry.updated_at,
+       container_registry.sync_options
 FROM container_registry;
 
 -- name: GetContainerRegistryByType :many
@@ -515,11 +518,351 @@ SET name=$1,
 WHERE id = $6
 RETURNING *;
 
+-- name: UpdateContainerRegistrySyncOptions :exec
+UPDATE container_registry
+SET sync_options=$1
+WHERE id = $2;
+
 -- name: DeleteContainerRegistry :exec
 DELETE
 FROM container_registry
 WHERE id = $1;
 
+-- name: UpsertRegistryWebhook :one
+INSERT INTO registry_webhook (container_registry_id, token_hash, scan_types)
+VALUES ($1, $2, $3)
+ON CONFLICT (container_registry_id) DO UPDATE
+    SET token_hash = $2,
+        scan_types = $3
+RETURNING *;
+
+-- name: GetRegistryWebhook :one
+SELECT *
+FROM registry_webhook
+WHERE container_registry_id = $1
+LIMIT 1;
+
+-- name: GetRegistryWebhooksFromTokenHash :many
+SELECT *
+FROM registry_webhook
+WHERE token_hash = $1;
+
+-- name: DeleteRegistryWebhook :exec
+DELETE
+FROM registry_webhook
+WHERE container_registry_id = $1;
+
+-- name: CreateRegistryScanPolicy :one
+INSERT INTO registry_scan_policy (container_registry_id, name, scan_types, filters, rescan_after_days,
+                                  max_concurrent_scans, is_enabled)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetRegistryScanPolicies :many
+SELECT *
+FROM registry_scan_policy
+WHERE container_registry_id = $1
+ORDER BY id;
+
+-- name: GetRegistryScanPolicy :one
+SELECT *
+FROM registry_scan_policy
+WHERE id = $1
+LIMIT 1;
+
+-- name: UpdateRegistryScanPolicy :one
+UPDATE registry_scan_policy
+SET name=$1,
+    scan_types=$2,
+    filters=$3,
+    rescan_after_days=$4,
+    max_concurrent_scans=$5,
+    is_enabled=$6
+WHERE id = $7
+RETURNING *;
+
+-- name: UpdateRegistryScanPolicyStatus :exec
+UPDATE registry_scan_policy
+SET last_status=$1,
+    last_evaluated_at=now()
+WHERE id = $2;
+
+-- name: DeleteRegistryScanPolicy :exec
+DELETE
+FROM registry_scan_policy
+WHERE id = $1;
+
+-- name: CreateImageTagHistory :exec
+INSERT INTO image_tag_history (registry_id, image_name, tag, digest, previous_digest)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetCurrentImageTags :many
+SELECT *
+FROM image_tag_history
+WHERE registry_id = $1
+  AND replaced_at IS NULL;
+
+-- name: GetCurrentImageTagsByDigest :many
+SELECT *
+FROM image_tag_history
+WHERE digest = $1
+  AND replaced_at IS NULL
+ORDER BY registry_id, image_name, tag;
+
+-- name: GetImageTagHistory :many
+SELECT *
+FROM image_tag_history
+WHERE registry_id = $1
+  AND image_name = $2
+  AND tag = $3
+ORDER BY first_seen_at DESC, id DESC;
+
+-- name: GetImageTagHistoryByID :one
+SELECT *
+FROM image_tag_history
+WHERE id = $1
+LIMIT 1;
+
+-- name: UpdateImageTagHistoriesLastSeen :exec
+UPDATE image_tag_history
+SET last_seen_at = now()
+WHERE id = ANY (@ids::bigint[]);
+
+-- name: ReplaceImageTagHistory :exec
+UPDATE image_tag_history
+SET replaced_at = now()
+WHERE id = $1
+  AND replaced_at IS NULL;
+
+-- name: UpsertNativeVulnerabilityReport :exec
+INSERT INTO native_vulnerability_report (registry_id, image_name, digest, source, scanner, vulnerabilities, generated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (registry_id, digest) DO UPDATE
+    SET image_name      = $2,
+        source          = $4,
+        scanner         = $5,
+        vulnerabilities = $6,
+        generated_at    = $7,
+        fetched_at      = now();
+
+-- name: GetNativeVulnerabilityReportsByDigest :many
+SELECT *
+FROM native_vulnerability_report
+WHERE digest = $1
+ORDER BY registry_id;
+
+-- name: GetNativeVulnerabilityReportFetchTimes :many
+SELECT digest, fetched_at
+FROM native_vulnerability_report
+WHERE registry_id = $1;
+
+-- name: CreateSourceRepository :one
+INSERT INTO source_repository (name, provider, url, branch, auth_type, username, known_hosts, encrypted_secret)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetSourceRepositories :many
+SELECT *
+FROM source_repository
+ORDER BY name;
+
+-- name: GetSourceRepository :one
+SELECT *
+FROM source_repository
+WHERE id = $1
+LIMIT 1;
+
+-- name: DeleteSourceRepository :exec
+DELETE
+FROM source_repository
+WHERE id = $1;
+
+-- name: CreateScanResultException :one
+INSERT INTO scan_result_exception (scan_type, result_id, scope_type, scope_value, reason, owner, created_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetScanResultExceptions :many
+SELECT *
+FROM scan_result_exception
+ORDER BY id DESC;
+
+-- name: GetScanResultException :one
+SELECT *
+FROM scan_result_exception
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetActiveScanResultExceptions :many
+SELECT *
+FROM scan_result_exception
+WHERE scan_type = $1
+  AND expired = false
+  AND (expires_at IS NULL OR expires_at > now())
+ORDER BY id;
+
+-- name: GetDueScanResultExceptions :many
+SELECT *
+FROM scan_result_exception
+WHERE expired = false
+  AND expires_at <= now()
+ORDER BY id;
+
+-- name: UpdateScanResultException :one
+UPDATE scan_result_exception
+SET reason     = $1,
+    owner      = $2,
+    expires_at = $3,
+    expired    = false
+WHERE id = $4
+RETURNING *;
+
+-- name: SetScanResultExceptionExpired :exec
+UPDATE scan_result_exception
+SET expired = true
+WHERE id = $1;
+
+-- name: DeleteScanResultException :exec
+DELETE
+FROM scan_result_exception
+WHERE id = $1;
+
+-- name: UpsertFindingLifecycles :exec
+INSERT INTO finding_lifecycle (scan_type, node_id, node_type, labels, result_id, severity, first_seen, opened_at,
+                               last_seen, last_scan_id, cloud_account)
+SELECT @scan_type,
+       @node_id,
+       @node_type,
+       @labels,
+       unnest(@result_ids::text[]),
+       unnest(@severities::text[]),
+       @seen_at,
+       @seen_at,
+       @seen_at,
+       @scan_id,
+       @cloud_account
+ON CONFLICT (scan_type, node_id, result_id) DO UPDATE
+    SET node_type     = excluded.node_type,
+        labels        = excluded.labels,
+        cloud_account = excluded.cloud_account,
+        severity      = excluded.severity,
+        last_seen     = excluded.last_seen,
+        last_scan_id  = excluded.last_scan_id,
+        opened_at     = CASE WHEN finding_lifecycle.resolved_at IS NULL THEN finding_lifecycle.opened_at ELSE excluded.last_seen END,
+        reopened      = finding_lifecycle.reopened + CASE WHEN finding_lifecycle.resolved_at IS NULL THEN 0 ELSE 1 END,
+        sla_policy_id = CASE WHEN finding_lifecycle.resolved_at IS NULL THEN finding_lifecycle.sla_policy_id END,
+        sla_due_at    = CASE WHEN finding_lifecycle.resolved_at IS NULL THEN finding_lifecycle.sla_due_at END,
+        sla_status    = CASE WHEN finding_lifecycle.resolved_at IS NULL THEN finding_lifecycle.sla_status ELSE '' END,
+        resolved_at   = NULL
+WHERE finding_lifecycle.last_seen <= excluded.last_seen;
+
+-- name: ResolveFindingLifecycles :exec
+UPDATE finding_lifecycle
+SET resolved_at = @resolved_at
+WHERE scan_type = @scan_type
+  AND node_id = @node_id
+  AND resolved_at IS NULL
+  AND last_seen < @resolved_at
+  AND NOT (result_id = ANY (@result_ids::text[]));
+
+-- name: GetFindingLifecycles :many
+SELECT *
+FROM finding_lifecycle
+WHERE scan_type = $1
+  AND node_id = $2
+ORDER BY resolved_at IS NOT NULL, first_seen DESC, result_id
+LIMIT $3 OFFSET $4;
+
+-- name: GetFindingMTTR :many
+SELECT date_trunc('day', resolved_at, 'UTC')::timestamptz        AS resolved_day,
+       (CASE @group_by::text
+            WHEN 'severity' THEN lower(severity)
+            WHEN 'node_type' THEN node_type
+            ELSE coalesce(labels ->> @label::text, '') END)::text AS group_value,
+       count(*)                                                  AS resolved_count,
+       sum(extract(epoch FROM resolved_at - opened_at))::float8  AS resolved_seconds
+FROM finding_lifecycle
+WHERE resolved_at >= @resolved_from
+  AND resolved_at < @resolved_to
+  AND (@scan_type::text = '' OR scan_type = @scan_type)
+GROUP BY resolved_day, group_value
+ORDER BY resolved_day, group_value;
+
+-- name: CreateSLAPolicy :one
+INSERT INTO sla_policy (name, scan_type, severity, days, due_soon_days, scope_type, scope_key, scope_value, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetSLAPolicies :many
+SELECT *
+FROM sla_policy
+ORDER BY scan_type, severity, id;
+
+-- name: GetSLAPolicy :one
+SELECT *
+FROM sla_policy
+WHERE id = $1
+LIMIT 1;
+
+-- name: UpdateSLAPolicy :one
+UPDATE sla_policy
+SET name          = $1,
+    severity      = $2,
+    days          = $3,
+    due_soon_days = $4,
+    scope_type    = $5,
+    scope_key     = $6,
+    scope_value   = $7
+WHERE id = $8
+RETURNING *;
+
+-- name: DeleteSLAPolicy :exec
+DELETE
+FROM sla_policy
+WHERE id = $1;
+
+-- name: EvaluateFindingSLAs :many
+WITH evaluated AS (SELECT DISTINCT ON (f.id) f.id,
+                                            f.sla_status                                AS previous_status,
+                                            p.id                                        AS policy_id,
+                                            f.opened_at + make_interval(days => p.days) AS due_at,
+                                            (CASE
+                                                 WHEN p.id IS NULL THEN ''
+                                                 WHEN f.opened_at + make_interval(days => p.days) <= now()
+                                                     THEN 'breached'
+                                                 WHEN f.opened_at + make_interval(days => p.days - p.due_soon_days) <= now()
+                                                     THEN 'due_soon'
+                                                 ELSE 'within_sla' END)::text           AS status
+                   FROM finding_lifecycle f
+                            LEFT JOIN sla_policy p
+                                      ON p.scan_type = f.scan_type
+                                          AND p.severity = lower(f.severity)
+                                          AND (p.scope_type = 'global'
+                                              OR (p.scope_type = 'label' AND f.labels ->> p.scope_key = p.scope_value)
+                                              OR (p.scope_type = 'cloud_account' AND f.cloud_account = p.scope_value))
+                   WHERE f.resolved_at IS NULL
+                   ORDER BY f.id, p.scope_type = 'global', p.days)
+UPDATE finding_lifecycle f
+SET sla_policy_id = e.policy_id,
+    sla_due_at    = e.due_at,
+    sla_status    = e.status
+FROM evaluated e
+WHERE f.id = e.id
+  AND (f.sla_status <> e.status
+    OR f.sla_due_at IS DISTINCT FROM e.due_at
+    OR f.sla_policy_id IS DISTINCT FROM e.policy_id)
+RETURNING f.id, f.scan_type, f.node_id, f.node_type, f.result_id, f.severity, f.sla_due_at, f.sla_status, e.previous_status;
+
+-- name: GetOpenFindingSLACounts :many
+SELECT node_id,
+       node_type,
+       count(*) FILTER (WHERE sla_status = 'breached') AS breached_count,
+       count(*) FILTER (WHERE sla_status = 'due_soon') AS due_soon_count
+FROM finding_lifecycle
+WHERE resolved_at IS NULL
+  AND sla_status IN ('breached', 'due_soon')
+GROUP BY node_id, node_type;
+
 -- name: CreateAuditLog :exec
 INSERT INTO audit_log (event, action, resources, success, user_email, user_role, created_at)
 VALUES ($1, $2, $3, $4, $5, $6, $7);
@@ -557,8 +900,9 @@ SELECT count(*)
 FROM deleted;
 
 -- name: CreateIntegration :one
-INSERT INTO integration (resource, filters, integration_type, interval_minutes, config, created_by_user_id)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO integration (resource, filters, integration_type, interval_minutes, config, created_by_user_id,
+                         message_template, digest_interval, rate_limit, new_findings_only, notify_resolved)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING *;
 
 -- name: GetIntegrationFromID :one
@@ -582,6 +926,29 @@ SET error_msg      = $2,
     last_sent_time = now()
 WHERE id = $1;
 
+-- name: UpdateIntegration :exec
+UPDATE integration
+SET resource          = $2,
+    filters           = $3,
+    config            = $4,
+    message_template  = $5,
+    digest_interval   = $6,
+    rate_limit        = $7,
+    new_findings_only = $8,
+    notify_resolved   = $9,
+    error_msg         = NULL,
+    -- a changed resource starts from now, retries of the previous one are dropped
+    last_scan_cursor      = CASE WHEN resource = $2 THEN last_scan_cursor ELSE (extract(epoch from now()) * 1000)::bigint END,
+    last_event_id         = CASE WHEN resource = $2 THEN last_event_id ELSE (SELECT max(id) FROM notification_event) END,
+    event_attempts        = CASE WHEN resource = $2 THEN event_attempts ELSE 0 END,
+    event_next_attempt_at = CASE WHEN resource = $2 THEN event_next_attempt_at ELSE NULL END
+WHERE id = $1;
+
+-- name: UpdateIntegrationLastSuccess :exec
+UPDATE integration
+SET last_success_time = now()
+WHERE id = $1;
+
 -- name: DeleteIntegration :exec
 DELETE
 FROM integration
@@ -592,6 +959,103 @@ DELETE
 FROM integration
 WHERE created_by_user_id = $1;
 
+-- name: UpdateIntegrationScanCursor :exec
+UPDATE integration
+SET last_scan_cursor = $2
+WHERE id = $1;
+
+-- name: UpdateIntegrationEventCursor :exec
+UPDATE integration
+SET last_event_id = $2
+WHERE id = $1;
+
+-- name: UpdateIntegrationEventRetry :exec
+UPDATE integration
+SET event_attempts        = $2,
+    event_next_attempt_at = $3
+WHERE id = $1;
+
+-- name: UpdateIntegrationLastDigest :exec
+UPDATE integration
+SET last_digest_time = $2
+WHERE id = $1;
+
+-- name: CreateNotificationDelivery :exec
+INSERT INTO notification_delivery (integration_id, scan_id, resource, status)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (integration_id, scan_id) DO NOTHING;
+
+-- name: GetDueNotificationDeliveries :many
+SELECT *
+FROM notification_delivery
+WHERE integration_id = $1
+  AND status = $2
+  AND next_attempt_at <= now()
+ORDER BY id
+LIMIT $3;
+
+-- name: UpdateNotificationDeliveryStatus :exec
+UPDATE notification_delivery
+SET status          = $2,
+    attempts        = attempts + 1,
+    last_error      = $3,
+    next_attempt_at = $4,
+    sent_at         = $5,
+    messages        = $6
+WHERE id = $1;
+
+-- name: GetNotificationDeliveriesByStatus :many
+SELECT *
+FROM notification_delivery
+WHERE integration_id = $1
+  AND status = $2
+ORDER BY id
+LIMIT $3;
+
+-- name: SetNotificationDeliveryStatus :exec
+UPDATE notification_delivery
+SET status  = $2,
+    sent_at = $3
+WHERE id = $1;
+
+-- name: CountNotificationMessagesSentSince :one
+SELECT coalesce(sum(messages), 0)::bigint AS messages
+FROM notification_delivery
+WHERE integration_id = $1
+  AND status = $2
+  AND sent_at > $3;
+
+-- name: DeleteNotificationDeliveriesOlderThan30days :one
+WITH deleted AS (
+    DELETE
+        FROM notification_delivery
+            WHERE status = $1
+                AND updated_at < (now() - interval '30 days')
+            RETURNING *)
+SELECT count(*)
+FROM deleted;
+
+-- name: CreateNotificationEvent :exec
+INSERT INTO notification_event (event_type, node_id, node_type, message, details)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetNotificationEventsAfter :many
+SELECT *
+FROM notification_event
+WHERE id > $1
+  AND created_at >= $2
+ORDER BY id
+LIMIT $3;
+
+-- name: DeleteNotificationEventsOlderThan30days :one
+WITH deleted AS (
+    DELETE
+        FROM notification_event
+            WHERE created_at < (now() - interval '30 days')
+            RETURNING *)
+SELECT count(*)
+FROM deleted;
+
 -- name: CreateSchedule :one
 INSERT INTO scheduler (action, description, cron_expr, payload, is_enabled, is_system, status)
 VALUES ($1, $2, $3, $4, $5, $6, '')
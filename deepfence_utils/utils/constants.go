@@ -25,30 +25,35 @@ const (
 
 // task names
 const (
-	SetUpGraphDBTask          = "set_up_graph_db"
-	CleanUpGraphDBTask        = "clean_up_graph_db"
-	CleanUpPostgresqlTask     = "clean_up_postgresql"
-	CleanupDiagnosisLogs      = "clean_up_diagnosis_logs"
-	RetryFailedScansTask      = "retry_failed_scans"
-	RetryFailedUpgradesTask   = "retry_failed_upgrades"
-	ScanSBOMTask              = "tasks_scan_sbom"
-	GenerateSBOMTask          = "tasks_generate_sbom"
-	CheckAgentUpgradeTask     = "tasks_check_agent_upgrade"
-	SyncRegistryTask          = "task_sync_registry"
-	TriggerConsoleActionsTask = "trigger_console_actions"
-	ScheduledTasks            = "scheduled_tasks"
-	SecretScanTask            = "task_secret_scan"
-	MalwareScanTask           = "task_malware_scan"
-	ReportGeneratorTask       = "tasks_generate_report"
-	ComputeThreatTask         = "compute_threat"
-	SendNotificationTask      = "tasks_send_notification"
-	CloudComplianceTask       = "cloud_compliance"
-	CachePostureProviders     = "cache_posture_providers"
-	ReportCleanUpTask         = "tasks_cleanup_reports"
-	LinkCloudResourceTask     = "link_cloud_resource"
-	LinkNodesTask             = "link_nodes"
-	StopSecretScanTask        = "task_stop_secret_scan"
-	StopMalwareScanTask       = "task_stop_malware_scan"
+	SetUpGraphDBTask             = "set_up_graph_db"
+	CleanUpGraphDBTask           = "clean_up_graph_db"
+	CleanUpPostgresqlTask        = "clean_up_postgresql"
+	CleanupDiagnosisLogs         = "clean_up_diagnosis_logs"
+	RetryFailedScansTask         = "retry_failed_scans"
+	RetryFailedUpgradesTask      = "retry_failed_upgrades"
+	ScanSBOMTask                 = "tasks_scan_sbom"
+	GenerateSBOMTask             = "tasks_generate_sbom"
+	ScanHelmChartTask            = "tasks_scan_helm_chart"
+	CheckAgentUpgradeTask        = "tasks_check_agent_upgrade"
+	SyncRegistryTask             = "task_sync_registry"
+	CheckRegistryCredentialsTask = "tasks_check_registry_credentials"
+	TriggerConsoleActionsTask    = "trigger_console_actions"
+	ScheduledTasks               = "scheduled_tasks"
+	SecretScanTask               = "task_secret_scan"
+	MalwareScanTask              = "task_malware_scan"
+	ReportGeneratorTask          = "tasks_generate_report"
+	ComputeThreatTask            = "compute_threat"
+	SendNotificationTask         = "tasks_send_notification"
+	CloudComplianceTask          = "cloud_compliance"
+	CachePostureProviders        = "cache_posture_providers"
+	ReportCleanUpTask            = "tasks_cleanup_reports"
+	LinkCloudResourceTask        = "link_cloud_resource"
+	LinkNodesTask                = "link_nodes"
+	StopSecretScanTask           = "task_stop_secret_scan"
+	StopMalwareScanTask          = "task_stop_malware_scan"
+	ExpireExceptionsTask         = "tasks_expire_scan_result_exceptions"
+	ThreatIntelTask              = "tasks_threat_intel_enrichment"
+	EvaluateSLATask              = "tasks_evaluate_finding_sla"
 )
 
 const (
@@ -61,6 +66,17 @@ const (
 	SCAN_STATUS_CANCELLED      = "CANCELLED"
 )
 
+// notification_delivery ledger states
+const (
+	NOTIFICATION_DELIVERY_PENDING = "pending"
+	NOTIFICATION_DELIVERY_SENT    = "sent"
+	NOTIFICATION_DELIVERY_FAILED  = "failed"
+	// scan is waiting for the next digest of the integration
+	NOTIFICATION_DELIVERY_DIGEST = "digest"
+	// scan was sent as part of a digest
+	NOTIFICATION_DELIVERY_DIGESTED = "digested"
+)
+
 // Neo4j Node Labels
 const (
 	NodeTypeCloudProvider     = "CloudProvider"
@@ -74,6 +90,8 @@ const (
 	NodeTypeCloudNode         = "CloudNode"
 	NodeTypeCloudResource     = "CloudResource"
 	NodeTypeRegistryAccount   = "RegistryAccount"
+	NodeTypeSourceRepository  = "SourceRepository"
+	NodeTypeRegistryArtifact  = "RegistryArtifact"
 )
 
 type Neo4jScanType string
@@ -185,8 +203,10 @@ var Tasks = []string{
 	RetryFailedUpgradesTask,
 	ScanSBOMTask,
 	GenerateSBOMTask,
+	ScanHelmChartTask,
 	CheckAgentUpgradeTask,
 	SyncRegistryTask,
+	CheckRegistryCredentialsTask,
 	TriggerConsoleActionsTask,
 	ScheduledTasks,
 	SecretScanTask,
@@ -201,6 +221,9 @@ var Tasks = []string{
 	LinkNodesTask,
 	StopSecretScanTask,
 	StopMalwareScanTask,
+	ExpireExceptionsTask,
+	ThreatIntelTask,
+	EvaluateSLATask,
 }
 
 type ReportType string
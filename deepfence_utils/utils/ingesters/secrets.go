@@ -27,4 +27,9 @@ type Secret struct {
 	} `json:"Severity"`
 	Masked bool   `json:"masked"`
 	ScanID string `json:"scan_id"`
+	// commit introducing the secret in source repository scans
+	Commit            string `json:"commit,omitempty"`
+	CommitAuthor      string `json:"commit_author,omitempty"`
+	CommitAuthorEmail string `json:"commit_author_email,omitempty"`
+	CommitTime        int64  `json:"commit_time,omitempty"`
 }
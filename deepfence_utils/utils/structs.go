@@ -54,6 +54,7 @@ type SecretScanParameters struct {
 	ContainerName         string `json:"container_name"`
 	Mode                  string `json:"mode,omitempty"`
 	RegistryId            string `json:"registry_id,omitempty"`
+	SourceRepositoryId    string `json:"source_repository_id,omitempty"`
 }
 
 type MalwareScanParameters struct {
@@ -70,6 +71,15 @@ type MalwareScanParameters struct {
 	RegistryId            string `json:"registry_id,omitempty"`
 }
 
+type HelmChartScanParameters struct {
+	ScanId       string `json:"scan_id" required:"true"`
+	NodeId       string `json:"node_id"`
+	NodeType     string `json:"node_type"`
+	RegistryId   string `json:"registry_id"`
+	ChartName    string `json:"chart_name"`
+	ChartVersion string `json:"chart_version"`
+}
+
 type ReportParams struct {
 	ReportID   string        `json:"report_id"`
 	ReportType string        `json:"report_type"`
@@ -88,6 +98,15 @@ type ReportFilters struct {
 
 type RegistrySyncParams struct {
 	PgID int32 `json:"pg_id"`
+	// set by registry webhooks to sync only the pushed images
+	Images    []RegistryPushedImage `json:"images,omitempty"`
+	ScanTypes []string              `json:"scan_types,omitempty"`
+}
+
+type RegistryPushedImage struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest"`
 }
 
 type AdvancedReportFilters struct {
@@ -99,6 +118,9 @@ type AdvancedReportFilters struct {
 	HostName              []string `json:"host_name,omitempty"`
 	AccountId             []string `json:"account_id,omitempty"`
 	KubernetesClusterName []string `json:"kubernetes_cluster_name,omitempty"`
+	// vulnerability reports only
+	CisaKev   []bool  `json:"cisa_kev,omitempty"`
+	EPSSScore float64 `json:"epss_score,omitempty"`
 }
 
 func (r ReportFilters) String() string {
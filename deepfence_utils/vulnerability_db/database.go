@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	"github.com/minio/minio-go/v7"
 )
@@ -117,11 +119,21 @@ func UploadToMinio(ctx context.Context, fb []byte, fName string) (string, string
 func UpdateListing(newFile, newFileCheckSum string, buildTime time.Time) {
 	log.Info().Msg("update vulnerability database listing")
 
+	if err := updateListing(newFile, newFileCheckSum, buildTime); err != nil {
+		log.Error().Msg(err.Error())
+		publishUpdateFailure(err)
+		return
+	}
+
+	log.Info().Msgf("vulnerability db listing updated with file %s checksum %s",
+		newFile, newFileCheckSum)
+}
+
+func updateListing(newFile, newFileCheckSum string, buildTime time.Time) error {
 	ctx := context.Background()
 	mc, err := directory.MinioClient(directory.WithDatabaseContext(ctx))
 	if err != nil {
-		log.Error().Msg(err.Error())
-		return
+		return err
 	}
 
 	data, err := mc.DownloadFileContexts(ctx, ListingPath, minio.GetObjectOptions{})
@@ -153,31 +165,29 @@ func UpdateListing(newFile, newFileCheckSum string, buildTime time.Time) {
 
 	lb, err := listing.Bytes()
 	if err != nil {
-		log.Error().Msgf(err.Error())
-		return
+		return err
 	}
 
 	err = mc.DeleteFile(ctx, ListingPath, true, minio.RemoveObjectOptions{ForceDelete: true})
 	if err != nil {
-		log.Error().Msgf(err.Error())
-		return
+		return err
 	}
 
 	_, err = mc.UploadFile(ctx, ListingPath, lb, minio.PutObjectOptions{ContentType: "application/json"})
-	if err != nil {
-		log.Error().Msgf(err.Error())
-		return
-	}
-
-	log.Info().Msgf("vulnerability db listing updated with file %s checksum %s",
-		newFile, newFileCheckSum)
-
+	return err
 }
 
 func DownloadDatabase() {
 
 	log.Info().Msg("download latest vulnerability database")
 
+	if err := downloadDatabase(); err != nil {
+		log.Error().Msg(err.Error())
+		publishUpdateFailure(err)
+	}
+}
+
+func downloadDatabase() error {
 	df_listing_url := utils.GetEnvOrDefault(
 		"DEEPFENCE_THREAT_INTEL_URL",
 		DEEPFENCE_THREAT_INTEL_URL,
@@ -187,26 +197,22 @@ func DownloadDatabase() {
 
 	resp, err := client.Get(df_listing_url)
 	if err != nil {
-		log.Error().Msgf(err.Error())
-		return
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Error().Msgf("listing url response: %s", resp.Status)
-		return
+		return fmt.Errorf("listing url response: %s", resp.Status)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Error().Msgf(err.Error())
-		return
+		return err
 	}
 
 	var listing VulnerabilityDBListing
 	if err := json.Unmarshal(body, &listing); err != nil {
-		log.Error().Msgf(err.Error())
-		return
+		return err
 	}
 
 	log.Info().Msgf("available vulnerability databases V3=%d V5=%d",
@@ -217,27 +223,38 @@ func DownloadDatabase() {
 
 	latest := listing.Latest(Version5)
 	if latest == nil {
-		log.Error().Msgf("latest v5 database are empty, check listing url")
-		return
+		return errors.New("latest v5 database are empty, check listing url")
 	}
 
 	log.Info().Msgf("latest threat intel db: %v", latest)
 
 	data, err := downloadFile(latest.URL)
 	if err != nil {
-		log.Error().Msgf(err.Error())
-		return
+		return err
 	}
 
 	path, _, err := UploadToMinio(context.Background(), data.Bytes(), path.Base(latest.URL))
 	if err != nil {
-		log.Error().Msg(err.Error())
-		return
+		return err
 	}
 
 	// update listing.json file
-	UpdateListing(path, latest.Checksum, latest.Built)
+	err = updateListing(path, latest.Checksum, latest.Built)
+	if err != nil {
+		return err
+	}
 
+	log.Info().Msgf("vulnerability db listing updated with file %s checksum %s",
+		path, latest.Checksum)
+	return nil
+}
+
+// publishUpdateFailure notifies every tenant, the database is shared
+func publishUpdateFailure(err error) {
+	notification.PublishAll(notification.Event{
+		EventType: notification.VulnerabilityDBUpdateFailed,
+		Message:   fmt.Sprintf("vulnerability database update failed: %v", err),
+	})
 }
 
 func downloadFile(url string) (*bytes.Buffer, error) {
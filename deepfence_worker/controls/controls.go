@@ -111,6 +111,29 @@ func ConsoleActionSetup(pub *kafka.Publisher) error {
 		return err
 	}
 
+	// for compliance scan, only helm charts are scanned by the console
+	err = RegisterControl(ctl.StartComplianceScan,
+		func(namespace string, req ctl.StartComplianceScanRequest) error {
+			metadata := map[string]string{directory.NamespaceKey: namespace}
+			log.Info().Msgf("payload: %+v", req.BinArgs)
+			if req.NodeType != ctl.RegistryArtifact {
+				return fmt.Errorf("compliance scan of %s not supported", ctl.ResourceTypeToString(req.NodeType))
+			}
+			data, err := json.Marshal(req.BinArgs)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				return err
+			}
+			if err := utils.PublishNewJob(pub, metadata, sdkUtils.ScanHelmChartTask, data); err != nil {
+				log.Error().Msg(err.Error())
+				return err
+			}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
 	// for malware scan
 	err = RegisterControl(ctl.StartMalwareScan,
 		func(namespace string, req ctl.StartMalwareScanRequest) error {
@@ -11,6 +11,7 @@ import (
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 )
@@ -64,6 +65,16 @@ func getPushBackValue(session neo4j.Session) int32 {
 
 var cleanUpRunning = atomic.Bool{}
 
+// publishEvents publishes an event for each node returned by the query
+func publishEvents(ctx context.Context, eventType string, res neo4j.Result) error {
+	recs, err := res.Collect()
+	if err != nil {
+		return err
+	}
+	notification.PublishRecords(ctx, eventType, recs)
+	return nil
+}
+
 func CleanUpDB(msg *message.Message) error {
 	RecordOffsets(msg)
 
@@ -99,15 +110,25 @@ func CleanUpDB(msg *message.Message) error {
 	start := time.Now()
 
 	// Set inactives
-	if _, err = session.Run(`
+	res, err := session.Run(`
 		MATCH (n:Node)
 		WHERE n.updated_at < TIMESTAMP()-$time_ms
 		AND NOT n.node_id IN ["in-the-internet", "out-the-internet"]
 		AND n.agent_running=true
 		AND n.active = true
 		WITH n LIMIT 10000
-		SET n.active=false`,
-		map[string]interface{}{"time_ms": dbReportCleanUpTimeout.Milliseconds()}, txConfig); err != nil {
+		SET n.active=false
+		RETURN n.node_id AS node_id, $node_type AS node_type, n.node_name AS node_name,
+			'agent on ' + coalesce(n.node_name, n.node_id) + ' is inactive' AS message`,
+		map[string]interface{}{
+			"time_ms":   dbReportCleanUpTimeout.Milliseconds(),
+			"node_type": utils.NodeTypeHost,
+		}, txConfig)
+	if err != nil {
+		log.Error().Msgf("Error in Clean up DB task: %v", err)
+		return err
+	}
+	if err = publishEvents(ctx, notification.AgentInactive, res); err != nil {
 		log.Error().Msgf("Error in Clean up DB task: %v", err)
 		return err
 	}
@@ -173,14 +194,24 @@ func CleanUpDB(msg *message.Message) error {
 		return err
 	}
 
-	if _, err = session.Run(`
+	res, err = session.Run(`
 		MATCH (n:KubernetesCluster)
 		WHERE n.updated_at < TIMESTAMP()-$time_ms
 		AND n.active = true
 		AND n.agent_running=true
 		WITH n LIMIT 10000
-		SET n.active=false`,
-		map[string]interface{}{"time_ms": dbReportCleanUpTimeout.Milliseconds()}, txConfig); err != nil {
+		SET n.active=false
+		RETURN n.node_id AS node_id, $node_type AS node_type, n.node_name AS node_name,
+			'agent on cluster ' + coalesce(n.node_name, n.node_id) + ' is inactive' AS message`,
+		map[string]interface{}{
+			"time_ms":   dbReportCleanUpTimeout.Milliseconds(),
+			"node_type": utils.NodeTypeKubernetesCluster,
+		}, txConfig)
+	if err != nil {
+		log.Error().Msgf("Error in Clean up DB task: %v", err)
+		return err
+	}
+	if err = publishEvents(ctx, notification.AgentInactive, res); err != nil {
 		log.Error().Msgf("Error in Clean up DB task: %v", err)
 		return err
 	}
@@ -275,28 +306,49 @@ func CleanUpDB(msg *message.Message) error {
 		return err
 	}
 
-	if _, err = session.Run(`
-		MATCH (n) -[:SCANNED]-> ()
+	res, err = session.Run(`
+		MATCH (n) -[:SCANNED]-> (m)
 		WHERE n.retries >= 3
-		WITH n LIMIT 10000
-		SET n.status = $new_status`,
+		WITH n, m, n.status <> $new_status AS changed LIMIT 10000
+		SET n.status = $new_status
+		WITH n, m, changed
+		WHERE changed
+		RETURN m.node_id AS node_id, labels(m)[0] AS node_type, m.node_name AS node_name,
+			n.node_id AS scan_id, labels(n)[0] AS scan_type,
+			labels(n)[0] + ' on ' + coalesce(m.node_name, m.node_id) + ' failed after retries' AS message`,
 		map[string]interface{}{
 			"time_ms":    dbScanTimeout.Milliseconds(),
 			"new_status": utils.SCAN_STATUS_FAILED,
-		}, txConfig); err != nil {
+		}, txConfig)
+	if err != nil {
+		log.Error().Msgf("Error in Clean up DB task: %v", err)
+		return err
+	}
+	if err = publishEvents(ctx, notification.ScanFailed, res); err != nil {
 		log.Error().Msgf("Error in Clean up DB task: %v", err)
 		return err
 	}
 
-	if _, err = session.Run(`
-		MATCH (:AgentVersion) -[n:SCHEDULED]-> (:Node)
+	res, err = session.Run(`
+		MATCH (v:AgentVersion) -[n:SCHEDULED]-> (m:Node)
 		WHERE n.retries >= 3
-		WITH n LIMIT 10000
-		SET n.status = $new_status`,
+		WITH n, v, m, n.status <> $new_status AS changed LIMIT 10000
+		SET n.status = $new_status
+		WITH v, m, changed
+		WHERE changed
+		RETURN m.node_id AS node_id, $node_type AS node_type, m.node_name AS node_name,
+			v.node_id AS version,
+			'agent upgrade of ' + coalesce(m.node_name, m.node_id) + ' to ' + v.node_id + ' failed' AS message`,
 		map[string]interface{}{
 			"time_ms":    dbUpgradeTimeout.Milliseconds(),
 			"new_status": utils.SCAN_STATUS_FAILED,
-		}, txConfig); err != nil {
+			"node_type":  utils.NodeTypeHost,
+		}, txConfig)
+	if err != nil {
+		log.Error().Msgf("Error in Clean up DB task: %v", err)
+		return err
+	}
+	if err = publishEvents(ctx, notification.AgentUpgradeFailed, res); err != nil {
 		log.Error().Msgf("Error in Clean up DB task: %v", err)
 		return err
 	}
@@ -1,24 +1,42 @@
 package cronjobs
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/constants"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/msgtemplate"
 	"github.com/deepfence/ThreatMapper/deepfence_server/reporters"
 	reporters_scan "github.com/deepfence/ThreatMapper/deepfence_server/reporters/scan"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
 	postgresql_db "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 )
 
+// errRateLimited is returned when the messages of a scan are over the rate
+// limit of the integration, the scan is deferred to the digest
+var errRateLimited = errors.New("rate limit reached")
+
+const (
+	deliveryCursorOverlap = 30 * time.Second
+	deliveryBatchSize     = 100
+	deliveryBaseBackoff   = 30 * time.Second
+	deliveryMaxBackoff    = time.Hour
+	maxDeliveryAttempts   = 10
+)
+
 func SendNotifications(msg *message.Message) error {
 	RecordOffsets(msg)
 
@@ -39,7 +57,7 @@ func SendNotifications(msg *message.Message) error {
 	for _, integrationRow := range integrations {
 		go func(integration postgresql_db.Integration) {
 			defer wg.Done()
-			err := processIntegrationRow(integration, msg)
+			err := processIntegrationRow(ctx, pgClient, integration)
 			update_row := err != nil || (err == nil && integration.ErrorMsg.Valid)
 			if update_row {
 				var params postgresql_db.UpdateIntegrationStatusParams
@@ -70,23 +88,359 @@ func SendNotifications(msg *message.Message) error {
 	return nil
 }
 
-func processIntegrationRow(integrationRow postgresql_db.Integration, msg *message.Message) error {
+func processIntegrationRow(ctx context.Context, pgClient *postgresql_db.Queries,
+	integrationRow postgresql_db.Integration) error {
 	log.Info().Msgf("Processing integration for %s rowId: %d", integrationRow.IntegrationType, integrationRow.ID)
+
+	var resources []string
 	switch integrationRow.Resource {
+	case utils.ScanTypeDetectedNode[utils.NEO4J_VULNERABILITY_SCAN],
+		utils.ScanTypeDetectedNode[utils.NEO4J_SECRET_SCAN],
+		utils.ScanTypeDetectedNode[utils.NEO4J_MALWARE_SCAN]:
+		resources = []string{integrationRow.Resource}
+	case utils.ScanTypeDetectedNode[utils.NEO4J_COMPLIANCE_SCAN]:
+		// cloud compliance scans
+		resources = []string{integrationRow.Resource,
+			utils.ScanTypeDetectedNode[utils.NEO4J_CLOUD_COMPLIANCE_SCAN]}
+	case notification.EventResource:
+		return deliverEvents(ctx, pgClient, integrationRow)
+	default:
+		return errors.New("No integration type")
+	}
+
+	err := queueScansForDelivery(ctx, pgClient, integrationRow, resources)
+	if err != nil {
+		return err
+	}
+
+	// scans of digest integrations are only sent as part of the digest
+	if integrationRow.DigestInterval == constants.DigestImmediate {
+		err = deliverPendingScans(ctx, pgClient, integrationRow)
+	}
+
+	return errors.Join(err, sendDigestIfDue(ctx, pgClient, integrationRow))
+}
+
+// queueScansForDelivery adds every completed scan newer than the integration
+// cursor to the notification_delivery ledger and moves the cursor forward
+func queueScansForDelivery(ctx context.Context, pgClient *postgresql_db.Queries,
+	integrationRow postgresql_db.Integration, resources []string) error {
+
+	var filters model.IntegrationFilters
+	err := json.Unmarshal(integrationRow.Filters, &filters)
+	if err != nil {
+		return err
+	}
+
+	cursor := integrationRow.CreatedAt.UnixMilli()
+	if integrationRow.LastScanCursor.Valid {
+		cursor = integrationRow.LastScanCursor.Int64
+	}
+	newCursor := cursor
+
+	// scans are stamped with updated_at before their transaction commits, so
+	// look back a little behind the cursor, ledger skips already queued scans
+	from := cursor - deliveryCursorOverlap.Milliseconds()
+
+	for _, resource := range resources {
+		log.Debug().Msgf("Check for %s scans to queue after timestamp %d", resource, from)
+
+		ff := reporters.FieldsFilters{
+			CompareFilters: []reporters.CompareFilter{
+				{
+					FieldName:   "updated_at",
+					GreaterThan: true,
+					FieldValue:  strconv.FormatInt(from, 10),
+				},
+			},
+			ContainsFilter: reporters.ContainsFilter{
+				FieldsValues: map[string][]interface{}{"status": {utils.SCAN_STATUS_SUCCESS}},
+			},
+		}
+		list, err := reporters_scan.GetScansList(ctx, utils.DetectedNodeScanType[resource],
+			filters.NodeIds, ff, model.FetchWindow{})
+		if err != nil {
+			return err
+		}
+
+		for _, scan := range list.ScansInfo {
+			err = pgClient.CreateNotificationDelivery(ctx, postgresql_db.CreateNotificationDeliveryParams{
+				IntegrationID: integrationRow.ID,
+				ScanID:        scan.ScanId,
+				Resource:      resource,
+				Status:        utils.NOTIFICATION_DELIVERY_PENDING,
+			})
+			if err != nil {
+				return err
+			}
+			if scan.UpdatedAt > newCursor {
+				newCursor = scan.UpdatedAt
+			}
+		}
+		log.Debug().Msgf("queued %d %s scans for integration id %d",
+			len(list.ScansInfo), resource, integrationRow.ID)
+	}
+
+	if newCursor == cursor && integrationRow.LastScanCursor.Valid {
+		return nil
+	}
+
+	return pgClient.UpdateIntegrationScanCursor(ctx, postgresql_db.UpdateIntegrationScanCursorParams{
+		ID:             integrationRow.ID,
+		LastScanCursor: sql.NullInt64{Int64: newCursor, Valid: true},
+	})
+}
+
+// deliverPendingScans sends notifications for ledger entries which are due,
+// failed deliveries are retried with exponential backoff
+func deliverPendingScans(ctx context.Context, pgClient *postgresql_db.Queries,
+	integrationRow postgresql_db.Integration) error {
+
+	deliveries, err := pgClient.GetDueNotificationDeliveries(ctx, postgresql_db.GetDueNotificationDeliveriesParams{
+		IntegrationID: integrationRow.ID,
+		Status:        utils.NOTIFICATION_DELIVERY_PENDING,
+		Limit:         deliveryBatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(deliveries) == 0 {
+		log.Info().Msgf("No %s scans to notify for integration id %d",
+			integrationRow.Resource, integrationRow.ID)
+		return nil
+	}
+
+	budget, err := rateLimitBudget(ctx, pgClient, integrationRow)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	overLimit := []postgresql_db.NotificationDelivery{}
+	for _, delivery := range deliveries {
+		if budget == 0 {
+			overLimit = append(overLimit, delivery)
+			continue
+		}
+		messages, err := sendScanNotification(ctx, integrationRow, delivery.Resource, delivery.ScanID, budget)
+		if errors.Is(err, errRateLimited) {
+			overLimit = append(overLimit, delivery)
+			continue
+		}
+		if budget > 0 {
+			budget -= messages
+		}
+
+		params := postgresql_db.UpdateNotificationDeliveryStatusParams{
+			ID:            delivery.ID,
+			Status:        utils.NOTIFICATION_DELIVERY_SENT,
+			NextAttemptAt: time.Now(),
+			SentAt:        sql.NullTime{Time: time.Now(), Valid: true},
+			Messages:      int32(messages),
+		}
+		if err != nil {
+			errs = append(errs, err)
+			params.Status = utils.NOTIFICATION_DELIVERY_PENDING
+			params.LastError = sql.NullString{String: err.Error(), Valid: true}
+			params.NextAttemptAt = time.Now().Add(deliveryBackoff(delivery.Attempts))
+			params.SentAt = sql.NullTime{}
+			if delivery.Attempts+1 >= maxDeliveryAttempts {
+				log.Error().Msgf("giving up notification of scan %s using integration id %d after %d attempts",
+					delivery.ScanID, integrationRow.ID, delivery.Attempts+1)
+				params.Status = utils.NOTIFICATION_DELIVERY_FAILED
+			}
+		}
+
+		if err := pgClient.UpdateNotificationDeliveryStatus(ctx, params); err != nil {
+			log.Error().Msgf("failed to update delivery status for scan %s: %v", delivery.ScanID, err)
+		}
+		if params.Status == utils.NOTIFICATION_DELIVERY_SENT {
+			if err := pgClient.UpdateIntegrationLastSuccess(ctx, integrationRow.ID); err != nil {
+				log.Error().Msgf("failed to update last success time for integration id %d: %v",
+					integrationRow.ID, err)
+			}
+		}
+	}
+
+	if len(overLimit) > 0 {
+		errs = append(errs, deferToDigest(ctx, pgClient, integrationRow, overLimit))
+	}
+
+	return errors.Join(errs...)
+}
+
+// deliverEvents sends the events recorded since the last event sent, failed
+// deliveries are retried with exponential backoff
+func deliverEvents(ctx context.Context, pgClient *postgresql_db.Queries,
+	integrationRow postgresql_db.Integration) error {
+
+	if integrationRow.EventNextAttemptAt.Valid && time.Now().Before(integrationRow.EventNextAttemptAt.Time) {
+		log.Info().Msgf("events of integration id %d are retried at %s",
+			integrationRow.ID, integrationRow.EventNextAttemptAt.Time.Format(time.RFC3339))
+		// keep the error of the failed delivery until it is retried
+		if integrationRow.ErrorMsg.Valid {
+			return errors.New(integrationRow.ErrorMsg.String)
+		}
+		return nil
+	}
+
+	var filters model.IntegrationFilters
+	err := json.Unmarshal(integrationRow.Filters, &filters)
+	if err != nil {
+		return err
+	}
+
+	var cursor int64
+	if integrationRow.LastEventID.Valid {
+		cursor = integrationRow.LastEventID.Int64
+	}
+	events, err := pgClient.GetNotificationEventsAfter(ctx, postgresql_db.GetNotificationEventsAfterParams{
+		ID:        cursor,
+		CreatedAt: integrationRow.CreatedAt,
+		Limit:     deliveryBatchSize,
+	})
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		log.Info().Msgf("No events to notify for integration id %d", integrationRow.ID)
+		return nil
+	}
+
+	eventTypes := map[string]struct{}{}
+	for _, t := range filters.EventTypes {
+		eventTypes[t] = struct{}{}
+	}
+	messagingFormat := integration.IsMessagingFormat(integrationRow.IntegrationType)
+
+	messages := []map[string]interface{}{}
+	for _, e := range events {
+		if _, ok := eventTypes[e.EventType]; len(eventTypes) > 0 && !ok {
+			continue
+		}
+		m := map[string]interface{}{}
+		if err := json.Unmarshal(e.Details, &m); err != nil {
+			log.Warn().Msgf("invalid details of event id %d: %v", e.ID, err)
+		}
+		m["event_type"] = e.EventType
+		m["node_id"] = e.NodeID
+		m["node_type"] = e.NodeType
+		m["message"] = e.Message
+		if messagingFormat {
+			m["created_at"] = e.CreatedAt
+		} else {
+			m["created_at"] = e.CreatedAt.UnixMilli()
+		}
+		messages = append(messages, m)
+	}
+
+	if len(messages) > 0 {
+		iByte, err := json.Marshal(integrationRow)
+		if err != nil {
+			return err
+		}
+		integrationModel, err := integration.GetIntegration(ctx, integrationRow.IntegrationType, iByte)
+		if err != nil {
+			return err
+		}
+		rendered, err := msgtemplate.Messages(integrationRow.MessageTemplate, notification.EventResource,
+			messages, map[string]interface{}{"scan_type": notification.EventResource})
+		if err != nil {
+			return err
+		}
+		for _, m := range rendered {
+			messageByte, err := json.Marshal(m.Findings)
+			if err != nil {
+				return err
+			}
+			err = integrationModel.SendNotification(ctx, string(messageByte), m.Extras)
+			if err != nil {
+				return retryEvents(ctx, pgClient, integrationRow, events[len(events)-1].ID, err)
+			}
+		}
+		if err := pgClient.UpdateIntegrationLastSuccess(ctx, integrationRow.ID); err != nil {
+			log.Error().Msgf("failed to update last success time for integration id %d: %v",
+				integrationRow.ID, err)
+		}
+		log.Info().Msgf("Notification sent %d events using %s id %d",
+			len(messages), integrationRow.IntegrationType, integrationRow.ID)
+	}
+
+	if integrationRow.EventAttempts > 0 {
+		err = pgClient.UpdateIntegrationEventRetry(ctx, postgresql_db.UpdateIntegrationEventRetryParams{
+			ID: integrationRow.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return pgClient.UpdateIntegrationEventCursor(ctx, postgresql_db.UpdateIntegrationEventCursorParams{
+		ID:          integrationRow.ID,
+		LastEventID: sql.NullInt64{Int64: events[len(events)-1].ID, Valid: true},
+	})
+}
+
+// retryEvents schedules the next attempt of the events after the last event
+// sent, they are skipped after the max attempts like failed scan deliveries
+func retryEvents(ctx context.Context, pgClient *postgresql_db.Queries,
+	integrationRow postgresql_db.Integration, lastEventID int64, sendErr error) error {
+
+	attempts := integrationRow.EventAttempts + 1
+	if attempts >= maxDeliveryAttempts {
+		log.Error().Msgf("giving up notification of events up to id %d using integration id %d after %d attempts",
+			lastEventID, integrationRow.ID, attempts)
+		err := pgClient.UpdateIntegrationEventRetry(ctx, postgresql_db.UpdateIntegrationEventRetryParams{
+			ID: integrationRow.ID,
+		})
+		if err != nil {
+			return errors.Join(sendErr, err)
+		}
+		err = pgClient.UpdateIntegrationEventCursor(ctx, postgresql_db.UpdateIntegrationEventCursorParams{
+			ID:          integrationRow.ID,
+			LastEventID: sql.NullInt64{Int64: lastEventID, Valid: true},
+		})
+		return errors.Join(sendErr, err)
+	}
+
+	err := pgClient.UpdateIntegrationEventRetry(ctx, postgresql_db.UpdateIntegrationEventRetryParams{
+		ID:                 integrationRow.ID,
+		EventAttempts:      attempts,
+		EventNextAttemptAt: sql.NullTime{Time: time.Now().Add(deliveryBackoff(integrationRow.EventAttempts)), Valid: true},
+	})
+	return errors.Join(sendErr, err)
+}
+
+func deliveryBackoff(attempts int32) time.Duration {
+	backoff := deliveryBaseBackoff
+	for i := int32(0); i < attempts; i++ {
+		backoff *= 2
+		if backoff >= deliveryMaxBackoff {
+			return deliveryMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// sendScanNotification returns the number of messages sent for the scan,
+// nothing is sent if they are more than the budget of a rate limited
+// integration, -1 budget is unlimited
+func sendScanNotification(ctx context.Context, integrationRow postgresql_db.Integration,
+	resource, scanID string, budget int) (int, error) {
+
+	switch resource {
 	case utils.ScanTypeDetectedNode[utils.NEO4J_VULNERABILITY_SCAN]:
-		return processIntegration[model.Vulnerability](msg, integrationRow)
+		return processIntegration[model.Vulnerability](ctx, integrationRow, resource, scanID, budget)
 	case utils.ScanTypeDetectedNode[utils.NEO4J_SECRET_SCAN]:
-		return processIntegration[model.Secret](msg, integrationRow)
+		return processIntegration[model.Secret](ctx, integrationRow, resource, scanID, budget)
 	case utils.ScanTypeDetectedNode[utils.NEO4J_MALWARE_SCAN]:
-		return processIntegration[model.Malware](msg, integrationRow)
+		return processIntegration[model.Malware](ctx, integrationRow, resource, scanID, budget)
 	case utils.ScanTypeDetectedNode[utils.NEO4J_COMPLIANCE_SCAN]:
-		err1 := processIntegration[model.Compliance](msg, integrationRow)
-		// cloud compliance scans
-		integrationRow.Resource = utils.ScanTypeDetectedNode[utils.NEO4J_CLOUD_COMPLIANCE_SCAN]
-		err2 := processIntegration[model.CloudCompliance](msg, integrationRow)
-		return errors.Join(err1, err2)
+		return processIntegration[model.Compliance](ctx, integrationRow, resource, scanID, budget)
+	case utils.ScanTypeDetectedNode[utils.NEO4J_CLOUD_COMPLIANCE_SCAN]:
+		return processIntegration[model.CloudCompliance](ctx, integrationRow, resource, scanID, budget)
 	}
-	return errors.New("No integration type")
+	return 0, errors.New("No integration type")
 }
 
 func injectNodeData[T any](results []T, common model.ScanResultsCommon,
@@ -127,93 +481,187 @@ func injectNodeData[T any](results []T, common model.ScanResultsCommon,
 	return data
 }
 
-func processIntegration[T any](msg *message.Message, integrationRow postgresql_db.Integration) error {
+// scanNotification is one notification of a scan, resolved notifications
+// go to integrations tracking findings
+type scanNotification struct {
+	findings []map[string]interface{}
+	extras   map[string]interface{}
+	resolved bool
+}
+
+func processIntegration[T any](ctx context.Context, integrationRow postgresql_db.Integration,
+	resource, scanID string, budget int) (int, error) {
+
 	var filters model.IntegrationFilters
 	err := json.Unmarshal(integrationRow.Filters, &filters)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	namespace := msg.Metadata.Get(directory.NamespaceKey)
-	ctx := directory.NewContextWithNameSpace(directory.NamespaceID(namespace))
 
-	// get ts from message
-	ts, err := strconv.ParseInt(string(msg.Payload), 10, 64)
+	results, common, err := integrationScanResults[T](ctx, integrationRow, filters, resource, scanID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	last30sTimeStamp := ts - 30000
-
-	log.Debug().Msgf("Check for %s scans to notify at timestamp (%d,%d)",
-		integrationRow.Resource, last30sTimeStamp, ts)
-
-	filters.FieldsFilters = reporters.FieldsFilters{}
-	filters.FieldsFilters.CompareFilters = append(
-		filters.FieldsFilters.CompareFilters,
-		reporters.CompareFilter{
-			FieldName:   "updated_at",
-			GreaterThan: true,
-			FieldValue:  strconv.FormatInt(last30sTimeStamp, 10),
-		},
-	)
-	filters.FieldsFilters.ContainsFilter = reporters.ContainsFilter{
-		FieldsValues: map[string][]interface{}{"status": {utils.SCAN_STATUS_SUCCESS}},
-	}
-	list, err := reporters_scan.GetScansList(ctx, utils.DetectedNodeScanType[integrationRow.Resource],
-		filters.NodeIds, filters.FieldsFilters, model.FetchWindow{})
+	iByte, err := json.Marshal(integrationRow)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	// nothing to notify
-	if len(list.ScansInfo) == 0 {
-		log.Info().Msgf("No %s scans to notify at timestamp (%d,%d)",
-			integrationRow.Resource, last30sTimeStamp, ts)
-		return nil
+	integrationModel, err := integration.GetIntegration(ctx, integrationRow.IntegrationType, iByte)
+	if err != nil {
+		return 0, err
 	}
 
-	log.Info().Msgf("list of %s scans to notify: %+v", integrationRow.Resource, list)
+	extras := utils.ToMap[any](common)
+	extras["scan_type"] = resource
 
-	filters = model.IntegrationFilters{}
-	err = json.Unmarshal(integrationRow.Filters, &filters)
-	if err != nil {
-		return err
-	}
-	filters.NodeIds = []model.NodeIdentifier{}
-	for _, scan := range list.ScansInfo {
-		results, common, err := reporters_scan.GetScanResults[T](ctx,
-			utils.DetectedNodeScanType[integrationRow.Resource], scan.ScanId,
-			filters.FieldsFilters, model.FetchWindow{})
-		if len(results) == 0 {
-			log.Info().Msgf("No Results filtered for scan id: %s with filters %+v", scan.ScanId, filters)
-			continue
-		}
+	notifications := []scanNotification{}
 
-		iByte, err := json.Marshal(integrationRow)
+	// findings tracked by the integration are resolved even when the
+	// latest scan has no results
+	_, tracksFindings := integrationModel.(integration.ResolvedNotifier)
+	if tracksFindings || integrationRow.NotifyResolved {
+		resolved, err := resolvedNotification[T](ctx, integrationModel, integrationRow, resource, scanID,
+			filters, common, extras)
 		if err != nil {
-			return err
+			return 0, err
 		}
-
-		integrationModel, err := integration.GetIntegration(ctx, integrationRow.IntegrationType, iByte)
-		if err != nil {
-			return err
+		if resolved != nil {
+			notifications = append(notifications, *resolved)
 		}
+	}
 
+	if len(results) == 0 {
+		log.Info().Msgf("No Results filtered for scan id: %s with filters %+v", scanID, filters)
+	} else {
 		// inject node details to results
 		updatedResults := injectNodeData[T](results, common, integrationRow.IntegrationType)
-		messageByte, err := json.Marshal(updatedResults)
+
+		messages, err := msgtemplate.Messages(integrationRow.MessageTemplate, resource, updatedResults, extras)
 		if err != nil {
-			return err
+			return 0, err
+		}
+		for _, m := range messages {
+			notifications = append(notifications, scanNotification{findings: m.Findings, extras: m.Extras})
 		}
+	}
 
-		extras := utils.ToMap[any](common)
-		extras["scan_type"] = integrationRow.Resource
-		err = integrationModel.SendNotification(ctx, string(messageByte), extras)
+	messages := 0
+	for _, n := range notifications {
+		messages += integration.MessageCount(integrationModel, len(n.findings))
+	}
+	if budget >= 0 && messages > budget {
+		log.Info().Msgf("%s scan %s needs %d messages, %d left in the rate limit of integration id %d",
+			resource, scanID, messages, budget, integrationRow.ID)
+		return 0, errRateLimited
+	}
+
+	for _, n := range notifications {
+		messageByte, err := json.Marshal(n.findings)
 		if err != nil {
-			return err
+			return 0, err
+		}
+		if n.resolved {
+			err = integrationModel.(integration.ResolvedNotifier).NotifyResolved(ctx, string(messageByte), n.extras)
+		} else {
+			err = integrationModel.SendNotification(ctx, string(messageByte), n.extras)
+		}
+		if err != nil {
+			return 0, err
 		}
-		log.Info().Msgf("Notification sent %s scan %d messages using %s id %d",
-			integrationRow.Resource, len(results), integrationRow.IntegrationType, integrationRow.ID)
 	}
-	return nil
+	if len(results) > 0 {
+		log.Info().Msgf("Notification sent %s scan %d findings in %d messages using %s id %d",
+			resource, len(results), messages, integrationRow.IntegrationType, integrationRow.ID)
+	}
+	return messages, nil
+}
+
+// integrationScanResults returns the filtered results of the scan, limited to
+// the findings not reported by the previous scan of the node when the
+// integration notifies new findings only
+func integrationScanResults[T any](ctx context.Context, integrationRow postgresql_db.Integration,
+	filters model.IntegrationFilters, resource, scanID string) ([]T, model.ScanResultsCommon, error) {
+
+	scanType := utils.DetectedNodeScanType[resource]
+	filters.FieldsFilters = unmaskedFilters(filters.FieldsFilters)
+	results, common, err := reporters_scan.GetScanResults[T](ctx, scanType, scanID,
+		filters.FieldsFilters, model.FetchWindow{})
+	if err != nil || !integrationRow.NewFindingsOnly {
+		return results, common, err
+	}
+
+	previousScanID, err := reporters_scan.GetPreviousScanID(ctx, scanType, scanID)
+	if err != nil {
+		return nil, common, err
+	}
+	// first scan of the node, every finding is new
+	if previousScanID == "" {
+		return results, common, nil
+	}
+
+	results, err = reporters_scan.GetScanResultDiff[T](ctx, scanType, scanID, previousScanID,
+		filters.FieldsFilters, model.FetchWindow{})
+	return results, common, err
+}
+
+// unmaskedFilters adds to the filters of the integration the exclusion of
+// the masked results, including the results covered by exceptions
+func unmaskedFilters(ff reporters.FieldsFilters) reporters.FieldsFilters {
+	values := make(map[string][]interface{}, len(ff.ContainsFilter.FieldsValues)+1)
+	for k, v := range ff.ContainsFilter.FieldsValues {
+		values[k] = v
+	}
+	values["masked"] = []interface{}{false}
+	ff.ContainsFilter.FieldsValues = values
+	return ff
+}
+
+// resolvedNotification returns the findings of the previous scan of the node
+// which are not reported by this scan, integrations tracking findings resolve
+// them and others receive them marked as resolved, nil if none
+func resolvedNotification[T any](ctx context.Context, integrationModel integration.Integration,
+	integrationRow postgresql_db.Integration, resource, scanID string,
+	filters model.IntegrationFilters, common model.ScanResultsCommon,
+	extras map[string]interface{}) (*scanNotification, error) {
+
+	scanType := utils.DetectedNodeScanType[resource]
+	previousScanID, err := reporters_scan.GetPreviousScanID(ctx, scanType, scanID)
+	if err != nil {
+		return nil, err
+	}
+	if previousScanID == "" {
+		return nil, nil
+	}
+
+	// masked results are kept on both sides, a finding masked since the
+	// previous scan is still detected and must not be resolved
+	resolved, err := reporters_scan.GetScanResultDiff[T](ctx, scanType, previousScanID, scanID,
+		filters.FieldsFilters, model.FetchWindow{})
+	if err != nil {
+		return nil, err
+	}
+	if len(resolved) == 0 {
+		return nil, nil
+	}
+	log.Info().Msgf("Resolved %s scan %d findings using %s id %d",
+		resource, len(resolved), integrationRow.IntegrationType, integrationRow.ID)
+
+	// resolved findings are reported with the node details of the latest scan
+	resolvedResults := injectNodeData[T](resolved, common, integrationRow.IntegrationType)
+
+	if _, ok := integrationModel.(integration.ResolvedNotifier); ok {
+		return &scanNotification{findings: resolvedResults, extras: extras, resolved: true}, nil
+	}
+
+	resolvedExtras := make(map[string]interface{}, len(extras)+1)
+	for k, v := range extras {
+		resolvedExtras[k] = v
+	}
+	resolvedExtras[msgtemplate.TitleKey] = fmt.Sprintf("Deepfence - %s resolved findings", resource)
+	for _, r := range resolvedResults {
+		r[finding.StatusKey] = finding.StatusResolved
+	}
+	return &scanNotification{findings: resolvedResults, extras: resolvedExtras}, nil
 }
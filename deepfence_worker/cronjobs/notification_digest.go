@@ -0,0 +1,316 @@
+package cronjobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/constants"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/finding"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/integration/msgtemplate"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	postgresql_db "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+)
+
+const (
+	rateLimitWindow = time.Hour
+	digestMaxScans  = 1000
+	digestTopNodes  = 10
+)
+
+var digestSeverities = []string{"critical", "high", "medium", "low"}
+
+// digestNode is the findings count of one node in the digest
+type digestNode struct {
+	name       string
+	nodeType   string
+	count      int
+	severities map[string]int
+}
+
+// digest aggregates findings of the scans queued for a digest
+type digest struct {
+	scans      int
+	count      int
+	severities map[string]int
+	nodes      map[string]*digestNode
+}
+
+func newDigest() *digest {
+	return &digest{
+		severities: map[string]int{},
+		nodes:      map[string]*digestNode{},
+	}
+}
+
+func (d *digest) add(findings []map[string]interface{}) {
+	d.scans++
+	for _, f := range findings {
+		severity := finding.Severity(f)
+		d.count++
+		d.severities[severity]++
+
+		name := finding.NodeName(f)
+		n, ok := d.nodes[name]
+		if !ok {
+			nodeType, _ := f["node_type"].(string)
+			n = &digestNode{name: name, nodeType: nodeType, severities: map[string]int{}}
+			d.nodes[name] = n
+		}
+		n.count++
+		n.severities[severity]++
+	}
+}
+
+// topNodes returns the nodes with the most findings, one row each
+func (d *digest) topNodes() []map[string]interface{} {
+	nodes := make([]*digestNode, 0, len(d.nodes))
+	for _, n := range d.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].count != nodes[j].count {
+			return nodes[i].count > nodes[j].count
+		}
+		return nodes[i].name < nodes[j].name
+	})
+	if len(nodes) > digestTopNodes {
+		nodes = nodes[:digestTopNodes]
+	}
+
+	rows := []map[string]interface{}{}
+	for _, n := range nodes {
+		row := map[string]interface{}{
+			"node_name": n.name,
+			"node_type": n.nodeType,
+			"findings":  n.count,
+		}
+		for _, s := range digestSeverities {
+			row[s] = n.severities[s]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (d *digest) summary() string {
+	counts := []string{}
+	for _, s := range digestSeverities {
+		counts = append(counts, fmt.Sprintf("%s: %d", s, d.severities[s]))
+	}
+	return fmt.Sprintf("%d findings in %d scans (%s), top %d affected nodes",
+		d.count, d.scans, strings.Join(counts, ", "), len(d.topNodes()))
+}
+
+// digestInterval returns the interval between digests of the integration,
+// rate limited integrations send their overflow hourly
+func digestInterval(integrationRow postgresql_db.Integration) time.Duration {
+	switch integrationRow.DigestInterval {
+	case constants.DigestDaily:
+		return 24 * time.Hour
+	case constants.DigestHourly:
+		return time.Hour
+	}
+	if integrationRow.RateLimit > 0 {
+		return time.Hour
+	}
+	return 0
+}
+
+// rateLimitBudget returns the number of messages the integration can still
+// send in the current window, -1 if it is not rate limited
+func rateLimitBudget(ctx context.Context, pgClient *postgresql_db.Queries,
+	integrationRow postgresql_db.Integration) (int, error) {
+
+	if integrationRow.RateLimit <= 0 {
+		return -1, nil
+	}
+	sent, err := pgClient.CountNotificationMessagesSentSince(ctx,
+		postgresql_db.CountNotificationMessagesSentSinceParams{
+			IntegrationID: integrationRow.ID,
+			Status:        utils.NOTIFICATION_DELIVERY_SENT,
+			SentAt:        sql.NullTime{Time: time.Now().Add(-rateLimitWindow), Valid: true},
+		})
+	if err != nil {
+		return 0, err
+	}
+	budget := int(integrationRow.RateLimit) - int(sent)
+	if budget < 0 {
+		budget = 0
+	}
+	return budget, nil
+}
+
+// deferToDigest moves the deliveries over the rate limit to the next digest
+func deferToDigest(ctx context.Context, pgClient *postgresql_db.Queries,
+	integrationRow postgresql_db.Integration, deliveries []postgresql_db.NotificationDelivery) error {
+
+	var errs []error
+	for _, delivery := range deliveries {
+		err := pgClient.SetNotificationDeliveryStatus(ctx, postgresql_db.SetNotificationDeliveryStatusParams{
+			ID:     delivery.ID,
+			Status: utils.NOTIFICATION_DELIVERY_DIGEST,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	log.Info().Msgf("rate limit reached for integration id %d, %d scans deferred to the next digest",
+		integrationRow.ID, len(deliveries))
+	return errors.Join(errs...)
+}
+
+// sendDigestIfDue aggregates the queued scans into a single summary message
+// once the digest interval of the integration has passed
+func sendDigestIfDue(ctx context.Context, pgClient *postgresql_db.Queries,
+	integrationRow postgresql_db.Integration) error {
+
+	interval := digestInterval(integrationRow)
+	if interval == 0 {
+		return nil
+	}
+	last := integrationRow.CreatedAt
+	if integrationRow.LastDigestTime.Valid {
+		last = integrationRow.LastDigestTime.Time
+	}
+	if time.Now().Before(last.Add(interval)) {
+		return nil
+	}
+
+	// in digest mode every scan goes to the digest, otherwise only the scans
+	// deferred by the rate limit
+	statuses := []string{utils.NOTIFICATION_DELIVERY_DIGEST}
+	if integrationRow.DigestInterval != constants.DigestImmediate {
+		statuses = append(statuses, utils.NOTIFICATION_DELIVERY_PENDING)
+	}
+	deliveries := []postgresql_db.NotificationDelivery{}
+	for _, status := range statuses {
+		d, err := pgClient.GetNotificationDeliveriesByStatus(ctx,
+			postgresql_db.GetNotificationDeliveriesByStatusParams{
+				IntegrationID: integrationRow.ID,
+				Status:        status,
+				Limit:         digestMaxScans,
+			})
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, d...)
+	}
+	if len(deliveries) == 0 {
+		log.Info().Msgf("No %s scans for digest of integration id %d",
+			integrationRow.Resource, integrationRow.ID)
+		return nil
+	}
+
+	var filters model.IntegrationFilters
+	err := json.Unmarshal(integrationRow.Filters, &filters)
+	if err != nil {
+		return err
+	}
+
+	d := newDigest()
+	for _, delivery := range deliveries {
+		findings, err := digestScanFindings(ctx, integrationRow, filters, delivery.Resource, delivery.ScanID)
+		if err != nil {
+			// scan could have been deleted since it was queued
+			log.Warn().Msgf("skipping scan %s from digest of integration id %d: %v",
+				delivery.ScanID, integrationRow.ID, err)
+			continue
+		}
+		d.add(findings)
+	}
+
+	if d.count > 0 {
+		err = sendDigest(ctx, integrationRow, d)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, delivery := range deliveries {
+		err := pgClient.SetNotificationDeliveryStatus(ctx, postgresql_db.SetNotificationDeliveryStatusParams{
+			ID:     delivery.ID,
+			Status: utils.NOTIFICATION_DELIVERY_DIGESTED,
+			SentAt: sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			log.Error().Msgf("failed to update delivery status for scan %s: %v", delivery.ScanID, err)
+		}
+	}
+
+	err = pgClient.UpdateIntegrationLastDigest(ctx, postgresql_db.UpdateIntegrationLastDigestParams{
+		ID:             integrationRow.ID,
+		LastDigestTime: sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if d.count > 0 {
+		if err := pgClient.UpdateIntegrationLastSuccess(ctx, integrationRow.ID); err != nil {
+			log.Error().Msgf("failed to update last success time for integration id %d: %v",
+				integrationRow.ID, err)
+		}
+	}
+	log.Info().Msgf("Digest sent %s %d findings from %d scans using %s id %d",
+		integrationRow.Resource, d.count, d.scans, integrationRow.IntegrationType, integrationRow.ID)
+	return nil
+}
+
+func sendDigest(ctx context.Context, integrationRow postgresql_db.Integration, d *digest) error {
+	iByte, err := json.Marshal(integrationRow)
+	if err != nil {
+		return err
+	}
+	integrationModel, err := integration.GetIntegration(ctx, integrationRow.IntegrationType, iByte)
+	if err != nil {
+		return err
+	}
+
+	messageByte, err := json.Marshal(d.topNodes())
+	if err != nil {
+		return err
+	}
+	extras := map[string]interface{}{
+		"scan_type":            integrationRow.Resource,
+		msgtemplate.TitleKey:   fmt.Sprintf("Deepfence %s digest", integrationRow.Resource),
+		msgtemplate.SummaryKey: d.summary(),
+	}
+	return integrationModel.SendNotification(ctx, string(messageByte), extras)
+}
+
+func digestScanFindings(ctx context.Context, integrationRow postgresql_db.Integration,
+	filters model.IntegrationFilters, resource, scanID string) ([]map[string]interface{}, error) {
+
+	switch resource {
+	case utils.ScanTypeDetectedNode[utils.NEO4J_VULNERABILITY_SCAN]:
+		return scanFindings[model.Vulnerability](ctx, integrationRow, filters, resource, scanID)
+	case utils.ScanTypeDetectedNode[utils.NEO4J_SECRET_SCAN]:
+		return scanFindings[model.Secret](ctx, integrationRow, filters, resource, scanID)
+	case utils.ScanTypeDetectedNode[utils.NEO4J_MALWARE_SCAN]:
+		return scanFindings[model.Malware](ctx, integrationRow, filters, resource, scanID)
+	case utils.ScanTypeDetectedNode[utils.NEO4J_COMPLIANCE_SCAN]:
+		return scanFindings[model.Compliance](ctx, integrationRow, filters, resource, scanID)
+	case utils.ScanTypeDetectedNode[utils.NEO4J_CLOUD_COMPLIANCE_SCAN]:
+		return scanFindings[model.CloudCompliance](ctx, integrationRow, filters, resource, scanID)
+	}
+	return nil, errors.New("No integration type")
+}
+
+func scanFindings[T any](ctx context.Context, integrationRow postgresql_db.Integration,
+	filters model.IntegrationFilters, resource, scanID string) ([]map[string]interface{}, error) {
+
+	results, common, err := integrationScanResults[T](ctx, integrationRow, filters, resource, scanID)
+	if err != nil {
+		return nil, err
+	}
+	return injectNodeData[T](results, common, integrationRow.IntegrationType), nil
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 )
 
 // CleanUpPostgresDB Delete expired user invites and password reset requests
@@ -35,5 +36,28 @@ func CleanUpPostgresDB(msg *message.Message) error {
 
 	log.Info().Msgf("deleted %d audit logs which were older than 30days", deleted)
 
+	// delivered notifications are only needed for de-duplication while the
+	// scan is inside the integration look back window
+	deleted, err = pgClient.DeleteNotificationDeliveriesOlderThan30days(ctx, utils.NOTIFICATION_DELIVERY_SENT)
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	log.Info().Msgf("deleted %d notification deliveries which were older than 30days", deleted)
+
+	deleted, err = pgClient.DeleteNotificationDeliveriesOlderThan30days(ctx, utils.NOTIFICATION_DELIVERY_DIGESTED)
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	log.Info().Msgf("deleted %d digested notification deliveries which were older than 30days", deleted)
+
+	deleted, err = pgClient.DeleteNotificationEventsOlderThan30days(ctx)
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	log.Info().Msgf("deleted %d notification events which were older than 30days", deleted)
+
 	return nil
 }
@@ -1,13 +1,22 @@
 package cronjobs
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/deepfence/ThreatMapper/deepfence_server/handler"
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry"
 	sync "github.com/deepfence/ThreatMapper/deepfence_server/pkg/registrysync"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/controls"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
 	postgresql_db "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 )
@@ -71,11 +80,205 @@ func SyncRegistry(msg *message.Message) error {
 			continue
 		}
 
-		err = sync.SyncRegistry(ctx, pgClient, r, row.ID)
+		if len(rsp.Images) > 0 {
+			err = syncPushedImages(ctx, pgClient, r, row.ID, rsp)
+		} else {
+			err = sync.SyncRegistry(ctx, pgClient, r, row.ID)
+		}
 		if err != nil {
 			log.Error().Msgf("unable to sync registry: %s (%s): %v", row.RegistryType, row.Name, err)
+			notification.Publish(ctx, notification.Event{
+				EventType: notification.RegistrySyncFailed,
+				NodeID:    model.GetRegistryID(r.GetRegistryType(), r.GetNamespace()),
+				NodeType:  utils.NodeTypeRegistryAccount,
+				Message:   fmt.Sprintf("sync of registry %s (%s) failed: %v", row.Name, row.RegistryType, err),
+				Details: map[string]interface{}{
+					"registry_id":   row.ID,
+					"registry_name": row.Name,
+					"registry_type": row.RegistryType,
+				},
+			})
+			continue
+		}
+
+		if err := evaluateScanPolicies(ctx, pgClient, r, row.ID); err != nil {
+			log.Error().Msgf("unable to evaluate scan policies of registry: %s (%s): %v", row.RegistryType, row.Name, err)
+		}
+	}
+	return nil
+}
+
+// CheckRegistryCredentials validates the credentials of every registry
+// account, the accounts with invalid or expiring credentials are reported
+// as events
+func CheckRegistryCredentials(msg *message.Message) error {
+	namespace := msg.Metadata.Get(directory.NamespaceKey)
+	ctx := directory.NewContextWithNameSpace(directory.NamespaceID(namespace))
+
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		log.Error().Msgf("unable to get postgres client: %v", err)
+		return nil
+	}
+
+	registries, err := pgClient.GetContainerRegistries(ctx)
+	if err != nil {
+		log.Error().Msgf("unable to get registries: %v", err)
+		return nil
+	}
+
+	for _, row := range registries {
+		r, err := registry.GetRegistryWithRegistryRow(row)
+		if err != nil {
+			log.Error().Msgf("unable to get registry for %s: %v", row.RegistryType, err)
+			continue
+		}
+		status, err := sync.CheckRegistryCredentials(ctx, pgClient, r, row.Name)
+		if err != nil {
+			log.Error().Msgf("unable to check credentials of registry: %s (%s): %v", row.RegistryType, row.Name, err)
+			continue
+		}
+		log.Debug().Msgf("credentials of registry %s (%s) valid=%t expires_at=%d",
+			row.Name, row.RegistryType, status.Valid, status.ExpiresAt)
+	}
+	return nil
+}
+
+// syncPushedImages upserts the images reported by the registry webhook and
+// starts the scans enabled on push
+func syncPushedImages(ctx context.Context, pgClient *postgresql_db.Queries, r registry.Registry,
+	pgID int32, rsp utils.RegistrySyncParams) error {
+
+	images, err := sync.SyncRegistryImages(ctx, pgClient, r, pgID, rsp.Images)
+	if err != nil {
+		return err
+	}
+	if len(rsp.ScanTypes) == 0 {
+		return nil
+	}
+
+	nodeIds := []model.NodeIdentifier{}
+	for _, image := range images {
+		nodeIds = append(nodeIds, model.NodeIdentifier{
+			NodeId:   image.ID,
+			NodeType: controls.ResourceTypeToString(controls.Image),
+		})
+	}
+	scanTrigger := model.ScanTriggerCommon{NodeIds: nodeIds, Filters: model.ScanFilter{}}
+
+	var errs []error
+	for _, scanType := range rsp.ScanTypes {
+		actionBuilder := scanActionBuilder(ctx, utils.Neo4jScanType(scanType))
+		if actionBuilder == nil {
+			log.Warn().Msgf("unsupported scan type %s on push", scanType)
+			continue
+		}
+		scanIds, _, err := handler.StartMultiScan(ctx, false, utils.Neo4jScanType(scanType), scanTrigger, actionBuilder)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("start %s on push: %w", scanType, err))
 			continue
 		}
+		log.Info().Msgf("started %s %v on push to registry id=%d", scanType, scanIds, pgID)
+	}
+	return errors.Join(errs...)
+}
+
+func scanActionBuilder(ctx context.Context,
+	scanType utils.Neo4jScanType) func(string, model.NodeIdentifier, int32) (controls.Action, error) {
+
+	switch scanType {
+	case utils.NEO4J_VULNERABILITY_SCAN:
+		return handler.StartScanActionBuilder(ctx, controls.StartVulnerabilityScan, map[string]string{"scan_type": "all"})
+	case utils.NEO4J_SECRET_SCAN:
+		return handler.StartScanActionBuilder(ctx, controls.StartSecretScan, nil)
+	case utils.NEO4J_MALWARE_SCAN:
+		return handler.StartScanActionBuilder(ctx, controls.StartMalwareScan, nil)
 	}
 	return nil
 }
+
+// evaluateScanPolicies starts the scans of the enabled scan policies of the
+// registry on the images they select, the outcome is saved on every policy
+func evaluateScanPolicies(ctx context.Context, pgClient *postgresql_db.Queries, r registry.Registry,
+	pgID int32) error {
+
+	rows, err := pgClient.GetRegistryScanPolicies(ctx, pgID)
+	if err != nil {
+		return err
+	}
+	registryId := model.GetRegistryID(r.GetRegistryType(), r.GetNamespace())
+
+	var errs []error
+	for _, row := range rows {
+		if !row.IsEnabled {
+			continue
+		}
+		policy, err := model.NewRegistryScanPolicy(registryId, row)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("scan policy %d: %w", row.ID, err))
+			continue
+		}
+
+		var (
+			statuses   []string
+			policyErrs []error
+		)
+		for _, scanType := range policy.ScanTypes {
+			started, err := startPolicyScans(ctx, policy, utils.Neo4jScanType(scanType))
+			if err != nil {
+				policyErrs = append(policyErrs, fmt.Errorf("%s: %w", scanType, err))
+				continue
+			}
+			statuses = append(statuses, fmt.Sprintf("%s: started %d", scanType, started))
+		}
+		for _, err := range policyErrs {
+			statuses = append(statuses, err.Error())
+		}
+		if len(policyErrs) > 0 {
+			errs = append(errs, fmt.Errorf("scan policy %d: %w", row.ID, errors.Join(policyErrs...)))
+		}
+
+		err = pgClient.UpdateRegistryScanPolicyStatus(ctx, postgresql_db.UpdateRegistryScanPolicyStatusParams{
+			LastStatus: strings.Join(statuses, ", "),
+			ID:         row.ID,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func startPolicyScans(ctx context.Context, policy model.RegistryScanPolicy, scanType utils.Neo4jScanType) (int, error) {
+	actionBuilder := scanActionBuilder(ctx, scanType)
+	if actionBuilder == nil {
+		return 0, fmt.Errorf("unsupported scan type %s", scanType)
+	}
+
+	images, err := sync.GetPolicyImages(ctx, policy.RegistryId, scanType)
+	if err != nil {
+		return 0, err
+	}
+	selected, err := sync.SelectPolicyImages(policy, images, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if len(selected) == 0 {
+		return 0, nil
+	}
+
+	nodeIds := []model.NodeIdentifier{}
+	for _, nodeId := range selected {
+		nodeIds = append(nodeIds, model.NodeIdentifier{
+			NodeId:   nodeId,
+			NodeType: controls.ResourceTypeToString(controls.Image),
+		})
+	}
+	scanTrigger := model.ScanTriggerCommon{NodeIds: nodeIds, Filters: model.ScanFilter{}}
+	scanIds, _, err := handler.StartMultiScan(ctx, false, scanType, scanTrigger, actionBuilder)
+	if err != nil {
+		return 0, err
+	}
+	log.Info().Msgf("scan policy %s of registry %s started %s %v", policy.Name, policy.RegistryId, scanType, scanIds)
+	return len(scanIds), nil
+}
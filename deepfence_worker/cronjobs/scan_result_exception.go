@@ -0,0 +1,66 @@
+package cronjobs
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+)
+
+// ExpireScanResultExceptions unmasks the results of the exceptions past
+// their expiry, integrations are notified of the findings shown again
+func ExpireScanResultExceptions(msg *message.Message) error {
+	namespace := msg.Metadata.Get(directory.NamespaceKey)
+	ctx := directory.NewContextWithNameSpace(directory.NamespaceID(namespace))
+
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		log.Error().Msgf("unable to get postgres client: %v", err)
+		return nil
+	}
+
+	exceptions, err := pgClient.GetDueScanResultExceptions(ctx)
+	if err != nil {
+		log.Error().Msgf("unable to get expired exceptions: %v", err)
+		return nil
+	}
+
+	for _, e := range exceptions {
+		nodes, err := model.RemoveScanResultException(ctx, utils.Neo4jScanType(e.ScanType), e.ID)
+		if err != nil {
+			log.Error().Msgf("unable to unmask results of exception %d: %v", e.ID, err)
+			continue
+		}
+		if err := pgClient.SetScanResultExceptionExpired(ctx, e.ID); err != nil {
+			log.Error().Msgf("unable to expire exception %d: %v", e.ID, err)
+			continue
+		}
+		log.Info().Msgf("exception %d of %s %s expired, %d results unmasked",
+			e.ID, e.ScanType, e.ResultID, nodes.Count)
+
+		notification.Publish(ctx, notification.Event{
+			EventType: notification.ExceptionExpired,
+			NodeID:    strconv.FormatInt(e.ID, 10),
+			NodeType:  e.ScanType,
+			Message: fmt.Sprintf("exception of %s %s expired, %d results on %d nodes are no longer masked",
+				e.ScanType, e.ResultID, nodes.Count, len(nodes.NodeIds)),
+			Details: map[string]interface{}{
+				"scan_type":   e.ScanType,
+				"result_id":   e.ResultID,
+				"scope_type":  e.ScopeType,
+				"scope_value": e.ScopeValue,
+				"reason":      e.Reason,
+				"owner":       e.Owner,
+				"created_by":  e.CreatedBy,
+				"node_ids":    nodes.NodeIds,
+				"count":       nodes.Count,
+			},
+		})
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package cronjobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// node types having findings, their SLA counts are reset when none of their
+// findings is due soon or breached anymore
+var slaNodeTypes = []string{"Node", "Container", "ContainerImage", "KubernetesCluster", "CloudNode"}
+
+// slaBreachedEvents returns an event per node with the findings which
+// breached their SLA since the last evaluation, findings already breached
+// whose deadline or policy changed are not notified again. There is one event
+// per node as a scan usually breaches many findings at once
+func slaBreachedEvents(changed []postgresqlDb.EvaluateFindingSLAsRow) []notification.Event {
+	breached := map[string][]postgresqlDb.EvaluateFindingSLAsRow{}
+	nodeIds := []string{}
+	for _, f := range changed {
+		if f.SlaStatus != model.SLAStatusBreached || f.PreviousStatus == model.SLAStatusBreached {
+			continue
+		}
+		if _, ok := breached[f.NodeID]; !ok {
+			nodeIds = append(nodeIds, f.NodeID)
+		}
+		breached[f.NodeID] = append(breached[f.NodeID], f)
+	}
+	events := []notification.Event{}
+	for _, nodeId := range nodeIds {
+		findings := breached[nodeId]
+		details := []map[string]interface{}{}
+		for _, f := range findings {
+			details = append(details, map[string]interface{}{
+				"scan_type": f.ScanType,
+				"result_id": f.ResultID,
+				"severity":  f.Severity,
+				"due_at":    f.SlaDueAt.Time.UTC().Format(time.RFC3339),
+			})
+		}
+		events = append(events, notification.Event{
+			EventType: notification.SLABreached,
+			NodeID:    nodeId,
+			NodeType:  findings[0].NodeType,
+			Message:   fmt.Sprintf("%d findings on %s breached their SLA", len(findings), nodeId),
+			Details: map[string]interface{}{
+				"count":    len(findings),
+				"findings": details,
+			},
+		})
+	}
+	return events
+}
+
+// EvaluateFindingSLA marks the open findings within SLA, due soon or breached
+// by the policy applying to them, integrations are notified of the newly
+// breached findings and the counts of every node are set for search
+func EvaluateFindingSLA(msg *message.Message) error {
+	namespace := msg.Metadata.Get(directory.NamespaceKey)
+	ctx := directory.NewContextWithNameSpace(directory.NamespaceID(namespace))
+
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		log.Error().Msgf("unable to get postgres client: %v", err)
+		return nil
+	}
+
+	changed, err := pgClient.EvaluateFindingSLAs(ctx)
+	if err != nil {
+		log.Error().Msgf("unable to evaluate finding SLAs: %v", err)
+		return nil
+	}
+
+	events := slaBreachedEvents(changed)
+	for _, event := range events {
+		notification.Publish(ctx, event)
+	}
+	log.Info().Msgf("SLA of %d findings changed, %d nodes breached", len(changed), len(events))
+
+	counts, err := pgClient.GetOpenFindingSLACounts(ctx)
+	if err != nil {
+		log.Error().Msgf("unable to get SLA counts: %v", err)
+		return nil
+	}
+
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	txConfig := neo4j.WithTxTimeout(120 * time.Second)
+
+	nodeBatches := map[string][]map[string]interface{}{}
+	countedIds := []string{}
+	for _, c := range counts {
+		nodeBatches[c.NodeType] = append(nodeBatches[c.NodeType], map[string]interface{}{
+			"node_id":        c.NodeID,
+			"breached_count": c.BreachedCount,
+			"due_soon_count": c.DueSoonCount,
+		})
+		countedIds = append(countedIds, c.NodeID)
+	}
+
+	for _, nodeType := range slaNodeTypes {
+		if _, err = session.Run(`
+			MATCH (n:`+nodeType+`)
+			WHERE (n.sla_breached_count > 0 OR n.sla_due_soon_count > 0)
+			AND NOT n.node_id IN $node_ids
+			SET n.sla_breached_count = 0, n.sla_due_soon_count = 0`,
+			map[string]interface{}{"node_ids": countedIds}, txConfig); err != nil {
+			return err
+		}
+	}
+	for nodeType, batch := range nodeBatches {
+		if _, err = session.Run(`
+			UNWIND $batch AS row
+			MATCH (n:`+nodeType+`{node_id: row.node_id})
+			SET n.sla_breached_count = row.breached_count,
+			    n.sla_due_soon_count = row.due_soon_count`,
+			map[string]interface{}{"batch": batch}, txConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package cronjobs
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
+	postgresqlDb "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+)
+
+func slaRow(nodeId, resultId, previous, status string) postgresqlDb.EvaluateFindingSLAsRow {
+	return postgresqlDb.EvaluateFindingSLAsRow{
+		ScanType:       "VulnerabilityScan",
+		NodeID:         nodeId,
+		NodeType:       "Node",
+		ResultID:       resultId,
+		Severity:       "critical",
+		SlaDueAt:       sql.NullTime{Time: time.Date(2023, time.August, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		SlaStatus:      status,
+		PreviousStatus: previous,
+	}
+}
+
+func TestSLABreachedEvents(t *testing.T) {
+	changed := []postgresqlDb.EvaluateFindingSLAsRow{
+		slaRow("host-1", "openssl:1.1CVE-1", model.SLAStatusWithin, model.SLAStatusBreached),
+		slaRow("host-2", "openssl:1.1CVE-1", model.SLAStatusDueSoon, model.SLAStatusBreached),
+		slaRow("host-1", "openssl:1.1CVE-2", "", model.SLAStatusBreached),
+		// deadline moved by a policy change, already notified
+		slaRow("host-3", "openssl:1.1CVE-1", model.SLAStatusBreached, model.SLAStatusBreached),
+		// not breached anymore or not yet
+		slaRow("host-4", "openssl:1.1CVE-1", model.SLAStatusBreached, model.SLAStatusWithin),
+		slaRow("host-4", "openssl:1.1CVE-2", model.SLAStatusWithin, model.SLAStatusDueSoon),
+	}
+
+	events := slaBreachedEvents(changed)
+	if len(events) != 2 {
+		t.Fatalf("expected events for host-1 and host-2, got %+v", events)
+	}
+	if events[0].NodeID != "host-1" || events[1].NodeID != "host-2" {
+		t.Errorf("events are in the order of the first breached finding of the nodes, got %s, %s",
+			events[0].NodeID, events[1].NodeID)
+	}
+	for _, e := range events {
+		if e.EventType != notification.SLABreached || e.NodeType != "Node" {
+			t.Errorf("unexpected event %+v", e)
+		}
+	}
+
+	if events[0].Details["count"] != 2 {
+		t.Errorf("findings of a node are in one event, got %v", events[0].Details["count"])
+	}
+	findings := events[0].Details["findings"].([]map[string]interface{})
+	if findings[1]["result_id"] != "openssl:1.1CVE-2" || findings[1]["due_at"] != "2023-08-01T00:00:00Z" {
+		t.Errorf("unexpected finding details %+v", findings[1])
+	}
+	if events[1].Message != "1 findings on host-2 breached their SLA" {
+		t.Errorf("unexpected message %q", events[1].Message)
+	}
+
+	if len(slaBreachedEvents(nil)) != 0 {
+		t.Error("no events without changes")
+	}
+}
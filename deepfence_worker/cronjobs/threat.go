@@ -6,6 +6,7 @@ import (
 
 	"github.com/ThreeDotsLabs/watermill/message"
 
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/threatintel"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
@@ -86,6 +87,19 @@ func computeThreatExploitability(session neo4j.Session) error {
 		return err
 	}
 
+	// Known exploited vulnerabilities and the ones likely exploited per EPSS
+	// rank above the scanner heuristic, reachable ones are raised below
+	if _, err = tx.Run(`
+		MATCH (v:Vulnerability)
+		WHERE v.cisa_kev = true OR v.epss_score >= $epss_threshold
+		SET v.exploitability_score = CASE
+			WHEN v.cisa_kev = true AND v.exploitability_score < 2 THEN 2
+			WHEN v.exploitability_score < 1 THEN 1
+			ELSE v.exploitability_score END`,
+		map[string]interface{}{"epss_threshold": threatintel.EPSSThreshold}); err != nil {
+		return err
+	}
+
 	// Following cypher request applies to Images & Containers
 	if _, err = tx.Run(`
 		MATCH (n:Node{node_id:"in-the-internet"}) -[:CONNECTS*1..3]-> (m:Node)
@@ -0,0 +1,193 @@
+package cronjobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/threatintel"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const threatIntelBatchSize = 10000
+
+func batches[T any](items []T, size int) [][]T {
+	res := [][]T{}
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		res = append(res, items[start:end])
+	}
+	return res
+}
+
+func toMaps[T any](items []T) []map[string]interface{} {
+	res := []map[string]interface{}{}
+	for _, item := range items {
+		res = append(res, utils.ToMap(item))
+	}
+	return res
+}
+
+// feedsVersion identifies the uploaded feeds, it is stored on the enriched
+// vulnerability stubs
+func feedsVersion(epssData, kevData []byte) string {
+	h := sha256.New()
+	for _, data := range [][]byte{epssData, kevData} {
+		sum := sha256.Sum256(data)
+		h.Write(sum[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func staleOnly[T any](items []T, cve func(T) string, stale map[string]struct{}) []T {
+	res := []T{}
+	for _, item := range items {
+		if _, ok := stale[cve(item)]; ok {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// EnrichThreatIntel stores the uploaded EPSS scores and CISA KEV catalog on
+// the vulnerability stubs and the vulnerabilities detected, they are folded
+// into the exploitability score by the threat computation. Only the stubs
+// not enriched with the current feeds are updated, the run is skipped when
+// the feeds did not change and no vulnerability was found since
+func EnrichThreatIntel(msg *message.Message) error {
+	namespace := msg.Metadata.Get(directory.NamespaceKey)
+	ctx := directory.NewContextWithNameSpace(directory.NamespaceID(namespace))
+
+	epssData, err := threatintel.Load(ctx, threatintel.FeedEPSS)
+	if err != nil {
+		log.Error().Msgf("unable to load EPSS scores: %v", err)
+		return nil
+	}
+	kevData, err := threatintel.Load(ctx, threatintel.FeedKEV)
+	if err != nil {
+		log.Error().Msgf("unable to load KEV catalog: %v", err)
+		return nil
+	}
+	if epssData == nil && kevData == nil {
+		log.Debug().Msg("no threat intel uploaded")
+		return nil
+	}
+	version := feedsVersion(epssData, kevData)
+
+	driver, err := directory.Neo4jClient(ctx)
+	if err != nil {
+		return err
+	}
+	session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	txConfig := neo4j.WithTxTimeout(600 * time.Second)
+
+	res, err := session.Run(`
+		MATCH (v:VulnerabilityStub)
+		WHERE v.threat_intel_version IS NULL OR v.threat_intel_version <> $version
+		RETURN v.node_id`,
+		map[string]interface{}{"version": version}, txConfig)
+	if err != nil {
+		return err
+	}
+	recs, err := res.Collect()
+	if err != nil {
+		return err
+	}
+	if len(recs) == 0 {
+		log.Debug().Msgf("threat intel %s unchanged", version)
+		return nil
+	}
+	stale := make(map[string]struct{}, len(recs))
+	staleIds := make([]string, 0, len(recs))
+	for _, rec := range recs {
+		if id, ok := rec.Values[0].(string); ok {
+			stale[id] = struct{}{}
+			staleIds = append(staleIds, id)
+		}
+	}
+
+	if epssData != nil {
+		scores, err := threatintel.ParseEPSS(epssData)
+		if err != nil {
+			log.Error().Msgf("unable to parse EPSS scores: %v", err)
+			return nil
+		}
+		scores = staleOnly(scores, func(e threatintel.EPSS) string { return e.CVE }, stale)
+		for _, batch := range batches(scores, threatIntelBatchSize) {
+			if _, err = session.Run(`
+				UNWIND $batch AS row
+				MATCH (v:VulnerabilityStub{node_id: row.cve})
+				SET v.epss_score = row.epss_score,
+				    v.epss_percentile = row.epss_percentile`,
+				map[string]interface{}{"batch": toMaps(batch)}, txConfig); err != nil {
+				return err
+			}
+		}
+		log.Info().Msgf("loaded %d EPSS scores", len(scores))
+	}
+
+	if kevData != nil {
+		kevs, err := threatintel.ParseKEV(kevData)
+		if err != nil {
+			log.Error().Msgf("unable to parse KEV catalog: %v", err)
+			return nil
+		}
+		cves := []string{}
+		for _, kev := range kevs {
+			cves = append(cves, kev.CVE)
+		}
+		if _, err = session.Run(`
+			MATCH (v:VulnerabilityStub)
+			WHERE v.cisa_kev = true AND NOT v.node_id IN $cves
+			SET v.cisa_kev = false
+			REMOVE v.cisa_kev_date_added, v.cisa_kev_due_date, v.cisa_kev_ransomware`,
+			map[string]interface{}{"cves": cves}, txConfig); err != nil {
+			return err
+		}
+		kevs = staleOnly(kevs, func(k threatintel.KEV) string { return k.CVE }, stale)
+		for _, batch := range batches(kevs, threatIntelBatchSize) {
+			if _, err = session.Run(`
+				UNWIND $batch AS row
+				MATCH (v:VulnerabilityStub{node_id: row.cve})
+				SET v.cisa_kev = true,
+				    v.cisa_kev_date_added = row.date_added,
+				    v.cisa_kev_due_date = row.due_date,
+				    v.cisa_kev_ransomware = row.ransomware`,
+				map[string]interface{}{"batch": toMaps(batch)}, txConfig); err != nil {
+				return err
+			}
+		}
+		log.Info().Msgf("loaded %d known exploited vulnerabilities", len(kevs))
+	}
+
+	for _, batch := range batches(staleIds, threatIntelBatchSize) {
+		if _, err = session.Run(`
+			UNWIND $batch AS id
+			MATCH (v:VulnerabilityStub{node_id: id})
+			SET v.epss_score = COALESCE(v.epss_score, 0.0),
+			    v.epss_percentile = COALESCE(v.epss_percentile, 0.0),
+			    v.cisa_kev = COALESCE(v.cisa_kev, false),
+			    v.threat_intel_version = $version
+			WITH v
+			MATCH (n:Vulnerability) -[:IS]-> (v)
+			SET n.epss_score = v.epss_score,
+			    n.epss_percentile = v.epss_percentile,
+			    n.cisa_kev = v.cisa_kev,
+			    n.cisa_kev_date_added = v.cisa_kev_date_added,
+			    n.cisa_kev_due_date = v.cisa_kev_due_date,
+			    n.cisa_kev_ransomware = v.cisa_kev_ransomware`,
+			map[string]interface{}{"batch": batch, "version": version}, txConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
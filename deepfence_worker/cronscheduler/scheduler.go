@@ -261,6 +261,12 @@ func (s *Scheduler) addCronJobs(ctx context.Context) error {
 	}
 	jobIDs = append(jobIDs, jobID)
 
+	jobID, err = s.cron.AddFunc("@every 60m", s.enqueueTask(namespace, sdkUtils.CheckRegistryCredentialsTask))
+	if err != nil {
+		return err
+	}
+	jobIDs = append(jobIDs, jobID)
+
 	jobID, err = s.cron.AddFunc("@every 30s", s.enqueueTask(namespace, sdkUtils.SendNotificationTask))
 	if err != nil {
 		return err
@@ -291,6 +297,24 @@ func (s *Scheduler) addCronJobs(ctx context.Context) error {
 	}
 	jobIDs = append(jobIDs, jobID)
 
+	jobID, err = s.cron.AddFunc("@every 5m", s.enqueueTask(namespace, sdkUtils.ExpireExceptionsTask))
+	if err != nil {
+		return err
+	}
+	jobIDs = append(jobIDs, jobID)
+
+	jobID, err = s.cron.AddFunc("@every 60m", s.enqueueTask(namespace, sdkUtils.ThreatIntelTask))
+	if err != nil {
+		return err
+	}
+	jobIDs = append(jobIDs, jobID)
+
+	jobID, err = s.cron.AddFunc("@every 30m", s.enqueueTask(namespace, sdkUtils.EvaluateSLATask))
+	if err != nil {
+		return err
+	}
+	jobIDs = append(jobIDs, jobID)
+
 	s.jobs.CronJobs[namespace] = CronJobs{jobIDs: jobIDs}
 
 	return nil
@@ -311,9 +335,12 @@ func (s *Scheduler) startInitJobs(ctx context.Context) error {
 	s.enqueueTask(namespace, sdkUtils.SetUpGraphDBTask)()
 	s.enqueueTask(namespace, sdkUtils.CheckAgentUpgradeTask)()
 	s.enqueueTask(namespace, sdkUtils.SyncRegistryTask)()
+	s.enqueueTask(namespace, sdkUtils.CheckRegistryCredentialsTask)()
 	s.enqueueTask(namespace, sdkUtils.CloudComplianceTask)()
 	s.enqueueTask(namespace, sdkUtils.ReportCleanUpTask)()
 	s.enqueueTask(namespace, sdkUtils.CachePostureProviders)()
+	s.enqueueTask(namespace, sdkUtils.ThreatIntelTask)()
+	s.enqueueTask(namespace, sdkUtils.EvaluateSLATask)()
 
 	return nil
 }
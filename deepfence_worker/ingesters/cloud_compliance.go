@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	ingestersUtil "github.com/deepfence/ThreatMapper/deepfence_utils/utils/ingesters"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 )
@@ -40,6 +41,11 @@ func CommitFuncCloudCompliance(ns string, data []ingestersUtil.CloudCompliance)
 		return err
 	}
 
+	if err = applyScanResultExceptions(ctx, tx, utils.NEO4J_CLOUD_COMPLIANCE_SCAN, data,
+		func(v ingestersUtil.CloudCompliance) string { return v.ScanID }); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -1,12 +1,15 @@
 package ingesters
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/notification"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	ingestersUtil "github.com/deepfence/ThreatMapper/deepfence_utils/utils/ingesters"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
@@ -65,6 +68,12 @@ func CommitFuncStatus[Status any](ts utils.Neo4jScanType) func(ns string, data [
 		}
 
 		recordMap := statusesToMaps(data)
+		failed, err := newlyFailedScans(tx, ts, recordMap)
+		if err != nil {
+			log.Error().Msgf("Error while checking failed scans: %+v", err)
+			return err
+		}
+
 		if _, err = tx.Run(query, map[string]interface{}{"batch": statusesToMaps(data)}); err != nil {
 			log.Error().Msgf("Error while updating scan status: %+v", err)
 			return err
@@ -75,6 +84,12 @@ func CommitFuncStatus[Status any](ts utils.Neo4jScanType) func(ns string, data [
 			return err
 		}
 
+		notification.PublishRecords(ctx, notification.ScanFailed, failed)
+
+		if err := model.UpdateFindingLifecycles(ctx, ts, completedScans(recordMap)); err != nil {
+			log.Error().Msgf("Error while updating finding lifecycles: %+v", err)
+		}
+
 		if ts != utils.NEO4J_CLOUD_COMPLIANCE_SCAN && ts != utils.NEO4J_COMPLIANCE_SCAN {
 			updatePodScanStatus(ts, recordMap, session)
 		}
@@ -83,6 +98,51 @@ func CommitFuncStatus[Status any](ts utils.Neo4jScanType) func(ns string, data [
 	}
 }
 
+// newlyFailedScans returns the scanned node of each scan in the batch which
+// is failing now, scans already marked failed are skipped
+func newlyFailedScans(tx neo4j.Transaction, ts utils.Neo4jScanType,
+	recordMap []map[string]interface{}) ([]*neo4j.Record, error) {
+
+	failed := []map[string]interface{}{}
+	for _, r := range recordMap {
+		if r["scan_status"] == utils.SCAN_STATUS_FAILED {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	res, err := tx.Run(`
+		UNWIND $batch as row
+		MATCH (n:`+string(ts)+`{node_id: row.scan_id}) -[:SCANNED]- (m)
+		WHERE n.status <> $failed
+		RETURN m.node_id AS node_id, labels(m)[0] AS node_type, m.node_name AS node_name,
+			n.node_id AS scan_id, $scan_type AS scan_type,
+			$scan_type + ' on ' + coalesce(m.node_name, m.node_id) + ' failed: ' + coalesce(row.scan_message, '') AS message`,
+		map[string]interface{}{
+			"batch":     failed,
+			"failed":    utils.SCAN_STATUS_FAILED,
+			"scan_type": string(ts),
+		})
+	if err != nil {
+		return nil, err
+	}
+	return res.Collect()
+}
+
+// completedScans returns the scans of the batch which completed, their
+// results are all ingested
+func completedScans(recordMap []map[string]interface{}) []string {
+	res := []string{}
+	for _, r := range recordMap {
+		if r["scan_status"] == utils.SCAN_STATUS_SUCCESS {
+			res = append(res, r["scan_id"].(string))
+		}
+	}
+	return res
+}
+
 func updatePodScanStatus(ts utils.Neo4jScanType,
 	recordMap []map[string]interface{}, session neo4j.Session) error {
 
@@ -156,3 +216,20 @@ func ToMap[T any](data T) map[string]interface{} {
 	_ = json.Unmarshal(out, &bb)
 	return bb
 }
+
+// applyScanResultExceptions masks the results of the batch covered by risk
+// exceptions, in the transaction ingesting them
+func applyScanResultExceptions[T any](ctx context.Context, tx neo4j.Transaction, ts utils.Neo4jScanType,
+	data []T, scanId func(T) string) error {
+
+	scanIds := []string{}
+	seen := map[string]struct{}{}
+	for _, v := range data {
+		id := scanId(v)
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			scanIds = append(scanIds, id)
+		}
+	}
+	return model.ApplyActiveScanResultExceptions(ctx, tx, ts, scanIds)
+}
@@ -47,6 +47,11 @@ func CommitFuncCompliance(ns string, data []ingestersUtil.Compliance) error {
 		return err
 	}
 
+	if err = applyScanResultExceptions(ctx, tx, utils.NEO4J_COMPLIANCE_SCAN, data,
+		func(v ingestersUtil.Compliance) string { return v.ScanId }); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
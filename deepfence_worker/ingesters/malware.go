@@ -61,6 +61,11 @@ func CommitFuncMalware(ns string, data []ingestersUtil.Malware) error {
 		return err
 	}
 
+	if err = applyScanResultExceptions(ctx, tx, utils.NEO4J_MALWARE_SCAN, data,
+		func(v ingestersUtil.Malware) string { return v.ScanID }); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
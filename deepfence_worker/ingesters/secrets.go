@@ -50,6 +50,11 @@ func CommitFuncSecrets(ns string, data []ingestersUtil.Secret) error {
 		return err
 	}
 
+	if err = applyScanResultExceptions(ctx, tx, utils.NEO4J_SECRET_SCAN, data,
+		func(v ingestersUtil.Secret) string { return v.ScanID }); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -69,6 +74,10 @@ func secretsToMaps(data []ingestersUtil.Secret) []map[string]map[string]interfac
 			secret[k] = v
 		}
 		secret["node_id"] = utils.ScanIdReplacer.Replace(fmt.Sprintf("%v:%v", i.Rule.ID, i.Match.FullFilename))
+		if i.Commit != "" {
+			// the same file holds different secrets in different commits
+			secret["node_id"] = utils.ScanIdReplacer.Replace(fmt.Sprintf("%v:%v:%v", i.Rule.ID, i.Commit, i.Match.FullFilename))
+		}
 		rule := utils.ToMap(i.Rule)
 		delete(rule, "id")
 		rule["rule_id"] = i.Rule.ID
@@ -36,9 +36,18 @@ func CommitFuncVulnerabilities(ns string, data []ingestersUtil.Vulnerability) er
 		MERGE (n) -[:IS]-> (v)
 		SET v += rule,
 		    v.masked = COALESCE(v.masked, false),
+		    v.epss_score = COALESCE(v.epss_score, 0.0),
+		    v.epss_percentile = COALESCE(v.epss_percentile, 0.0),
+		    v.cisa_kev = COALESCE(v.cisa_kev, false),
 		    v.updated_at = TIMESTAMP(),
 		    n += data,
 		    n.masked = COALESCE(n.masked, false),
+		    n.epss_score = COALESCE(v.epss_score, 0.0),
+		    n.epss_percentile = COALESCE(v.epss_percentile, 0.0),
+		    n.cisa_kev = COALESCE(v.cisa_kev, false),
+		    n.cisa_kev_date_added = v.cisa_kev_date_added,
+		    n.cisa_kev_due_date = v.cisa_kev_due_date,
+		    n.cisa_kev_ransomware = v.cisa_kev_ransomware,
 		    n.updated_at = TIMESTAMP()
 		WITH n, scan_id
 		MATCH (m:VulnerabilityScan{node_id: scan_id})
@@ -49,6 +58,11 @@ func CommitFuncVulnerabilities(ns string, data []ingestersUtil.Vulnerability) er
 		return err
 	}
 
+	if err = applyScanResultExceptions(ctx, tx, utils.NEO4J_VULNERABILITY_SCAN, data,
+		func(v ingestersUtil.Vulnerability) string { return v.ScanId }); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -0,0 +1,227 @@
+package helmchart
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/deepfence/ThreatMapper/deepfence_server/handler"
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/helmchart"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/controls"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	ingestersUtil "github.com/deepfence/ThreatMapper/deepfence_utils/utils/ingesters"
+	"github.com/deepfence/ThreatMapper/deepfence_worker/cronjobs"
+	workerUtils "github.com/deepfence/ThreatMapper/deepfence_worker/utils"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type HelmChartScan struct {
+	ingestC chan *kgo.Record
+}
+
+func NewHelmChartScanner(ingest chan *kgo.Record) HelmChartScan {
+	return HelmChartScan{ingestC: ingest}
+}
+
+func (s HelmChartScan) sendStatus(params utils.HelmChartScanParameters, status, msg string, rh []kgo.RecordHeader) {
+	sb, err := json.Marshal(ingestersUtil.ComplianceScanStatus{
+		ScanID:      params.ScanId,
+		ScanStatus:  status,
+		ScanMessage: msg,
+	})
+	if err != nil {
+		log.Error().Msg(err.Error())
+		return
+	}
+	s.ingestC <- &kgo.Record{
+		Topic:   utils.COMPLIANCE_SCAN_STATUS,
+		Value:   sb,
+		Headers: rh,
+	}
+}
+
+// ScanHelmChart renders the templates of a chart with its default values,
+// checks the rendered workloads for misconfigurations and starts the
+// vulnerability scans of the images they run
+func (s HelmChartScan) ScanHelmChart(msg *message.Message) error {
+	defer cronjobs.ScanWorkloadAllocator.Free()
+
+	tenantID := msg.Metadata.Get(directory.NamespaceKey)
+	if len(tenantID) == 0 {
+		log.Error().Msg("tenant-id/namespace is empty")
+		return nil
+	}
+
+	rh := []kgo.RecordHeader{
+		{Key: "namespace", Value: []byte(tenantID)},
+	}
+
+	ctx := directory.NewContextWithNameSpace(directory.NamespaceID(tenantID))
+	log.Info().Msgf("uuid: %s payload: %s ", msg.UUID, string(msg.Payload))
+
+	var params utils.HelmChartScanParameters
+	if err := json.Unmarshal(msg.Payload, &params); err != nil {
+		log.Error().Msg(err.Error())
+		return nil
+	}
+
+	s.sendStatus(params, utils.SCAN_STATUS_INPROGRESS, "", rh)
+	if err := s.scan(ctx, params, rh); err != nil {
+		log.Error().Msgf("helm chart scan %s failed: %v", params.ScanId, err)
+		s.sendStatus(params, utils.SCAN_STATUS_FAILED, err.Error(), rh)
+		return nil
+	}
+	s.sendStatus(params, utils.SCAN_STATUS_SUCCESS, "", rh)
+	return nil
+}
+
+func (s HelmChartScan) scan(ctx context.Context, params utils.HelmChartScanParameters, rh []kgo.RecordHeader) error {
+	dir, err := os.MkdirTemp("/tmp", "helmchart-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	manifests, err := renderChart(ctx, params, dir)
+	if err != nil {
+		return err
+	}
+	resources, err := helmchart.ParseManifests(manifests)
+	if err != nil {
+		return fmt.Errorf("parse rendered manifests: %w", err)
+	}
+
+	findings := helmchart.CheckResources(resources)
+	for _, f := range findings {
+		cb, err := json.Marshal(ingestersUtil.Compliance{
+			Type:                "helm",
+			TestCategory:        "Kubernetes hardening",
+			TestNumber:          f.RuleID,
+			TestInfo:            f.Title,
+			RemediationScript:   f.Remediation,
+			Resource:            f.Resource,
+			TestRationale:       f.Description,
+			TestSeverity:        f.Severity,
+			TestDesc:            f.Description,
+			Status:              f.Status,
+			ComplianceCheckType: helmchart.ComplianceCheckType,
+			ScanId:              params.ScanId,
+			NodeId:              fmt.Sprintf("%s;%s;%s", params.NodeId, f.RuleID, f.Resource),
+			NodeType:            params.NodeType,
+		})
+		if err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		s.ingestC <- &kgo.Record{
+			Topic:   utils.COMPLIANCE_SCAN,
+			Value:   cb,
+			Headers: rh,
+		}
+	}
+
+	images := helmchart.ExtractImages(resources)
+	imageIds, err := model.SetRegistryArtifactImages(ctx, params.NodeId, images)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("helm chart %s:%s has %d findings, references %d images, %d synced",
+		params.ChartName, params.ChartVersion, len(findings), len(images), len(imageIds))
+
+	return startImageScans(ctx, imageIds)
+}
+
+// renderChart pulls the chart with the credentials of its registry and
+// returns the manifests rendered by helm
+func renderChart(ctx context.Context, params utils.HelmChartScanParameters, dir string) ([]byte, error) {
+	creds, err := workerUtils.GetCredentialsFromRegistry(ctx, params.RegistryId)
+	if err != nil {
+		return nil, err
+	}
+	authDir, authCreds, err := workerUtils.GetConfigFileFromRegistry(ctx, params.RegistryId)
+	if err != nil {
+		return nil, err
+	}
+	if authDir != "" {
+		defer os.RemoveAll(authDir)
+	}
+
+	chart := "oci://" + path.Join(creds.ImagePrefix, params.ChartName)
+	args := []string{"pull", chart, "--version", params.ChartVersion, "--destination", dir}
+	if authDir != "" {
+		args = append(args, "--registry-config", filepath.Join(authDir, "config.json"))
+	}
+	if creds.SkipTLSVerify {
+		args = append(args, "--insecure-skip-tls-verify")
+	}
+	if creds.UseHttp {
+		args = append(args, "--plain-http")
+	}
+	if authCreds.CertDir != "" {
+		if authCreds.CACertificate != "" {
+			args = append(args, "--ca-file", filepath.Join(authCreds.CertDir, workerUtils.CACertFile))
+		}
+		if authCreds.ClientCertificate != "" {
+			args = append(args, "--cert-file", filepath.Join(authCreds.CertDir, workerUtils.ClientCertFile),
+				"--key-file", filepath.Join(authCreds.CertDir, workerUtils.ClientKeyFile))
+		}
+	}
+	if _, err := runHelm(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	packages, err := filepath.Glob(filepath.Join(dir, "*.tgz"))
+	if err != nil {
+		return nil, err
+	}
+	if len(packages) != 1 {
+		return nil, fmt.Errorf("pulled %d chart packages from %s", len(packages), chart)
+	}
+	return runHelm(ctx, "template", path.Base(params.ChartName), packages[0])
+}
+
+func runHelm(ctx context.Context, args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, "helm", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("helm %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// startImageScans scans the images the chart deploys for vulnerabilities,
+// like scans started from the registry page
+func startImageScans(ctx context.Context, imageIds []string) error {
+	if len(imageIds) == 0 {
+		return nil
+	}
+	nodeIds := []model.NodeIdentifier{}
+	for _, id := range imageIds {
+		nodeIds = append(nodeIds, model.NodeIdentifier{
+			NodeId:   id,
+			NodeType: controls.ResourceTypeToString(controls.Image),
+		})
+	}
+	scanTrigger := model.ScanTriggerCommon{NodeIds: nodeIds, Filters: model.ScanFilter{}}
+	actionBuilder := handler.StartScanActionBuilder(ctx, controls.StartVulnerabilityScan,
+		map[string]string{"scan_type": "all"})
+	scanIds, _, err := handler.StartMultiScan(ctx, false, utils.NEO4J_VULNERABILITY_SCAN, scanTrigger, actionBuilder)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("started vulnerability scans %v of images referenced by helm chart", scanIds)
+	return nil
+}
@@ -168,8 +168,11 @@ func (s MalwareScan) StartMalwareScan(msg *message.Message) error {
 	authFile := authDir + "/config.json"
 	imgTar := dir + "/save-output.tar"
 
-	cmd := exec.Command("skopeo", []string{"copy", "--insecure-policy", "--src-tls-verify=false",
-		"--authfile", authFile, "docker://" + imageName, "docker-archive:" + imgTar}...)
+	args := []string{"copy", "--insecure-policy", "--src-tls-verify=false", "--authfile", authFile}
+	if creds.CertDir != "" {
+		args = append(args, "--src-cert-dir", creds.CertDir)
+	}
+	cmd := exec.Command("skopeo", append(args, "docker://"+imageName, "docker-archive:"+imgTar)...)
 
 	log.Info().Msgf("command: %s", cmd.String())
 	if out, err := workerUtils.RunCommand(cmd); err != nil {
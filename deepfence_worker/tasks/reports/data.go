@@ -146,6 +146,17 @@ func getVulnerabilityData(ctx context.Context, params sdkUtils.ReportParams) (*I
 
 	severityFilter := scanResultFilter("cve_severity",
 		params.Filters.SeverityOrCheckType, params.Filters.AdvancedReportFilters.Masked)
+	if len(params.Filters.AdvancedReportFilters.CisaKev) > 0 {
+		severityFilter.ContainsFilter.FieldsValues["cisa_kev"] =
+			sdkUtils.BoolArrayToInterfaceArray(params.Filters.AdvancedReportFilters.CisaKev)
+	}
+	if params.Filters.AdvancedReportFilters.EPSSScore > 0 {
+		severityFilter.CompareFilters = append(severityFilter.CompareFilters, reporters.CompareFilter{
+			FieldName:   "epss_score",
+			FieldValue:  params.Filters.AdvancedReportFilters.EPSSScore,
+			GreaterThan: true,
+		})
+	}
 
 	nodeWiseData := NodeWiseData[model.Vulnerability]{
 		SeverityCount: make(map[string]map[string]int32),
@@ -160,7 +171,10 @@ func getVulnerabilityData(ctx context.Context, params sdkUtils.ReportParams) (*I
 			continue
 		}
 		sort.Slice(result[:], func(i, j int) bool {
-			return result[i].Cve_severity < result[j].Cve_severity
+			if result[i].Cve_severity != result[j].Cve_severity {
+				return result[i].Cve_severity < result[j].Cve_severity
+			}
+			return result[i].EPSSScore > result[j].EPSSScore
 		})
 		nodeWiseData.SeverityCount[s.NodeName] = s.SeverityCounts
 		nodeWiseData.ScanData[s.NodeName] = ScanData[model.Vulnerability]{
@@ -468,7 +482,8 @@ func NodeIdToNodeName(ctx context.Context, nodeIds []string, node_type string) [
 		query = `
 		MATCH (n:ContainerImage)
 		WHERE n.node_id in ["%s"]
-		RETURN n.docker_image_name + ':' + n.docker_image_tag as name
+		RETURN n.docker_image_name + ':' + n.docker_image_tag +
+			CASE WHEN n.parent_image_id IS NULL THEN '' ELSE ' (' + n.platform + ')' END as name
 		`
 	case "container":
 		query = `
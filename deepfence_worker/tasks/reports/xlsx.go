@@ -28,6 +28,9 @@ var (
 		"O1": "host",
 		"P1": "host_name",
 		"Q1": "masked",
+		"R1": "epss_score",
+		"S1": "epss_percentile",
+		"T1": "cisa_kev",
 	}
 	secretHeader = map[string]string{
 		"A1": "Filename",
@@ -164,6 +167,9 @@ func vulnerabilityXLSX(ctx context.Context, params utils.ReportParams) (string,
 				nodeScanData.ScanInfo.HostName,
 				nodeScanData.ScanInfo.HostName,
 				v.Masked,
+				v.EPSSScore,
+				v.EPSSPercentile,
+				v.CisaKev,
 			}
 			xlsx.SetSheetRow("Sheet1", cellName, &value)
 		}
@@ -5,7 +5,9 @@ import (
 	"compress/gzip"
 	"encoding/json"
 	"os"
+	"os/exec"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -120,6 +122,38 @@ func (s SbomGenerator) GenerateSbom(msg *message.Message) ([]*message.Message, e
 		cfg.Source = params.ImageId
 	}
 
+	// syft is run by the package scanner which can't be given the registry
+	// certificates, pull the image with them like secret scans do and
+	// generate the sbom from the archive
+	if creds.CertDir != "" {
+		dir, err := os.MkdirTemp("/tmp", "sbom-*")
+		if err != nil {
+			log.Error().Msg(err.Error())
+			statusChan <- NewSbomScanStatus(params, utils.SCAN_STATUS_FAILED, err.Error(), nil)
+			return nil, nil
+		}
+		defer os.RemoveAll(dir)
+
+		imgTar := path.Join(dir, "save-output.tar")
+		cmd := exec.Command("skopeo", "copy", "--insecure-policy",
+			"--src-tls-verify="+strconv.FormatBool(!creds.SkipTLSVerify),
+			"--authfile", path.Join(authFile, "config.json"), "--src-cert-dir", creds.CertDir,
+			"docker://"+cfg.Source, "docker-archive:"+imgTar)
+		log.Info().Msgf("command: %s", cmd.String())
+		if out, err := workerUtils.RunCommand(cmd); err != nil {
+			log.Error().Err(err).Msg(cmd.String())
+			log.Error().Msgf("output: %s", out.String())
+			statusChan <- NewSbomScanStatus(params, utils.SCAN_STATUS_FAILED, err.Error(), nil)
+			return nil, nil
+		}
+
+		// without registry id the package scanner passes the source to syft
+		// as is instead of pulling it from the registry
+		cfg.Source = "docker-archive:" + imgTar
+		cfg.RegistryId = ""
+		cfg.RegistryCreds = psUtils.RegistryCreds{}
+	}
+
 	log.Debug().Msgf("config: %+v", cfg)
 
 	statusChan <- NewSbomScanStatus(params, utils.SCAN_STATUS_INPROGRESS, "", nil)
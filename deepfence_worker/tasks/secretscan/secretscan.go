@@ -86,7 +86,7 @@ func (s SecretScan) StartSecretScan(msg *message.Message) error {
 		return nil
 	}
 
-	if params.RegistryId == "" {
+	if params.RegistryId == "" && params.SourceRepositoryId == "" {
 		log.Error().Msgf("registry id is empty in params %+v", params)
 		return nil
 	}
@@ -109,6 +109,14 @@ func (s SecretScan) StartSecretScan(msg *message.Message) error {
 	// send inprogress status
 	scanCtx.ScanStatusChan <- true
 
+	if params.SourceRepositoryId != "" {
+		if err := s.scanSourceRepository(ctx, scanCtx, params, rh); err != nil {
+			log.Error().Msg(err.Error())
+			hardErr = err
+		}
+		return nil
+	}
+
 	// get registry credentials
 	authDir, creds, err := workerUtils.GetConfigFileFromRegistry(ctx, params.RegistryId)
 	if err != nil {
@@ -146,8 +154,11 @@ func (s SecretScan) StartSecretScan(msg *message.Message) error {
 	authFile := authDir + "/config.json"
 	imgTar := dir + "/save-output.tar"
 
-	cmd := exec.Command("skopeo", []string{"copy", "--insecure-policy", "--src-tls-verify=false",
-		"--authfile", authFile, "docker://" + imageName, "docker-archive:" + imgTar}...)
+	args := []string{"copy", "--insecure-policy", "--src-tls-verify=false", "--authfile", authFile}
+	if creds.CertDir != "" {
+		args = append(args, "--src-cert-dir", creds.CertDir)
+	}
+	cmd := exec.Command("skopeo", append(args, "docker://"+imageName, "docker-archive:"+imgTar)...)
 
 	log.Info().Msgf("command: %s", cmd.String())
 
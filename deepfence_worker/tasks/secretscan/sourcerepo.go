@@ -0,0 +1,108 @@
+package secretscan
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deepfence/SecretScanner/output"
+	"github.com/deepfence/SecretScanner/scan"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/log"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
+	workerUtils "github.com/deepfence/ThreatMapper/deepfence_worker/utils"
+	"github.com/deepfence/ThreatMapper/deepfence_worker/utils/gitrepo"
+	pb "github.com/deepfence/agent-plugins-grpc/srcgo"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// files of the history written out and scanned at once
+const sourceRepositoryBatchSize = 5000
+
+type sourceRepositorySecret struct {
+	utils.SecretScanParameters
+	pb.SecretInfo
+	Commit            string `json:"commit"`
+	CommitAuthor      string `json:"commit_author"`
+	CommitAuthorEmail string `json:"commit_author_email"`
+	CommitTime        int64  `json:"commit_time"`
+}
+
+// lookupFile finds the file version of a secret, the scanner reports the
+// file name relative to the scanned dir or as a full path
+func lookupFile(files map[string]gitrepo.FileVersion, dir, name string) (gitrepo.FileVersion, bool) {
+	if f, ok := files[name]; ok {
+		return f, true
+	}
+	f, ok := files[filepath.Join(dir, strings.TrimPrefix(name, "/"))]
+	return f, ok
+}
+
+// scanSourceRepository clones the repository and scans every version of
+// every file in its history, attributing the secrets to the commit which
+// introduced them
+func (s SecretScan) scanSourceRepository(ctx context.Context, scanCtx *scan.ScanContext,
+	params utils.SecretScanParameters, rh []kgo.RecordHeader) error {
+
+	repo, auth, err := workerUtils.GetSourceRepository(ctx, params.SourceRepositoryId)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("/tmp", "secretscan-repo-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	cloneDir := filepath.Join(dir, "repo.git")
+	log.Info().Msgf("clone source repository id=%d url=%s branch=%s", repo.ID, repo.Url, repo.Branch)
+	if err := gitrepo.Clone(ctx, repo.Url, repo.Branch, auth, cloneDir); err != nil {
+		return err
+	}
+
+	// init secret scan
+	scanCtx.ScanStatusChan <- true
+
+	filesDir := filepath.Join(dir, "files")
+	isFirstSecret := true
+	return gitrepo.ScanHistory(ctx, cloneDir, filesDir, sourceRepositoryBatchSize,
+		func(files map[string]gitrepo.FileVersion) error {
+			secrets, err := scan.ScanSecretsInDir("", filesDir, filesDir, &isFirstSecret, scanCtx)
+			if err != nil {
+				return err
+			}
+			scanCtx.ScanStatusChan <- true
+
+			for _, secret := range secrets {
+				file, ok := lookupFile(files, filesDir, secret.CompleteFilename)
+				if !ok {
+					log.Warn().Msgf("secret in unknown file %s", secret.CompleteFilename)
+					continue
+				}
+				for _, c := range output.SecretsToSecretInfos([]output.SecretFound{secret}) {
+					c.Match.FullFilename = file.Path
+					r := sourceRepositorySecret{
+						SecretScanParameters: params,
+						SecretInfo:           *c,
+						Commit:               file.Commit.Hash,
+						CommitAuthor:         file.Commit.Author,
+						CommitAuthorEmail:    file.Commit.AuthorEmail,
+						CommitTime:           file.Commit.Time,
+					}
+					cb, err := json.Marshal(r)
+					if err != nil {
+						log.Error().Msg(err.Error())
+						continue
+					}
+					s.ingestC <- &kgo.Record{
+						Topic:   utils.SECRET_SCAN,
+						Value:   cb,
+						Headers: rh,
+					}
+				}
+			}
+			return nil
+		})
+}
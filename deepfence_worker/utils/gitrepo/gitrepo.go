@@ -0,0 +1,298 @@
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// files bigger than this are not written out for scanning
+const MaxBlobSize = 10 * 1024 * 1024
+
+const emptyBlob = "0000000000000000000000000000000000000000"
+
+type Auth struct {
+	Provider string
+	Username string
+	Token    string
+	SSHKey   string
+	// pinned host keys of the ssh server, in known_hosts format
+	KnownHosts string
+}
+
+// tokenUsername is the user of https token auth, the providers ignore it
+// but need a non empty one
+func (a Auth) tokenUsername() string {
+	if a.Username != "" {
+		return a.Username
+	}
+	switch a.Provider {
+	case "github":
+		return "x-access-token"
+	case "gitlab":
+		return "oauth2"
+	}
+	return "git"
+}
+
+type Commit struct {
+	Hash        string
+	Author      string
+	AuthorEmail string
+	Time        int64
+}
+
+// FileVersion is the content of a file introduced by a commit
+type FileVersion struct {
+	Commit Commit
+	Path   string
+	Blob   string
+}
+
+func runGit(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), append([]string{"GIT_TERMINAL_PROMPT=0"}, env...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// Clone makes a bare clone of the repository in dir, of all the branches
+// and tags when branch is empty. The credentials are passed through the
+// environment and never written to the clone config.
+func Clone(ctx context.Context, url, branch string, auth Auth, dir string) error {
+	env := []string{}
+	if auth.Token != "" {
+		header := "Authorization: Basic " +
+			base64.StdEncoding.EncodeToString([]byte(auth.tokenUsername()+":"+auth.Token))
+		env = append(env, "GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader", "GIT_CONFIG_VALUE_0="+header)
+	}
+	if auth.SSHKey != "" {
+		if strings.TrimSpace(auth.KnownHosts) == "" {
+			return errors.New("ssh clone needs the known hosts of the repository server")
+		}
+		keyDir, err := os.MkdirTemp("", "gitrepo-key-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(keyDir)
+		key := auth.SSHKey
+		if !strings.HasSuffix(key, "\n") {
+			key += "\n"
+		}
+		keyFile := filepath.Join(keyDir, "id")
+		if err := os.WriteFile(keyFile, []byte(key), 0600); err != nil {
+			return err
+		}
+		knownHostsFile := filepath.Join(keyDir, "known_hosts")
+		if err := os.WriteFile(knownHostsFile, []byte(auth.KnownHosts+"\n"), 0600); err != nil {
+			return err
+		}
+		// only the configured host keys are trusted
+		env = append(env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes -o StrictHostKeyChecking=yes -o UserKnownHostsFile=%s -o GlobalKnownHostsFile=/dev/null",
+			keyFile, knownHostsFile))
+	}
+
+	args := []string{"clone", "--bare", "--quiet"}
+	if branch != "" {
+		args = append(args, "--single-branch", "--branch", branch)
+	}
+	args = append(args, "--", url, dir)
+	_, err := runGit(ctx, "", env, args...)
+	return err
+}
+
+// Commits lists the commits of all the refs of the repository, oldest first
+func Commits(ctx context.Context, dir string) ([]Commit, error) {
+	out, err := runGit(ctx, dir, nil, "log", "--all", "--reverse", "--format=%H%x1f%an%x1f%ae%x1f%ct")
+	if err != nil {
+		return nil, err
+	}
+	commits := []Commit{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected git log line %q", line)
+		}
+		ts, _ := strconv.ParseInt(fields[3], 10, 64)
+		commits = append(commits, Commit{Hash: fields[0], Author: fields[1], AuthorEmail: fields[2], Time: ts})
+	}
+	return commits, nil
+}
+
+// changedFiles returns the files added or modified by the commit, merges
+// are skipped as their content comes from the merged commits
+func changedFiles(ctx context.Context, dir string, commit Commit) ([]FileVersion, error) {
+	out, err := runGit(ctx, dir, nil, "diff-tree", "-r", "--root", "--no-renames", "--no-commit-id", "-z", commit.Hash)
+	if err != nil {
+		return nil, err
+	}
+	files := []FileVersion{}
+	parts := strings.Split(string(out), "\x00")
+	for i := 0; i+1 < len(parts); i += 2 {
+		// :<old mode> <new mode> <old blob> <new blob> <status>
+		meta := strings.Fields(strings.TrimPrefix(parts[i], ":"))
+		if len(meta) != 5 {
+			return nil, fmt.Errorf("unexpected git diff-tree entry %q", parts[i])
+		}
+		mode, blob := meta[1], meta[3]
+		// skip deletions, submodules and symlinks
+		if blob == emptyBlob || mode == "160000" || mode == "120000" {
+			continue
+		}
+		files = append(files, FileVersion{Commit: commit, Path: parts[i+1], Blob: blob})
+	}
+	return files, nil
+}
+
+// blobReader reads blobs from a long running git cat-file
+type blobReader struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+func newBlobReader(ctx context.Context, dir string) (*blobReader, error) {
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = dir
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &blobReader{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// writeTo writes the blob to path, returns false if the blob was skipped
+func (b *blobReader) writeTo(blob, path string) (bool, error) {
+	if _, err := fmt.Fprintln(b.stdin, blob); err != nil {
+		return false, err
+	}
+	header, err := b.stdout.ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	// <blob> <type> <size>
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return false, fmt.Errorf("git cat-file: %s", strings.TrimSpace(header))
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return false, err
+	}
+	var w io.Writer = io.Discard
+	var f *os.File
+	if size <= MaxBlobSize {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return false, err
+		}
+		f, err = os.Create(path)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		w = f
+	}
+	if _, err := io.CopyN(w, b.stdout, size); err != nil {
+		return false, err
+	}
+	// content is followed by a newline
+	if _, err := b.stdout.Discard(1); err != nil {
+		return false, err
+	}
+	return f != nil, nil
+}
+
+func (b *blobReader) close() error {
+	b.stdin.Close()
+	return b.cmd.Wait()
+}
+
+// ScanHistory writes out every version of every file in the history of the
+// repository in batches of batchSize files under outDir and calls scan
+// with the written files, keyed by their path. A content committed several
+// times is written once, attributed to the oldest commit introducing it.
+func ScanHistory(ctx context.Context, dir, outDir string, batchSize int,
+	scan func(files map[string]FileVersion) error) error {
+
+	commits, err := Commits(ctx, dir)
+	if err != nil {
+		return err
+	}
+	blobs, err := newBlobReader(ctx, dir)
+	if err != nil {
+		return err
+	}
+	defer blobs.close()
+
+	flush := func(files map[string]FileVersion) error {
+		if len(files) == 0 {
+			return nil
+		}
+		if err := scan(files); err != nil {
+			return err
+		}
+		return os.RemoveAll(outDir)
+	}
+
+	seen := map[string]bool{}
+	batch := map[string]FileVersion{}
+	for _, commit := range commits {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		files, err := changedFiles(ctx, dir, commit)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if seen[file.Blob] {
+				continue
+			}
+			seen[file.Blob] = true
+			if !filepath.IsLocal(filepath.FromSlash(file.Path)) {
+				continue
+			}
+			path := filepath.Join(outDir, file.Blob, filepath.FromSlash(file.Path))
+			written, err := blobs.writeTo(file.Blob, path)
+			if err != nil {
+				return err
+			}
+			if !written {
+				continue
+			}
+			batch[path] = file
+			if len(batch) >= batchSize {
+				if err := flush(batch); err != nil {
+					return err
+				}
+				batch = map[string]FileVersion{}
+			}
+		}
+	}
+	return flush(batch)
+}
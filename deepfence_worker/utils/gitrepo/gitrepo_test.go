@@ -0,0 +1,133 @@
+package gitrepo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func git(t *testing.T, dir string, env []string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+func commit(t *testing.T, dir, author, file, content, message string) {
+	t.Helper()
+	env := []string{
+		"GIT_AUTHOR_NAME=" + author, "GIT_AUTHOR_EMAIL=" + author + "@example.com",
+		"GIT_COMMITTER_NAME=" + author, "GIT_COMMITTER_EMAIL=" + author + "@example.com",
+	}
+	if content == "" {
+		git(t, dir, env, "rm", "-q", file)
+	} else {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		git(t, dir, env, "add", file)
+	}
+	git(t, dir, env, "commit", "-q", "-m", message)
+}
+
+// bareRepo makes a bare repository where a secret is committed and removed
+// in a later commit, so it only exists in the history
+func bareRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	work := t.TempDir()
+	git(t, work, nil, "init", "-q", "-b", "main")
+	commit(t, work, "alice", "README.md", "hello\n", "readme")
+	commit(t, work, "bob", "config/app.env", "AWS_SECRET_ACCESS_KEY=wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY\n", "add config")
+	commit(t, work, "carol", "config/app.env", "", "remove config")
+	commit(t, work, "carol", "notes/readme-copy.md", "hello\n", "copy readme")
+
+	bare := filepath.Join(t.TempDir(), "origin.git")
+	git(t, "", nil, "clone", "-q", "--bare", work, bare)
+	return bare
+}
+
+func TestScanHistory(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "clone")
+	if err := Clone(ctx, bareRepo(t), "", Auth{}, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err := Commits(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 4 || commits[0].Author != "alice" || commits[1].AuthorEmail != "bob@example.com" {
+		t.Fatalf("unexpected commits %+v", commits)
+	}
+
+	outDir := filepath.Join(t.TempDir(), "files")
+	found := map[string]FileVersion{}
+	batches := 0
+	err = ScanHistory(ctx, dir, outDir, 1, func(files map[string]FileVersion) error {
+		batches++
+		for path, file := range files {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if !strings.HasSuffix(filepath.ToSlash(path), "/"+file.Path) {
+				t.Errorf("path %s does not end with %s", path, file.Path)
+			}
+			found[string(content)] = file
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the copy of the readme has the same content and is not scanned again
+	if batches != 2 || len(found) != 2 {
+		t.Fatalf("expected 2 batches of 2 files, got %d batches %+v", batches, found)
+	}
+	secret, ok := found["AWS_SECRET_ACCESS_KEY=wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY\n"]
+	if !ok {
+		t.Fatal("removed secret not found in history")
+	}
+	if secret.Path != "config/app.env" || secret.Commit.Author != "bob" || secret.Commit.Hash != commits[1].Hash {
+		t.Errorf("secret attributed to %+v", secret)
+	}
+	if found["hello\n"].Commit.Author != "alice" {
+		t.Errorf("readme attributed to %+v", found["hello\n"])
+	}
+	if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+		t.Errorf("files left in %s", outDir)
+	}
+}
+
+func TestCloneBranch(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "clone")
+	if err := Clone(ctx, bareRepo(t), "missing", Auth{}, dir); err == nil {
+		t.Fatal("clone of a missing branch should fail")
+	}
+	if err := Clone(ctx, bareRepo(t), "main", Auth{}, dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCloneSSHKnownHosts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "clone")
+	err := Clone(context.Background(), "git@example.com:org/repo.git", "", Auth{SSHKey: "key"}, dir)
+	if err == nil || !strings.Contains(err.Error(), "known hosts") {
+		t.Fatalf("ssh clone without known hosts should fail, got %v", err)
+	}
+}
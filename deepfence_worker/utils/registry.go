@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/gitlab"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/harbor"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/jfrog"
+	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/oci"
 	"github.com/deepfence/ThreatMapper/deepfence_server/pkg/registry/quay"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/encryption"
@@ -42,24 +44,71 @@ type regCreds struct {
 	ImagePrefix   string
 	SkipTLSVerify bool
 	UseHttp       bool
+	// CertDir holds the ca and the client certificate of the registry, in the
+	// layout of the cert dirs of skopeo, set only when the registry has them
+	CertDir           string
+	CACertificate     string
+	ClientCertificate string
+	ClientKey         string
 }
 
+// files of the registry certificates in the cert dir
+const (
+	CACertFile     = "ca.crt"
+	ClientCertFile = "client.cert"
+	ClientKeyFile  = "client.key"
+)
+
 func useHttp(url string) bool {
 	return !strings.HasPrefix(url, "https://")
 }
 
+func (rc regCreds) hasCertificates() bool {
+	return rc.CACertificate != "" || rc.ClientCertificate != ""
+}
+
 func GetConfigFileFromRegistry(ctx context.Context, registryId string) (string, regCreds, error) {
 	rc, err := GetCredentialsFromRegistry(ctx, registryId)
-	if rc.UserName == "" || err != nil {
+	if err != nil || (rc.UserName == "" && !rc.hasCertificates()) {
 		return "", regCreds{}, err
 	}
 	authFile, err := createAuthFile(registryId, rc.URL, rc.UserName, rc.Password)
 	if err != nil {
 		return "", rc, fmt.Errorf("unable to create credential file for docker")
 	}
+	if rc.hasCertificates() {
+		rc.CertDir, err = writeCertDir(authFile, rc)
+		if err != nil {
+			os.RemoveAll(authFile)
+			return "", rc, fmt.Errorf("unable to write registry certificates: %w", err)
+		}
+	}
 	return authFile, rc, nil
 }
 
+// writeCertDir writes the registry certificates in the auth directory, so
+// they are removed along with the credentials once the image is pulled
+func writeCertDir(authDir string, rc regCreds) (string, error) {
+	certDir := filepath.Join(authDir, "certs")
+	if err := os.MkdirAll(certDir, 0700); err != nil {
+		return "", err
+	}
+	files := map[string]string{
+		CACertFile:     rc.CACertificate,
+		ClientCertFile: rc.ClientCertificate,
+		ClientKeyFile:  rc.ClientKey,
+	}
+	for name, content := range files {
+		if content == "" {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(certDir, name), []byte(content), 0600); err != nil {
+			return "", err
+		}
+	}
+	return certDir, nil
+}
+
 func GetCredentialsFromRegistry(ctx context.Context, registryId string) (regCreds, error) {
 	pgClient, err := directory.PostgresClient(ctx)
 	if err != nil {
@@ -110,6 +159,8 @@ func GetCredentialsFromRegistry(ctx context.Context, registryId string) (regCred
 		return ecrCreds(reg, aes)
 	case constants.GITLAB:
 		return gitlabCreds(reg, aes)
+	case constants.OCI:
+		return ociCreds(reg, aes)
 	default:
 		return regCreds{}, nil
 	}
@@ -456,6 +507,49 @@ func dockerprivateCreds(reg postgresql_db.GetContainerRegistryRow, aes encryptio
 	}, nil
 }
 
+// ociCreds carries the ca and the client certificate of the registry along
+// with the username and password, they are written to the cert dir on pulls
+func ociCreds(reg postgresql_db.GetContainerRegistryRow, aes encryption.AES) (regCreds, error) {
+	var (
+		err       error
+		hub       oci.RegistryOCI
+		nonsecret oci.NonSecret
+		secret    oci.Secret
+	)
+	err = json.Unmarshal(reg.NonSecret, &nonsecret)
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+	err = json.Unmarshal(reg.EncryptedSecret, &secret)
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+	hub = oci.RegistryOCI{
+		Name:      reg.Name,
+		Secret:    secret,
+		NonSecret: nonsecret,
+	}
+
+	err = hub.DecryptSecret(aes)
+	if err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	return regCreds{
+		URL:           hub.NonSecret.OCIRegistryURL,
+		UserName:      hub.NonSecret.OCIUsername,
+		Password:      hub.Secret.OCIPassword,
+		NameSpace:     "",
+		ImagePrefix:   httpReplacer.Replace(hub.NonSecret.OCIRegistryURL),
+		SkipTLSVerify: hub.NonSecret.OCIInsecure == "true",
+		UseHttp:       useHttp(hub.NonSecret.OCIRegistryURL),
+
+		CACertificate:     hub.NonSecret.OCICACertificate,
+		ClientCertificate: hub.NonSecret.OCIClientCertificate,
+		ClientKey:         hub.Secret.OCIClientKey,
+	}, nil
+}
+
 func jfrogCreds(reg postgresql_db.GetContainerRegistryRow, aes encryption.AES) (regCreds, error) {
 	var (
 		err       error
@@ -568,6 +662,8 @@ func GetDockerCredentials(registryData map[string]interface{}) (string, string,
 		return registryUrl, "gitlab-ci-token", dockerPassword
 	case "jfrog_container_registry":
 		return getDefaultDockerCredentials(registryData, "jfrog_registry_url", "jfrog_username", "jfrog_password")
+	case "oci_registry":
+		return getDefaultDockerCredentials(registryData, "oci_registry_url", "oci_username", "oci_password")
 	default:
 		return "", "", ""
 	}
@@ -597,7 +693,13 @@ func createAuthFile(registryId, registryUrl, username, password string) (string,
 			return "", err
 		}
 	}
-	if password == "" {
+	if username == "" {
+		// anonymous pulls of registries with certificates only
+		err := os.WriteFile(authFilePath+"/config.json", []byte("{\"auths\": {} }"), 0644)
+		if err != nil {
+			return "", err
+		}
+	} else if password == "" {
 		configJson := []byte("{\"auths\": {\"" + registryUrl + "\": {\"auth\": \"" + strings.ReplaceAll(username, "\"", "\\\"") + "\"} } }")
 		err := os.WriteFile(authFilePath+"/config.json", configJson, 0644)
 		if err != nil {
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/deepfence/ThreatMapper/deepfence_server/model"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/directory"
+	"github.com/deepfence/ThreatMapper/deepfence_utils/encryption"
+	postgresql_db "github.com/deepfence/ThreatMapper/deepfence_utils/postgresql/postgresql-db"
+	"github.com/deepfence/ThreatMapper/deepfence_worker/utils/gitrepo"
+)
+
+// GetSourceRepository returns the repository with its decrypted credentials
+func GetSourceRepository(ctx context.Context, repositoryId string) (postgresql_db.SourceRepository, gitrepo.Auth, error) {
+	pgClient, err := directory.PostgresClient(ctx)
+	if err != nil {
+		return postgresql_db.SourceRepository{}, gitrepo.Auth{}, err
+	}
+
+	i, err := strconv.ParseInt(repositoryId, 10, 32)
+	if err != nil {
+		return postgresql_db.SourceRepository{}, gitrepo.Auth{}, err
+	}
+
+	repo, err := pgClient.GetSourceRepository(ctx, int32(i))
+	if err != nil {
+		return repo, gitrepo.Auth{}, err
+	}
+
+	auth := gitrepo.Auth{Provider: repo.Provider, Username: repo.Username, KnownHosts: repo.KnownHosts}
+	if repo.AuthType == model.SourceRepositoryAuthNone {
+		return repo, auth, nil
+	}
+
+	key, err := model.GetAESValueForEncryption(ctx, pgClient)
+	if err != nil {
+		return repo, auth, err
+	}
+	aes := encryption.AES{}
+	err = json.Unmarshal(key, &aes)
+	if err != nil {
+		return repo, auth, err
+	}
+
+	var secret model.SourceRepositorySecret
+	err = json.Unmarshal(repo.EncryptedSecret, &secret)
+	if err != nil {
+		return repo, auth, err
+	}
+	switch repo.AuthType {
+	case model.SourceRepositoryAuthToken:
+		auth.Token, err = aes.Decrypt(secret.Token)
+	case model.SourceRepositoryAuthSSHKey:
+		auth.SSHKey, err = aes.Decrypt(secret.SSHKey)
+	}
+	return repo, auth, err
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/deepfence/ThreatMapper/deepfence_utils/telemetry"
 	"github.com/deepfence/ThreatMapper/deepfence_utils/utils"
 	"github.com/deepfence/ThreatMapper/deepfence_worker/cronjobs"
+	"github.com/deepfence/ThreatMapper/deepfence_worker/tasks/helmchart"
 	"github.com/deepfence/ThreatMapper/deepfence_worker/tasks/malwarescan"
 	"github.com/deepfence/ThreatMapper/deepfence_worker/tasks/reports"
 	"github.com/deepfence/ThreatMapper/deepfence_worker/tasks/sbom"
@@ -312,12 +313,17 @@ func startWorker(wml watermill.LoggerAdapter, cfg config) error {
 
 	worker.AddNoPublisherHandler(utils.SyncRegistryTask, LogErrorWrapper(cronjobs.SyncRegistry), false)
 
+	worker.AddNoPublisherHandler(utils.CheckRegistryCredentialsTask, LogErrorWrapper(cronjobs.CheckRegistryCredentials), false)
+
 	worker.AddNoPublisherHandler(utils.SecretScanTask,
 		LogErrorWrapper(secretscan.NewSecretScanner(ingestC).StartSecretScan), false)
 
 	worker.AddNoPublisherHandler(utils.StopSecretScanTask,
 		LogErrorWrapper(secretscan.NewSecretScanner(ingestC).StopSecretScan), false)
 
+	worker.AddNoPublisherHandler(utils.ScanHelmChartTask,
+		LogErrorWrapper(helmchart.NewHelmChartScanner(ingestC).ScanHelmChart), false)
+
 	worker.AddNoPublisherHandler(utils.MalwareScanTask,
 		LogErrorWrapper(malwarescan.NewMalwareScanner(ingestC).StartMalwareScan), false)
 
@@ -338,6 +344,12 @@ func startWorker(wml watermill.LoggerAdapter, cfg config) error {
 
 	worker.AddNoPublisherHandler(utils.LinkNodesTask, LogErrorWrapper(cronjobs.LinkNodes), true)
 
+	worker.AddNoPublisherHandler(utils.ExpireExceptionsTask, LogErrorWrapper(cronjobs.ExpireScanResultExceptions), true)
+
+	worker.AddNoPublisherHandler(utils.ThreatIntelTask, LogErrorWrapper(cronjobs.EnrichThreatIntel), true)
+
+	worker.AddNoPublisherHandler(utils.EvaluateSLATask, LogErrorWrapper(cronjobs.EvaluateFindingSLA), true)
+
 	go worker.pollHandlers()
 
 	log.Info().Msg("Starting the consumer")